@@ -4,9 +4,14 @@ import (
 	_ "embed"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/nus25/yuge/feed/store/editor"
 	"github.com/nus25/yuge/subscriber"
 	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
 )
 
 //go:embed version.txt
@@ -22,83 +27,28 @@ func run(args []string) {
 		Usage:   "jetstream subscriber for bluesky custom feeds",
 		Version: version,
 		Commands: []*cli.Command{
+			newRunCommand(subscriber.JetstreamSubscriber),
 			{
-				Name:   "run",
-				Usage:  "Run the jetstream subscriber",
-				Action: subscriber.JetstreamSubscriber,
+				Name:   "feeds",
+				Usage:  "List feeds of a running subscriber with their status and post counts",
+				Action: subscriber.ListFeedsCommand,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
-						Name:    "log-level",
-						Aliases: []string{"l"},
-						Value:   "info",
-						Usage:   "Set log level (debug, info, warn, error)",
-						EnvVars: []string{"LOG_LEVEL"},
+						Name:    "api",
+						Usage:   "base url of the subscriber api",
+						Value:   "http://localhost:8082",
+						EnvVars: []string{"SUBSCRIBER_API_URL"},
 					},
 					&cli.StringFlag{
-						Name:     "feed-editor-endpoint",
-						Usage:    "endpoint url for gyoka editor",
-						EnvVars:  []string{"FEED_EDITOR_ENDPOINT"},
-						Required: false,
-					},
-					&cli.StringFlag{
-						Name:    "feed-editor-cf-id",
-						Usage:   "Cloudflare access id",
-						Value:   "",
-						EnvVars: []string{"CF_ACCESS_CLIENT_ID"},
-					},
-					&cli.StringFlag{
-						Name:    "feed-editor-cf-secret",
-						Usage:   "Cloudflare access secret",
-						Value:   "",
-						EnvVars: []string{"CF_ACCESS_CLIENT_SECRET"},
-					},
-					&cli.StringFlag{
-						Name:    "gyoka-api-key",
-						Usage:   "Gyoka API key",
+						Name:    "api-token",
+						Usage:   "bearer token for feed api",
 						Value:   "",
-						EnvVars: []string{"GYOKA_API_KEY"},
-					},
-					&cli.StringFlag{
-						Name:    "jetstream-url",
-						Usage:   "full websocket path to the jetstream endpoint",
-						Value:   "ws://localhost:6009/subscribe",
-						EnvVars: []string{"JETSTREAM_WS_URL"},
-					},
-					&cli.Int64Flag{
-						Name:    "override-cursor",
-						Usage:   "override cursor value for jetstream",
-						Value:   -1,
-						EnvVars: []string{"OVERRIDE_CURSOR"},
+						EnvVars: []string{"API_TOKEN"},
 					},
-					&cli.BoolFlag{
-						Name:    "jetstream-commpression",
-						Usage:   "enable compression of jetstream",
-						Value:   true,
-						EnvVars: []string{"JETSTREAM_COMPRESSION"},
-					},
-					&cli.StringFlag{
-						Name:    "config-directory-path",
-						Usage:   "config directory path",
-						Value:   "./config",
-						EnvVars: []string{"CONFIG_DIR"},
-					},
-					&cli.StringFlag{
-						Name:    "data-directory-path",
-						Usage:   "data directory path",
-						Value:   "./data",
-						EnvVars: []string{"DATA_DIR"},
-					},
-					&cli.StringFlag{
-						Name:    "api-listen-addr",
-						Usage:   "addr to serve prometheus metrics on",
-						Value:   ":8082",
-						EnvVars: []string{"SUBSCRIBER_API_LISTEN_ADDR"},
-					},
-					&cli.StringFlag{
-						Name:    "metrics-listen-addr",
-						Usage:   "addr to serve prometheus metrics on",
-						Value:   ":9102",
-						EnvVars: []string{"SUBSCRIBER_METRICS_LISTEN_ADDR"},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Usage: "timeout of each api request",
+						Value: 10 * time.Second,
 					},
 				},
 			},
@@ -110,3 +60,266 @@ func run(args []string) {
 		log.Fatal(err)
 	}
 }
+
+// newRunCommand returns the run command with its flags, which can also be set by the file given by --config.
+func newRunCommand(action cli.ActionFunc) *cli.Command {
+	configFlag := &cli.PathFlag{
+		Name:    "config",
+		Usage:   "path to a YAML or TOML file setting the flags below by their names. flags and environment variables take precedence over the file",
+		EnvVars: []string{"SUBSCRIBER_CONFIG"},
+	}
+	runFlags := []cli.Flag{
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "log-level",
+			Aliases: []string{"l"},
+			Value:   "info",
+			Usage:   "Set log level (debug, info, warn, error)",
+			EnvVars: []string{"LOG_LEVEL"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "log-format",
+			Value:   "json",
+			Usage:   "Set log format (json, text)",
+			EnvVars: []string{"LOG_FORMAT"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:     "feed-editor-endpoint",
+			Usage:    "endpoint url for gyoka editor",
+			EnvVars:  []string{"FEED_EDITOR_ENDPOINT"},
+			Required: false,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "feed-editor-cf-id",
+			Usage:   "Cloudflare access id",
+			Value:   "",
+			EnvVars: []string{"CF_ACCESS_CLIENT_ID"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "feed-editor-cf-secret",
+			Usage:   "Cloudflare access secret",
+			Value:   "",
+			EnvVars: []string{"CF_ACCESS_CLIENT_SECRET"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "gyoka-api-key",
+			Usage:   "Gyoka API key",
+			Value:   "",
+			EnvVars: []string{"GYOKA_API_KEY"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "jetstream-url",
+			Usage:   "full websocket path to the jetstream endpoint. comma-separated urls are tried in order on connection failure",
+			Value:   "ws://localhost:6009/subscribe",
+			EnvVars: []string{"JETSTREAM_WS_URL"},
+		}),
+		altsrc.NewInt64Flag(&cli.Int64Flag{
+			Name:    "override-cursor",
+			Usage:   "override cursor value for jetstream. if negative, resume from the cursor persisted in data directory at last shutdown",
+			Value:   -1,
+			EnvVars: []string{"OVERRIDE_CURSOR"},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "jetstream-commpression",
+			Usage:   "enable compression of jetstream",
+			Value:   true,
+			EnvVars: []string{"JETSTREAM_COMPRESSION"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "jetstream-read-timeout",
+			Usage:   "read deadline for jetstream connection, extended on each pong. must be larger than ping interval",
+			Value:   time.Minute,
+			EnvVars: []string{"JETSTREAM_READ_TIMEOUT"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "jetstream-ping-interval",
+			Usage:   "interval of pings sent to jetstream",
+			Value:   30 * time.Second,
+			EnvVars: []string{"JETSTREAM_PING_INTERVAL"},
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "jetstream-max-decode-errors",
+			Usage:   "number of consecutive malformed jetstream messages skipped before reconnecting",
+			Value:   10,
+			EnvVars: []string{"JETSTREAM_MAX_DECODE_ERRORS"},
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "jetstream-tls-pins",
+			Usage:   "comma-separated base64 sha256 hashes of public keys (\"sha256/...\") the jetstream server certificate must match. requires wss urls",
+			EnvVars: []string{"JETSTREAM_TLS_PINS"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "jetstream-tls-ca-file",
+			Usage:   "PEM file of CA certificates trusted for wss jetstream connections in addition to the system roots",
+			EnvVars: []string{"JETSTREAM_TLS_CA_FILE"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "jetstream-tls-cert-file",
+			Usage:   "PEM file of the client certificate for jetstream servers requiring mutual TLS",
+			EnvVars: []string{"JETSTREAM_TLS_CERT_FILE"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "jetstream-tls-key-file",
+			Usage:   "PEM file of the client certificate key",
+			EnvVars: []string{"JETSTREAM_TLS_KEY_FILE"},
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "jetstream-max-size",
+			Usage:   "maximum size in bytes of events sent by jetstream (0: unlimited)",
+			Value:   0,
+			EnvVars: []string{"JETSTREAM_MAX_SIZE"},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "jetstream-wanted-dids",
+			Usage:   "subscribe only to feed authors when every active feed is limited to a user list (reconnects when feeds change)",
+			Value:   false,
+			EnvVars: []string{"JETSTREAM_WANTED_DIDS"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "config-directory-path",
+			Usage:   "config directory path",
+			Value:   "./config",
+			EnvVars: []string{"CONFIG_DIR"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "data-directory-path",
+			Usage:   "data directory path",
+			Value:   "./data",
+			EnvVars: []string{"DATA_DIR"},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "disable-pds-config-cache",
+			Usage:   "do not cache feed configs fetched from PDS under the data directory",
+			Value:   false,
+			EnvVars: []string{"DISABLE_PDS_CONFIG_CACHE"},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "remove-deleted-accounts",
+			Usage:   "remove all posts of accounts that are deleted or taken down when jetstream reports it",
+			Value:   false,
+			EnvVars: []string{"REMOVE_DELETED_ACCOUNTS"},
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "ingest-langs",
+			Usage:   "comma-separated langs of posts passed to feeds. posts without any of them are skipped before parsing. if empty, all posts are passed",
+			EnvVars: []string{"INGEST_LANGS"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "feed-create-timeout",
+			Usage:   "timeout for creating a feed, including loading its posts from the store backend",
+			Value:   30 * time.Second,
+			EnvVars: []string{"FEED_CREATE_TIMEOUT"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "feed-shutdown-timeout",
+			Usage:   "timeout for shutting down a feed on reload or removal",
+			Value:   30 * time.Second,
+			EnvVars: []string{"FEED_SHUTDOWN_TIMEOUT"},
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "feed-recent-tests",
+			Usage:   "number of recently tested posts kept by each feed, served at /api/feed/:feedid/recent for debugging. 0 disables recording",
+			Value:   0,
+			EnvVars: []string{"FEED_RECENT_TESTS"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "feed-block-test-timeout",
+			Usage:   "maximum time each logic block may take to test a post. a block exceeding it is treated as not matched. 0 disables the timeout",
+			Value:   0,
+			EnvVars: []string{"FEED_BLOCK_TEST_TIMEOUT"},
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "feeds",
+			Usage:   "comma-separated feed ids to run. if empty, all feeds in the feed list are run",
+			EnvVars: []string{"SUBSCRIBER_FEEDS"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "feed-retry-interval",
+			Usage:   "interval of reloading feeds that failed to be created (error or pending state; feeds set to error via the api are not reloaded), backed off for each failure (0: disabled)",
+			Value:   time.Minute,
+			EnvVars: []string{"FEED_RETRY_INTERVAL"},
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "feed-retry-max-attempts",
+			Usage:   "maximum number of reload attempts for a feed that failed to be created (0: unlimited)",
+			Value:   10,
+			EnvVars: []string{"FEED_RETRY_MAX_ATTEMPTS"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "store-backend",
+			Usage:   "local store backend used when feed-editor-endpoint is not set (file or sqlite)",
+			Value:   "file",
+			EnvVars: []string{"STORE_BACKEND"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "stream-nats-url",
+			Usage:   "if set, publish feed mutations (add/delete/trim) to this NATS server",
+			EnvVars: []string{"STREAM_NATS_URL"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "stream-subject",
+			Usage:   "NATS subject to publish feed mutations to",
+			Value:   editor.DefaultStreamSubject,
+			EnvVars: []string{"STREAM_SUBJECT"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "api-listen-addr",
+			Usage:   "addr to serve prometheus metrics on",
+			Value:   ":8082",
+			EnvVars: []string{"SUBSCRIBER_API_LISTEN_ADDR"},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "api-compression",
+			Usage:   "enable gzip/zstd compression of api responses",
+			Value:   false,
+			EnvVars: []string{"SUBSCRIBER_API_COMPRESSION"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "api-token",
+			Usage:   "bearer token required for feed api. if empty, authentication is disabled",
+			Value:   "",
+			EnvVars: []string{"API_TOKEN"},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "api-token-exempt-read",
+			Usage:   "allow read-only (GET) feed api requests without api token",
+			Value:   false,
+			EnvVars: []string{"API_TOKEN_EXEMPT_READ"},
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "cors-origins",
+			Usage:   "allowed origins for CORS requests to api (\"*\" allows any origin). if empty, CORS headers are not sent",
+			EnvVars: []string{"CORS_ORIGINS"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "metrics-listen-addr",
+			Usage:   "addr to serve prometheus metrics on",
+			Value:   ":9102",
+			EnvVars: []string{"SUBSCRIBER_METRICS_LISTEN_ADDR"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "metrics-cache-ttl",
+			Usage:   "duration for which feed metrics computed on a scrape are reused. 0 recomputes them on every scrape",
+			Value:   10 * time.Second,
+			EnvVars: []string{"SUBSCRIBER_METRICS_CACHE_TTL"},
+		}),
+	}
+	return &cli.Command{
+		Name:   "run",
+		Usage:  "Run the jetstream subscriber",
+		Action: action,
+		Before: altsrc.InitInputSourceWithContext(runFlags, newConfigFileSource("config")),
+		Flags:  append([]cli.Flag{configFlag}, runFlags...),
+	}
+}
+
+// newConfigFileSource returns an input source reading the file given by flagName.
+// files with the .toml extension are read as TOML, others as YAML.
+func newConfigFileSource(flagName string) func(cCtx *cli.Context) (altsrc.InputSourceContext, error) {
+	return func(cCtx *cli.Context) (altsrc.InputSourceContext, error) {
+		path := cCtx.String(flagName)
+		if strings.EqualFold(filepath.Ext(path), ".toml") {
+			return altsrc.NewTomlSourceFromFlagFunc(flagName)(cCtx)
+		}
+		return altsrc.NewYamlSourceFromFlagFunc(flagName)(cCtx)
+	}
+}
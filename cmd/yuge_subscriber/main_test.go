@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestRunCommandConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(yamlFile, []byte("log-level: debug\nfeed-editor-endpoint: https://file.example.com\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	tomlFile := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(tomlFile, []byte("log-level = \"warn\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantLevel    string
+		wantEndpoint string
+	}{
+		{
+			name:      "without config file",
+			args:      []string{"run"},
+			wantLevel: "info",
+		},
+		{
+			name:         "yaml file",
+			args:         []string{"run", "--config", yamlFile},
+			wantLevel:    "debug",
+			wantEndpoint: "https://file.example.com",
+		},
+		{
+			name:      "toml file",
+			args:      []string{"run", "--config", tomlFile},
+			wantLevel: "warn",
+		},
+		{
+			name:         "config file given by env",
+			args:         []string{"run"},
+			env:          map[string]string{"SUBSCRIBER_CONFIG": yamlFile},
+			wantLevel:    "debug",
+			wantEndpoint: "https://file.example.com",
+		},
+		{
+			name:         "env overrides file",
+			args:         []string{"run", "--config", yamlFile},
+			env:          map[string]string{"LOG_LEVEL": "error"},
+			wantLevel:    "error",
+			wantEndpoint: "https://file.example.com",
+		},
+		{
+			name:         "flag overrides env and file",
+			args:         []string{"run", "--config", yamlFile, "--log-level", "warn", "--feed-editor-endpoint", "https://flag.example.com"},
+			env:          map[string]string{"LOG_LEVEL": "error"},
+			wantLevel:    "warn",
+			wantEndpoint: "https://flag.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 実行環境の値が結果に影響しないようにする
+			for _, key := range []string{"SUBSCRIBER_CONFIG", "LOG_LEVEL", "FEED_EDITOR_ENDPOINT"} {
+				t.Setenv(key, tt.env[key])
+				if _, ok := tt.env[key]; !ok {
+					os.Unsetenv(key)
+				}
+			}
+			var level, endpoint string
+			app := cli.App{
+				Commands: []*cli.Command{
+					newRunCommand(func(cCtx *cli.Context) error {
+						level = cCtx.String("log-level")
+						endpoint = cCtx.String("feed-editor-endpoint")
+						return nil
+					}),
+				},
+			}
+			if err := app.Run(append([]string{"yuge_subscriber"}, tt.args...)); err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+			if level != tt.wantLevel {
+				t.Errorf("log-level = %q, want %q", level, tt.wantLevel)
+			}
+			if endpoint != tt.wantEndpoint {
+				t.Errorf("feed-editor-endpoint = %q, want %q", endpoint, tt.wantEndpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/nus25/yuge/feed/config/types"
+)
+
+// ValueChange is a value changed between two configs. nil means the value is not set
+type ValueChange struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// BlockRef identifies a logic block in a config
+type BlockRef struct {
+	Key  string `json:"key"` // block name, or "#N" (index in the config) for blocks without a name
+	Type string `json:"type"`
+}
+
+// BlockChange is a logic block existing in both configs with a different type or options
+type BlockChange struct {
+	BlockRef
+	Type    *ValueChange  `json:"typeChange,omitempty"`
+	Options []ValueChange `json:"options,omitempty"`
+}
+
+// ConfigDiff is the difference between two feed configs
+type ConfigDiff struct {
+	Changed       bool          `json:"changed"`
+	BlocksAdded   []BlockRef    `json:"blocksAdded"`
+	BlocksRemoved []BlockRef    `json:"blocksRemoved"`
+	BlocksChanged []BlockChange `json:"blocksChanged"`
+	MinMatch      *ValueChange  `json:"minMatch,omitempty"`
+	Store         []ValueChange `json:"store"`
+	DetailedLog   *ValueChange  `json:"detailedLog,omitempty"`
+}
+
+// Diff returns the changes from oldCfg to newCfg.
+// logic blocks are matched by name, or by index for blocks without a name.
+func Diff(oldCfg types.FeedConfig, newCfg types.FeedConfig) ConfigDiff {
+	d := ConfigDiff{
+		BlocksAdded:   []BlockRef{},
+		BlocksRemoved: []BlockRef{},
+		BlocksChanged: []BlockChange{},
+		Store:         diffValues(toValueMap(oldCfg.Store()), toValueMap(newCfg.Store())),
+	}
+
+	oldBlocks := blocksByKey(oldCfg.FeedLogic().GetLogicBlockConfigs())
+	newBlocks := blocksByKey(newCfg.FeedLogic().GetLogicBlockConfigs())
+	for _, key := range slices.Sorted(maps.Keys(oldBlocks)) {
+		ob := oldBlocks[key]
+		nb, ok := newBlocks[key]
+		if !ok {
+			d.BlocksRemoved = append(d.BlocksRemoved, BlockRef{Key: key, Type: ob.GetBlockType()})
+			continue
+		}
+		change := BlockChange{
+			BlockRef: BlockRef{Key: key, Type: nb.GetBlockType()},
+			Options:  diffValues(ob.GetOptions(), nb.GetOptions()),
+		}
+		if ob.GetBlockType() != nb.GetBlockType() {
+			change.Type = &ValueChange{Key: "type", Old: ob.GetBlockType(), New: nb.GetBlockType()}
+		}
+		if change.Type != nil || len(change.Options) > 0 {
+			d.BlocksChanged = append(d.BlocksChanged, change)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(newBlocks)) {
+		if _, ok := oldBlocks[key]; !ok {
+			d.BlocksAdded = append(d.BlocksAdded, BlockRef{Key: key, Type: newBlocks[key].GetBlockType()})
+		}
+	}
+
+	if o, n := oldCfg.FeedLogic().GetMinMatch(), newCfg.FeedLogic().GetMinMatch(); o != n {
+		d.MinMatch = &ValueChange{Key: "minMatch", Old: o, New: n}
+	}
+	if o, n := oldCfg.DetailedLog(), newCfg.DetailedLog(); o != n {
+		d.DetailedLog = &ValueChange{Key: "detailedLog", Old: o, New: n}
+	}
+	d.Changed = len(d.BlocksAdded) > 0 || len(d.BlocksRemoved) > 0 || len(d.BlocksChanged) > 0 ||
+		d.MinMatch != nil || len(d.Store) > 0 || d.DetailedLog != nil
+	return d
+}
+
+func blocksByKey(blocks []types.LogicBlockConfig) map[string]types.LogicBlockConfig {
+	m := make(map[string]types.LogicBlockConfig, len(blocks))
+	for i, b := range blocks {
+		key := b.GetBlockName()
+		if _, dup := m[key]; key == "" || dup {
+			key = "#" + strconv.Itoa(i)
+		}
+		m[key] = b
+	}
+	return m
+}
+
+// toValueMap converts v to a map of its json fields
+func toValueMap(v any) map[string]any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// diffValues returns the changed keys sorted by key.
+// values are compared by their json representation, so that e.g. []string and []interface{} are equal.
+func diffValues(oldValues map[string]any, newValues map[string]any) []ValueChange {
+	changes := []ValueChange{}
+	keys := slices.Sorted(maps.Keys(oldValues))
+	for k := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		o, n := oldValues[k], newValues[k]
+		if !jsonEqual(o, n) {
+			changes = append(changes, ValueChange{Key: k, Old: o, New: n})
+		}
+	}
+	return changes
+}
+
+func jsonEqual(a any, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return bytes.Equal(ja, jb)
+}
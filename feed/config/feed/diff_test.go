@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	base := `{
+		"logic": {"blocks": [
+			{"name": "words", "type": "regex", "options": {"value": "apple", "caseSensitive": false, "invert": false}},
+			{"type": "domain", "options": {"domains": ["example.com"]}}
+		]},
+		"store": {"trimAt": 100, "trimRemain": 50}
+	}`
+	tests := []struct {
+		name      string
+		candidate string
+		want      string // json of the expected diff
+	}{
+		{
+			name:      "同一の設定",
+			candidate: base,
+			want:      `{"changed":false,"blocksAdded":[],"blocksRemoved":[],"blocksChanged":[],"store":[]}`,
+		},
+		{
+			name: "オプションの変更とブロックの追加",
+			candidate: `{
+				"logic": {"blocks": [
+					{"name": "words", "type": "regex", "options": {"value": "banana", "caseSensitive": false, "invert": false}},
+					{"type": "domain", "options": {"domains": ["example.com"]}},
+					{"name": "replies", "type": "reply", "options": {"keep": "toplevel"}}
+				], "minMatch": 1},
+				"store": {"trimAt": 100, "trimRemain": 50},
+				"detailedLog": true
+			}`,
+			want: `{"changed":true,"blocksAdded":[{"key":"replies","type":"reply"}],"blocksRemoved":[],` +
+				`"blocksChanged":[{"key":"words","type":"regex","options":[{"key":"value","old":"apple","new":"banana"}]}],` +
+				`"minMatch":{"key":"minMatch","old":0,"new":1},"store":[],"detailedLog":{"key":"detailedLog","old":false,"new":true}}`,
+		},
+		{
+			name: "ブロックの削除とストア設定の変更",
+			candidate: `{
+				"logic": {"blocks": [
+					{"name": "words", "type": "regex", "options": {"value": "apple", "caseSensitive": false, "invert": false}}
+				]},
+				"store": {"trimAt": 200, "trimRemain": 50}
+			}`,
+			want: `{"changed":true,"blocksAdded":[],"blocksRemoved":[{"key":"#1","type":"domain"}],"blocksChanged":[],` +
+				`"store":[{"key":"trimAt","old":100,"new":200}]}`,
+		},
+	}
+	oldCfg, err := createMockConfigJSON(base)
+	if err != nil {
+		t.Fatalf("failed to parse base config: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newCfg, err := createMockConfigJSON(tt.candidate)
+			if err != nil {
+				t.Fatalf("failed to parse candidate config: %v", err)
+			}
+			if err := newCfg.ValidateAll(); err != nil {
+				t.Fatalf("invalid candidate config: %v", err)
+			}
+			got, err := json.Marshal(Diff(oldCfg, newCfg))
+			if err != nil {
+				t.Fatalf("failed to marshal diff: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
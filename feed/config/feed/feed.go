@@ -2,6 +2,7 @@ package feed
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/nus25/yuge/feed/config/logic"
 	"github.com/nus25/yuge/feed/config/store"
@@ -187,18 +188,13 @@ func (f *FeedConfigImpl) Validate(key string, value interface{}) error {
 		if err := feedLogic.Validate(key, value); err != nil {
 			return errors.NewConfigError("FeedConfig", key, err.Error())
 		}
-	case "store.trimAt", "store.trimRemain":
+	case "store.trimAt", "store.trimRemain", "store.trimBytes", "store.syncDisabled", "store.maxStoredTextLength", "store.rejectOversized", "store.richPostLimit":
 		store := f.Store()
 		if store == nil {
 			return errors.NewConfigError("FeedConfig", key, "store is nil")
 		}
 
-		storeKey := key
-		if key == "store.trimAt" {
-			storeKey = "trimAt"
-		} else if key == "store.trimRemain" {
-			storeKey = "trimRemain"
-		}
+		storeKey := strings.TrimPrefix(key, "store.")
 
 		if err := store.Validate(storeKey, value); err != nil {
 			return errors.NewConfigError("FeedConfig", key, err.Error())
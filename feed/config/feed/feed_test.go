@@ -2,6 +2,8 @@ package feed
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/goccy/go-yaml"
@@ -136,6 +138,46 @@ func TestFeedConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestFeedConfig_ValidateStoreKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   interface{}
+		get     func(types.StoreConfig) interface{}
+		wantErr bool
+	}{
+		{key: "store.trimBytes", value: 1024, get: func(s types.StoreConfig) interface{} { return s.GetTrimBytes() }},
+		{key: "store.trimBytes", value: -1, wantErr: true},
+		{key: "store.trimBytes", value: "1024", wantErr: true},
+		{key: "store.richPostLimit", value: 500, get: func(s types.StoreConfig) interface{} { return s.GetRichPostLimit() }},
+		{key: "store.richPostLimit", value: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s=%v", tt.key, tt.value), func(t *testing.T) {
+			cfg := DefaultFeedConfig()
+			err := cfg.Validate(tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%s, %v) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			// 検証を通った値はストア設定に反映できる
+			updater, ok := cfg.Store().(interface {
+				Update(string, interface{}) error
+			})
+			if !ok {
+				t.Fatal("store config does not support Update")
+			}
+			if err := updater.Update(strings.TrimPrefix(tt.key, "store."), tt.value); err != nil {
+				t.Errorf("Update(%s, %v) error = %v", tt.key, tt.value, err)
+			}
+			if got := tt.get(cfg.Store()); got != tt.value {
+				t.Errorf("expected %s %v after Update, got %v", tt.key, tt.value, got)
+			}
+		})
+	}
+}
+
 func TestNewFeedConfigFromJSON(t *testing.T) {
 	tests := []struct {
 		name    string
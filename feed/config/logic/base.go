@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/nus25/yuge/feed/config/types"
@@ -30,6 +31,10 @@ func (c *BaseLogicBlockConfig) GetBlockName() string {
 	return c.BlockName
 }
 
+func (c *BaseLogicBlockConfig) elementDefinitions() map[string]types.ConfigElementDefinition {
+	return c.definitions
+}
+
 func (c *BaseLogicBlockConfig) GetOptions() map[string]interface{} {
 	return c.Options
 }
@@ -69,6 +74,24 @@ func (c *BaseLogicBlockConfig) GetIntOption(key string) (val int, exists bool) {
 	return 0, false
 }
 
+func (c *BaseLogicBlockConfig) GetFloatOption(key string) (val float64, exists bool) {
+	if v, ok := c.GetOption(key).(float64); ok {
+		return v, true
+	}
+	if v, ok := c.GetOption(key).(int); ok {
+		return float64(v), true
+	}
+	if v, ok := c.GetOption(key).(uint64); ok {
+		return float64(v), true
+	}
+	if v, ok := c.GetOption(key).(string); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
 func (c *BaseLogicBlockConfig) GetDurationOption(key string) (val time.Duration, exists bool) {
 	if v, ok := c.GetOption(key).(string); ok {
 		if duration, err := time.ParseDuration(v); err == nil {
@@ -151,11 +174,24 @@ func (l *BaseLogicBlockConfig) Update(key string, value interface{}) error {
 	return nil
 }
 
+// DeepCopy returns a copy of the config.
+// configs of registered block types are recreated by their factory to keep the concrete type and definitions.
 func (l *BaseLogicBlockConfig) DeepCopy() types.LogicBlockConfig {
-	copy := &BaseLogicBlockConfig{
-		BlockName: l.BlockName,
-		BlockType: l.BlockType,
-		Options:   make(map[string]interface{}),
+	copy := l.copyBase()
+	if factory, ok := logicBlockFactories[l.BlockType]; ok {
+		if cfg, err := factory.Create(copy); err == nil {
+			return cfg
+		}
+	}
+	return &copy
+}
+
+func (l *BaseLogicBlockConfig) copyBase() BaseLogicBlockConfig {
+	copy := BaseLogicBlockConfig{
+		definitions: l.definitions,
+		BlockName:   l.BlockName,
+		BlockType:   l.BlockType,
+		Options:     make(map[string]interface{}),
 	}
 	for k, v := range l.Options {
 		copy.Options[k] = v
@@ -472,3 +472,40 @@ func TestBaseLogicBlockConfig_DeepCopy(t *testing.T) {
 		t.Errorf("DeepCopy() didn't create a deep copy, changes to original affected the copy")
 	}
 }
+
+func TestBaseLogicBlockConfig_DeepCopyKeepsType(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   types.LogicBlockConfig
+		wantType string
+	}{
+		{
+			name: "registered block type",
+			config: &DomainLogicBlockConfig{BaseLogicBlockConfig: BaseLogicBlockConfig{
+				definitions: DomainConfigElements,
+				BlockType:   DomainBlockType,
+				Options:     map[string]interface{}{DomainOptionDomains: []string{"example.com"}},
+			}},
+			wantType: "*logic.DomainLogicBlockConfig",
+		},
+		{
+			name: "custom block type",
+			config: &CustomLogicBlockConfig{BaseLogicBlockConfig: BaseLogicBlockConfig{
+				BlockType: "myCustom",
+				Options:   map[string]interface{}{"any": "value"},
+			}},
+			wantType: "*logic.CustomLogicBlockConfig",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copy := tt.config.DeepCopy()
+			if got := reflect.TypeOf(copy).String(); got != tt.wantType {
+				t.Errorf("DeepCopy() type = %s, want %s", got, tt.wantType)
+			}
+			if err := copy.ValidateAll(); err != nil {
+				t.Errorf("copied config should be valid: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"time"
+
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+func init() {
+	RegisterFactory(CreatedAtBlockType, &CreatedAtLogicBlockFactory{})
+}
+
+// CreatedAtLogicBlockConfig defines a logic block that passes posts whose record createdAt is within
+// [now - pastWindow, now + futureSkew]. posts with an invalid createdAt are rejected.
+// - pastWindow: maximum age of a post. if not set, old posts are not rejected
+// - futureSkew: maximum time a post may be dated in the future (default 5m)
+type CreatedAtLogicBlockConfig struct {
+	BaseLogicBlockConfig
+}
+
+const (
+	CreatedAtBlockType        = "createdat"
+	CreatedAtOptionPastWindow = "pastWindow" // optional
+	CreatedAtOptionFutureSkew = "futureSkew" // optional
+
+	CreatedAtDefaultFutureSkew = 5 * time.Minute
+)
+
+// CreatedAtLogicBlockFactory is a factory for creating CreatedAtLogicBlockConfig
+type CreatedAtLogicBlockFactory struct{}
+
+func (f *CreatedAtLogicBlockFactory) Create(base BaseLogicBlockConfig) (types.LogicBlockConfig, error) {
+	cfg := CreatedAtLogicBlockConfig{BaseLogicBlockConfig: base}
+	cfg.definitions = CreatedAtConfigElements
+	return &cfg, nil
+}
+
+var CreatedAtConfigElements = map[string]types.ConfigElementDefinition{
+	CreatedAtOptionPastWindow: {
+		Type:         types.ElementTypeDuration,
+		Key:          CreatedAtOptionPastWindow,
+		DefaultValue: nil,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			duration, ok := value.(time.Duration)
+			if !ok {
+				return errors.NewValidationError(CreatedAtOptionPastWindow, value, "must be a duration")
+			}
+			if duration <= 0 {
+				return errors.NewValidationError(CreatedAtOptionPastWindow, value, "must be positive")
+			}
+			return nil
+		},
+	},
+	CreatedAtOptionFutureSkew: {
+		Type:         types.ElementTypeDuration,
+		Key:          CreatedAtOptionFutureSkew,
+		DefaultValue: CreatedAtDefaultFutureSkew,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			duration, ok := value.(time.Duration)
+			if !ok {
+				return errors.NewValidationError(CreatedAtOptionFutureSkew, value, "must be a duration")
+			}
+			if duration < 0 {
+				return errors.NewValidationError(CreatedAtOptionFutureSkew, value, "must not be negative")
+			}
+			return nil
+		},
+	},
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"testing"
+)
+
+func TestCreatedAtLogicBlockConfig_ValidateAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *BaseLogicBlockConfig
+		wantErr bool
+	}{
+		{
+			name: "Success: no options",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: all options",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"pastWindow": "24h",
+					"futureSkew": "10m",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: zero futureSkew",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"futureSkew": "0s",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error: zero pastWindow",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"pastWindow": "0s",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: negative futureSkew",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"futureSkew": "-1m",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: invalid pastWindow",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"pastWindow": "one day",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&CreatedAtLogicBlockFactory{}).Create(*tt.config)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			err = cfg.ValidateAll()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
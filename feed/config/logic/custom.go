@@ -1,21 +1,70 @@
 package logic
 
-// CustomLogicBlockConfig don't have validation funcs
+import (
+	"sync"
+
+	"github.com/nus25/yuge/feed/config/types"
+)
+
+// customBlockSchemas holds the option definitions registered for custom block types.
+// schemas can be registered while configs are being created, so access it through customBlockSchemasMu.
+var (
+	customBlockSchemasMu sync.RWMutex
+	customBlockSchemas   = map[string]map[string]types.ConfigElementDefinition{}
+)
+
+// RegisterCustomBlockSchema registers the option definitions of a custom block type.
+// options of blocks of the type are validated against the definitions, so unknown keys and invalid values are rejected.
+// blocks of custom types without a schema accept any options. types with a registered factory don't use the schema.
+func RegisterCustomBlockSchema(blockType string, definitions map[string]types.ConfigElementDefinition) {
+	customBlockSchemasMu.Lock()
+	defer customBlockSchemasMu.Unlock()
+	customBlockSchemas[blockType] = definitions
+}
+
+// customBlockSchema returns the definitions registered for the custom block type
+func customBlockSchema(blockType string) (definitions map[string]types.ConfigElementDefinition, ok bool) {
+	customBlockSchemasMu.RLock()
+	defer customBlockSchemasMu.RUnlock()
+	definitions, ok = customBlockSchemas[blockType]
+	return definitions, ok
+}
+
+// CustomLogicBlockConfig is a config of a block type without a factory.
+// it doesn't validate options unless a schema is registered for the type by RegisterCustomBlockSchema.
 type CustomLogicBlockConfig struct {
 	BaseLogicBlockConfig
 }
 
+func newCustomLogicBlockConfig(base BaseLogicBlockConfig) *CustomLogicBlockConfig {
+	cfg := CustomLogicBlockConfig{BaseLogicBlockConfig: base}
+	cfg.definitions, _ = customBlockSchema(base.BlockType)
+	return &cfg
+}
+
 func (l *CustomLogicBlockConfig) ValidateAll() error {
-	// custom logic block don't have validation funcs
-	return nil
+	if l.definitions == nil {
+		// スキーマのないカスタムロジックブロックはバリデーションしない
+		return nil
+	}
+	return l.BaseLogicBlockConfig.ValidateAll()
 }
 
 func (l *CustomLogicBlockConfig) Validate(key string, value interface{}) error {
-	// custom logic block don't have validation funcs
-	return nil
+	if l.definitions == nil {
+		return nil
+	}
+	return l.BaseLogicBlockConfig.Validate(key, value)
 }
 
 func (c *CustomLogicBlockConfig) Update(key string, value interface{}) error {
+	if c.definitions != nil {
+		return c.BaseLogicBlockConfig.Update(key, value)
+	}
 	c.Options[key] = value
 	return nil
 }
+
+func (c *CustomLogicBlockConfig) DeepCopy() types.LogicBlockConfig {
+	return &CustomLogicBlockConfig{BaseLogicBlockConfig: c.copyBase()}
+}
@@ -1,7 +1,14 @@
 package logic
 
 import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
 	"testing"
+
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
 )
 
 func TestCustomLogicBlockConfig_Update(t *testing.T) {
@@ -85,3 +92,158 @@ func TestCustomLogicBlockConfig_Validate(t *testing.T) {
 		t.Errorf("Validate() error = %v, want nil", err)
 	}
 }
+
+func TestCustomLogicBlockConfig_RegisteredSchema(t *testing.T) {
+	const blockType = "schemaCustom"
+	RegisterCustomBlockSchema(blockType, map[string]types.ConfigElementDefinition{
+		"threshold": {
+			Type:     types.ElementTypeInt,
+			Key:      "threshold",
+			Required: true,
+			Validator: func(value interface{}) error {
+				if v, ok := value.(int); !ok || v < 0 {
+					return errors.NewValidationError("threshold", value, "must be a non-negative int")
+				}
+				return nil
+			},
+		},
+		"label": {
+			Type: types.ElementTypeString,
+			Key:  "label",
+		},
+	})
+	t.Cleanup(func() { unregisterCustomBlockSchema(blockType) })
+
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{
+			name: "正常系: スキーマに沿ったオプション",
+			json: `{"blocks":[{"type":"schemaCustom","options":{"threshold":3,"label":"a"}}]}`,
+		},
+		{
+			name:    "異常系: 未知のオプション",
+			json:    `{"blocks":[{"type":"schemaCustom","options":{"threshold":3,"lable":"a"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "異常系: 必須オプションがない",
+			json:    `{"blocks":[{"type":"schemaCustom","options":{"label":"a"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "異常系: 不正な値",
+			json:    `{"blocks":[{"type":"schemaCustom","options":{"threshold":-1}}]}`,
+			wantErr: true,
+		},
+		{
+			name: "正常系: スキーマのないカスタムブロックは任意のオプションを受け付ける",
+			json: `{"blocks":[{"type":"dynamicCustom","options":{"anything":true}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg FeedLogicConfigimpl
+			if err := json.Unmarshal([]byte(tt.json), &cfg); err != nil {
+				t.Fatalf("failed to unmarshal config: %v", err)
+			}
+			if _, ok := cfg.LogicBlocks[0].(*CustomLogicBlockConfig); !ok {
+				t.Fatalf("expected *CustomLogicBlockConfig, got %T", cfg.LogicBlocks[0])
+			}
+			err := cfg.ValidateAll()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// コピーもスキーマを引き継ぐ
+			if err := cfg.DeepCopy().ValidateAll(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAll() on copy error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// スキーマがある場合、Updateも検証して値を変換する
+	var cfg FeedLogicConfigimpl
+	if err := json.Unmarshal([]byte(`{"blocks":[{"type":"schemaCustom","options":{"threshold":3}}]}`), &cfg); err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	block := cfg.LogicBlocks[0].(*CustomLogicBlockConfig)
+	if err := block.Update("unknown", "value"); err == nil {
+		t.Error("expected error when updating unknown option")
+	}
+	if err := block.Update("threshold", float64(5)); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := block.GetOptions()["threshold"]; got != 5 {
+		t.Errorf("expected converted threshold 5, got %v (%T)", got, got)
+	}
+}
+
+func unregisterCustomBlockSchema(blockType string) {
+	customBlockSchemasMu.Lock()
+	defer customBlockSchemasMu.Unlock()
+	delete(customBlockSchemas, blockType)
+}
+
+func TestCustomLogicBlockConfig_ConcurrentSchemaRegistration(t *testing.T) {
+	// 設定の作成中にスキーマが登録されても競合しない
+	const blockType = "concurrentCustom"
+	t.Cleanup(func() { unregisterCustomBlockSchema(blockType) })
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterCustomBlockSchema(blockType, map[string]types.ConfigElementDefinition{
+				"label": {Type: types.ElementTypeString, Key: "label"},
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			var cfg FeedLogicConfigimpl
+			if err := json.Unmarshal([]byte(`{"blocks":[{"type":"concurrentCustom","options":{"label":"a"}}]}`), &cfg); err != nil {
+				t.Errorf("failed to unmarshal config: %v", err)
+				return
+			}
+			if err := cfg.ValidateAll(); err != nil {
+				t.Errorf("ValidateAll() error = %v", err)
+			}
+			BlockTypeDefinitions(blockType)
+		}()
+	}
+	wg.Wait()
+	if _, ok := BlockTypeDefinitions(blockType); !ok {
+		t.Error("expected registered schema")
+	}
+}
+
+func TestBlockTypeDefinitions(t *testing.T) {
+	RegisterCustomBlockSchema("schemaListed", map[string]types.ConfigElementDefinition{
+		"threshold": {Type: types.ElementTypeInt, Key: "threshold"},
+	})
+	t.Cleanup(func() { unregisterCustomBlockSchema("schemaListed") })
+
+	tests := []struct {
+		name      string
+		blockType string
+		wantOK    bool
+		wantKeys  []string
+	}{
+		{name: "組み込みブロック", blockType: RegexBlockType, wantOK: true, wantKeys: []string{RegexOptionCaseSensitive, TextOptionCollapseWhitespace, RegexOptionInvert, TextOptionNFKC, RegexOptionValue}},
+		{name: "オプションで定義が変わるブロックは全ての定義を返す", blockType: RemoveBlockType, wantOK: true, wantKeys: []string{RemoveOptionLanguage, RemoveOptionOperator, RemoveOptionSubject, RemoveOptionValue}},
+		{name: "スキーマを登録したカスタムブロック", blockType: "schemaListed", wantOK: true, wantKeys: []string{"threshold"}},
+		{name: "スキーマのないカスタムブロック", blockType: "dynamicCustom", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definitions, ok := BlockTypeDefinitions(tt.blockType)
+			if ok != tt.wantOK {
+				t.Fatalf("BlockTypeDefinitions() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got := slices.Sorted(maps.Keys(definitions)); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("BlockTypeDefinitions() keys = %v, want %v", got, tt.wantKeys)
+			}
+		})
+	}
+}
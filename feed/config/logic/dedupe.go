@@ -0,0 +1,84 @@
+package logic
+
+import (
+	"time"
+
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+func init() {
+	RegisterFactory(DedupeBlockType, &DedupeLogicBlockFactory{})
+}
+
+// DedupeLogicBlockConfig defines a logic block that rejects near-identical posts.
+// windowSize: int maximum number of recent texts to remember
+// ttl: duration how long a text is remembered
+// nfkc: bool applies unicode NFKC normalization before comparing
+// collapseWhitespace: bool ignores differences of whitespace (default true)
+// texts are always compared case-insensitively
+type DedupeLogicBlockConfig struct {
+	BaseLogicBlockConfig
+}
+
+const (
+	DedupeBlockType         = "dedupe"
+	DedupeOptionWindowSize  = "windowSize" // optional
+	DedupeOptionTTL         = "ttl"        // optional
+	DedupeDefaultWindowSize = 10000
+	DedupeDefaultTTL        = time.Hour
+	// 空白の違いはデフォルトで無視する
+	DedupeDefaultCollapseWhitespace = true
+)
+
+// DedupeLogicBlockFactory is a factory for creating DedupeLogicBlockConfig
+type DedupeLogicBlockFactory struct{}
+
+func (f *DedupeLogicBlockFactory) Create(base BaseLogicBlockConfig) (types.LogicBlockConfig, error) {
+	cfg := DedupeLogicBlockConfig{BaseLogicBlockConfig: base}
+	cfg.definitions = DedupeConfigElements
+	return &cfg, nil
+}
+
+var DedupeConfigElements = map[string]types.ConfigElementDefinition{
+	DedupeOptionWindowSize: {
+		Type:         types.ElementTypeInt,
+		Key:          DedupeOptionWindowSize,
+		DefaultValue: DedupeDefaultWindowSize,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			size, ok := value.(int)
+			if !ok {
+				return errors.NewValidationError(DedupeOptionWindowSize, value, "must be an integer")
+			}
+			if size <= 0 {
+				return errors.NewValidationError(DedupeOptionWindowSize, value, "must be positive")
+			}
+			return nil
+		},
+	},
+	TextOptionNFKC:               textNFKCElement,
+	TextOptionCollapseWhitespace: dedupeCollapseWhitespaceElement,
+	DedupeOptionTTL: {
+		Type:         types.ElementTypeDuration,
+		Key:          DedupeOptionTTL,
+		DefaultValue: DedupeDefaultTTL,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			duration, ok := value.(time.Duration)
+			if !ok {
+				return errors.NewValidationError(DedupeOptionTTL, value, "must be a duration")
+			}
+			if duration <= 0 {
+				return errors.NewValidationError(DedupeOptionTTL, value, "must be positive")
+			}
+			return nil
+		},
+	},
+}
+
+var dedupeCollapseWhitespaceElement = func() types.ConfigElementDefinition {
+	e := boolElement(TextOptionCollapseWhitespace)
+	e.DefaultValue = DedupeDefaultCollapseWhitespace
+	return e
+}()
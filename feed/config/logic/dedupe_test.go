@@ -0,0 +1,71 @@
+package logic
+
+import (
+	"testing"
+)
+
+func TestDedupeLogicBlockConfig_ValidateAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *BaseLogicBlockConfig
+		wantErr bool
+	}{
+		{
+			name: "Success: no options",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: windowSize and ttl",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"windowSize": 100,
+					"ttl":        "30m",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error: windowSize is zero",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"windowSize": 0,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: invalid ttl",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"ttl": "forever",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: negative ttl",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"ttl": "-1m",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&DedupeLogicBlockFactory{}).Create(*tt.config)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			err = cfg.ValidateAll()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
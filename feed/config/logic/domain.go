@@ -0,0 +1,85 @@
+package logic
+
+import (
+	"strings"
+
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+func init() {
+	RegisterFactory(DomainBlockType, &DomainLogicBlockFactory{})
+}
+
+// DomainLogicBlockConfig defines a logic block for filtering posts by the domains of their links.
+// links are taken from link facets and external embeds.
+// - domains: host names to match, e.g. "example.com"
+// - includeSubdomains: if true, subdomains of the domains also match (e.g. "news.example.com")
+// - invert: if true, inverts the match result (keeps posts without matching links)
+type DomainLogicBlockConfig struct {
+	BaseLogicBlockConfig
+}
+
+const (
+	DomainBlockType               = "domain"
+	DomainOptionDomains           = "domains"           // required
+	DomainOptionIncludeSubdomains = "includeSubdomains" // optional
+	DomainOptionInvert            = "invert"            // optional
+)
+
+// DomainLogicBlockFactory is a factory for creating DomainLogicBlockConfig
+type DomainLogicBlockFactory struct{}
+
+func (f *DomainLogicBlockFactory) Create(base BaseLogicBlockConfig) (types.LogicBlockConfig, error) {
+	cfg := DomainLogicBlockConfig{BaseLogicBlockConfig: base}
+	cfg.definitions = DomainConfigElements
+	return &cfg, nil
+}
+
+var DomainConfigElements = map[string]types.ConfigElementDefinition{
+	DomainOptionDomains: {
+		Type:         types.ElementTypeStringArray,
+		Key:          DomainOptionDomains,
+		DefaultValue: nil,
+		Required:     true,
+		Validator: func(value interface{}) error {
+			domains, err := types.ConvertStringArray(value)
+			if err != nil {
+				return errors.NewValidationError(DomainOptionDomains, value, "must be a string array")
+			}
+			if len(domains) == 0 {
+				return errors.NewValidationError(DomainOptionDomains, value, "must not be empty")
+			}
+			for _, d := range domains {
+				if d == "" || strings.ContainsAny(d, "/: ") {
+					return errors.NewValidationError(DomainOptionDomains, d, "must be a host name such as example.com")
+				}
+			}
+			return nil
+		},
+	},
+	DomainOptionIncludeSubdomains: {
+		Type:         types.ElementTypeBool,
+		Key:          DomainOptionIncludeSubdomains,
+		DefaultValue: false,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			if _, ok := value.(bool); !ok {
+				return errors.NewValidationError(DomainOptionIncludeSubdomains, value, "must be a boolean")
+			}
+			return nil
+		},
+	},
+	DomainOptionInvert: {
+		Type:         types.ElementTypeBool,
+		Key:          DomainOptionInvert,
+		DefaultValue: false,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			if _, ok := value.(bool); !ok {
+				return errors.NewValidationError(DomainOptionInvert, value, "must be a boolean")
+			}
+			return nil
+		},
+	},
+}
@@ -0,0 +1,84 @@
+package logic
+
+import (
+	"testing"
+)
+
+func TestDomainLogicBlockConfig_ValidateAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *BaseLogicBlockConfig
+		wantErr bool
+	}{
+		{
+			name: "Success: domains only",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"domains": []string{"example.com", "news.example.org"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: all options",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"domains":           []interface{}{"example.com"},
+					"includeSubdomains": true,
+					"invert":            false,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error: domains is not set",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"invert": true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: empty domains",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"domains": []string{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: url instead of domain",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"domains": []string{"https://example.com/"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: invalid includeSubdomains",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"domains":           []string{"example.com"},
+					"includeSubdomains": "yes",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&DomainLogicBlockFactory{}).Create(*tt.config)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			err = cfg.ValidateAll()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ type DropInLogicBlockConfig struct {
 	TargetWord     []string
 	CancelWord     []string
 	IgnoreWord     []string
+	MaxEntries     int
 }
 
 const (
@@ -25,6 +26,12 @@ const (
 	DropInOptionCancelWord     = "cancelWord"     // optional
 	DropInOptionIgnoreWord     = "ignoreWord"     // optional
 	DropInOptionExpireDuration = "expireDuration" // optional
+	DropInOptionMaxEntries     = "maxEntries"     // optional, 0 means unlimited
+	// if true, words match only when not adjacent to other letters or digits.
+	// not suitable for languages without spaces between words such as Japanese.
+	DropInOptionWordBoundary = "wordBoundary" // optional
+	// if true, text inside link and mention facets is not matched
+	DropInOptionExcludeFacets = "excludeFacets" // optional
 )
 
 // DropInLogicBlockFactory is a factory for creating DropInLogicBlockConfig
@@ -37,6 +44,7 @@ func (f *DropInLogicBlockFactory) Create(base BaseLogicBlockConfig) (types.Logic
 	cfg.TargetWord, _ = cfg.GetStringArrayOption(DropInOptionTargetWord)
 	cfg.CancelWord, _ = cfg.GetStringArrayOption(DropInOptionCancelWord)
 	cfg.IgnoreWord, _ = cfg.GetStringArrayOption(DropInOptionIgnoreWord)
+	cfg.MaxEntries, _ = cfg.GetIntOption(DropInOptionMaxEntries)
 
 	return &cfg, nil
 }
@@ -103,4 +111,31 @@ var DropInConfigElements = map[string]types.ConfigElementDefinition{
 			return nil
 		},
 	},
+	DropInOptionMaxEntries: {
+		Type:         types.ElementTypeInt,
+		Key:          DropInOptionMaxEntries,
+		DefaultValue: 0,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			var n int
+			switch v := value.(type) {
+			case int:
+				n = v
+			case uint64:
+				n = int(v)
+			case float64:
+				n = int(v)
+			default:
+				return errors.NewValidationError(DropInOptionMaxEntries, value, "must be an integer")
+			}
+			if n < 0 {
+				return errors.NewValidationError(DropInOptionMaxEntries, value, "must not be negative")
+			}
+			return nil
+		},
+	},
+	DropInOptionWordBoundary:     boolElement(DropInOptionWordBoundary),
+	DropInOptionExcludeFacets:    boolElement(DropInOptionExcludeFacets),
+	TextOptionNFKC:               textNFKCElement,
+	TextOptionCollapseWhitespace: textCollapseWhitespaceElement,
 }
@@ -23,6 +23,28 @@ func TestDropInLogicBlockConfig_ValidateAll(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "正常系: maxEntriesが設定されている",
+			config: &BaseLogicBlockConfig{
+				BlockType: "dropin",
+				Options: map[string]interface{}{
+					"targetWord": []string{"hello"},
+					"maxEntries": 100,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "異常系: maxEntriesが負数",
+			config: &BaseLogicBlockConfig{
+				BlockType: "dropin",
+				Options: map[string]interface{}{
+					"targetWord": []string{"hello"},
+					"maxEntries": -1,
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "異常系: targetWordが設定されていない",
 			config: &BaseLogicBlockConfig{
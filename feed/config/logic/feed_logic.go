@@ -12,6 +12,8 @@ var _ types.FeedLogicConfig = (*FeedLogicConfigimpl)(nil) //type check
 
 type FeedLogicConfigimpl struct {
 	LogicBlocks []types.LogicBlockConfig `yaml:"blocks" json:"blocks"`
+	// MinMatch passes a post when at least MinMatch blocks match. 0 means all blocks must match.
+	MinMatch int `yaml:"minMatch,omitempty" json:"minMatch,omitempty"`
 }
 
 func DefaultFeedLogicConfig() *FeedLogicConfigimpl {
@@ -23,6 +25,7 @@ func DefaultFeedLogicConfig() *FeedLogicConfigimpl {
 func (f *FeedLogicConfigimpl) DeepCopy() types.FeedLogicConfig {
 	copy := FeedLogicConfigimpl{
 		LogicBlocks: make([]types.LogicBlockConfig, len(f.LogicBlocks)),
+		MinMatch:    f.MinMatch,
 	}
 	for i, block := range f.LogicBlocks {
 		copy.LogicBlocks[i] = block.DeepCopy()
@@ -34,6 +37,10 @@ func (f *FeedLogicConfigimpl) GetLogicBlockConfigs() []types.LogicBlockConfig {
 	return f.LogicBlocks
 }
 
+func (f *FeedLogicConfigimpl) GetMinMatch() int {
+	return f.MinMatch
+}
+
 type tempLogicBlockConfig struct {
 	Type    string                 `yaml:"type" json:"type"`
 	Name    string                 `yaml:"name,omitempty" json:"name,omitempty"`
@@ -57,7 +64,7 @@ func (f *FeedLogicConfigimpl) createLogicBlocks(blocks []tempLogicBlockConfig) (
 				return nil, err
 			}
 		} else {
-			logicBlock = &CustomLogicBlockConfig{BaseLogicBlockConfig: base}
+			logicBlock = newCustomLogicBlockConfig(base)
 		}
 
 		logicBlocks[i] = logicBlock
@@ -68,6 +75,7 @@ func (f *FeedLogicConfigimpl) createLogicBlocks(blocks []tempLogicBlockConfig) (
 func (f *FeedLogicConfigimpl) UnmarshalJSON(data []byte) error {
 	var tempConfig struct {
 		LogicBlocks []tempLogicBlockConfig `json:"blocks"`
+		MinMatch    int                    `json:"minMatch"`
 	}
 
 	if err := json.Unmarshal(data, &tempConfig); err != nil {
@@ -79,6 +87,7 @@ func (f *FeedLogicConfigimpl) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	f.LogicBlocks = logicBlocks
+	f.MinMatch = tempConfig.MinMatch
 	return nil
 }
 
@@ -94,14 +103,17 @@ func (f *FeedLogicConfigimpl) MarshalYAML() (interface{}, error) {
 
 	return struct {
 		LogicBlocks []tempLogicBlockConfig `yaml:"blocks"`
+		MinMatch    int                    `yaml:"minMatch,omitempty"`
 	}{
 		LogicBlocks: blocks,
+		MinMatch:    f.MinMatch,
 	}, nil
 }
 
 func (f *FeedLogicConfigimpl) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var tempConfig struct {
 		LogicBlocks []tempLogicBlockConfig `yaml:"blocks"`
+		MinMatch    int                    `yaml:"minMatch"`
 	}
 
 	if err := unmarshal(&tempConfig); err != nil {
@@ -113,10 +125,14 @@ func (f *FeedLogicConfigimpl) UnmarshalYAML(unmarshal func(interface{}) error) e
 		return err
 	}
 	f.LogicBlocks = logicBlocks
+	f.MinMatch = tempConfig.MinMatch
 	return nil
 }
 
 func (f *FeedLogicConfigimpl) ValidateAll() error {
+	if err := f.Validate("minMatch", f.MinMatch); err != nil {
+		return err
+	}
 	for i, block := range f.LogicBlocks {
 		if err := block.ValidateAll(); err != nil {
 			return errors.NewConfigError(
@@ -148,5 +164,17 @@ func (f *FeedLogicConfigimpl) Validate(key string, value interface{}) error {
 			return errors.NewConfigError("FeedLogic", key, "invalid type for logicBlocks: expected []LogicBlockConfig")
 		}
 	}
+	if key == "minMatch" {
+		minMatch, ok := value.(int)
+		if !ok {
+			return errors.NewConfigError("FeedLogic", key, "invalid type for minMatch: expected int")
+		}
+		if minMatch < 0 {
+			return errors.NewConfigError("FeedLogic", key, "minMatch must not be negative")
+		}
+		if minMatch > len(f.LogicBlocks) {
+			return errors.NewConfigError("FeedLogic", key, fmt.Sprintf("minMatch must not exceed the number of logic blocks (%d)", len(f.LogicBlocks)))
+		}
+	}
 	return nil
 }
@@ -420,3 +420,75 @@ func TestFeedLogicConfigimpl_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestFeedLogicConfig_MinMatch(t *testing.T) {
+	blocks := `blocks:
+- type: regex
+  options:
+    value: apple
+    invert: false
+    caseSensitive: true
+- type: regex
+  options:
+    value: banana
+    invert: false
+    caseSensitive: true
+`
+	tests := []struct {
+		name     string
+		config   string
+		wantErr  bool
+		minMatch int
+	}{
+		{name: "正常系: minMatch未指定", config: blocks, minMatch: 0},
+		{name: "正常系: minMatchがブロック数以下", config: blocks + "minMatch: 1\n", minMatch: 1},
+		{name: "正常系: minMatchがブロック数と同じ", config: blocks + "minMatch: 2\n", minMatch: 2},
+		{name: "異常系: minMatchがブロック数を超える", config: blocks + "minMatch: 3\n", wantErr: true},
+		{name: "異常系: minMatchが負の値", config: blocks + "minMatch: -1\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := FeedLogicConfigimpl{}
+			if err := yaml.Unmarshal([]byte(tt.config), &cfg); err != nil {
+				t.Fatalf("Failed to unmarshal config: %v", err)
+			}
+			err := cfg.ValidateAll()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := cfg.GetMinMatch(); got != tt.minMatch {
+				t.Errorf("GetMinMatch() = %d, want %d", got, tt.minMatch)
+			}
+			// YAML/JSON/DeepCopyで値が保持されること
+			out, err := yaml.Marshal(&cfg)
+			if err != nil {
+				t.Fatalf("Failed to marshal config: %v", err)
+			}
+			var yamlCfg FeedLogicConfigimpl
+			if err := yaml.Unmarshal(out, &yamlCfg); err != nil {
+				t.Fatalf("Failed to unmarshal marshaled config: %v", err)
+			}
+			if yamlCfg.GetMinMatch() != tt.minMatch {
+				t.Errorf("yaml roundtrip minMatch = %d, want %d", yamlCfg.GetMinMatch(), tt.minMatch)
+			}
+			js, err := json.Marshal(&cfg)
+			if err != nil {
+				t.Fatalf("Failed to marshal config to json: %v", err)
+			}
+			var jsonCfg FeedLogicConfigimpl
+			if err := json.Unmarshal(js, &jsonCfg); err != nil {
+				t.Fatalf("Failed to unmarshal json config: %v", err)
+			}
+			if jsonCfg.GetMinMatch() != tt.minMatch {
+				t.Errorf("json roundtrip minMatch = %d, want %d", jsonCfg.GetMinMatch(), tt.minMatch)
+			}
+			if got := cfg.DeepCopy().GetMinMatch(); got != tt.minMatch {
+				t.Errorf("DeepCopy minMatch = %d, want %d", got, tt.minMatch)
+			}
+		})
+	}
+}
@@ -2,6 +2,12 @@ package logic
 
 import "github.com/nus25/yuge/feed/config/types"
 
+// DefinitionsProvider is implemented by factories whose option definitions depend on the options, e.g. remove.
+// Definitions returns the definitions of all options the block type accepts.
+type DefinitionsProvider interface {
+	Definitions() map[string]types.ConfigElementDefinition
+}
+
 type LogicBlockFactory interface {
 	Create(base BaseLogicBlockConfig) (types.LogicBlockConfig, error)
 }
@@ -13,3 +19,26 @@ var logicBlockFactories = map[string]LogicBlockFactory{}
 func RegisterFactory(blockType string, factory LogicBlockFactory) {
 	logicBlockFactories[blockType] = factory
 }
+
+// BlockTypeDefinitions returns the option definitions of the block type.
+// ok is false if the type has neither a factory nor a schema registered by RegisterCustomBlockSchema.
+func BlockTypeDefinitions(blockType string) (definitions map[string]types.ConfigElementDefinition, ok bool) {
+	factory, exists := logicBlockFactories[blockType]
+	if !exists {
+		return customBlockSchema(blockType)
+	}
+	if p, ok := factory.(DefinitionsProvider); ok {
+		return p.Definitions(), true
+	}
+	cfg, err := factory.Create(BaseLogicBlockConfig{BlockType: blockType, Options: map[string]interface{}{}})
+	if err != nil {
+		return nil, false
+	}
+	d, ok := cfg.(interface {
+		elementDefinitions() map[string]types.ConfigElementDefinition
+	})
+	if !ok {
+		return nil, false
+	}
+	return d.elementDefinitions(), true
+}
@@ -18,6 +18,8 @@ func init() {
 // - value: The regex pattern to match against
 // - invert: If true, inverts the match result (keeps non-matching posts)
 // - caseSensitive: If true, performs case-sensitive regex matching
+// - nfkc, collapseWhitespace: normalize the post text before matching (see text_normalize.go).
+// the pattern is not normalized, so write it in the normalized form.
 type RegexLogicBlockConfig struct {
 	BaseLogicBlockConfig
 }
@@ -81,4 +83,6 @@ var RegexConfigElements = map[string]types.ConfigElementDefinition{
 			return nil
 		},
 	},
+	TextOptionNFKC:               textNFKCElement,
+	TextOptionCollapseWhitespace: textCollapseWhitespaceElement,
 }
@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"maps"
 	"slices"
 	"strings"
 
@@ -54,6 +55,14 @@ func (f *RemoveLogicBlockFactory) Create(base BaseLogicBlockConfig) (types.Logic
 	return config, nil
 }
 
+// Definitions returns the options of both subjects.
+// value is used with subject item, language and operator with subject language.
+func (f *RemoveLogicBlockFactory) Definitions() map[string]types.ConfigElementDefinition {
+	definitions := maps.Clone(RemoveItemConfigElements)
+	maps.Copy(definitions, RemoveSubjectConfigElements)
+	return definitions
+}
+
 var elementDefinitionSubject = types.ConfigElementDefinition{
 	Type:         types.ElementTypeString,
 	Key:          RemoveOptionSubject,
@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+func init() {
+	RegisterFactory(ReplyBlockType, &ReplyLogicBlockFactory{})
+}
+
+// ReplyLogicBlockConfig defines a logic block for filtering posts by their reply state.
+// keep: "toplevel" passes only posts that are not replies,
+// "replies" passes only replies and "all" passes both.
+// replyToDid: if set, replies pass only when the parent or root post author matches the DID.
+// replyToDid cannot be combined with keep: toplevel.
+type ReplyLogicBlockConfig struct {
+	BaseLogicBlockConfig
+}
+
+const (
+	ReplyBlockType        = "reply"
+	ReplyOptionKeep       = "keep"       // required
+	ReplyOptionReplyToDid = "replyToDid" // optional
+	ReplyKeepTopLevel     = "toplevel"
+	ReplyKeepReplies      = "replies"
+	ReplyKeepAll          = "all"
+)
+
+// ReplyLogicBlockFactory is a factory for creating ReplyLogicBlockConfig
+type ReplyLogicBlockFactory struct{}
+
+func (f *ReplyLogicBlockFactory) Create(base BaseLogicBlockConfig) (types.LogicBlockConfig, error) {
+	cfg := ReplyLogicBlockConfig{BaseLogicBlockConfig: base}
+	cfg.definitions = ReplyConfigElements
+	return &cfg, nil
+}
+
+var ReplyConfigElements = map[string]types.ConfigElementDefinition{
+	ReplyOptionKeep: {
+		Type:         types.ElementTypeString,
+		Key:          ReplyOptionKeep,
+		DefaultValue: nil,
+		Required:     true,
+		Validator: func(value interface{}) error {
+			arr := []string{ReplyKeepTopLevel, ReplyKeepReplies, ReplyKeepAll}
+			if !slices.Contains(arr, value.(string)) {
+				return errors.NewValidationError(ReplyOptionKeep, value, "keep must be one of the following: "+strings.Join(arr, ", "))
+			}
+			return nil
+		},
+	},
+	ReplyOptionReplyToDid: {
+		Type:         types.ElementTypeString,
+		Key:          ReplyOptionReplyToDid,
+		DefaultValue: "",
+		Required:     false,
+		Validator: func(value interface{}) error {
+			if _, err := syntax.ParseDID(value.(string)); err != nil {
+				return errors.NewValidationError(ReplyOptionReplyToDid, value, "must be a valid did")
+			}
+			return nil
+		},
+	},
+}
+
+func (l *ReplyLogicBlockConfig) ValidateAll() error {
+	if err := l.BaseLogicBlockConfig.ValidateAll(); err != nil {
+		return err
+	}
+	// replyToDid only makes sense when replies can pass
+	keep, _ := l.GetStringOption(ReplyOptionKeep)
+	if did, exists := l.GetStringOption(ReplyOptionReplyToDid); exists && keep == ReplyKeepTopLevel {
+		return errors.NewValidationError(ReplyOptionReplyToDid, did, "replyToDid cannot be used with keep: "+ReplyKeepTopLevel)
+	}
+	return nil
+}
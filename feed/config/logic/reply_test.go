@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"testing"
+)
+
+func TestReplyLogicBlockConfig_ValidateAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *BaseLogicBlockConfig
+		wantErr bool
+	}{
+		{
+			name: "Success: keep toplevel",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"keep": "toplevel",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: keep replies with replyToDid",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"keep":       "replies",
+					"replyToDid": "did:plc:test",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: keep all with replyToDid",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"keep":       "all",
+					"replyToDid": "did:plc:test",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error: keep is not set",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"replyToDid": "did:plc:test",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: invalid keep value",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"keep": "quotes",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: invalid replyToDid",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"keep":       "replies",
+					"replyToDid": "not-a-did",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: toplevel and replyToDid are mutually exclusive",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"keep":       "toplevel",
+					"replyToDid": "did:plc:test",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&ReplyLogicBlockFactory{}).Create(*tt.config)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			err = cfg.ValidateAll()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
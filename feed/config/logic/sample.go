@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+func init() {
+	RegisterFactory(SampleBlockType, &SampleLogicBlockFactory{})
+}
+
+// SampleLogicBlockConfig defines a logic block that passes posts with the given probability.
+// rate: float 0..1 probability of passing
+// deterministic: bool if true, the decision is derived from a hash of did+rkey and is stable across restarts
+// seed: int seed of the RNG used when deterministic is false. if not set, a random seed is used
+type SampleLogicBlockConfig struct {
+	BaseLogicBlockConfig
+}
+
+const (
+	SampleBlockType           = "sample"
+	SampleOptionRate          = "rate"          // required
+	SampleOptionDeterministic = "deterministic" // optional
+	SampleOptionSeed          = "seed"          // optional
+)
+
+// SampleLogicBlockFactory is a factory for creating SampleLogicBlockConfig
+type SampleLogicBlockFactory struct{}
+
+func (f *SampleLogicBlockFactory) Create(base BaseLogicBlockConfig) (types.LogicBlockConfig, error) {
+	cfg := SampleLogicBlockConfig{BaseLogicBlockConfig: base}
+	cfg.definitions = SampleConfigElements
+	return &cfg, nil
+}
+
+var SampleConfigElements = map[string]types.ConfigElementDefinition{
+	SampleOptionRate: {
+		Type:         types.ElementTypeFloat,
+		Key:          SampleOptionRate,
+		DefaultValue: nil,
+		Required:     true,
+		Validator: func(value interface{}) error {
+			rate, ok := value.(float64)
+			if !ok {
+				return errors.NewValidationError(SampleOptionRate, value, "must be a float")
+			}
+			if rate < 0 || rate > 1 {
+				return errors.NewValidationError(SampleOptionRate, value, "must be between 0 and 1")
+			}
+			return nil
+		},
+	},
+	SampleOptionDeterministic: {
+		Type:         types.ElementTypeBool,
+		Key:          SampleOptionDeterministic,
+		DefaultValue: false,
+		Required:     false,
+		Validator:    nil,
+	},
+	SampleOptionSeed: {
+		Type:         types.ElementTypeInt,
+		Key:          SampleOptionSeed,
+		DefaultValue: nil,
+		Required:     false,
+		Validator:    nil,
+	},
+}
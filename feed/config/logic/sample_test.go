@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"testing"
+)
+
+func TestSampleLogicBlockConfig_ValidateAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *BaseLogicBlockConfig
+		wantErr bool
+	}{
+		{
+			name: "Success: rate only",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"rate": 0.1,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: deterministic with seed",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"rate":          1.0,
+					"deterministic": true,
+					"seed":          42,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Success: rate as string",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"rate": "0.5",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error: rate is not set",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"deterministic": true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: rate is negative",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"rate": -0.1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Error: rate is greater than 1",
+			config: &BaseLogicBlockConfig{
+				Options: map[string]interface{}{
+					"rate": 1.5,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&SampleLogicBlockFactory{}).Create(*tt.config)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			err = cfg.ValidateAll()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package logic
+
+import (
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+// options for normalizing post text, shared by text based logic blocks (regex, dropin, dedupe)
+// - nfkc: applies unicode NFKC normalization, e.g. fullwidth "Ａ" to "A" and halfwidth "ｶ" to "カ"
+// - collapseWhitespace: replaces runs of whitespace with a single space and trims both ends
+const (
+	TextOptionNFKC               = "nfkc"               // optional
+	TextOptionCollapseWhitespace = "collapseWhitespace" // optional
+)
+
+func boolElement(key string) types.ConfigElementDefinition {
+	return types.ConfigElementDefinition{
+		Type:         types.ElementTypeBool,
+		Key:          key,
+		DefaultValue: false,
+		Required:     false,
+		Validator: func(value interface{}) error {
+			if _, ok := value.(bool); !ok {
+				return errors.NewValidationError(key, value, "must be a boolean")
+			}
+			return nil
+		},
+	}
+}
+
+var (
+	textNFKCElement               = boolElement(TextOptionNFKC)
+	textCollapseWhitespaceElement = boolElement(TextOptionCollapseWhitespace)
+)
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nus25/yuge/feed/config/types"
+)
+
+var _ FeedConfigProvider = (*InMemoryFeedConfigProvider)(nil) //type check
+
+// ErrConfigNotPersisted is returned by Save of providers that keep the config only in memory
+var ErrConfigNotPersisted = errors.New("config is kept in memory and not persisted")
+
+// InMemoryFeedConfigProvider provides feed configuration given directly (e.g. inline in an API request).
+type InMemoryFeedConfigProvider struct {
+	original types.FeedConfig
+	config   types.FeedConfig
+}
+
+// NewInMemoryFeedConfigProvider creates a provider from cfg after validating it.
+func NewInMemoryFeedConfigProvider(cfg types.FeedConfig) (FeedConfigProvider, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+	if err := cfg.ValidateAll(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	// 呼び出し元やフィードが設定を書き換えてもLoadで作成時の設定に戻せるようにコピーを保持する
+	original := cfg.DeepCopy()
+	return &InMemoryFeedConfigProvider{
+		original: original,
+		config:   original.DeepCopy(),
+	}, nil
+}
+
+// Load returns a copy of the configuration given on creation.
+func (p *InMemoryFeedConfigProvider) Load() (types.FeedConfig, error) {
+	p.config = p.original.DeepCopy()
+	return p.config, nil
+}
+
+// Save always returns ErrConfigNotPersisted.
+func (p *InMemoryFeedConfigProvider) Save() error {
+	slog.Warn("Save operation is not supported in InMemoryProvider")
+	return fmt.Errorf("save operation is not supported in InMemoryProvider: %w", ErrConfigNotPersisted)
+}
+
+// FeedConfig returns the current configuration.
+func (p *InMemoryFeedConfigProvider) FeedConfig() types.FeedConfig {
+	return p.config
+}
+
+// Update updates the configuration.
+func (p *InMemoryFeedConfigProvider) Update(cfg types.FeedConfig) error {
+	p.config = cfg.DeepCopy()
+	return nil
+}
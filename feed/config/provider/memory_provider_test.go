@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nus25/yuge/feed/config/feed"
+	"github.com/nus25/yuge/feed/config/types"
+)
+
+func TestInMemoryFeedConfigProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{
+			name:    "valid config",
+			json:    `{"logic":{"blocks":[{"type":"remove","options":{"subject":"item","value":"reply"}}]},"store":{"trimAt":24,"trimRemain":20}}`,
+			wantErr: false,
+		},
+		{
+			name:    "nil config",
+			json:    "",
+			wantErr: true,
+		},
+		{
+			name:    "invalid store config",
+			json:    `{"logic":{"blocks":[]},"store":{"trimAt":-1,"trimRemain":20}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg types.FeedConfig
+			if tt.json != "" {
+				c, err := feed.NewFeedConfigFromJSON(tt.json)
+				if err != nil {
+					t.Fatalf("failed to unmarshal config: %v", err)
+				}
+				cfg = c
+			}
+			p, err := NewInMemoryFeedConfigProvider(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewInMemoryFeedConfigProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := p.FeedConfig().Store().GetTrimAt(); got != 24 {
+				t.Errorf("trimAt = %d, want 24", got)
+			}
+			// 渡した設定や取得した設定を書き換えても作成時の設定は変わらない
+			for _, c := range []types.FeedConfig{cfg, p.FeedConfig()} {
+				if err := c.Store().(interface {
+					Update(string, interface{}) error
+				}).Update("trimAt", 50); err != nil {
+					t.Fatalf("failed to update store config: %v", err)
+				}
+			}
+			if loaded, _ := p.Load(); loaded.Store().GetTrimAt() != 24 {
+				t.Errorf("trimAt after load = %d, want 24", loaded.Store().GetTrimAt())
+			}
+
+			// Loadは作成時の設定に戻す
+			var updated feed.FeedConfigImpl
+			if err := updated.UnmarshalJSON([]byte(`{"logic":{"blocks":[]},"store":{"trimAt":100,"trimRemain":20}}`)); err != nil {
+				t.Fatalf("failed to unmarshal config: %v", err)
+			}
+			p.Update(&updated)
+			if got := p.FeedConfig().Store().GetTrimAt(); got != 100 {
+				t.Errorf("trimAt after update = %d, want 100", got)
+			}
+			loaded, err := p.Load()
+			if err != nil {
+				t.Fatalf("failed to load: %v", err)
+			}
+			if got := loaded.Store().GetTrimAt(); got != 24 {
+				t.Errorf("trimAt after load = %d, want 24", got)
+			}
+			if err := p.Save(); !errors.Is(err, ErrConfigNotPersisted) {
+				t.Errorf("Save() error = %v, want ErrConfigNotPersisted", err)
+			}
+		})
+	}
+}
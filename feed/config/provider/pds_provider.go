@@ -1,12 +1,18 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/nus25/yuge/feed/config/feed"
 	"github.com/nus25/yuge/feed/config/types"
@@ -16,25 +22,85 @@ var _ FeedConfigProvider = (*PDSFeedConfigProvider)(nil) //type check
 
 const (
 	BlueskyAPIBaseURL = "https://public.api.bsky.app"
+	// PDSConfigCacheDirName is the directory name under the data directory for cached PDS configs
+	PDSConfigCacheDirName = "pdsconfig"
+
+	defaultPDSMaxRetries     = 3
+	defaultPDSRetryWaitTime  = 2 * time.Second
+	defaultPDSRequestTimeout = 10 * time.Second
 )
 
+// ErrPDSUnavailable is returned when the PDS could not be reached or answered with a temporary error.
+// the config may be loaded successfully by retrying later.
+var ErrPDSUnavailable = errors.New("pds unavailable")
+
 // PDSFeedConfigProvider provides feed configuration from PDS.
 type PDSFeedConfigProvider struct {
-	apiBaseURL string
-	uri        string
-	config     types.FeedConfig
+	apiBaseURL    string
+	uri           string
+	config        types.FeedConfig
+	maxRetries    int
+	retryWaitTime time.Duration
+	cacheDir      string
+	httpClient    *http.Client
+}
+
+// PDSProviderOption configures PDSFeedConfigProvider.
+type PDSProviderOption func(*PDSFeedConfigProvider)
+
+// WithPDSMaxRetries sets how many times the initial load is retried when the PDS is unavailable.
+func WithPDSMaxRetries(maxRetries int) PDSProviderOption {
+	return func(p *PDSFeedConfigProvider) {
+		p.maxRetries = maxRetries
+	}
+}
+
+// WithPDSRetryWaitTime sets the base wait time of the exponential backoff between retries.
+func WithPDSRetryWaitTime(retryWaitTime time.Duration) PDSProviderOption {
+	return func(p *PDSFeedConfigProvider) {
+		p.retryWaitTime = retryWaitTime
+	}
+}
+
+// WithPDSRequestTimeout sets the timeout of each request to the PDS.
+func WithPDSRequestTimeout(timeout time.Duration) PDSProviderOption {
+	return func(p *PDSFeedConfigProvider) {
+		p.httpClient = &http.Client{Timeout: timeout}
+	}
+}
+
+// WithPDSCacheDir enables caching of the last successfully fetched config under dir.
+// when the PDS is unavailable at startup, the cached config is used instead.
+// caching is disabled if dir is empty (default).
+func WithPDSCacheDir(dir string) PDSProviderOption {
+	return func(p *PDSFeedConfigProvider) {
+		p.cacheDir = dir
+	}
 }
 
 // NewPDSFeedConfigProvider creates a new PDSProvider instance.
 // uri is the URI of the PDS "app.bsky.feed.generator" record to load the feed configuration from.
-func NewPDSFeedConfigProvider(uri string) (FeedConfigProvider, error) {
-	return NewPDSFeedConfigProviderWithBaseURL(uri, "")
+// the initial load and its retries are aborted when ctx is done.
+func NewPDSFeedConfigProvider(ctx context.Context, uri string, opts ...PDSProviderOption) (FeedConfigProvider, error) {
+	return NewPDSFeedConfigProviderWithBaseURL(ctx, uri, "", opts...)
 }
 
 // apiBaseURL is the base URL of the XRPC API.if nil, BlueskyAPIBaseURL will be used.
-func NewPDSFeedConfigProviderWithBaseURL(uri string, apiBaseURL string) (FeedConfigProvider, error) {
+// if the PDS is unavailable, the initial load is retried with exponential backoff.
+// when all retries fail or ctx is done, the cached config is used if available.
+// otherwise the returned error wraps ErrPDSUnavailable.
+func NewPDSFeedConfigProviderWithBaseURL(ctx context.Context, uri string, apiBaseURL string, opts ...PDSProviderOption) (FeedConfigProvider, error) {
 	provider := &PDSFeedConfigProvider{
-		uri: uri,
+		uri:           uri,
+		maxRetries:    defaultPDSMaxRetries,
+		retryWaitTime: defaultPDSRetryWaitTime,
+		httpClient:    &http.Client{Timeout: defaultPDSRequestTimeout},
+	}
+	for _, opt := range opts {
+		opt(provider)
+	}
+	if provider.maxRetries < 0 {
+		return nil, fmt.Errorf("max retries must not be negative: %d", provider.maxRetries)
 	}
 
 	if apiBaseURL == "" {
@@ -43,7 +109,7 @@ func NewPDSFeedConfigProviderWithBaseURL(uri string, apiBaseURL string) (FeedCon
 	provider.apiBaseURL = apiBaseURL
 
 	// Initial load
-	cfg, err := provider.Load()
+	cfg, err := provider.loadWithRetry(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -52,8 +118,58 @@ func NewPDSFeedConfigProviderWithBaseURL(uri string, apiBaseURL string) (FeedCon
 	return provider, nil
 }
 
+func (p *PDSFeedConfigProvider) loadWithRetry(ctx context.Context) (types.FeedConfig, error) {
+	var lastErr error
+retry:
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(float64(p.retryWaitTime) * math.Pow(2, float64(attempt-1)))
+			slog.Info("retrying feed config load from PDS", "uri", p.uri, "attempt", attempt, "delay", delay)
+			select {
+			case <-ctx.Done():
+				break retry
+			case <-time.After(delay):
+			}
+		}
+		cfg, err := p.load(ctx)
+		if err == nil {
+			return cfg, nil
+		}
+		lastErr = err
+		if !errors.Is(err, ErrPDSUnavailable) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if attempt < p.maxRetries {
+			slog.Warn("failed to load feed config from PDS, will retry", "uri", p.uri, "attempt", attempt, "error", err)
+		}
+	}
+	if ctx.Err() != nil {
+		// 中断された場合もキャッシュがあれば使い、なければ後で再試行できるエラーとして返す
+		lastErr = fmt.Errorf("%w: load aborted: %w", ErrPDSUnavailable, ctx.Err())
+		slog.Error("feed config load from PDS aborted", "uri", p.uri, "error", lastErr)
+	} else {
+		slog.Error("failed to load feed config from PDS after all retries", "uri", p.uri, "attempts", p.maxRetries+1, "error", lastErr)
+	}
+	if p.cacheDir != "" {
+		cfg, err := p.loadCache()
+		if err == nil {
+			slog.Warn("PDS is unavailable, using cached feed config", "uri", p.uri, "path", p.cachePath())
+			return cfg, nil
+		}
+		slog.Warn("failed to load cached feed config", "uri", p.uri, "error", err)
+	}
+	return nil, lastErr
+}
+
 // Load loads configuration from PDS.
 func (p *PDSFeedConfigProvider) Load() (types.FeedConfig, error) {
+	return p.load(context.Background())
+}
+
+func (p *PDSFeedConfigProvider) load(ctx context.Context) (types.FeedConfig, error) {
 	slog.Info("loading feed config from PDS", "uri", p.uri)
 
 	// Parse URI
@@ -66,16 +182,26 @@ func (p *PDSFeedConfigProvider) Load() (types.FeedConfig, error) {
 	rkey := parts[4]
 
 	url := fmt.Sprintf("%s/xrpc/com.atproto.repo.getRecord?repo=%s&collection=app.bsky.feed.generator&rkey=%s", p.apiBaseURL, repo, rkey)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get record: %w", err)
+		return nil, fmt.Errorf("%w: failed to get record: %w", ErrPDSUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("%w: failed to read response body: %w", ErrPDSUnavailable, err)
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout {
+		return nil, fmt.Errorf("%w: status=%d, body=%s", ErrPDSUnavailable, resp.StatusCode, string(body))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get record: status=%d, body=%s", resp.StatusCode, string(body))
 	}
 
 	// Parse JSON
@@ -94,11 +220,21 @@ func (p *PDSFeedConfigProvider) Load() (types.FeedConfig, error) {
 		return nil, fmt.Errorf("failed to parse yugeFeed JSON string: %w", err)
 	}
 
+	cfg, err := p.parseConfig(yugeFeedData, "PDS")
+	if err != nil {
+		return nil, err
+	}
+	p.writeCache(yugeFeedData)
+	return cfg, nil
+}
+
+// parseConfig parses and validates yugeFeed JSON. source is used only for logging.
+func (p *PDSFeedConfigProvider) parseConfig(data []byte, source string) (types.FeedConfig, error) {
 	// Create config object
 	var cfg feed.FeedConfigImpl
 
 	// Unmarshal JSON to feed config
-	if err := cfg.UnmarshalJSON(yugeFeedData); err != nil {
+	if err := cfg.UnmarshalJSON(data); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal feed config: %w", err)
 	}
 
@@ -107,13 +243,13 @@ func (p *PDSFeedConfigProvider) Load() (types.FeedConfig, error) {
 		return nil, fmt.Errorf("invalid feed config: %w", err)
 	}
 
-	slog.Info("feed config loaded from PDS",
+	slog.Info("feed config loaded from "+source,
 		"feedLogic", cfg.FeedLogic(),
 		"store", func() string {
 			if cfg.Store() == nil {
 				return "nil"
 			}
-			return fmt.Sprintf("trimAt=%d,trimRemain=%d", cfg.Store().GetTrimAt(), cfg.Store().GetTrimRemain())
+			return fmt.Sprintf("trimAt=%d,trimRemain=%d,trimBytes=%d", cfg.Store().GetTrimAt(), cfg.Store().GetTrimRemain(), cfg.Store().GetTrimBytes())
 		}(),
 		"detailedLog", cfg.DetailedLog())
 
@@ -121,6 +257,50 @@ func (p *PDSFeedConfigProvider) Load() (types.FeedConfig, error) {
 	return &cfg, nil
 }
 
+// cachePath returns the path of the cache file for the feed uri.
+// returns "" if caching is disabled.
+func (p *PDSFeedConfigProvider) cachePath() string {
+	if p.cacheDir == "" {
+		return ""
+	}
+	name := strings.NewReplacer("at://", "", ":", "_", "/", "_").Replace(p.uri)
+	return filepath.Join(p.cacheDir, name+".json")
+}
+
+// writeCache saves the last successfully fetched config. failures are only logged.
+func (p *PDSFeedConfigProvider) writeCache(data []byte) {
+	path := p.cachePath()
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(p.cacheDir, 0755); err != nil {
+		slog.Warn("failed to create PDS config cache directory", "path", p.cacheDir, "error", err)
+		return
+	}
+	// write to temp file and rename to avoid a broken cache file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		slog.Warn("failed to write PDS config cache", "path", tmp, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		slog.Warn("failed to write PDS config cache", "path", path, "error", err)
+	}
+}
+
+// loadCache loads the config from the cache file written by the last successful fetch.
+func (p *PDSFeedConfigProvider) loadCache() (types.FeedConfig, error) {
+	path := p.cachePath()
+	if path == "" {
+		return nil, fmt.Errorf("PDS config cache is disabled")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDS config cache: %w", err)
+	}
+	return p.parseConfig(data, "cache")
+}
+
 // Save saves current configuration to PDS.
 // Note: Writing to PDS is not supported in the current version.
 func (p *PDSFeedConfigProvider) Save() error {
@@ -1,10 +1,15 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/goccy/go-yaml"
 	"github.com/nus25/yuge/feed/config/feed"
@@ -84,7 +89,7 @@ func TestNewPDSFeedConfigProvider(t *testing.T) {
 	defer server.Close()
 
 	uri := "at://repo/app.bsky.feed.generator/rkey"
-	provider, err := NewPDSFeedConfigProviderWithBaseURL(uri, server.URL)
+	provider, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL)
 
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -121,7 +126,7 @@ func TestPDSProviderLoad(t *testing.T) {
 
 		uri := "at://did:plc:testuser/app.bsky.feed.generator/yugetest"
 
-		provider, _ := NewPDSFeedConfigProviderWithBaseURL(uri, server.URL)
+		provider, _ := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL)
 		config, err := provider.Load()
 
 		if err != nil {
@@ -146,7 +151,7 @@ func TestPDSProviderLoad(t *testing.T) {
 
 		uri := "at://repo/app.bsky.feed.generator/rkey"
 
-		provider, err := NewPDSFeedConfigProviderWithBaseURL(uri, server.URL)
+		provider, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, WithPDSMaxRetries(0))
 
 		if provider != nil || err == nil {
 			t.Error("expected error, but nil was returned")
@@ -167,7 +172,7 @@ func TestPDSProviderLoad(t *testing.T) {
 
 		uri := "at://repo/app.bsky.feed.generator/rkey"
 
-		provider, err := NewPDSFeedConfigProviderWithBaseURL(uri, server.URL)
+		provider, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL)
 
 		if provider != nil || err == nil {
 			t.Error("expected error, but nil was returned")
@@ -175,6 +180,188 @@ func TestPDSProviderLoad(t *testing.T) {
 	})
 }
 
+// TestPDSProviderRetry
+func TestPDSProviderRetry(t *testing.T) {
+	uri := "at://repo/app.bsky.feed.generator/rkey"
+
+	t.Run("recovers after temporary errors", func(t *testing.T) {
+		var count atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if count.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(validResponse))
+		}))
+		defer server.Close()
+
+		provider, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, WithPDSMaxRetries(3), WithPDSRetryWaitTime(time.Millisecond))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if provider.FeedConfig() == nil {
+			t.Error("config is nil")
+		}
+		if got := count.Load(); got != 3 {
+			t.Errorf("expected 3 requests, got %d", got)
+		}
+	})
+
+	t.Run("fails after all retries", func(t *testing.T) {
+		var count atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		_, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, WithPDSMaxRetries(2), WithPDSRetryWaitTime(time.Millisecond))
+		if !errors.Is(err, ErrPDSUnavailable) {
+			t.Errorf("expected ErrPDSUnavailable, got %v", err)
+		}
+		if got := count.Load(); got != 3 {
+			t.Errorf("expected 3 requests, got %d", got)
+		}
+	})
+
+	t.Run("aborts retries when context is canceled", func(t *testing.T) {
+		var count atomic.Int32
+		ctx, cancel := context.WithCancel(context.Background())
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			cancel()
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		start := time.Now()
+		_, err := NewPDSFeedConfigProviderWithBaseURL(ctx, uri, server.URL, WithPDSMaxRetries(3), WithPDSRetryWaitTime(time.Minute))
+		if !errors.Is(err, ErrPDSUnavailable) || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected ErrPDSUnavailable wrapping context.Canceled, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= time.Minute {
+			t.Errorf("expected backoff to be aborted, took %v", elapsed)
+		}
+		if got := count.Load(); got != 1 {
+			t.Errorf("expected 1 request, got %d", got)
+		}
+	})
+
+	t.Run("does not retry client error", func(t *testing.T) {
+		var count atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		_, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, WithPDSMaxRetries(2), WithPDSRetryWaitTime(time.Millisecond))
+		if err == nil || errors.Is(err, ErrPDSUnavailable) {
+			t.Errorf("expected non-retryable error, got %v", err)
+		}
+		if got := count.Load(); got != 1 {
+			t.Errorf("expected 1 request, got %d", got)
+		}
+	})
+
+	t.Run("negative max retries", func(t *testing.T) {
+		_, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, "http://127.0.0.1:0", WithPDSMaxRetries(-1))
+		if err == nil {
+			t.Error("expected error for negative max retries")
+		}
+	})
+}
+
+// TestPDSProviderCache
+func TestPDSProviderCache(t *testing.T) {
+	uri := "at://did:plc:testuser/app.bsky.feed.generator/yugetest"
+	var unavailable atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(validResponse))
+	}))
+	defer server.Close()
+	opts := []PDSProviderOption{WithPDSMaxRetries(0), WithPDSRetryWaitTime(time.Millisecond)}
+
+	t.Run("falls back to cache", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		unavailable.Store(false)
+		if _, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, append(opts, WithPDSCacheDir(cacheDir))...); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		files, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+		if len(files) != 1 {
+			t.Fatalf("expected 1 cache file, got %d", len(files))
+		}
+
+		unavailable.Store(true)
+		provider, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, append(opts, WithPDSCacheDir(cacheDir))...)
+		if err != nil {
+			t.Fatalf("expected cached config, got error: %v", err)
+		}
+		if !provider.FeedConfig().DetailedLog() {
+			t.Error("cached config is not correct")
+		}
+	})
+
+	t.Run("falls back to cache when canceled", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		unavailable.Store(false)
+		if _, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, append(opts, WithPDSCacheDir(cacheDir))...); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		unavailable.Store(true)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		provider, err := NewPDSFeedConfigProviderWithBaseURL(ctx, uri, server.URL, append(opts, WithPDSCacheDir(cacheDir))...)
+		if err != nil {
+			t.Fatalf("expected cached config, got error: %v", err)
+		}
+		if !provider.FeedConfig().DetailedLog() {
+			t.Error("cached config is not correct")
+		}
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer slow.Close()
+		_, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, slow.URL, append(opts, WithPDSRequestTimeout(20*time.Millisecond))...)
+		if !errors.Is(err, ErrPDSUnavailable) {
+			t.Errorf("expected ErrPDSUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("no cache file", func(t *testing.T) {
+		unavailable.Store(true)
+		_, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, append(opts, WithPDSCacheDir(t.TempDir()))...)
+		if !errors.Is(err, ErrPDSUnavailable) {
+			t.Errorf("expected ErrPDSUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("cache disabled", func(t *testing.T) {
+		unavailable.Store(false)
+		if _, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, opts...); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		unavailable.Store(true)
+		if _, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL, opts...); err == nil {
+			t.Error("expected error without cache")
+		}
+	})
+}
+
 // TestPDSProviderSave
 func TestPDSProviderSave(t *testing.T) {
 	t.Run("save is not supported", func(t *testing.T) {
@@ -186,7 +373,7 @@ func TestPDSProviderSave(t *testing.T) {
 		defer server.Close()
 
 		uri := "at://repo/app.bsky.feed.generator/rkey"
-		provider, _ := NewPDSFeedConfigProviderWithBaseURL(uri, server.URL)
+		provider, _ := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL)
 
 		err := provider.Save()
 
@@ -215,7 +402,7 @@ func TestPDSProviderFeedConfig(t *testing.T) {
 	defer server.Close()
 
 	uri := "at://repo/app.bsky.feed.generator/rkey"
-	provider, _ := NewPDSFeedConfigProviderWithBaseURL(uri, server.URL)
+	provider, _ := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL)
 
 	config, err := provider.Load()
 
@@ -242,7 +429,7 @@ func TestPDSProviderUpdate(t *testing.T) {
 	defer server.Close()
 
 	uri := "at://repo/app.bsky.feed.generator/rkey"
-	provider, err := NewPDSFeedConfigProviderWithBaseURL(uri, server.URL)
+	provider, err := NewPDSFeedConfigProviderWithBaseURL(context.Background(), uri, server.URL)
 	if err != nil {
 		t.Errorf("Unexpected error during NewPDSFeedConfigProvider: %v", err)
 	}
@@ -13,6 +13,17 @@ var _ types.StoreConfig = (*StoreConfigImpl)(nil) //type check
 type StoreConfigImpl struct {
 	TrimAt     int `yaml:"trimAt" json:"trimAt"`
 	TrimRemain int `yaml:"trimRemain" json:"trimRemain"`
+	// trims oldest posts when the estimated size (uri+cid+indexedAt) exceeds this (in bytes). 0 means no limit.
+	// can be combined with trimAt: trimming by count is applied first, then by size.
+	TrimBytes int `yaml:"trimBytes,omitempty" json:"trimBytes,omitempty"`
+	// if true, posts are kept only in memory and add/delete/trim are not synced to the editor
+	SyncDisabled bool `yaml:"syncDisabled,omitempty" json:"syncDisabled"`
+	// posts with text longer than this (in bytes) are counted as oversized. 0 means no limit
+	MaxStoredTextLength int `yaml:"maxStoredTextLength,omitempty" json:"maxStoredTextLength,omitempty"`
+	// if true, oversized posts are rejected without testing
+	RejectOversized bool `yaml:"rejectOversized,omitempty" json:"rejectOversized,omitempty"`
+	// number of matched posts whose text, langs and facets are kept in memory for reevaluation. 0 disables it
+	RichPostLimit int `yaml:"richPostLimit,omitempty" json:"richPostLimit,omitempty"`
 }
 
 func DefaultStoreConfig() types.StoreConfig {
@@ -26,6 +37,15 @@ type storeConfigAlias StoreConfigImpl
 
 // if trimAt and trimRemain are both 0, it means that the store is disabled
 func (s *StoreConfigImpl) ValidateAll() error {
+	if s.MaxStoredTextLength < 0 {
+		return errors.NewConfigError("StoreConfig", "maxStoredTextLength", "maxStoredTextLength must be greater than or equal to 0")
+	}
+	if s.TrimBytes < 0 {
+		return errors.NewConfigError("StoreConfig", "trimBytes", "trimBytes must be greater than or equal to 0")
+	}
+	if s.RichPostLimit < 0 {
+		return errors.NewConfigError("StoreConfig", "richPostLimit", "richPostLimit must be greater than or equal to 0")
+	}
 	if s.TrimAt == 0 && s.TrimRemain == 0 {
 		return nil
 	}
@@ -59,6 +79,18 @@ func (s *StoreConfigImpl) Validate(key string, value interface{}) error {
 		} else {
 			return errors.NewConfigError("StoreConfig", key, fmt.Sprintf("invalid type for trimRemain: %T", value))
 		}
+	case "syncDisabled", "rejectOversized":
+		if _, ok := value.(bool); !ok {
+			return errors.NewConfigError("StoreConfig", key, fmt.Sprintf("invalid type for %s: %T", key, value))
+		}
+	case "maxStoredTextLength", "trimBytes", "richPostLimit":
+		if v, ok := value.(int); ok {
+			if v < 0 {
+				return errors.NewConfigError("StoreConfig", key, key+" must be greater than or equal to 0")
+			}
+		} else {
+			return errors.NewConfigError("StoreConfig", key, fmt.Sprintf("invalid type for %s: %T", key, value))
+		}
 	}
 	return nil
 }
@@ -81,6 +113,16 @@ func (s *StoreConfigImpl) Update(key string, value interface{}) error {
 		} else if v, ok := value.(int); ok {
 			s.TrimRemain = v
 		}
+	case "syncDisabled":
+		s.SyncDisabled = value.(bool)
+	case "maxStoredTextLength":
+		s.MaxStoredTextLength = value.(int)
+	case "trimBytes":
+		s.TrimBytes = value.(int)
+	case "rejectOversized":
+		s.RejectOversized = value.(bool)
+	case "richPostLimit":
+		s.RichPostLimit = value.(int)
 	}
 	return nil
 }
@@ -93,9 +135,34 @@ func (s *StoreConfigImpl) GetTrimRemain() int {
 	return s.TrimRemain
 }
 
+func (s *StoreConfigImpl) GetTrimBytes() int {
+	return s.TrimBytes
+}
+
+func (s *StoreConfigImpl) GetSyncDisabled() bool {
+	return s.SyncDisabled
+}
+
+func (s *StoreConfigImpl) GetMaxStoredTextLength() int {
+	return s.MaxStoredTextLength
+}
+
+func (s *StoreConfigImpl) GetRejectOversized() bool {
+	return s.RejectOversized
+}
+
+func (s *StoreConfigImpl) GetRichPostLimit() int {
+	return s.RichPostLimit
+}
+
 func (s *StoreConfigImpl) DeepCopy() types.StoreConfig {
 	return &StoreConfigImpl{
-		TrimAt:     s.TrimAt,
-		TrimRemain: s.TrimRemain,
+		TrimAt:              s.TrimAt,
+		TrimRemain:          s.TrimRemain,
+		TrimBytes:           s.TrimBytes,
+		SyncDisabled:        s.SyncDisabled,
+		MaxStoredTextLength: s.MaxStoredTextLength,
+		RejectOversized:     s.RejectOversized,
+		RichPostLimit:       s.RichPostLimit,
 	}
 }
@@ -51,6 +51,25 @@ func TestStoreConfig_ValidateAll(t *testing.T) {
 			wantKey:        "trimRemain",
 			wantErrMessage: "trimRemain must be greater than or equal to 0",
 		},
+		{
+			name: "正常系: trimBytesのみ指定",
+			config: &StoreConfigImpl{
+				TrimBytes: 100000,
+			},
+			wantErr: false,
+		},
+		{
+			name: "異常系: TrimBytesが負数",
+			config: &StoreConfigImpl{
+				TrimAt:    100,
+				TrimBytes: -1,
+			},
+			wantErr:        true,
+			wantErrType:    &yugeErrors.ConfigError{},
+			wantComponent:  "StoreConfig",
+			wantKey:        "trimBytes",
+			wantErrMessage: "trimBytes must be greater than or equal to 0",
+		},
 	}
 
 	for _, tt := range tests {
@@ -130,6 +149,85 @@ func TestStoreConfig_Validate(t *testing.T) {
 			wantKey:        "trimRemain",
 			wantErrMessage: "trimRemain must be greater than or equal to 0",
 		},
+		{
+			name:    "正常系: 有効なsyncDisabled",
+			config:  &StoreConfigImpl{},
+			key:     "syncDisabled",
+			value:   true,
+			wantErr: false,
+		},
+		{
+			name:           "異常系: 無効なsyncDisabled",
+			config:         &StoreConfigImpl{},
+			key:            "syncDisabled",
+			value:          "true",
+			wantErr:        true,
+			wantErrType:    &yugeErrors.ConfigError{},
+			wantComponent:  "StoreConfig",
+			wantKey:        "syncDisabled",
+			wantErrMessage: "invalid type for syncDisabled: string",
+		},
+		{
+			name:    "正常系: 有効なmaxStoredTextLength",
+			config:  &StoreConfigImpl{},
+			key:     "maxStoredTextLength",
+			value:   300,
+			wantErr: false,
+		},
+		{
+			name:           "異常系: 負のmaxStoredTextLength",
+			config:         &StoreConfigImpl{},
+			key:            "maxStoredTextLength",
+			value:          -1,
+			wantErr:        true,
+			wantErrType:    &yugeErrors.ConfigError{},
+			wantComponent:  "StoreConfig",
+			wantKey:        "maxStoredTextLength",
+			wantErrMessage: "maxStoredTextLength must be greater than or equal to 0",
+		},
+		{
+			name:    "正常系: 有効なrichPostLimit",
+			config:  &StoreConfigImpl{},
+			key:     "richPostLimit",
+			value:   1000,
+			wantErr: false,
+		},
+		{
+			name:           "異常系: 負のrichPostLimit",
+			config:         &StoreConfigImpl{},
+			key:            "richPostLimit",
+			value:          -1,
+			wantErr:        true,
+			wantErrType:    &yugeErrors.ConfigError{},
+			wantComponent:  "StoreConfig",
+			wantKey:        "richPostLimit",
+			wantErrMessage: "richPostLimit must be greater than or equal to 0",
+		},
+		{
+			name:    "正常系: 有効なtrimBytes",
+			config:  &StoreConfigImpl{},
+			key:     "trimBytes",
+			value:   100000,
+			wantErr: false,
+		},
+		{
+			name:           "異常系: 負のtrimBytes",
+			config:         &StoreConfigImpl{},
+			key:            "trimBytes",
+			value:          -1,
+			wantErr:        true,
+			wantErrType:    &yugeErrors.ConfigError{},
+			wantComponent:  "StoreConfig",
+			wantKey:        "trimBytes",
+			wantErrMessage: "trimBytes must be greater than or equal to 0",
+		},
+		{
+			name:    "正常系: 有効なrejectOversized",
+			config:  &StoreConfigImpl{},
+			key:     "rejectOversized",
+			value:   true,
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
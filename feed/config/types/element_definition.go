@@ -95,6 +95,9 @@ func (def *ConfigElementDefinition) ConvertValue(value interface{}) (interface{}
 		if i, ok := value.(int); ok {
 			return float64(i), nil
 		}
+		if i, ok := value.(uint64); ok {
+			return float64(i), nil
+		}
 		if str, ok := value.(string); ok {
 			if f, err := strconv.ParseFloat(str, 64); err == nil {
 				return f, nil
@@ -163,6 +166,10 @@ func (def *ConfigElementDefinition) ValidateType(key string, value interface{})
 			if _, err := strconv.ParseFloat(strVal, 64); err != nil {
 				return errors.NewValidationError(key, value, "must be a float or a string that can be converted to a float")
 			}
+		} else if _, ok := value.(uint64); ok {
+			return nil
+		} else if _, ok := value.(int); ok {
+			return nil
 		} else if _, ok := value.(float64); !ok {
 			return errors.NewValidationError(key, value, "must be a float")
 		}
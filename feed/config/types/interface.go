@@ -24,6 +24,8 @@ type FeedConfig interface {
 type FeedLogicConfig interface {
 	Validatable
 	GetLogicBlockConfigs() []LogicBlockConfig
+	// GetMinMatch returns the number of blocks that must match. 0 means all blocks.
+	GetMinMatch() int
 	DeepCopy() FeedLogicConfig
 }
 
@@ -41,4 +43,9 @@ type StoreConfig interface {
 	DeepCopy() StoreConfig
 	GetTrimAt() int
 	GetTrimRemain() int
+	GetTrimBytes() int
+	GetSyncDisabled() bool
+	GetMaxStoredTextLength() int
+	GetRejectOversized() bool
+	GetRichPostLimit() int
 }
@@ -0,0 +1,124 @@
+package dedupe
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/nus25/yuge/feed/errors"
+)
+
+const previewLength = 50
+
+// Window keeps hashes of recently seen texts.
+// it holds at most size entries and entries older than ttl are treated as unseen.
+type Window struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[uint64]Entry
+	order   []queued // oldest first
+	now     func() time.Time
+}
+
+type Entry struct {
+	SeenAt  time.Time `json:"seenAt"`
+	Preview string    `json:"preview"`
+}
+
+type queued struct {
+	hash   uint64
+	seenAt time.Time
+}
+
+func NewWindow(size int, ttl time.Duration) (*Window, error) {
+	if size <= 0 {
+		return nil, errors.NewConfigError("DedupeWindow", "size", "size must be greater than 0")
+	}
+	if ttl <= 0 {
+		return nil, errors.NewConfigError("DedupeWindow", "ttl", "ttl must be greater than 0")
+	}
+	return &Window{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[uint64]Entry, size),
+		order:   make([]queued, 0, size),
+		now:     time.Now,
+	}, nil
+}
+
+func hash(normalized string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(normalized))
+	return h.Sum64()
+}
+
+// Seen reports whether the normalized text was seen within ttl.
+// if not, the text is recorded. normalizing the text is up to the caller.
+func (w *Window) Seen(normalized string) bool {
+	h := hash(normalized)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	w.evictExpired(now)
+
+	if e, ok := w.entries[h]; ok && now.Sub(e.SeenAt) < w.ttl {
+		return true
+	}
+
+	preview := normalized
+	if r := []rune(preview); len(r) > previewLength {
+		preview = string(r[:previewLength])
+	}
+	w.entries[h] = Entry{SeenAt: now, Preview: preview}
+	w.order = append(w.order, queued{hash: h, seenAt: now})
+	for len(w.order) > w.size {
+		w.popOldest()
+	}
+	return false
+}
+
+func (w *Window) evictExpired(now time.Time) {
+	for len(w.order) > 0 && now.Sub(w.order[0].seenAt) >= w.ttl {
+		w.popOldest()
+	}
+}
+
+func (w *Window) popOldest() {
+	q := w.order[0]
+	w.order = w.order[1:]
+	// the entry may have been re-recorded after this queue item
+	if e, ok := w.entries[q.hash]; ok && e.SeenAt.Equal(q.seenAt) {
+		delete(w.entries, q.hash)
+	}
+}
+
+// Len returns the number of texts in the window
+func (w *Window) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.evictExpired(w.now())
+	return len(w.entries)
+}
+
+// List returns a copy of the entries in the window, oldest first
+func (w *Window) List() []Entry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.evictExpired(w.now())
+	list := make([]Entry, 0, len(w.entries))
+	for _, q := range w.order {
+		if e, ok := w.entries[q.hash]; ok && e.SeenAt.Equal(q.seenAt) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+func (w *Window) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = make(map[uint64]Entry, w.size)
+	w.order = make([]queued, 0, w.size)
+}
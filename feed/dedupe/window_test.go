@@ -0,0 +1,77 @@
+package dedupe
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	t.Run("同じテキストは重複と判定される", func(t *testing.T) {
+		w, _ := NewWindow(10, time.Hour)
+		if w.Seen("hello world") {
+			t.Error("first text should not be seen")
+		}
+		if !w.Seen("hello world") {
+			t.Error("duplicate should be seen")
+		}
+		if w.Seen("hello world!") {
+			t.Error("different text should not be seen")
+		}
+	})
+
+	t.Run("ttlを過ぎたテキストは重複と判定されない", func(t *testing.T) {
+		w, _ := NewWindow(10, time.Minute)
+		now := time.Now()
+		w.now = func() time.Time { return now }
+		w.Seen("spam")
+		now = now.Add(2 * time.Minute)
+		if w.Seen("spam") {
+			t.Error("expired text should not be seen")
+		}
+		if w.Len() != 1 {
+			t.Errorf("Len() = %d, want 1", w.Len())
+		}
+	})
+
+	t.Run("サイズを超えると古いものから削除される", func(t *testing.T) {
+		w, _ := NewWindow(3, time.Hour)
+		for i := 0; i < 5; i++ {
+			w.Seen(fmt.Sprintf("text %d", i))
+		}
+		if w.Len() != 3 {
+			t.Errorf("Len() = %d, want 3", w.Len())
+		}
+		if len(w.order) > 3 {
+			t.Errorf("queue length = %d, want <= 3", len(w.order))
+		}
+		if w.Seen("text 4") != true {
+			t.Error("newest text should still be seen")
+		}
+		if w.Seen("text 0") {
+			t.Error("oldest text should have been evicted")
+		}
+	})
+
+	t.Run("Clearで全て削除される", func(t *testing.T) {
+		w, _ := NewWindow(10, time.Hour)
+		w.Seen("a")
+		w.Seen("b")
+		w.Clear()
+		if w.Len() != 0 {
+			t.Errorf("Len() = %d, want 0", w.Len())
+		}
+		if w.Seen("a") {
+			t.Error("text should not be seen after clear")
+		}
+	})
+
+	t.Run("不正なパラメータ", func(t *testing.T) {
+		if _, err := NewWindow(0, time.Hour); err == nil {
+			t.Error("expected error for size 0")
+		}
+		if _, err := NewWindow(10, 0); err == nil {
+			t.Error("expected error for ttl 0")
+		}
+	})
+}
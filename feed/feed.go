@@ -2,8 +2,14 @@ package feed
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	apibsky "github.com/bluesky-social/indigo/api/bsky"
@@ -20,24 +26,89 @@ import (
 var _ Feed = (*feedImpl)(nil) //type check
 
 const (
-	FeedMetricNamePostCount = "feed_post_count"
+	FeedMetricNamePostCount     = "feed_post_count"
+	FeedMetricNameUniqueAuthors = "feed_unique_authors"
 )
 
 type Feed interface {
 	FeedId() string
 	FeedUri() string
 	AddPost(did string, rkey string, cid string, t time.Time, langs []string) error
-	DeletePost(did string, rkey string) error
+	UpdatePost(did string, rkey string, cid string, t time.Time, langs []string) error
+	// DeletePost deletes the post. deleted is false if the post is not in the feed, which is not an error
+	DeletePost(did string, rkey string) (deleted bool, err error)
+	DeletePostVersion(did string, rkey string, indexedAt time.Time) (deleted bool, err error)
 	DeletePostByDid(did string) (deleted []types.Post, err error)
 	GetPost(did string, rkey string) (post types.Post, exists bool)
 	ListPost(did string) []types.Post
 	Test(did string, rkey string, post *apibsky.FeedPost) bool
+	TestVerbose(did string, rkey string, post *apibsky.FeedPost) (bool, []BlockResult)
+	// DryRun evaluates the post like TestVerbose without side effects on the feed.
+	// blocks whose Test has side effects (e.g. dedupe, limiter, sample) are not evaluated and reported as skipped,
+	// and passed is decided as if they passed.
+	DryRun(did string, rkey string, post *apibsky.FeedPost) (passed bool, results []BlockResult)
+	AuthorDids() (dids []string, scoped bool)
 	PostCount() int
 	Shutdown(ctx context.Context) error
 	Clear() error
+	RebuildIndex() (before int, after int)
+	Validate() []string
+	AuthorCounts() map[string]int
+	StoreStats() store.StoreStats
 	Config() cfgTypes.FeedConfig
 	Metrics() *metrics.Metrics
 	ProcessCommand(logicBlockName string, command string, args map[string]string) (message string, err error)
+	LogicBlocks() []BlockInfo
+	ExportState() (map[string]BlockState, error)
+	ImportState(states map[string]BlockState) error
+	// RecentTests returns the last tested posts from newest to oldest. ok is false if recording is disabled
+	RecentTests() (tests []RecentTest, ok bool)
+	// Reevaluate removes stored posts that the feed logic no longer passes
+	Reevaluate() (ReevaluateResult, error)
+	// RecentMatches returns the metadata of the stored posts kept by the rich store from newest to oldest.
+	// ok is false if the rich store is disabled
+	RecentMatches() (posts []RichPost, ok bool)
+	// RestoreMatches keeps posts returned by RecentMatches of another instance, e.g. when the feed is reloaded.
+	// it does nothing if the rich store is disabled
+	RestoreMatches(posts []RichPost)
+}
+
+// ReevaluateResult is the result of Reevaluate
+type ReevaluateResult struct {
+	CheckedAuthors int      `json:"checkedAuthors"`
+	AuthorBlocks   int      `json:"authorBlocks"` // number of blocks decided by the author alone
+	PostBlocks     int      `json:"postBlocks"`   // number of blocks tested again with the rich store
+	RetestedPosts  int      `json:"retestedPosts"`
+	RemovedAuthors []string `json:"removedAuthors"`
+	RemovedPosts   int      `json:"removedPosts"`
+}
+
+// BlockResult is the result of a single logic block evaluated by TestVerbose
+type BlockResult struct {
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	Passed  bool          `json:"passed"`
+	Latency time.Duration `json:"latency"`
+	// TimedOut is true if the block didn't return within the block test timeout and was treated as not passed
+	TimedOut bool `json:"timedOut,omitempty"`
+	// Skipped is true if the block was not evaluated by DryRun because its Test has side effects
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// BlockInfo describes a logic block and the optional interfaces it implements
+type BlockInfo struct {
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	CommandProcessor bool   `json:"commandProcessor"`
+	MetricProvider   bool   `json:"metricProvider"`
+	PreDeleteHandler bool   `json:"preDeleteHandler"`
+	StateExporter    bool   `json:"stateExporter"`
+}
+
+// BlockState is the exported runtime state of a logic block
+type BlockState struct {
+	Type  string          `json:"type"`
+	State json.RawMessage `json:"state"`
 }
 
 type feedImpl struct {
@@ -46,6 +117,10 @@ type feedImpl struct {
 	config      cfgTypes.FeedConfig
 	store       store.Store
 	logicblocks []logicblock.LogicBlock
+	recent      *recentTests // nil if disabled
+	rich        *richPosts   // nil if disabled
+	testTimeout time.Duration
+	stalled     []atomic.Int32 // timed out Tests still running per block
 	logger      *slog.Logger
 }
 
@@ -59,6 +134,14 @@ type FeedOptions struct {
 	// Logger is an optional logger for feed operations.
 	// If not specified, slog.Default() will be used.
 	Logger *slog.Logger
+
+	// RecentTestsSize is the number of recently tested posts kept for debugging.
+	// 0 disables recording.
+	RecentTestsSize int
+
+	// BlockTestTimeout is the maximum time each logic block may take to test a post.
+	// a block exceeding it is treated as not passed. 0 disables the timeout.
+	BlockTestTimeout time.Duration
 }
 
 func NewFeedWithOptions(ctx context.Context, feedId string, feedUri string, opts FeedOptions) (Feed, error) {
@@ -130,6 +213,9 @@ func NewFeedWithOptions(ctx context.Context, feedId string, feedUri string, opts
 			lg.Error("failed to create logic block", "error", err)
 			return nil, errors.NewDependencyError("Feed", "logicBlock", fmt.Sprintf("failed to create logic block: %v", err))
 		}
+		if ts, ok := block.(logicblock.TestTimeoutSetter); ok && opts.BlockTestTimeout > 0 {
+			ts.SetTestTimeout(opts.BlockTestTimeout)
+		}
 		logicblocks = append(logicblocks, block)
 	}
 
@@ -140,6 +226,10 @@ func NewFeedWithOptions(ctx context.Context, feedId string, feedUri string, opts
 		config:      opts.Config,
 		store:       s,
 		logicblocks: logicblocks,
+		recent:      newRecentTests(opts.RecentTestsSize),
+		rich:        newRichPosts(cfg.Store().GetRichPostLimit()),
+		testTimeout: max(opts.BlockTestTimeout, 0),
+		stalled:     make([]atomic.Int32, len(logicblocks)),
 		logger:      lg,
 	}
 
@@ -176,6 +266,9 @@ func (f *feedImpl) Clear() error {
 	if err := f.store.Trim(0); err != nil {
 		return err
 	}
+	if f.rich != nil {
+		f.rich.clear()
+	}
 	//clear logicblocks
 	for _, b := range f.logicblocks {
 		if err := b.Reset(); err != nil {
@@ -185,11 +278,89 @@ func (f *feedImpl) Clear() error {
 	return nil
 }
 
+func (f *feedImpl) RebuildIndex() (before int, after int) {
+	return f.store.RebuildIndex()
+}
+
+func (f *feedImpl) Validate() []string {
+	return f.store.Validate()
+}
+
+func (f *feedImpl) AuthorCounts() map[string]int {
+	return f.store.AuthorCounts()
+}
+
+func (f *feedImpl) StoreStats() store.StoreStats {
+	return f.store.Stats()
+}
+
 func (f *feedImpl) AddPost(did string, rkey string, cid string, t time.Time, langs []string) error {
-	return f.store.Add(did, rkey, cid, t, langs)
+	if err := f.store.Add(did, rkey, cid, t, langs); err != nil {
+		return err
+	}
+	postsAdded.WithLabelValues(f.id).Inc()
+	return nil
+}
+
+// UpdatePost updates an edited post. posts not in the feed are ignored.
+func (f *feedImpl) UpdatePost(did string, rkey string, cid string, t time.Time, langs []string) error {
+	// 編集前の内容で再評価しないように破棄する
+	if f.rich != nil {
+		f.rich.remove(postUri(did, rkey))
+	}
+	return f.store.Update(did, rkey, cid, t, langs)
+}
+
+func (f *feedImpl) DeletePost(did string, rkey string) (deleted bool, err error) {
+	if err := f.handlePreDelete(did, rkey); err != nil {
+		return false, err
+	}
+	deleted, err = f.store.Delete(did, rkey)
+	if err != nil {
+		return deleted, err
+	}
+	if f.rich != nil {
+		f.rich.remove(postUri(did, rkey))
+	}
+	if deleted {
+		postsDeleted.WithLabelValues(f.id).Inc()
+	}
+	return deleted, nil
+}
+
+// DeletePostVersion deletes the post only if it was indexed at indexedAt
+func (f *feedImpl) DeletePostVersion(did string, rkey string, indexedAt time.Time) (deleted bool, err error) {
+	if err := f.handlePreDelete(did, rkey); err != nil {
+		return false, err
+	}
+	deleted, err = f.store.DeleteVersion(did, rkey, indexedAt)
+	if err != nil {
+		return deleted, err
+	}
+	if f.rich != nil {
+		f.rich.remove(postUri(did, rkey))
+	}
+	if deleted {
+		postsDeleted.WithLabelValues(f.id).Inc()
+	}
+	return deleted, nil
+}
+
+func (f *feedImpl) DeletePostByDid(did string) (deleted []types.Post, err error) {
+	if err := f.handlePreDelete(did, ""); err != nil {
+		return nil, err
+	}
+	deleted, err = f.store.DeleteByDid(did)
+	if f.rich != nil {
+		f.rich.removeByDid(did)
+	}
+	postsDeleted.WithLabelValues(f.id).Add(float64(len(deleted)))
+	return deleted, err
 }
 
-func (f *feedImpl) DeletePost(did string, rkey string) error {
+// handlePreDelete notifies logic blocks before posts are deleted.
+// rkey is empty when all posts of the did are deleted.
+func (f *feedImpl) handlePreDelete(did string, rkey string) error {
 	for _, b := range f.logicblocks {
 		if handler, ok := b.(logicblock.PreDeleteHandler); ok {
 			if err := handler.HandlePreDelete(did, rkey); err != nil {
@@ -197,10 +368,7 @@ func (f *feedImpl) DeletePost(did string, rkey string) error {
 			}
 		}
 	}
-	return f.store.Delete(did, rkey)
-}
-func (f *feedImpl) DeletePostByDid(did string) (deleted []types.Post, err error) {
-	return f.store.DeleteByDid(did)
+	return nil
 }
 
 func (f *feedImpl) GetPost(did string, rkey string) (post types.Post, exists bool) {
@@ -219,31 +387,323 @@ func (f *feedImpl) ListPost(did string) []types.Post {
 
 // test if given post passes all logicblocks
 func (f *feedImpl) Test(did string, rkey string, post *apibsky.FeedPost) bool {
+	result, _ := f.TestVerbose(did, rkey, post)
+	if result && f.rich != nil {
+		f.rich.add(newRichPost(did, rkey, post))
+	}
+	if f.recent != nil {
+		f.recent.add(RecentTest{
+			Did:      did,
+			Rkey:     rkey,
+			Text:     textSnippet(post.Text),
+			Passed:   result,
+			TestedAt: time.Now(),
+		})
+	}
+	return result
+}
+
+// RecentMatches returns the kept posts that are still in the store
+func (f *feedImpl) RecentMatches() ([]RichPost, bool) {
+	if f.rich == nil {
+		return nil, false
+	}
+	posts := []RichPost{}
+	for _, rp := range f.rich.list() {
+		if _, exists := f.store.GetPost(rp.Did, rp.Rkey); exists {
+			posts = append(posts, rp)
+		}
+	}
+	return posts, true
+}
+
+func (f *feedImpl) RestoreMatches(posts []RichPost) {
+	if f.rich == nil {
+		return
+	}
+	// 新しい順に渡されるので古いものから追加する
+	for i := len(posts) - 1; i >= 0; i-- {
+		f.rich.add(posts[i])
+	}
+}
+
+func (f *feedImpl) RecentTests() ([]RecentTest, bool) {
+	if f.recent == nil {
+		return nil, false
+	}
+	return f.recent.list(), true
+}
+
+// TestVerbose evaluates the post and returns the result of each evaluated block.
+// blocks after the outcome is decided are not evaluated and not included in the results.
+func (f *feedImpl) TestVerbose(did string, rkey string, post *apibsky.FeedPost) (bool, []BlockResult) {
+	return f.evaluate(did, rkey, post, false)
+}
+
+func (f *feedImpl) DryRun(did string, rkey string, post *apibsky.FeedPost) (bool, []BlockResult) {
+	return f.evaluate(did, rkey, post, true)
+}
+
+// dryRunBlock tests the i-th block only if it can be tested without side effects. ok is false if the block must be skipped
+func (f *feedImpl) dryRunBlock(i int, did string, rkey string, post *apibsky.FeedPost) (result bool, timedOut bool, ok bool) {
+	block := f.logicblocks[i]
+	if at, isAuthorTester := block.(logicblock.AuthorTester); isAuthorTester {
+		if r, decided := at.TestAuthor(did); decided {
+			return r, false, true
+		}
+	}
+	if _, isRetestable := block.(logicblock.Retestable); isRetestable {
+		r, timedOut := f.testBlock(i, true, did, rkey, post)
+		return r, timedOut, true
+	}
+	return false, false, false
+}
+
+// evaluate runs the feed logic. in dry run, metrics and logs are not recorded and blocks with side effects are skipped
+func (f *feedImpl) evaluate(did string, rkey string, post *apibsky.FeedPost, dryRun bool) (bool, []BlockResult) {
 	cfg := f.config
+	results := make([]BlockResult, 0, len(f.logicblocks))
 	if len(cfg.FeedLogic().GetLogicBlockConfigs()) == 0 {
-		return false
+		return false, results
+	}
+	if !dryRun {
+		postsTested.WithLabelValues(f.id).Inc()
 	}
 
+	// 長すぎるテキストは診断用に記録する。rejectOversizedの場合のみ判定に影響する
+	if maxLen := cfg.Store().GetMaxStoredTextLength(); maxLen > 0 && len(post.Text) > maxLen {
+		reject := cfg.Store().GetRejectOversized()
+		if !dryRun {
+			postsOversized.WithLabelValues(f.id).Inc()
+			f.logger.Info("oversized post", "did", did, "rkey", rkey, "length", len(post.Text), "max", maxLen, "rejected", reject)
+		}
+		if reject {
+			return false, results
+		}
+	}
+
+	minMatch := cfg.FeedLogic().GetMinMatch()
+	matched := 0
 	for i, block := range f.logicblocks {
-		var start time.Time
-		if cfg.DetailedLog() {
-			start = time.Now()
-		}
-		r := block.Test(did, rkey, post)
-		if cfg.DetailedLog() {
-			elapsed := time.Since(start)
-			f.logger.Info("test",
+		start := time.Now()
+		var r, timedOut bool
+		skipped := false
+		if dryRun {
+			var ok bool
+			r, timedOut, ok = f.dryRunBlock(i, did, rkey, post)
+			if !ok {
+				// 副作用のあるブロックは評価せず通過したものとみなす
+				r, skipped = true, true
+			}
+		} else {
+			r, timedOut = f.testBlock(i, false, did, rkey, post)
+		}
+		elapsed := time.Since(start)
+		results = append(results, BlockResult{
+			Name:     block.BlockName(),
+			Type:     block.BlockType(),
+			Passed:   r,
+			Latency:  elapsed,
+			TimedOut: timedOut,
+			Skipped:  skipped,
+		})
+		if cfg.DetailedLog() && !dryRun {
+			attrs := []any{
 				"block_index", i,
 				"block", block.BlockType(),
 				"result", r,
-				"latency(ns)", elapsed)
+				"latency(ns)", elapsed,
+			}
+			if rp, ok := block.(logicblock.ReasonProvider); ok {
+				if reason := rp.LastReason(); reason != "" {
+					attrs = append(attrs, "reason", reason)
+				}
+			}
+			f.logger.Info("test", attrs...)
+		}
+		if minMatch <= 0 {
+			if !r {
+				return false, results
+			}
+			continue
 		}
-		if !r {
-			return false
+		// minMatch指定時は必要数に達した時点で通過、残りで届かなければ不通過
+		if r {
+			matched++
+		}
+		if matched >= minMatch {
+			return true, results
+		}
+		if matched+len(f.logicblocks)-i-1 < minMatch {
+			return false, results
 		}
 	}
 	//全てのテストをパスした場合はフィードに追加するポストとみなす
-	return true
+	return minMatch <= 0, results
+}
+
+// testBlock runs the Test of the i-th block within the block test timeout.
+// if retest is true, Retest of the block is run instead so that the block is not changed.
+// on timeout the post is treated as not passed. the Test keeps running in the background unless the block
+// aborts it by itself (TestTimeoutSetter), so the block is skipped as timed out until the running Test finishes.
+func (f *feedImpl) testBlock(i int, retest bool, did string, rkey string, post *apibsky.FeedPost) (result bool, timedOut bool) {
+	block := f.logicblocks[i]
+	test := block.Test
+	if rt, ok := block.(logicblock.Retestable); ok && retest {
+		test = rt.Retest
+	}
+	if f.testTimeout <= 0 {
+		return test(did, rkey, post), false
+	}
+	// タイムアウトしたTestが終わるまでは新たなgoroutineを起動しない
+	if f.stalled[i].Load() > 0 {
+		f.logger.Debug("logic block skipped while timed out test is running", "block", block.BlockType(), "name", block.BlockName(), "did", did, "rkey", rkey)
+		return false, true
+	}
+	const (
+		running int32 = iota
+		finished
+		abandoned
+	)
+	var state atomic.Int32
+	done := make(chan bool, 1)
+	go func() {
+		done <- test(did, rkey, post)
+		if !state.CompareAndSwap(running, finished) {
+			f.stalled[i].Add(-1)
+		}
+	}()
+	timer := time.NewTimer(f.testTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r, false
+	case <-timer.C:
+		if !state.CompareAndSwap(running, abandoned) {
+			// タイムアウトと同時に終わった
+			return <-done, false
+		}
+		f.stalled[i].Add(1)
+		logicblockTimeouts.WithLabelValues(f.id, block.BlockType()).Inc()
+		f.logger.Warn("logic block test timed out", "block", block.BlockType(), "name", block.BlockName(), "did", did, "rkey", rkey, "timeout", f.testTimeout)
+		return false, true
+	}
+}
+
+// AuthorDids returns the author DIDs whose posts can pass the feed logic.
+// scoped is false if posts from any author may pass.
+func (f *feedImpl) AuthorDids() (dids []string, scoped bool) {
+	// minMatch指定時はブロックを満たさなくても通過しうるので限定できない
+	if f.config.FeedLogic().GetMinMatch() > 0 {
+		return nil, false
+	}
+	var set map[string]struct{}
+	for _, block := range f.logicblocks {
+		as, ok := block.(logicblock.AuthorScoper)
+		if !ok {
+			continue
+		}
+		blockDids, ok := as.AuthorDids()
+		if !ok {
+			continue
+		}
+		// 全ブロックを通過する必要があるので積集合をとる
+		next := make(map[string]struct{}, len(blockDids))
+		for _, did := range blockDids {
+			if _, exists := set[did]; set == nil || exists {
+				next[did] = struct{}{}
+			}
+		}
+		set = next
+	}
+	if set == nil {
+		return nil, false
+	}
+	return slices.Sorted(maps.Keys(set)), true
+}
+
+// Reevaluate re-runs the blocks that depend only on the author (e.g. userlist) against the authors of the stored posts
+// and removes all posts of the authors that can no longer pass the feed logic.
+// if the rich store is enabled, the blocks that depend only on the post (e.g. regex) are also re-run
+// against the kept metadata and the posts that no longer pass are removed.
+// posts not kept by the rich store are checked only by their author.
+func (f *feedImpl) Reevaluate() (ReevaluateResult, error) {
+	result := ReevaluateResult{RemovedAuthors: []string{}}
+	var authorTesters []logicblock.AuthorTester
+	var postBlocks []int // index of the blocks
+	for i, block := range f.logicblocks {
+		if at, ok := block.(logicblock.AuthorTester); ok {
+			authorTesters = append(authorTesters, at)
+			continue
+		}
+		if _, ok := block.(logicblock.Retestable); ok && f.rich != nil {
+			postBlocks = append(postBlocks, i)
+		}
+	}
+	result.AuthorBlocks = len(authorTesters)
+	result.PostBlocks = len(postBlocks)
+	if len(authorTesters) == 0 && len(postBlocks) == 0 {
+		return result, nil
+	}
+
+	// minMatch指定時は残りのブロックを全て満たしても届かない場合のみ除外する
+	minMatch := f.config.FeedLogic().GetMinMatch()
+	excluded := func(failed int) bool {
+		if minMatch > 0 {
+			return len(f.logicblocks)-failed < minMatch
+		}
+		return failed > 0
+	}
+
+	for _, did := range slices.Sorted(maps.Keys(f.store.AuthorCounts())) {
+		result.CheckedAuthors++
+		authorFailed := 0
+		for _, at := range authorTesters {
+			if r, ok := at.TestAuthor(did); ok && !r {
+				authorFailed++
+			}
+		}
+		if excluded(authorFailed) {
+			deleted, err := f.DeletePostByDid(did)
+			result.RemovedPosts += len(deleted)
+			if err != nil {
+				return result, fmt.Errorf("failed to delete posts of %s: %w", did, err)
+			}
+			result.RemovedAuthors = append(result.RemovedAuthors, did)
+			f.logger.Info("removed posts of excluded author", "did", did, "count", len(deleted))
+			continue
+		}
+		if len(postBlocks) == 0 {
+			continue
+		}
+		for _, p := range f.ListPost(did) {
+			rp, ok := f.rich.get(string(p.Uri))
+			if !ok {
+				continue
+			}
+			result.RetestedPosts++
+			post := rp.FeedPost()
+			failed := authorFailed
+			for _, i := range postBlocks {
+				if r, _ := f.testBlock(i, true, rp.Did, rp.Rkey, post); !r {
+					failed++
+				}
+			}
+			if !excluded(failed) {
+				continue
+			}
+			deleted, err := f.DeletePost(rp.Did, rp.Rkey)
+			if err != nil {
+				return result, fmt.Errorf("failed to delete post %s: %w", rp.Uri, err)
+			}
+			if !deleted {
+				continue
+			}
+			result.RemovedPosts++
+			f.logger.Info("removed post that no longer passes", "did", rp.Did, "rkey", rp.Rkey)
+		}
+	}
+	return result, nil
 }
 
 func (f *feedImpl) PostCount() int {
@@ -259,6 +719,7 @@ func (f *feedImpl) Metrics() *metrics.Metrics {
 	response := metrics.NewMetrics()
 	//feed metrics
 	response.AddMetric(metrics.NewMetric(FeedMetricNamePostCount, "post count of the feed", "", metrics.MetricTypeInt, int64(f.PostCount())))
+	response.AddMetric(metrics.NewMetric(FeedMetricNameUniqueAuthors, "distinct authors of the feed", "", metrics.MetricTypeInt, int64(f.store.UniqueAuthorCount())))
 
 	//logic block metrics
 	for _, block := range f.logicblocks {
@@ -272,6 +733,8 @@ func (f *feedImpl) Metrics() *metrics.Metrics {
 	return response
 }
 
+// ProcessCommand runs the command on the logic block named logicBlockName.
+// blocks without a name can be addressed by their 0-based position as "#N" (same as block_index in detailed logs).
 func (f *feedImpl) ProcessCommand(logicBlockName string, command string, args map[string]string) (message string, err error) {
 	for _, block := range f.logicblocks {
 		if block.BlockName() == logicBlockName {
@@ -284,5 +747,116 @@ func (f *feedImpl) ProcessCommand(logicBlockName string, command string, args ma
 			}
 		}
 	}
+	if idx, ok := strings.CutPrefix(logicBlockName, "#"); ok {
+		i, err := strconv.Atoi(idx)
+		if err != nil {
+			return "", fmt.Errorf("invalid logic block index: %s", logicBlockName)
+		}
+		if i < 0 || i >= len(f.logicblocks) {
+			return "", fmt.Errorf("logic block index out of range: %d (feed has %d blocks)", i, len(f.logicblocks))
+		}
+		processor, ok := f.logicblocks[i].(logicblock.CommandProcessor)
+		if !ok {
+			return "", fmt.Errorf("logic block %s (%s) does not support commands", logicBlockName, f.logicblocks[i].BlockType())
+		}
+		return processor.ProcessCommand(command, args)
+	}
 	return "", fmt.Errorf("logic block not found: %s", logicBlockName)
 }
+
+// LogicBlocks returns the logic blocks of the feed in evaluation order
+func (f *feedImpl) LogicBlocks() []BlockInfo {
+	infos := make([]BlockInfo, 0, len(f.logicblocks))
+	for _, block := range f.logicblocks {
+		_, isProcessor := block.(logicblock.CommandProcessor)
+		_, isProvider := block.(logicblock.MetricProvider)
+		_, isHandler := block.(logicblock.PreDeleteHandler)
+		_, isExporter := block.(logicblock.StateExporter)
+		infos = append(infos, BlockInfo{
+			Name:             block.BlockName(),
+			Type:             block.BlockType(),
+			CommandProcessor: isProcessor,
+			MetricProvider:   isProvider,
+			PreDeleteHandler: isHandler,
+			StateExporter:    isExporter,
+		})
+	}
+	return infos
+}
+
+// blockKey returns the key to address the i-th logic block: its name, or "#N" if it has no name
+func (f *feedImpl) blockKey(i int) string {
+	if name := f.logicblocks[i].BlockName(); name != "" {
+		return name
+	}
+	return "#" + strconv.Itoa(i)
+}
+
+// ExportState exports the runtime state of the logic blocks implementing StateExporter.
+// states are keyed by block name, or "#N" for blocks without a name.
+func (f *feedImpl) ExportState() (map[string]BlockState, error) {
+	states := make(map[string]BlockState)
+	for i, block := range f.logicblocks {
+		exporter, ok := block.(logicblock.StateExporter)
+		if !ok {
+			continue
+		}
+		key := f.blockKey(i)
+		if _, exists := states[key]; exists {
+			// 名前が重複している場合は位置で指定する
+			key = "#" + strconv.Itoa(i)
+		}
+		state, err := exporter.ExportState()
+		if err != nil {
+			return nil, fmt.Errorf("failed to export state of logic block %s: %w", key, err)
+		}
+		states[key] = BlockState{Type: block.BlockType(), State: state}
+	}
+	return states, nil
+}
+
+// ImportState restores the runtime state exported by ExportState.
+// all states are checked before importing, so nothing is imported if any block is missing or has another type.
+func (f *feedImpl) ImportState(states map[string]BlockState) error {
+	importers := make(map[string]logicblock.StateImporter, len(states))
+	for key, st := range states {
+		block, err := f.findBlock(key)
+		if err != nil {
+			return err
+		}
+		if block.BlockType() != st.Type {
+			return fmt.Errorf("logic block %s is %s, but state is for %s", key, block.BlockType(), st.Type)
+		}
+		importer, ok := block.(logicblock.StateImporter)
+		if !ok {
+			return fmt.Errorf("logic block %s (%s) does not support importing state", key, block.BlockType())
+		}
+		importers[key] = importer
+	}
+	for key, importer := range importers {
+		if err := importer.ImportState(states[key].State); err != nil {
+			return fmt.Errorf("failed to import state of logic block %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// findBlock returns the logic block addressed by its name or "#N"
+func (f *feedImpl) findBlock(key string) (logicblock.LogicBlock, error) {
+	if idx, ok := strings.CutPrefix(key, "#"); ok {
+		i, err := strconv.Atoi(idx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logic block index: %s", key)
+		}
+		if i < 0 || i >= len(f.logicblocks) {
+			return nil, fmt.Errorf("logic block index out of range: %d (feed has %d blocks)", i, len(f.logicblocks))
+		}
+		return f.logicblocks[i], nil
+	}
+	for _, block := range f.logicblocks {
+		if block.BlockName() == key {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("logic block not found: %s", key)
+}
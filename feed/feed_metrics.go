@@ -0,0 +1,38 @@
+package feed
+
+import (
+	"github.com/nus25/yuge/feed/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// フィードに追加された投稿数
+	postsAdded = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "feed_posts_added_total",
+		Help: "The total number of posts added to feed",
+	}, []string{"feed_id"})
+
+	// フィードから削除された投稿数
+	postsDeleted = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "feed_posts_deleted_total",
+		Help: "The total number of posts deleted from feed",
+	}, []string{"feed_id"})
+
+	// フィードロジックで判定された投稿数
+	postsTested = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "feed_posts_tested_total",
+		Help: "The total number of posts tested by feed logic",
+	}, []string{"feed_id"})
+
+	// maxStoredTextLengthを超えた投稿数
+	postsOversized = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "feed_posts_oversized_total",
+		Help: "The total number of tested posts with text longer than maxStoredTextLength",
+	}, []string{"feed_id"})
+
+	// タイムアウトしたロジックブロックの判定数
+	logicblockTimeouts = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "feed_logicblock_timeout_total",
+		Help: "The total number of logic block tests that exceeded the block test timeout",
+	}, []string{"feed_id", "block_type"})
+)
@@ -2,15 +2,22 @@ package feed
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/nus25/yuge/feed/config/feed"
 	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/logicblock"
 	"github.com/nus25/yuge/feed/store/editor"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // Integration test for Feed
@@ -69,10 +76,13 @@ func TestFeedIntegration(t *testing.T) {
 	}
 
 	// Delete post
-	err = feed.DeletePost("did:plc:user1", "post1")
+	removed, err := feed.DeletePost("did:plc:user1", "post1")
 	if err != nil {
 		t.Errorf("Failed to delete post: %v", err)
 	}
+	if !removed {
+		t.Error("Expected post to be reported as deleted")
+	}
 
 	// Verify post doesn't exist after deletion
 	_, exists = feed.GetPost("did:plc:user1", "post1")
@@ -80,6 +90,15 @@ func TestFeedIntegration(t *testing.T) {
 		t.Error("Post should not exist after deletion")
 	}
 
+	// Deleting again is not an error but reports not found
+	removed, err = feed.DeletePost("did:plc:user1", "post1")
+	if err != nil {
+		t.Errorf("Deleting absent post should not fail: %v", err)
+	}
+	if removed {
+		t.Error("Expected absent post to be reported as not deleted")
+	}
+
 	// delete post by did
 	err = feed.AddPost("did:plc:user1", "post1", "cid1", time.Now(), []string{"en", "fr"})
 	if err != nil {
@@ -101,6 +120,14 @@ func TestFeedIntegration(t *testing.T) {
 		t.Errorf("Expected 2 posts to be deleted, got %d", len(deleted))
 	}
 
+	// metrics
+	if got := testutil.ToFloat64(postsAdded.WithLabelValues("test-feed")); got != 4 {
+		t.Errorf("Expected feed_posts_added_total to be 4, got %v", got)
+	}
+	if got := testutil.ToFloat64(postsDeleted.WithLabelValues("test-feed")); got != 3 {
+		t.Errorf("Expected feed_posts_deleted_total to be 3, got %v", got)
+	}
+
 	// Clear feed
 	err = feed.Clear()
 	if err != nil {
@@ -215,3 +242,672 @@ func createTestConfig(t *testing.T) types.FeedConfig {
 
 	return feedConfig
 }
+
+// Test for minMatch
+func TestFeedMinMatch(t *testing.T) {
+	regex := func(v string) string {
+		return fmt.Sprintf(`{"type": "regex", "options": {"value": %q, "caseSensitive": false, "invert": false}}`, v)
+	}
+	jsonStr := fmt.Sprintf(`{
+		"logic": {
+			"blocks": [%s, %s, %s],
+			"minMatch": 2
+		}
+	}`, regex("apple"), regex("banana"), regex("cherry"))
+	config, err := feed.NewFeedConfigFromJSON(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create file editor: %v", err)
+	}
+	ctx := context.Background()
+	f, err := NewFeedWithOptions(ctx, "test-minmatch", "at://did:plc:test/app.bsky.feed.generator/minmatch", FeedOptions{
+		Config:      config,
+		StoreEditor: fileEditor,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	defer f.Shutdown(ctx)
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{text: "apple", want: false},
+		{text: "apple banana", want: true},
+		{text: "banana cherry", want: true},
+		{text: "apple cherry", want: true},
+		{text: "apple banana cherry", want: true},
+		{text: "nothing", want: false},
+	}
+	for _, tt := range tests {
+		if got := f.Test("did:plc:user1", "constantRkey", &apibsky.FeedPost{Text: tt.text}); got != tt.want {
+			t.Errorf("Test(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFeedTestVerbose(t *testing.T) {
+	jsonStr := `{
+		"logic": {
+			"blocks": [
+				{"name": "apple", "type": "regex", "options": {"value": "apple", "caseSensitive": false, "invert": false}},
+				{"name": "banana", "type": "regex", "options": {"value": "banana", "caseSensitive": false, "invert": false}}
+			]
+		}
+	}`
+	config, err := feed.NewFeedConfigFromJSON(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create file editor: %v", err)
+	}
+	ctx := context.Background()
+	f, err := NewFeedWithOptions(ctx, "test-verbose", "at://did:plc:test/app.bsky.feed.generator/verbose", FeedOptions{
+		Config:      config,
+		StoreEditor: fileEditor,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	defer f.Shutdown(ctx)
+
+	tests := []struct {
+		text    string
+		want    bool
+		results []bool
+	}{
+		{text: "apple banana", want: true, results: []bool{true, true}},
+		{text: "apple", want: false, results: []bool{true, false}},
+		// 最初のブロックで不通過が確定した場合、以降のブロックは評価されない
+		{text: "banana", want: false, results: []bool{false}},
+	}
+	for _, tt := range tests {
+		post := &apibsky.FeedPost{Text: tt.text}
+		got, results := f.TestVerbose("did:plc:user1", "constantRkey", post)
+		if got != tt.want {
+			t.Errorf("TestVerbose(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+		if got != f.Test("did:plc:user1", "constantRkey", post) {
+			t.Errorf("Test(%q) differs from TestVerbose", tt.text)
+		}
+		if len(results) != len(tt.results) {
+			t.Fatalf("TestVerbose(%q) returned %d results, want %d", tt.text, len(results), len(tt.results))
+		}
+		for i, r := range results {
+			if r.Passed != tt.results[i] {
+				t.Errorf("TestVerbose(%q) block %d passed = %v, want %v", tt.text, i, r.Passed, tt.results[i])
+			}
+			if r.Type != "regex" || r.Name != []string{"apple", "banana"}[i] {
+				t.Errorf("TestVerbose(%q) block %d = %+v", tt.text, i, r)
+			}
+		}
+	}
+}
+
+func TestFeedDeletePostByDidCleansDropIn(t *testing.T) {
+	jsonStr := `{
+		"logic": {
+			"blocks": [
+				{"type": "dropin", "options": {"targetWord": ["join"], "expireDuration": "1h"}}
+			]
+		}
+	}`
+	config, err := feed.NewFeedConfigFromJSON(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create file editor: %v", err)
+	}
+	ctx := context.Background()
+	f, err := NewFeedWithOptions(ctx, "test-dropin", "at://did:plc:test/app.bsky.feed.generator/dropin", FeedOptions{
+		Config:      config,
+		StoreEditor: fileEditor,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	defer f.Shutdown(ctx)
+
+	did := "did:plc:user1"
+	if !f.Test(did, "trigger", &apibsky.FeedPost{Text: "join"}) {
+		t.Fatal("trigger post should pass")
+	}
+	if err := f.AddPost(did, "trigger", "cid1", time.Now(), nil); err != nil {
+		t.Fatalf("Failed to add post: %v", err)
+	}
+	// watchlistに入っているので対象ワードがなくても通過する
+	if !f.Test(did, "other", &apibsky.FeedPost{Text: "hello"}) {
+		t.Fatal("post from watched user should pass")
+	}
+
+	if _, err := f.DeletePostByDid(did); err != nil {
+		t.Fatalf("Failed to delete posts by did: %v", err)
+	}
+	if f.Test(did, "after", &apibsky.FeedPost{Text: "hello"}) {
+		t.Error("user should be removed from watchlist after delete by did")
+	}
+}
+
+func TestFeedProcessCommandByIndex(t *testing.T) {
+	jsonStr := `{
+		"logic": {
+			"blocks": [
+				{"type": "regex", "options": {"value": "hello", "caseSensitive": false, "invert": false}},
+				{"type": "dropin", "options": {"targetWord": ["join"]}}
+			]
+		}
+	}`
+	config, err := feed.NewFeedConfigFromJSON(jsonStr)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+
+	fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create file editor: %v", err)
+	}
+	ctx := context.Background()
+	f, err := NewFeedWithOptions(ctx, "test-command", "at://did:plc:test/app.bsky.feed.generator/command", FeedOptions{
+		Config:      config,
+		StoreEditor: fileEditor,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	defer f.Shutdown(ctx)
+
+	tests := []struct {
+		name    string
+		block   string
+		wantMsg string
+		wantErr string
+	}{
+		{name: "index of dropin", block: "#1", wantMsg: "reset success"},
+		{name: "block without command", block: "#0", wantErr: "does not support commands"},
+		{name: "out of range", block: "#2", wantErr: "out of range"},
+		{name: "negative index", block: "#-1", wantErr: "out of range"},
+		{name: "invalid index", block: "#abc", wantErr: "invalid logic block index"},
+		{name: "unknown name", block: "unknown", wantErr: "logic block not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := f.ProcessCommand(tt.block, "reset", nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ProcessCommand(%q) error = %v, want %q", tt.block, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessCommand(%q) error = %v", tt.block, err)
+			}
+			if msg != tt.wantMsg {
+				t.Errorf("ProcessCommand(%q) = %q, want %q", tt.block, msg, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestFeedOversizedPost(t *testing.T) {
+	tests := []struct {
+		name         string
+		reject       bool
+		text         string
+		want         bool
+		wantOversize float64
+	}{
+		{name: "short post", text: "hello", want: true, wantOversize: 0},
+		{name: "oversized post is still tested", text: "hello world", want: true, wantOversize: 1},
+		{name: "oversized post is rejected", reject: true, text: "hello world", want: false, wantOversize: 1},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := feed.NewFeedConfigFromJSON(fmt.Sprintf(`{
+				"logic": {"blocks": [{"type": "regex", "options": {"value": "hello", "caseSensitive": false, "invert": false}}]},
+				"store": {"maxStoredTextLength": 5, "rejectOversized": %t}
+			}`, tt.reject))
+			if err != nil {
+				t.Fatalf("Failed to unmarshal config: %v", err)
+			}
+			fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+			if err != nil {
+				t.Fatalf("Failed to create file editor: %v", err)
+			}
+			ctx := context.Background()
+			feedId := fmt.Sprintf("test-oversized-%d", i)
+			f, err := NewFeedWithOptions(ctx, feedId, "at://did:plc:test/app.bsky.feed.generator/oversized", FeedOptions{
+				Config:      config,
+				StoreEditor: fileEditor,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create feed: %v", err)
+			}
+			defer f.Shutdown(ctx)
+
+			if got := f.Test("did:plc:user1", "rkey", &apibsky.FeedPost{Text: tt.text}); got != tt.want {
+				t.Errorf("Test(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if got := testutil.ToFloat64(postsOversized.WithLabelValues(feedId)); got != tt.wantOversize {
+				t.Errorf("feed_posts_oversized_total = %v, want %v", got, tt.wantOversize)
+			}
+		})
+	}
+}
+
+func TestFeedRecentTests(t *testing.T) {
+	config, err := feed.NewFeedConfigFromJSON(`{"logic": {"blocks": [{"type": "regex", "options": {"value": "apple", "caseSensitive": false, "invert": false}}]}}`)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	ctx := context.Background()
+	newFeed := func(t *testing.T, size int) Feed {
+		t.Helper()
+		fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+		if err != nil {
+			t.Fatalf("Failed to create file editor: %v", err)
+		}
+		f, err := NewFeedWithOptions(ctx, "test-recent", "at://did:plc:test/app.bsky.feed.generator/recent", FeedOptions{
+			Config:          config,
+			StoreEditor:     fileEditor,
+			RecentTestsSize: size,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create feed: %v", err)
+		}
+		t.Cleanup(func() { f.Shutdown(ctx) })
+		return f
+	}
+	long := strings.Repeat("あ", 100)
+
+	tests := []struct {
+		name    string
+		size    int
+		texts   []string
+		enabled bool
+		want    []RecentTest // newest first, TestedAt is ignored
+	}{
+		{name: "disabled", size: 0, texts: []string{"apple"}, enabled: false},
+		{
+			name:    "not full",
+			size:    3,
+			texts:   []string{"apple", "banana"},
+			enabled: true,
+			want: []RecentTest{
+				{Did: "did:plc:user1", Rkey: "rkey1", Text: "banana", Passed: false},
+				{Did: "did:plc:user1", Rkey: "rkey0", Text: "apple", Passed: true},
+			},
+		},
+		{
+			name:    "oldest entries are overwritten",
+			size:    2,
+			texts:   []string{"apple", "banana", "apple pie", long},
+			enabled: true,
+			want: []RecentTest{
+				{Did: "did:plc:user1", Rkey: "rkey3", Text: strings.Repeat("あ", recentTextLength) + "…", Passed: false},
+				{Did: "did:plc:user1", Rkey: "rkey2", Text: "apple pie", Passed: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFeed(t, tt.size)
+			for i, text := range tt.texts {
+				f.Test("did:plc:user1", fmt.Sprintf("rkey%d", i), &apibsky.FeedPost{Text: text})
+			}
+			// TestVerboseは記録しない
+			f.TestVerbose("did:plc:user1", "verbose", &apibsky.FeedPost{Text: "apple"})
+
+			got, enabled := f.RecentTests()
+			if enabled != tt.enabled {
+				t.Fatalf("RecentTests() enabled = %v, want %v", enabled, tt.enabled)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("RecentTests() returned %d entries, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].TestedAt.IsZero() {
+					t.Errorf("entry %d: TestedAt is not set", i)
+				}
+				got[i].TestedAt = time.Time{}
+				if got[i] != tt.want[i] {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFeedReevaluate(t *testing.T) {
+	const listed = "did:plc:listed"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"list": map[string]any{
+				"uri":       "at://did:plc:owner/app.bsky.graph.list/rkey",
+				"cid":       "cid",
+				"name":      "list",
+				"purpose":   "app.bsky.graph.defs#curatelist",
+				"indexedAt": "2024-01-01T00:00:00Z",
+			},
+			"items": []map[string]any{
+				{"uri": "at://did:plc:owner/app.bsky.graph.listitem/1", "subject": map[string]any{"did": listed, "handle": "listed.test"}},
+			},
+		})
+	}))
+	defer ts.Close()
+
+	userlist := func(allow bool) string {
+		return fmt.Sprintf(`{"type": "userlist", "options": {"listUri": "at://did:plc:owner/app.bsky.graph.list/rkey", "allow": %t, "apiBaseURL": %q}}`, allow, ts.URL)
+	}
+	regex := `{"type": "regex", "options": {"value": "apple", "caseSensitive": false, "invert": false}}`
+
+	tests := []struct {
+		name        string
+		blocks      string
+		minMatch    int
+		wantRemoved []string
+	}{
+		{name: "allowlist", blocks: userlist(true), wantRemoved: []string{"did:plc:other"}},
+		{name: "denylist", blocks: userlist(false), wantRemoved: []string{listed}},
+		{name: "no author block", blocks: regex, wantRemoved: []string{}},
+		// minMatch 1 なら他のブロックで通過しうるので削除しない
+		{name: "minMatch reachable", blocks: userlist(true) + "," + regex, minMatch: 1, wantRemoved: []string{}},
+		{name: "minMatch unreachable", blocks: userlist(true) + "," + regex, minMatch: 2, wantRemoved: []string{"did:plc:other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := feed.NewFeedConfigFromJSON(fmt.Sprintf(`{"logic": {"blocks": [%s], "minMatch": %d}}`, tt.blocks, tt.minMatch))
+			if err != nil {
+				t.Fatalf("Failed to unmarshal config: %v", err)
+			}
+			fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+			if err != nil {
+				t.Fatalf("Failed to create file editor: %v", err)
+			}
+			ctx := context.Background()
+			f, err := NewFeedWithOptions(ctx, "test-reevaluate", "at://did:plc:test/app.bsky.feed.generator/reevaluate", FeedOptions{
+				Config:      config,
+				StoreEditor: fileEditor,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create feed: %v", err)
+			}
+			defer f.Shutdown(ctx)
+
+			now := time.Now()
+			for i, did := range []string{listed, listed, "did:plc:other"} {
+				if err := f.AddPost(did, fmt.Sprintf("rkey%d", i), "cid", now.Add(time.Duration(i)*time.Second), nil); err != nil {
+					t.Fatalf("Failed to add post: %v", err)
+				}
+			}
+
+			result, err := f.Reevaluate()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(result.RemovedAuthors, tt.wantRemoved) {
+				t.Errorf("RemovedAuthors = %v, want %v", result.RemovedAuthors, tt.wantRemoved)
+			}
+			wantPosts := 0
+			for _, did := range tt.wantRemoved {
+				if did == listed {
+					wantPosts += 2
+				} else {
+					wantPosts++
+				}
+			}
+			if result.RemovedPosts != wantPosts {
+				t.Errorf("RemovedPosts = %d, want %d", result.RemovedPosts, wantPosts)
+			}
+			if got := f.PostCount(); got != 3-wantPosts {
+				t.Errorf("PostCount = %d, want %d", got, 3-wantPosts)
+			}
+		})
+	}
+}
+
+func TestFeedReevaluateRichStore(t *testing.T) {
+	regexConfig := func(v string) types.FeedConfig {
+		config, err := feed.NewFeedConfigFromJSON(fmt.Sprintf(`{
+			"logic": {"blocks": [{"type": "regex", "options": {"value": %q, "caseSensitive": false, "invert": false}}]},
+			"store": {"richPostLimit": 10}
+		}`, v))
+		if err != nil {
+			t.Fatalf("Failed to unmarshal config: %v", err)
+		}
+		return config
+	}
+	dir := t.TempDir()
+	ctx := context.Background()
+	newFeed := func(config types.FeedConfig) Feed {
+		fileEditor, err := editor.NewFileEditor(dir, slog.Default())
+		if err != nil {
+			t.Fatalf("Failed to create file editor: %v", err)
+		}
+		f, err := NewFeedWithOptions(ctx, "test-rich", "at://did:plc:test/app.bsky.feed.generator/rich", FeedOptions{
+			Config:      config,
+			StoreEditor: fileEditor,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create feed: %v", err)
+		}
+		return f
+	}
+
+	f := newFeed(regexConfig("apple"))
+	now := time.Now()
+	for i, text := range []string{"apple pie", "apple banana"} {
+		rkey := fmt.Sprintf("rkey%d", i)
+		if !f.Test("did:plc:user1", rkey, &apibsky.FeedPost{Text: text}) {
+			t.Fatalf("post %q should pass", text)
+		}
+		if err := f.AddPost("did:plc:user1", rkey, "cid", now.Add(time.Duration(i)*time.Second), nil); err != nil {
+			t.Fatalf("Failed to add post: %v", err)
+		}
+	}
+	// 判定を通過していないポストは保持されない
+	if err := f.AddPost("did:plc:user2", "manual", "cid", now, nil); err != nil {
+		t.Fatalf("Failed to add post: %v", err)
+	}
+	matches, ok := f.RecentMatches()
+	if !ok || len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %v %+v", ok, matches)
+	}
+	if err := f.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to shutdown feed: %v", err)
+	}
+
+	// 設定を変更して再作成する
+	f = newFeed(regexConfig("banana"))
+	defer f.Shutdown(ctx)
+	f.RestoreMatches(matches)
+	result, err := f.Reevaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.PostBlocks != 1 || result.RetestedPosts != 2 || result.RemovedPosts != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, exists := f.GetPost("did:plc:user1", "rkey0"); exists {
+		t.Error("expected post not matching the new config to be removed")
+	}
+	if _, exists := f.GetPost("did:plc:user1", "rkey1"); !exists {
+		t.Error("expected post matching the new config to remain")
+	}
+	if _, exists := f.GetPost("did:plc:user2", "manual"); !exists {
+		t.Error("expected post without metadata to remain")
+	}
+	if matches, _ := f.RecentMatches(); len(matches) != 1 {
+		t.Errorf("expected removed post to be dropped from matches, got %+v", matches)
+	}
+}
+
+// slowLogicBlock passes every post after the delay
+type slowLogicBlock struct {
+	*logicblock.BaseLogicblock
+	delay time.Duration
+}
+
+func (b *slowLogicBlock) Test(did string, rkey string, post *apibsky.FeedPost) bool {
+	time.Sleep(b.delay)
+	return true
+}
+
+func TestFeedBlockTestTimeout(t *testing.T) {
+	const blockType = "slowTest"
+	factory := logicblock.FactoryInstance()
+	if err := factory.RegisterCreator(blockType, func(cfg types.LogicBlockConfig, logger *slog.Logger) (logicblock.LogicBlock, error) {
+		return &slowLogicBlock{BaseLogicblock: logicblock.NewBaseLogicblock(blockType, cfg, logger), delay: 200 * time.Millisecond}, nil
+	}); err != nil {
+		t.Fatalf("Failed to register block: %v", err)
+	}
+	t.Cleanup(func() { delete(factory.Creators, blockType) })
+
+	config, err := feed.NewFeedConfigFromJSON(`{"logic": {"blocks": [{"type": "slowTest"}]}}`)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	ctx := context.Background()
+	post := &apibsky.FeedPost{Text: "hello"}
+
+	tests := []struct {
+		name         string
+		feedId       string
+		timeout      time.Duration
+		wantPassed   bool
+		wantTimeouts float64
+	}{
+		{name: "timeout treated as not matched", feedId: "test-timeout", timeout: 20 * time.Millisecond, wantPassed: false, wantTimeouts: 1},
+		{name: "disabled", feedId: "test-no-timeout", timeout: 0, wantPassed: true, wantTimeouts: 0},
+		{name: "within timeout", feedId: "test-long-timeout", timeout: 5 * time.Second, wantPassed: true, wantTimeouts: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+			if err != nil {
+				t.Fatalf("Failed to create file editor: %v", err)
+			}
+			f, err := NewFeedWithOptions(ctx, tt.feedId, "at://did:plc:test/app.bsky.feed.generator/"+tt.feedId, FeedOptions{
+				Config:           config,
+				StoreEditor:      fileEditor,
+				BlockTestTimeout: tt.timeout,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create feed: %v", err)
+			}
+			t.Cleanup(func() { f.Shutdown(ctx) })
+
+			start := time.Now()
+			passed, results := f.TestVerbose("did:plc:user1", "rkey1", post)
+			elapsed := time.Since(start)
+			if passed != tt.wantPassed {
+				t.Errorf("expected passed %v, got %v", tt.wantPassed, passed)
+			}
+			if len(results) != 1 || results[0].TimedOut != (tt.wantTimeouts > 0) {
+				t.Errorf("unexpected block results: %+v", results)
+			}
+			if tt.wantTimeouts > 0 && elapsed >= 200*time.Millisecond {
+				t.Errorf("expected Test to return before the block finished, took %v", elapsed)
+			}
+			if got := testutil.ToFloat64(logicblockTimeouts.WithLabelValues(tt.feedId, blockType)); got != tt.wantTimeouts {
+				t.Errorf("expected feed_logicblock_timeout_total %v, got %v", tt.wantTimeouts, got)
+			}
+			if tt.wantTimeouts == 0 {
+				return
+			}
+			// タイムアウトしたTestの実行中はブロックを評価せずスキップする
+			start = time.Now()
+			passed, results = f.TestVerbose("did:plc:user1", "rkey2", post)
+			if passed || len(results) != 1 || !results[0].TimedOut {
+				t.Errorf("expected block skipped as timed out, got passed %v, results %+v", passed, results)
+			}
+			if elapsed := time.Since(start); elapsed >= tt.timeout {
+				t.Errorf("expected skipped block not to wait for the timeout, took %v", elapsed)
+			}
+			if got := testutil.ToFloat64(logicblockTimeouts.WithLabelValues(tt.feedId, blockType)); got != tt.wantTimeouts {
+				t.Errorf("expected skipped block not counted as timeout, got %v", got)
+			}
+			// 実行中のTestが終われば再び評価される
+			time.Sleep(300 * time.Millisecond)
+			if _, results = f.TestVerbose("did:plc:user1", "rkey3", post); len(results) != 1 || !results[0].TimedOut {
+				t.Errorf("unexpected block results: %+v", results)
+			}
+			if got := testutil.ToFloat64(logicblockTimeouts.WithLabelValues(tt.feedId, blockType)); got != tt.wantTimeouts+1 {
+				t.Errorf("expected feed_logicblock_timeout_total %v, got %v", tt.wantTimeouts+1, got)
+			}
+		})
+	}
+}
+
+func TestFeedDryRun(t *testing.T) {
+	config, err := feed.NewFeedConfigFromJSON(`{"logic": {"blocks": [
+		{"type": "regex", "options": {"value": "apple", "caseSensitive": false, "invert": false}},
+		{"type": "dedupe", "options": {"windowSize": 10}}
+	]}}`)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal config: %v", err)
+	}
+	fileEditor, err := editor.NewFileEditor(t.TempDir(), slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create file editor: %v", err)
+	}
+	ctx := context.Background()
+	f, err := NewFeedWithOptions(ctx, "test-dryrun", "at://did:plc:test/app.bsky.feed.generator/dryrun", FeedOptions{
+		Config:      config,
+		StoreEditor: fileEditor,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	t.Cleanup(func() { f.Shutdown(ctx) })
+	post := &apibsky.FeedPost{Text: "apple pie"}
+
+	tests := []struct {
+		name        string
+		text        string
+		wantPassed  bool
+		wantSkipped []bool
+	}{
+		// 本文のブロックで不通過になれば残りは評価しない
+		{name: "not matched", text: "banana", wantPassed: false, wantSkipped: []bool{false}},
+		{name: "dedupe is skipped", text: "apple pie", wantPassed: true, wantSkipped: []bool{false, true}},
+		{name: "same text again", text: "apple pie", wantPassed: true, wantSkipped: []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, results := f.DryRun("did:plc:user1", "rkey1", &apibsky.FeedPost{Text: tt.text})
+			if passed != tt.wantPassed {
+				t.Errorf("expected passed %v, got %v", tt.wantPassed, passed)
+			}
+			skipped := make([]bool, len(results))
+			for i, r := range results {
+				skipped[i] = r.Skipped
+			}
+			if !slices.Equal(skipped, tt.wantSkipped) {
+				t.Errorf("expected skipped %v, got %v", tt.wantSkipped, skipped)
+			}
+		})
+	}
+
+	// ドライランはdedupeの状態とメトリクスを変更しない
+	if got := testutil.ToFloat64(postsTested.WithLabelValues("test-dryrun")); got != 0 {
+		t.Errorf("expected dry run not to count tested posts, got %v", got)
+	}
+	if !f.Test("did:plc:user1", "rkey1", post) {
+		t.Error("expected real post to pass after dry runs")
+	}
+	if f.Test("did:plc:user1", "rkey2", post) {
+		t.Error("expected duplicate real post to be rejected")
+	}
+}
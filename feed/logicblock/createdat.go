@@ -0,0 +1,93 @@
+package logicblock
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	config "github.com/nus25/yuge/feed/config/logic"
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+var _ LogicBlock = (*CreatedAtLogicblock)(nil) //type check
+
+func init() {
+	FactoryInstance().MustRegisterCreator(BlockTypeCreatedAt, NewCreatedAtLogicBlock)
+}
+
+const BlockTypeCreatedAt = config.CreatedAtBlockType
+
+type CreatedAtLogicblock struct {
+	*BaseLogicblock
+	pastWindow time.Duration // 0 means no limit
+	futureSkew time.Duration
+	now        func() time.Time
+}
+
+func NewCreatedAtLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
+	if cfg.GetBlockType() != BlockTypeCreatedAt {
+		logger.Error("invalid block type", "type", cfg.GetBlockType())
+		return nil, errors.NewConfigError("block type", cfg.GetBlockType(), "invalid block type")
+	}
+	ccfg, ok := cfg.(*config.CreatedAtLogicBlockConfig)
+	if !ok {
+		logger.Error("invalid config type", "type", fmt.Sprintf("%T", cfg))
+		return nil, errors.NewConfigError("config type", fmt.Sprintf("%T", cfg), "invalid config type")
+	}
+	//pastWindow (optional)
+	pastWindow, ok := ccfg.GetDurationOption(config.CreatedAtOptionPastWindow)
+	if ok && pastWindow <= 0 {
+		logger.Error("pastWindow must be positive", "pastWindow", pastWindow)
+		return nil, errors.NewConfigError(config.CreatedAtOptionPastWindow, pastWindow.String(), "pastWindow must be positive")
+	}
+	//futureSkew (optional)
+	futureSkew, ok := ccfg.GetDurationOption(config.CreatedAtOptionFutureSkew)
+	if !ok {
+		futureSkew = config.CreatedAtDefaultFutureSkew
+	}
+	if futureSkew < 0 {
+		logger.Error("futureSkew must not be negative", "futureSkew", futureSkew)
+		return nil, errors.NewConfigError(config.CreatedAtOptionFutureSkew, futureSkew.String(), "futureSkew must not be negative")
+	}
+
+	return &CreatedAtLogicblock{
+		BaseLogicblock: &BaseLogicblock{
+			blockType: BlockTypeCreatedAt,
+			config:    cfg,
+			logger:    logger,
+		},
+		pastWindow: pastWindow,
+		futureSkew: futureSkew,
+		now:        time.Now,
+	}, nil
+}
+
+// Returns true if createdAt of the post is within [now - pastWindow, now + futureSkew].
+// posts dated far in the future would otherwise stay at the top of the feed.
+func (l *CreatedAtLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) (result bool) {
+	dt, err := syntax.ParseDatetimeLenient(post.CreatedAt)
+	if err != nil {
+		return false
+	}
+	createdAt := dt.Time()
+	now := l.now()
+	if createdAt.After(now.Add(l.futureSkew)) {
+		return false
+	}
+	if l.pastWindow > 0 && createdAt.Before(now.Add(-l.pastWindow)) {
+		return false
+	}
+	return true
+}
+
+func (l *CreatedAtLogicblock) Reset() error {
+	return nil
+}
+
+func (l *CreatedAtLogicblock) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package logicblock
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/nus25/yuge/feed/config/logic"
+)
+
+func TestCreatedAtLogicblock(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string {
+		return now.Add(d).Format(time.RFC3339Nano)
+	}
+
+	tests := []struct {
+		name      string
+		options   map[string]interface{}
+		createdAt string
+		expected  bool
+	}{
+		{
+			name:      "now passes",
+			options:   map[string]interface{}{"pastWindow": "24h"},
+			createdAt: at(0),
+			expected:  true,
+		},
+		{
+			name:      "within default future skew",
+			options:   map[string]interface{}{},
+			createdAt: at(4 * time.Minute),
+			expected:  true,
+		},
+		{
+			name:      "beyond default future skew",
+			options:   map[string]interface{}{},
+			createdAt: at(6 * time.Minute),
+			expected:  false,
+		},
+		{
+			name:      "far future",
+			options:   map[string]interface{}{"futureSkew": "1h"},
+			createdAt: "2099-01-01T00:00:00Z",
+			expected:  false,
+		},
+		{
+			name:      "at future bound",
+			options:   map[string]interface{}{"futureSkew": "1h"},
+			createdAt: at(time.Hour),
+			expected:  true,
+		},
+		{
+			name:      "zero future skew",
+			options:   map[string]interface{}{"futureSkew": "0s"},
+			createdAt: at(time.Second),
+			expected:  false,
+		},
+		{
+			name:      "at past bound",
+			options:   map[string]interface{}{"pastWindow": "24h"},
+			createdAt: at(-24 * time.Hour),
+			expected:  true,
+		},
+		{
+			name:      "beyond past window",
+			options:   map[string]interface{}{"pastWindow": "24h"},
+			createdAt: at(-25 * time.Hour),
+			expected:  false,
+		},
+		{
+			name:      "old post without past window",
+			options:   map[string]interface{}{},
+			createdAt: "2000-01-01T00:00:00Z",
+			expected:  true,
+		},
+		{
+			name:      "timezone offset",
+			options:   map[string]interface{}{"pastWindow": "1h"},
+			createdAt: "2025-06-01T20:30:00+09:00",
+			expected:  true,
+		},
+		{
+			name:      "invalid createdAt",
+			options:   map[string]interface{}{},
+			createdAt: "yesterday",
+			expected:  false,
+		},
+		{
+			name:      "empty createdAt",
+			options:   map[string]interface{}{},
+			createdAt: "",
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &logic.CreatedAtLogicBlockConfig{
+				BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+					BlockType: "createdat",
+					Options:   tt.options,
+				},
+			}
+			block, err := NewCreatedAtLogicBlock(cfg, slog.Default())
+			if err != nil {
+				t.Fatalf("failed to create block: %v", err)
+			}
+			block.(*CreatedAtLogicblock).now = func() time.Time { return now }
+			post := &apibsky.FeedPost{Text: "test", CreatedAt: tt.createdAt}
+			if result := block.Test("did:plc:test", "rkey", post); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCreatedAtLogicblock_InvalidConfig(t *testing.T) {
+	cfg := &logic.CreatedAtLogicBlockConfig{
+		BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+			BlockType: "createdat",
+			Options:   map[string]interface{}{"futureSkew": "-1m"},
+		},
+	}
+	if _, err := NewCreatedAtLogicBlock(cfg, slog.Default()); err == nil {
+		t.Error("expected error for negative futureSkew")
+	}
+}
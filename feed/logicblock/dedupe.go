@@ -0,0 +1,136 @@
+package logicblock
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	config "github.com/nus25/yuge/feed/config/logic"
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/dedupe"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+// type check
+var _ LogicBlock = (*DedupeLogicblock)(nil)
+var _ CommandProcessor = (*DedupeLogicblock)(nil)
+
+const (
+	BlockTypeDedupe    = config.DedupeBlockType
+	DedupeCommandClear = "clear"
+	DedupeCommandList  = "list"
+)
+
+func init() {
+	FactoryInstance().MustRegisterCreator(BlockTypeDedupe, NewDedupeLogicBlock)
+}
+
+type DedupeLogicblock struct {
+	*BaseLogicblock
+	windowSize int
+	ttl        time.Duration
+	normalizer TextNormalizer
+	window     *dedupe.Window
+}
+
+func NewDedupeLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
+	if cfg.GetBlockType() != BlockTypeDedupe {
+		logger.Error("invalid block type", "type", cfg.GetBlockType())
+		return nil, errors.NewConfigError("block type", cfg.GetBlockType(), "invalid block type")
+	}
+	dcfg, ok := cfg.(*config.DedupeLogicBlockConfig)
+	if !ok {
+		logger.Error("invalid config type", "type", fmt.Sprintf("%T", cfg))
+		return nil, errors.NewConfigError("config type", fmt.Sprintf("%T", cfg), "invalid config type")
+	}
+	// windowSize (optional)
+	size, ok := dcfg.GetIntOption(config.DedupeOptionWindowSize)
+	if !ok {
+		size = config.DedupeDefaultWindowSize
+	}
+	if size <= 0 {
+		logger.Error("windowSize must be greater than 0", "windowSize", size)
+		return nil, errors.NewConfigError(config.DedupeOptionWindowSize, fmt.Sprintf("%d", size), "windowSize must be greater than 0")
+	}
+	// ttl (optional)
+	ttl, ok := dcfg.GetDurationOption(config.DedupeOptionTTL)
+	if !ok {
+		ttl = config.DedupeDefaultTTL
+	}
+	if ttl <= 0 {
+		logger.Error("ttl must be greater than 0", "ttl", ttl)
+		return nil, errors.NewConfigError(config.DedupeOptionTTL, ttl.String(), "ttl must be greater than 0")
+	}
+
+	// 大文字小文字は常に区別しない
+	normalizer := NewTextNormalizer(dcfg, true)
+	if _, ok := dcfg.GetBoolOption(config.TextOptionCollapseWhitespace); !ok {
+		normalizer.CollapseWhitespace = config.DedupeDefaultCollapseWhitespace
+	}
+
+	w, err := dedupe.NewWindow(size, ttl)
+	if err != nil {
+		logger.Error("failed to create dedupe window", "error", err)
+		return nil, errors.NewConfigError("dedupe logic block", "", "failed to create dedupe window")
+	}
+
+	return &DedupeLogicblock{
+		BaseLogicblock: &BaseLogicblock{
+			blockType: BlockTypeDedupe,
+			config:    cfg,
+			logger:    logger,
+		},
+		windowSize: size,
+		ttl:        ttl,
+		normalizer: normalizer,
+		window:     w,
+	}, nil
+}
+
+// Returns false if the same normalized text was seen within ttl
+func (d *DedupeLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) bool {
+	// posts without text (e.g. image only) are not deduplicated
+	if strings.TrimSpace(post.Text) == "" {
+		return true
+	}
+	if d.window.Seen(d.normalizer.Normalize(post.Text)) {
+		d.logger.Debug("duplicate post rejected", "did", did, "rkey", rkey)
+		return false
+	}
+	return true
+}
+
+func (d *DedupeLogicblock) Reset() error {
+	d.logger.Info("resetting dedupe block")
+	d.window.Clear()
+	return nil
+}
+
+func (d *DedupeLogicblock) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (d *DedupeLogicblock) ProcessCommand(command string, args map[string]string) (message string, err error) {
+	switch strings.ToLower(command) {
+	case DedupeCommandClear:
+		if err := d.Reset(); err != nil {
+			return "", err
+		}
+		return "clear success", nil
+	case DedupeCommandList:
+		list := d.window.List()
+		listStr := ""
+		for _, e := range list {
+			if listStr != "" {
+				listStr += ", "
+			}
+			listStr += fmt.Sprintf("{text: %q, seenAt: %s}", e.Preview, e.SeenAt.UTC().Format(time.RFC3339))
+		}
+		return fmt.Sprintf("list success: %d/%d [%s]", len(list), d.windowSize, listStr), nil
+	default:
+		return "", fmt.Errorf("invalid command: %s", command)
+	}
+}
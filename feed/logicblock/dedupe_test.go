@@ -0,0 +1,97 @@
+package logicblock
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/nus25/yuge/feed/config/logic"
+)
+
+func newDedupeBlock(t *testing.T, options map[string]interface{}) *DedupeLogicblock {
+	t.Helper()
+	cfg, err := (&logic.DedupeLogicBlockFactory{}).Create(logic.BaseLogicBlockConfig{
+		BlockType: "dedupe",
+		Options:   options,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	block, err := NewDedupeLogicBlock(cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("failed to create block: %v", err)
+	}
+	return block.(*DedupeLogicblock)
+}
+
+func TestDedupeLogicblock(t *testing.T) {
+	block := newDedupeBlock(t, map[string]interface{}{"windowSize": 2, "ttl": "1h"})
+
+	steps := []struct {
+		did  string
+		text string
+		want bool
+	}{
+		{did: "did:plc:a", text: "Buy now!", want: true},
+		{did: "did:plc:b", text: "buy   NOW!", want: false}, // 正規化後に同一
+		{did: "did:plc:c", text: "", want: true},
+		{did: "did:plc:c", text: "", want: true}, // テキストなしは対象外
+		{did: "did:plc:d", text: "second", want: true},
+		{did: "did:plc:e", text: "third", want: true}, // windowSize超過で"buy now!"が押し出される
+		{did: "did:plc:f", text: "Buy now!", want: true},
+	}
+	for i, s := range steps {
+		if got := block.Test(s.did, "rkey", &apibsky.FeedPost{Text: s.text}); got != s.want {
+			t.Errorf("step %d: Test(%q) = %v, want %v", i, s.text, got, s.want)
+		}
+	}
+}
+
+func TestDedupeLogicblock_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		first   string
+		second  string
+		want    bool // Test result of the second text
+	}{
+		{name: "whitespace collapsed by default", options: map[string]interface{}{}, first: "buy  now", second: "Buy now", want: false},
+		{name: "collapseWhitespace disabled", options: map[string]interface{}{"collapseWhitespace": false}, first: "buy  now", second: "buy now", want: true},
+		{name: "width variants differ without nfkc", options: map[string]interface{}{}, first: "ＢＵＹ ｎｏｗ", second: "buy now", want: true},
+		{name: "nfkc", options: map[string]interface{}{"nfkc": true}, first: "ＢＵＹ ｎｏｗ", second: "buy now", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := newDedupeBlock(t, tt.options)
+			block.Test("did:plc:a", "rkey", &apibsky.FeedPost{Text: tt.first})
+			if got := block.Test("did:plc:b", "rkey", &apibsky.FeedPost{Text: tt.second}); got != tt.want {
+				t.Errorf("Test(%q) after %q = %v, want %v", tt.second, tt.first, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupeLogicblock_ProcessCommand(t *testing.T) {
+	block := newDedupeBlock(t, map[string]interface{}{})
+	block.Test("did:plc:a", "rkey", &apibsky.FeedPost{Text: "copypasta"})
+
+	msg, err := block.ProcessCommand("list", nil)
+	if err != nil {
+		t.Fatalf("list command failed: %v", err)
+	}
+	if !strings.Contains(msg, "copypasta") {
+		t.Errorf("list message should contain recorded text: %s", msg)
+	}
+
+	if _, err := block.ProcessCommand("clear", nil); err != nil {
+		t.Fatalf("clear command failed: %v", err)
+	}
+	if !block.Test("did:plc:b", "rkey", &apibsky.FeedPost{Text: "copypasta"}) {
+		t.Error("text should pass after clear")
+	}
+
+	if _, err := block.ProcessCommand("unknown", nil); err == nil {
+		t.Error("expected error for unknown command")
+	}
+}
@@ -0,0 +1,163 @@
+package logicblock
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	config "github.com/nus25/yuge/feed/config/logic"
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+var _ LogicBlock = (*DomainLogicblock)(nil) //type check
+var _ Retestable = (*DomainLogicblock)(nil)
+var _ ReasonProvider = (*DomainLogicblock)(nil)
+
+func init() {
+	FactoryInstance().MustRegisterCreator(BlockTypeDomain, NewDomainLogicBlock)
+}
+
+const BlockTypeDomain = config.DomainBlockType
+
+type DomainLogicblock struct {
+	*BaseLogicblock
+	domains           map[string]struct{}
+	includeSubdomains bool
+	invert            bool
+	lastReason        atomic.Pointer[string]
+}
+
+func NewDomainLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
+	if cfg.GetBlockType() != BlockTypeDomain {
+		logger.Error("invalid block type", "type", cfg.GetBlockType())
+		return nil, errors.NewConfigError("block type", cfg.GetBlockType(), "invalid block type")
+	}
+	dcfg, ok := cfg.(*config.DomainLogicBlockConfig)
+	if !ok {
+		logger.Error("invalid config type", "type", fmt.Sprintf("%T", cfg))
+		return nil, errors.NewConfigError("config type", fmt.Sprintf("%T", cfg), "invalid config type")
+	}
+	//domains
+	list, ok := dcfg.GetStringArrayOption(config.DomainOptionDomains)
+	if !ok || len(list) == 0 {
+		logger.Error("domains option not found")
+		return nil, errors.NewConfigError(config.DomainOptionDomains, "", "domains option not found")
+	}
+	domains := make(map[string]struct{}, len(list))
+	for _, d := range list {
+		domains[normalizeHost(d)] = struct{}{}
+	}
+	//includeSubdomains, invert (optional)
+	includeSubdomains, _ := dcfg.GetBoolOption(config.DomainOptionIncludeSubdomains)
+	invert, _ := dcfg.GetBoolOption(config.DomainOptionInvert)
+
+	return &DomainLogicblock{
+		BaseLogicblock: &BaseLogicblock{
+			blockType: BlockTypeDomain,
+			config:    cfg,
+			logger:    logger,
+		},
+		domains:           domains,
+		includeSubdomains: includeSubdomains,
+		invert:            invert,
+	}, nil
+}
+
+// Returns true if the post links to any of the configured domains
+func (l *DomainLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) (result bool) {
+	host, matched := l.findMatch(linkUris(post))
+	if matched {
+		reason := "matched: " + host
+		l.lastReason.Store(&reason)
+	} else {
+		l.lastReason.Store(nil)
+	}
+	return matched != l.invert
+}
+
+// Retest tests the post without recording the matched host
+func (l *DomainLogicblock) Retest(did string, rkey string, post *apibsky.FeedPost) bool {
+	_, matched := l.findMatch(linkUris(post))
+	return matched != l.invert
+}
+
+// findMatch returns the first host of uris that matches the configured domains
+func (l *DomainLogicblock) findMatch(uris []string) (string, bool) {
+	for _, uri := range uris {
+		u, err := url.Parse(uri)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		host := normalizeHost(u.Hostname())
+		if _, ok := l.domains[host]; ok {
+			return host, true
+		}
+		if !l.includeSubdomains {
+			continue
+		}
+		// 親ドメインを順にたどる
+		for parent, ok := parentDomain(host); ok; parent, ok = parentDomain(parent) {
+			if _, exists := l.domains[parent]; exists {
+				return host, true
+			}
+		}
+	}
+	return "", false
+}
+
+// linkUris returns the uris of link facets and external embeds of the post
+func linkUris(post *apibsky.FeedPost) []string {
+	var uris []string
+	for _, facet := range post.Facets {
+		if facet == nil {
+			continue
+		}
+		for _, feature := range facet.Features {
+			if feature != nil && feature.RichtextFacet_Link != nil {
+				uris = append(uris, feature.RichtextFacet_Link.Uri)
+			}
+		}
+	}
+	if post.Embed == nil {
+		return uris
+	}
+	external := post.Embed.EmbedExternal
+	if external == nil && post.Embed.EmbedRecordWithMedia != nil && post.Embed.EmbedRecordWithMedia.Media != nil {
+		external = post.Embed.EmbedRecordWithMedia.Media.EmbedExternal
+	}
+	if external != nil && external.External != nil {
+		uris = append(uris, external.External.Uri)
+	}
+	return uris
+}
+
+// parentDomain returns host without its first label, e.g. "example.com" for "news.example.com"
+func parentDomain(host string) (string, bool) {
+	_, parent, found := strings.Cut(host, ".")
+	return parent, found && parent != ""
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// LastReason returns the host matched by the last Test, or "" if it did not match
+func (l *DomainLogicblock) LastReason() string {
+	if r := l.lastReason.Load(); r != nil {
+		return *r
+	}
+	return ""
+}
+
+func (l *DomainLogicblock) Reset() error {
+	return nil
+}
+
+func (l *DomainLogicblock) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,155 @@
+package logicblock
+
+import (
+	"log/slog"
+	"testing"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/nus25/yuge/feed/config/logic"
+)
+
+func newLinkPost(uris ...string) *apibsky.FeedPost {
+	post := &apibsky.FeedPost{Text: "link post"}
+	for _, uri := range uris {
+		post.Facets = append(post.Facets, &apibsky.RichtextFacet{
+			Features: []*apibsky.RichtextFacet_Features_Elem{
+				{RichtextFacet_Link: &apibsky.RichtextFacet_Link{Uri: uri}},
+			},
+		})
+	}
+	return post
+}
+
+func newExternalPost(uri string) *apibsky.FeedPost {
+	return &apibsky.FeedPost{
+		Text: "external embed",
+		Embed: &apibsky.FeedPost_Embed{
+			EmbedExternal: &apibsky.EmbedExternal{
+				External: &apibsky.EmbedExternal_External{Uri: uri},
+			},
+		},
+	}
+}
+
+func TestDomainLogicblock(t *testing.T) {
+	domains := []string{"example.com", "News.Example.org"}
+	recordWithMedia := &apibsky.FeedPost{
+		Text: "quote with external",
+		Embed: &apibsky.FeedPost_Embed{
+			EmbedRecordWithMedia: &apibsky.EmbedRecordWithMedia{
+				Media: &apibsky.EmbedRecordWithMedia_Media{
+					EmbedExternal: &apibsky.EmbedExternal{
+						External: &apibsky.EmbedExternal_External{Uri: "https://example.com/article"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		options    map[string]interface{}
+		post       *apibsky.FeedPost
+		expected   bool
+		wantReason string
+	}{
+		{
+			name:       "facet link matches",
+			options:    map[string]interface{}{"domains": domains},
+			post:       newLinkPost("https://other.net/", "https://example.com/news/1"),
+			expected:   true,
+			wantReason: "matched: example.com",
+		},
+		{
+			name:       "external embed matches case insensitively",
+			options:    map[string]interface{}{"domains": domains},
+			post:       newExternalPost("https://NEWS.example.org/a"),
+			expected:   true,
+			wantReason: "matched: news.example.org",
+		},
+		{
+			name:     "external embed in record with media matches",
+			options:  map[string]interface{}{"domains": domains},
+			post:     recordWithMedia,
+			expected: true,
+		},
+		{
+			name:     "subdomain does not match by default",
+			options:  map[string]interface{}{"domains": domains},
+			post:     newLinkPost("https://www.example.com/"),
+			expected: false,
+		},
+		{
+			name:       "subdomain matches with includeSubdomains",
+			options:    map[string]interface{}{"domains": domains, "includeSubdomains": true},
+			post:       newLinkPost("https://www.example.com/"),
+			expected:   true,
+			wantReason: "matched: www.example.com",
+		},
+		{
+			name:     "parent domain does not match subdomain setting",
+			options:  map[string]interface{}{"domains": domains, "includeSubdomains": true},
+			post:     newLinkPost("https://example.org/"),
+			expected: false,
+		},
+		{
+			name:     "similar domain does not match",
+			options:  map[string]interface{}{"domains": domains, "includeSubdomains": true},
+			post:     newLinkPost("https://notexample.com/"),
+			expected: false,
+		},
+		{
+			name:     "post without links",
+			options:  map[string]interface{}{"domains": domains},
+			post:     &apibsky.FeedPost{Text: "no links"},
+			expected: false,
+		},
+		{
+			name:     "invert removes matching post",
+			options:  map[string]interface{}{"domains": domains, "invert": true},
+			post:     newExternalPost("https://example.com/"),
+			expected: false,
+		},
+		{
+			name:     "invert keeps post without links",
+			options:  map[string]interface{}{"domains": domains, "invert": true},
+			post:     &apibsky.FeedPost{Text: "no links"},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &logic.DomainLogicBlockConfig{
+				BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+					BlockType: "domain",
+					Options:   tt.options,
+				},
+			}
+			block, err := NewDomainLogicBlock(cfg, slog.Default())
+			if err != nil {
+				t.Fatalf("failed to create block: %v", err)
+			}
+			if result := block.Test("did:plc:test", "rkey", tt.post); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+			if tt.wantReason != "" {
+				if reason := block.(ReasonProvider).LastReason(); reason != tt.wantReason {
+					t.Errorf("expected reason %q, got %q", tt.wantReason, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestDomainLogicblock_InvalidConfig(t *testing.T) {
+	cfg := &logic.DomainLogicBlockConfig{
+		BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+			BlockType: "domain",
+			Options:   map[string]interface{}{},
+		},
+	}
+	if _, err := NewDomainLogicBlock(cfg, slog.Default()); err == nil {
+		t.Error("expected error for missing domains")
+	}
+}
@@ -2,10 +2,14 @@ package logicblock
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	config "github.com/nus25/yuge/feed/config/logic"
@@ -19,6 +23,9 @@ import (
 var _ LogicBlock = (*DropInLogicblock)(nil)
 var _ CommandProcessor = (*DropInLogicblock)(nil)
 var _ MetricProvider = (*DropInLogicblock)(nil)
+var _ StateExporter = (*DropInLogicblock)(nil)
+var _ StateImporter = (*DropInLogicblock)(nil)
+var _ ReasonProvider = (*DropInLogicblock)(nil)
 
 const (
 	BlockTypeDropIn                      = config.DropInBlockType
@@ -30,7 +37,7 @@ const (
 )
 
 func init() {
-	FactoryInstance().RegisterCreator(BlockTypeDropIn, NewDropInLogicBlock)
+	FactoryInstance().MustRegisterCreator(BlockTypeDropIn, NewDropInLogicBlock)
 }
 
 type DropInLogicblock struct {
@@ -39,7 +46,11 @@ type DropInLogicblock struct {
 	targetWord     []string
 	cancelWord     []string
 	ignoreWord     []string
+	normalizer     TextNormalizer
+	wordBoundary   bool
+	excludeFacets  bool
 	watchlist      *watchlist.Watchlist
+	lastReason     atomic.Pointer[string]
 }
 
 func NewDropInLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
@@ -64,30 +75,25 @@ func NewDropInLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (Logic
 		logger.Error("targetWord must not be empty")
 		return nil, errors.NewConfigError(config.DropInOptionTargetWord, fmt.Sprintf("%v", tw), "targetWord must not be empty")
 	}
-	// convert to lower case
-	for i := range tw {
-		tw[i] = strings.ToLower(tw[i])
-	}
 
 	// cancelWord (optional)
 	cw, ok := dcfg.GetStringArrayOption(config.DropInOptionCancelWord)
 	if !ok {
 		cw = []string{}
 	}
-	// convert to lower case
-	for i := range cw {
-		cw[i] = strings.ToLower(cw[i])
-	}
 
 	// ignoreWord (optional)
 	iw, ok := dcfg.GetStringArrayOption(config.DropInOptionIgnoreWord)
 	if !ok {
 		iw = []string{}
 	}
-	// convert to lower case
-	for i := range iw {
-		iw[i] = strings.ToLower(iw[i])
-	}
+
+	// words are matched case-insensitively
+	normalizer := NewTextNormalizer(dcfg, true)
+
+	// wordBoundary, excludeFacets (optional)
+	wordBoundary, _ := dcfg.GetBoolOption(config.DropInOptionWordBoundary)
+	excludeFacets, _ := dcfg.GetBoolOption(config.DropInOptionExcludeFacets)
 
 	// expireDuration (optional)
 	ed, ok := dcfg.GetDurationOption(config.DropInOptionExpireDuration)
@@ -101,6 +107,10 @@ func NewDropInLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (Logic
 		logger.Error("failed to create watchlist", "error", err)
 		return nil, errors.NewConfigError("drop in logic block", "", "failed to create watchlist")
 	}
+	// maxEntries (optional)
+	if maxEntries, ok := dcfg.GetIntOption(config.DropInOptionMaxEntries); ok && maxEntries > 0 {
+		wl.SetMaxEntries(maxEntries)
+	}
 
 	return &DropInLogicblock{
 		BaseLogicblock: &BaseLogicblock{
@@ -109,9 +119,12 @@ func NewDropInLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (Logic
 			logger:    logger,
 		},
 		expireDuration: ed,
-		targetWord:     tw,
-		cancelWord:     cw,
-		ignoreWord:     iw,
+		targetWord:     normalizer.NormalizeAll(tw),
+		cancelWord:     normalizer.NormalizeAll(cw),
+		ignoreWord:     normalizer.NormalizeAll(iw),
+		normalizer:     normalizer,
+		wordBoundary:   wordBoundary,
+		excludeFacets:  excludeFacets,
 		watchlist:      wl,
 	}, nil
 }
@@ -128,45 +141,126 @@ func (d *DropInLogicblock) Shutdown(ctx context.Context) error {
 }
 
 func (d *DropInLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) bool {
-	txt := strings.ToLower(post.Text)
+	txt := post.Text
+	if d.excludeFacets {
+		txt = maskFacets(post)
+	}
+	txt = d.normalizer.Normalize(txt)
 	// cancelWord
 	for _, w := range d.cancelWord {
-		if strings.Contains(txt, w) {
+		if d.contains(txt, w) {
 			d.watchlist.Delete(did)
+			d.setLastReason("canceled: " + w)
 			return false
 		}
 	}
 
 	// ignoreWord
 	for _, w := range d.ignoreWord {
-		if strings.Contains(txt, w) {
+		if d.contains(txt, w) {
+			d.lastReason.Store(nil)
 			return false
 		}
 	}
 
 	// check did is in watchlist
 	if d.watchlist.Contains(did) != nil {
+		d.setLastReason("watched")
 		return true
 	}
 
 	// if targetWord is in post.Text, add to watchlist
 	for _, w := range d.targetWord {
-		if strings.Contains(txt, w) {
+		if d.contains(txt, w) {
 			d.watchlist.Add(did, rkey)
+			d.setLastReason("matched: " + w)
 			return true
 		}
 	}
 
+	d.lastReason.Store(nil)
+	return false
+}
+
+func (d *DropInLogicblock) setLastReason(reason string) {
+	d.lastReason.Store(&reason)
+}
+
+// LastReason returns the target word matched by the last Test, the cancel word that removed the author from the watchlist,
+// or "watched" if the author was already on the watchlist. "" if the last Test matched nothing
+func (d *DropInLogicblock) LastReason() string {
+	if r := d.lastReason.Load(); r != nil {
+		return *r
+	}
+	return ""
+}
+
+func (d *DropInLogicblock) contains(txt string, word string) bool {
+	if !d.wordBoundary {
+		return strings.Contains(txt, word)
+	}
+	return containsWord(txt, word)
+}
+
+// containsWord reports whether word appears in txt without adjacent letters or digits
+func containsWord(txt string, word string) bool {
+	if word == "" {
+		return false
+	}
+	for offset := 0; offset < len(txt); {
+		i := strings.Index(txt[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(word)
+		before, _ := utf8.DecodeLastRuneInString(txt[:start])
+		after, _ := utf8.DecodeRuneInString(txt[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(txt[start:])
+		offset = start + size
+	}
 	return false
 }
 
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// maskFacets returns the text of post with link and mention facets replaced by spaces
+func maskFacets(post *apibsky.FeedPost) string {
+	b := []byte(post.Text)
+	for _, facet := range post.Facets {
+		if facet == nil || facet.Index == nil {
+			continue
+		}
+		masked := false
+		for _, feature := range facet.Features {
+			if feature != nil && (feature.RichtextFacet_Link != nil || feature.RichtextFacet_Mention != nil) {
+				masked = true
+				break
+			}
+		}
+		start, end := facet.Index.ByteStart, facet.Index.ByteEnd
+		if !masked || start < 0 || end > int64(len(b)) || start >= end {
+			continue
+		}
+		for i := start; i < end; i++ {
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
 func (d *DropInLogicblock) HandlePreDelete(did string, rkey string) error {
 	item := d.watchlist.Contains(did)
 	if item == nil {
 		return nil
 	}
-	// if trigger post is deleted, delete from watchlist
-	if item.RKey == rkey {
+	// if trigger post or all posts of the user are deleted, delete from watchlist
+	if rkey == "" || item.RKey == rkey {
 		d.watchlist.Delete(did)
 	}
 	return nil
@@ -174,10 +268,29 @@ func (d *DropInLogicblock) HandlePreDelete(did string, rkey string) error {
 
 func (d *DropInLogicblock) GetMetrics() []metrics.Metric {
 	ms := []metrics.Metric{}
-	ms = append(ms, metrics.NewMetric(DropInLogicMetricDropinListUserCount, "dropin list user count", d.BlockName(), metrics.MetricTypeInt, int64(len(d.watchlist.List()))))
+	ms = append(ms, metrics.NewMetric(DropInLogicMetricDropinListUserCount, "dropin list user count", d.BlockName(), metrics.MetricTypeInt, int64(d.watchlist.Len())))
 	return ms
 }
 
+// dropInState is the exported runtime state of DropInLogicblock
+type dropInState struct {
+	Watchlist map[string]watchlist.WatchItem `json:"watchlist"`
+}
+
+func (d *DropInLogicblock) ExportState() (json.RawMessage, error) {
+	return json.Marshal(dropInState{Watchlist: d.watchlist.List()})
+}
+
+// ImportState replaces the watchlist with the exported one. expired items are skipped.
+func (d *DropInLogicblock) ImportState(state json.RawMessage) error {
+	var st dropInState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return fmt.Errorf("invalid dropin state: %w", err)
+	}
+	d.watchlist.Restore(st.Watchlist)
+	return nil
+}
+
 func (d *DropInLogicblock) ProcessCommand(command string, args map[string]string) (message string, err error) {
 	switch strings.ToLower(command) {
 	case DropInCommandReset:
@@ -2,6 +2,7 @@ package logicblock
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"testing"
 	"time"
@@ -196,6 +197,30 @@ func TestDropInLogicblock_Test(t *testing.T) {
 		}
 	})
 
+	t.Run("正常系_nfkc正規化", func(t *testing.T) {
+		cfg := &config.DropInLogicBlockConfig{
+			BaseLogicBlockConfig: config.BaseLogicBlockConfig{
+				BlockType: BlockTypeDropIn,
+				Options: map[string]interface{}{
+					config.DropInOptionTargetWord: []string{"ＨＥＬＬＯ", "ｶﾀｶﾅ"},
+					config.TextOptionNFKC:         true,
+				},
+			},
+		}
+
+		block, err := NewDropInLogicBlock(cfg, logger)
+		if err != nil {
+			t.Fatalf("failed to create block: %v", err)
+		}
+
+		// 全角・半角の表記ゆれを同一視する
+		for _, txt := range []string{"hello world", "Ｈｅｌｌｏ world", "カタカナ"} {
+			if !block.Test("did1", "rkey1", &apibsky.FeedPost{Text: txt}) {
+				t.Errorf("expected true for %q but got false", txt)
+			}
+		}
+	})
+
 	t.Run("正常系_watchlist期限切れ", func(t *testing.T) {
 		cfg := &config.DropInLogicBlockConfig{
 			BaseLogicBlockConfig: config.BaseLogicBlockConfig{
@@ -452,3 +477,220 @@ func TestDropInLogicblock_ProcessCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestDropInLogicblock_State(t *testing.T) {
+	logger := slog.Default()
+	newBlock := func(t *testing.T) *DropInLogicblock {
+		t.Helper()
+		cfg := &config.DropInLogicBlockConfig{
+			BaseLogicBlockConfig: config.BaseLogicBlockConfig{
+				BlockType: BlockTypeDropIn,
+				Options: map[string]interface{}{
+					config.DropInOptionTargetWord:     []string{"hello"},
+					config.DropInOptionExpireDuration: time.Hour,
+				},
+			},
+		}
+		block, err := NewDropInLogicBlock(cfg, logger)
+		if err != nil {
+			t.Fatalf("failed to create block: %v", err)
+		}
+		t.Cleanup(func() { block.Shutdown(context.Background()) })
+		return block.(*DropInLogicblock)
+	}
+
+	src := newBlock(t)
+	if !src.Test("did1", "rkey1", &apibsky.FeedPost{Text: "hello"}) {
+		t.Fatal("expected true but got false")
+	}
+	state, err := src.ExportState()
+	if err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+
+	dst := newBlock(t)
+	if err := dst.ImportState(state); err != nil {
+		t.Fatalf("failed to import state: %v", err)
+	}
+	// 取り込んだwatchlistのユーザーはtargetWordなしでも通過する
+	if !dst.Test("did1", "rkey2", &apibsky.FeedPost{Text: "world"}) {
+		t.Error("expected imported user to pass")
+	}
+
+	// 期限切れのアイテムは取り込まない
+	expired := []byte(`{"watchlist":{"did2":{"expireAt":"2000-01-01T00:00:00Z","rkey":"rkey"}}}`)
+	if err := dst.ImportState(expired); err != nil {
+		t.Fatalf("failed to import state: %v", err)
+	}
+	if len(dst.watchlist.List()) != 0 {
+		t.Errorf("expected expired items to be skipped, got %v", dst.watchlist.List())
+	}
+
+	if err := dst.ImportState([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid state")
+	}
+}
+
+func TestDropInLogicblock_MaxEntries(t *testing.T) {
+	cfg := &config.DropInLogicBlockConfig{
+		BaseLogicBlockConfig: config.BaseLogicBlockConfig{
+			BlockType: BlockTypeDropIn,
+			Options: map[string]interface{}{
+				config.DropInOptionTargetWord:     []string{"hello"},
+				config.DropInOptionExpireDuration: time.Hour,
+				config.DropInOptionMaxEntries:     3,
+			},
+		},
+	}
+	block, err := NewDropInLogicBlock(cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("failed to create block: %v", err)
+	}
+	defer block.Shutdown(context.Background())
+	dropIn := block.(*DropInLogicblock)
+
+	for i := range 10 {
+		if !dropIn.Test(fmt.Sprintf("did%d", i), "rkey", &apibsky.FeedPost{Text: "hello"}) {
+			t.Fatalf("expected true for did%d", i)
+		}
+		if size := len(dropIn.watchlist.List()); size > 3 {
+			t.Fatalf("watchlist exceeded maxEntries: %d", size)
+		}
+	}
+
+	// 古いものから削除され、最新の3件が残る
+	list := dropIn.watchlist.List()
+	for _, did := range []string{"did7", "did8", "did9"} {
+		if _, ok := list[did]; !ok {
+			t.Errorf("expected %s to remain in watchlist, got %v", did, list)
+		}
+	}
+	ms := dropIn.GetMetrics()
+	if len(ms) != 1 || ms[0].IntValue != 3 {
+		t.Errorf("expected metric to report 3 users, got %v", ms)
+	}
+}
+
+func TestDropInLogicblock_WordBoundaryAndFacets(t *testing.T) {
+	// prefixの後ろにリンクfacet付きのURLを置いたポストを作る
+	linkPost := func(prefix string) *apibsky.FeedPost {
+		url := "https://example.com/join-us"
+		return &apibsky.FeedPost{
+			Text: prefix + url,
+			Facets: []*apibsky.RichtextFacet{{
+				Index: &apibsky.RichtextFacet_ByteSlice{ByteStart: int64(len(prefix)), ByteEnd: int64(len(prefix) + len(url))},
+				Features: []*apibsky.RichtextFacet_Features_Elem{{
+					RichtextFacet_Link: &apibsky.RichtextFacet_Link{Uri: url},
+				}},
+			}},
+		}
+	}
+	tests := []struct {
+		name     string
+		options  map[string]interface{}
+		post     *apibsky.FeedPost
+		expected bool
+	}{
+		{
+			name:     "URL内のtargetWordに一致する",
+			options:  map[string]interface{}{},
+			post:     linkPost("check this "),
+			expected: true,
+		},
+		{
+			name:     "excludeFacetsでリンク内のtargetWordを無視する",
+			options:  map[string]interface{}{config.DropInOptionExcludeFacets: true},
+			post:     linkPost("check this "),
+			expected: false,
+		},
+		{
+			name:     "excludeFacetsでもリンク外のtargetWordに一致する",
+			options:  map[string]interface{}{config.DropInOptionExcludeFacets: true},
+			post:     linkPost("let's join "),
+			expected: true,
+		},
+		{
+			name:     "wordBoundaryで単語の一部には一致しない",
+			options:  map[string]interface{}{config.DropInOptionWordBoundary: true},
+			post:     &apibsky.FeedPost{Text: "rejoined the server"},
+			expected: false,
+		},
+		{
+			name:     "wordBoundaryで単語には一致する",
+			options:  map[string]interface{}{config.DropInOptionWordBoundary: true},
+			post:     &apibsky.FeedPost{Text: "I will join, too"},
+			expected: true,
+		},
+		{
+			name:     "wordBoundaryで記号に囲まれた単語に一致する",
+			options:  map[string]interface{}{config.DropInOptionWordBoundary: true},
+			post:     &apibsky.FeedPost{Text: "#join"},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := map[string]interface{}{
+				config.DropInOptionTargetWord: []string{"join"},
+			}
+			for k, v := range tt.options {
+				options[k] = v
+			}
+			cfg := &config.DropInLogicBlockConfig{
+				BaseLogicBlockConfig: config.BaseLogicBlockConfig{
+					BlockType: BlockTypeDropIn,
+					Options:   options,
+				},
+			}
+			block, err := NewDropInLogicBlock(cfg, slog.Default())
+			if err != nil {
+				t.Fatalf("failed to create block: %v", err)
+			}
+			defer block.Shutdown(context.Background())
+			if got := block.Test("did1", "rkey1", tt.post); got != tt.expected {
+				t.Errorf("expected %v, got %v for %q", tt.expected, got, tt.post.Text)
+			}
+		})
+	}
+}
+
+func TestDropInLogicblock_LastReason(t *testing.T) {
+	cfg := &config.DropInLogicBlockConfig{
+		BaseLogicBlockConfig: config.BaseLogicBlockConfig{
+			BlockType: BlockTypeDropIn,
+			Options: map[string]interface{}{
+				config.DropInOptionTargetWord:     []string{"join"},
+				config.DropInOptionCancelWord:     []string{"leave"},
+				config.DropInOptionExpireDuration: time.Hour,
+			},
+		},
+	}
+	block, err := NewDropInLogicBlock(cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("failed to create block: %v", err)
+	}
+	defer block.Shutdown(context.Background())
+	rp, ok := block.(ReasonProvider)
+	if !ok {
+		t.Fatal("drop-in logicblock should implement ReasonProvider")
+	}
+
+	steps := []struct {
+		did    string
+		text   string
+		reason string
+	}{
+		{did: "did1", text: "hello", reason: ""},
+		{did: "did1", text: "I will join", reason: "matched: join"},
+		{did: "did1", text: "hello again", reason: "watched"},
+		{did: "did1", text: "I leave now", reason: "canceled: leave"},
+		{did: "did1", text: "bye", reason: ""},
+	}
+	for i, s := range steps {
+		block.Test(s.did, fmt.Sprintf("rkey%d", i), &apibsky.FeedPost{Text: s.text})
+		if got := rp.LastReason(); got != s.reason {
+			t.Errorf("step %d: LastReason() for %q = %q, want %q", i, s.text, got, s.reason)
+		}
+	}
+}
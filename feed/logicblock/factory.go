@@ -1,13 +1,19 @@
 package logicblock
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/nus25/yuge/feed/config/types"
 )
 
+// ErrDuplicateBlockType is returned when a block type is registered twice
+var ErrDuplicateBlockType = errors.New("logic block type already registered")
+
 type LogicBlockFactory struct {
 	Creators map[string]LogicBlockCreator
 }
@@ -24,8 +30,29 @@ func FactoryInstance() *LogicBlockFactory {
 	return instance
 }
 
-func (f *LogicBlockFactory) RegisterCreator(name string, creator func(types.LogicBlockConfig, *slog.Logger) (LogicBlock, error)) {
+// RegisterCreator registers the creator of a block type.
+// it returns an error if the type is already registered, so that a block type can't be shadowed silently.
+func (f *LogicBlockFactory) RegisterCreator(name string, creator func(types.LogicBlockConfig, *slog.Logger) (LogicBlock, error)) error {
+	if name == "" {
+		return errors.New("logic block type must not be empty")
+	}
+	if _, exists := f.Creators[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateBlockType, name)
+	}
 	f.Creators[name] = creator
+	return nil
+}
+
+// MustRegisterCreator is like RegisterCreator but panics on error. it is intended to be called from init.
+func (f *LogicBlockFactory) MustRegisterCreator(name string, creator func(types.LogicBlockConfig, *slog.Logger) (LogicBlock, error)) {
+	if err := f.RegisterCreator(name, creator); err != nil {
+		panic(fmt.Sprintf("logicblock: failed to register block type: %v", err))
+	}
+}
+
+// Types returns the registered block types sorted by name
+func (f *LogicBlockFactory) Types() []string {
+	return slices.Sorted(maps.Keys(f.Creators))
 }
 
 func (f *LogicBlockFactory) Create(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
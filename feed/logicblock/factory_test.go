@@ -1,6 +1,7 @@
 package logicblock
 
 import (
+	"errors"
 	"log/slog"
 	"testing"
 
@@ -74,3 +75,44 @@ func TestLogicblockFactory(t *testing.T) {
 		})
 	}
 }
+
+func TestLogicblockFactory_RegisterDuplicate(t *testing.T) {
+	factory := FactoryInstance()
+	creator := func(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
+		return nil, nil
+	}
+
+	// 組み込みのブロックタイプは上書きできない
+	err := factory.RegisterCreator(BlockTypeRegex, creator)
+	if !errors.Is(err, ErrDuplicateBlockType) {
+		t.Errorf("expected ErrDuplicateBlockType, got %v", err)
+	}
+	if _, err := factory.Create(&logic.RegexLogicBlockConfig{
+		BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+			BlockType: BlockTypeRegex,
+			Options:   map[string]interface{}{"value": "test", "caseSensitive": true, "invert": false},
+		},
+	}, slog.Default()); err != nil {
+		t.Errorf("expected built-in regex block to be kept, got error %v", err)
+	}
+
+	// 新しいブロックタイプは1回だけ登録できる
+	const blockType = "duplicateTest"
+	t.Cleanup(func() { delete(factory.Creators, blockType) })
+	if err := factory.RegisterCreator(blockType, creator); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := factory.RegisterCreator(blockType, creator); !errors.Is(err, ErrDuplicateBlockType) {
+		t.Errorf("expected ErrDuplicateBlockType, got %v", err)
+	}
+	if err := factory.RegisterCreator("", creator); err == nil {
+		t.Error("expected error for empty block type")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected MustRegisterCreator to panic on duplicate")
+		}
+	}()
+	factory.MustRegisterCreator(blockType, creator)
+}
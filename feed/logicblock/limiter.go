@@ -24,7 +24,7 @@ const (
 )
 
 func init() {
-	FactoryInstance().RegisterCreator(BlockTypeLimiter, NewLimiterLogicBlock)
+	FactoryInstance().MustRegisterCreator(BlockTypeLimiter, NewLimiterLogicBlock)
 }
 
 type LimiterLogicblock struct {
@@ -2,7 +2,9 @@ package logicblock
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
+	"time"
 
 	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/nus25/yuge/feed/config/types"
@@ -10,6 +12,7 @@ import (
 )
 
 // PreDeleteHandler is an interface for logic blocks that handle pre-delete events
+// rkey is empty when all posts of the did are deleted
 type PreDeleteHandler interface {
 	HandlePreDelete(did string, rkey string) error
 }
@@ -22,6 +25,47 @@ type CommandProcessor interface {
 	ProcessCommand(command string, args map[string]string) (message string, err error)
 }
 
+// ReasonProvider is an interface for logic blocks that can explain why the last Test matched
+type ReasonProvider interface {
+	LastReason() string
+}
+
+// AuthorScoper is an interface for logic blocks that pass only posts from known authors.
+// scoped is false if the block may pass posts from any author.
+type AuthorScoper interface {
+	AuthorDids() (dids []string, scoped bool)
+}
+
+// AuthorTester is an interface for logic blocks whose result can be decided by the author alone.
+// ok is false if the result also depends on the post.
+type AuthorTester interface {
+	TestAuthor(did string) (result bool, ok bool)
+}
+
+// Retestable is an interface for logic blocks whose result depends only on the post.
+// Retest returns the same result as Test without any side effects, including the reason reported by LastReason,
+// so that posts can be dry run and stored posts can be tested again after the config is changed.
+type Retestable interface {
+	Retest(did string, rkey string, post *apibsky.FeedPost) bool
+}
+
+// TestTimeoutSetter is an interface for logic blocks that can abort their own Test
+// when it takes longer than the block test timeout of the feed
+type TestTimeoutSetter interface {
+	SetTestTimeout(timeout time.Duration)
+}
+
+// StateExporter is an interface for stateful logic blocks that can export their runtime state as JSON,
+// e.g. to migrate a feed to another host without losing the state
+type StateExporter interface {
+	ExportState() (json.RawMessage, error)
+}
+
+// StateImporter is an interface for stateful logic blocks that can restore the state exported by StateExporter
+type StateImporter interface {
+	ImportState(state json.RawMessage) error
+}
+
 // LogicBlock represents a unit of logic that can be applied to posts
 // for filtering and processing in the feed generation pipeline.
 type LogicBlock interface {
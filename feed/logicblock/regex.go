@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/dlclark/regexp2"
@@ -13,19 +15,28 @@ import (
 )
 
 var _ LogicBlock = (*RegexLogicblock)(nil) //type check
+var _ Retestable = (*RegexLogicblock)(nil)
+var _ ReasonProvider = (*RegexLogicblock)(nil)
+var _ TestTimeoutSetter = (*RegexLogicblock)(nil)
 
 func init() {
-	FactoryInstance().RegisterCreator(BlockTypeRegex, NewRegexLogicBlock)
+	FactoryInstance().MustRegisterCreator(BlockTypeRegex, NewRegexLogicBlock)
 }
 
 const BlockTypeRegex = config.RegexBlockType
 
+// defaultRegexMatchTimeout is the match timeout used until the feed sets its block test timeout.
+// catastrophic backtracking of a pattern must not keep running forever
+const defaultRegexMatchTimeout = 5 * time.Second
+
 type RegexLogicblock struct {
 	*BaseLogicblock
 	pattern       string
 	caseSensitive bool
 	invert        bool
+	normalizer    TextNormalizer
 	regexp        *regexp2.Regexp
+	lastReason    atomic.Pointer[string]
 }
 
 func NewRegexLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
@@ -73,6 +84,7 @@ func NewRegexLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicB
 		logger.Error("failed to compile regex pattern", "error", err)
 		return nil, errors.NewConfigError(config.RegexOptionValue, pattern, fmt.Sprintf("invalid regex pattern: %v", err))
 	}
+	re.MatchTimeout = defaultRegexMatchTimeout
 	return &RegexLogicblock{
 		BaseLogicblock: &BaseLogicblock{
 			blockType: BlockTypeRegex,
@@ -82,24 +94,61 @@ func NewRegexLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicB
 		pattern:       pattern,
 		caseSensitive: caseSensitive,
 		invert:        invert,
+		normalizer:    NewTextNormalizer(rcfg, false), // case is handled by caseSensitive
 		regexp:        re,
 	}, nil
 }
 
+// SetTestTimeout makes the matching abort after timeout. 0 restores the default match timeout
+func (l *RegexLogicblock) SetTestTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultRegexMatchTimeout
+	}
+	l.regexp.MatchTimeout = timeout
+}
+
 func (l *RegexLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) (result bool) {
+	result, reason := l.match(post)
+	l.lastReason.Store(reason)
+	return result
+}
+
+// Retest tests the post without recording the matched substring
+func (l *RegexLogicblock) Retest(did string, rkey string, post *apibsky.FeedPost) bool {
+	result, _ := l.match(post)
+	return result
+}
+
+// match returns the result of the post and the reason if the pattern matched
+func (l *RegexLogicblock) match(post *apibsky.FeedPost) (result bool, reason *string) {
 	if post.Text == "" {
-		return false
+		return false, nil
 	}
 
-	text := post.Text
-	matched, err := l.regexp.MatchString(text)
+	text := l.normalizer.Normalize(post.Text)
+	m, err := l.regexp.FindStringMatch(text)
 	if err != nil {
-		return false
+		// マッチのタイムアウト
+		l.logger.Warn("failed to match regex pattern", "pattern", l.pattern, "error", err)
+		return false, nil
+	}
+	matched := m != nil
+	if matched {
+		r := "matched: " + m.String()
+		reason = &r
 	}
 	if l.invert {
-		return !matched
+		return !matched, reason
+	}
+	return matched, reason
+}
+
+// LastReason returns the substring matched by the last Test, or "" if it did not match
+func (l *RegexLogicblock) LastReason() string {
+	if r := l.lastReason.Load(); r != nil {
+		return *r
 	}
-	return matched
+	return ""
 }
 
 func (l *RegexLogicblock) Reset() error {
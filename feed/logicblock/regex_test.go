@@ -2,7 +2,9 @@ package logicblock
 
 import (
 	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
 	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/nus25/yuge/feed/config/logic"
@@ -134,6 +136,60 @@ func TestRegexLogicblock(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "Fullwidth text without nfkc",
+			config: logic.RegexLogicBlockConfig{
+				BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+					BlockType: "regex",
+					Options: map[string]interface{}{
+						"value":         "test",
+						"caseSensitive": false,
+						"invert":        false,
+					},
+				},
+			},
+			post: &apibsky.FeedPost{
+				Text: "ｔｅｓｔ message",
+			},
+			expected: false,
+		},
+		{
+			name: "Fullwidth text with nfkc",
+			config: logic.RegexLogicBlockConfig{
+				BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+					BlockType: "regex",
+					Options: map[string]interface{}{
+						"value":         "test",
+						"caseSensitive": false,
+						"invert":        false,
+						"nfkc":          true,
+					},
+				},
+			},
+			post: &apibsky.FeedPost{
+				Text: "ＴＥＳＴ message",
+			},
+			expected: true,
+		},
+		{
+			name: "Collapsed whitespace",
+			config: logic.RegexLogicBlockConfig{
+				BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+					BlockType: "regex",
+					Options: map[string]interface{}{
+						"value":              "^hello world$",
+						"caseSensitive":      false,
+						"invert":             false,
+						"nfkc":               true,
+						"collapseWhitespace": true,
+					},
+				},
+			},
+			post: &apibsky.FeedPost{
+				Text: "  hello\u3000\n world ",
+			},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -236,3 +292,80 @@ func TestRegexLogicblockInvalidConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestRegexLogicblockLastReason(t *testing.T) {
+	cfg := logic.RegexLogicBlockConfig{
+		BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+			BlockType: "regex",
+			Options: map[string]interface{}{
+				"value":         `\d+`,
+				"caseSensitive": false,
+				"invert":        false,
+			},
+		},
+	}
+	block, err := NewRegexLogicBlock(&cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("failed to create regex logicblock: %v", err)
+	}
+	rp, ok := block.(ReasonProvider)
+	if !ok {
+		t.Fatal("regex logicblock should implement ReasonProvider")
+	}
+
+	tests := []struct {
+		text   string
+		reason string
+	}{
+		{text: "Contains 123 numbers", reason: "matched: 123"},
+		{text: "no numbers", reason: ""},
+		{text: "", reason: ""},
+	}
+	for _, tt := range tests {
+		block.Test("testdid", "constantRkey", &apibsky.FeedPost{Text: tt.text})
+		if got := rp.LastReason(); got != tt.reason {
+			t.Errorf("LastReason() for %q = %q, want %q", tt.text, got, tt.reason)
+		}
+	}
+
+	// Retestは直前のLastReasonを変更しない
+	block.Test("testdid", "constantRkey", &apibsky.FeedPost{Text: "Contains 123 numbers"})
+	if !block.(Retestable).Retest("testdid", "constantRkey", &apibsky.FeedPost{Text: "456"}) {
+		t.Error("expected Retest to match")
+	}
+	if got := rp.LastReason(); got != "matched: 123" {
+		t.Errorf("LastReason() after Retest = %q, want %q", got, "matched: 123")
+	}
+}
+
+func TestRegexLogicblockMatchTimeout(t *testing.T) {
+	cfg := logic.RegexLogicBlockConfig{
+		BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+			BlockType: "regex",
+			Options: map[string]interface{}{
+				"value":         `^(a+)+$`,
+				"caseSensitive": true,
+				"invert":        false,
+			},
+		},
+	}
+	block, err := NewRegexLogicBlock(&cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("failed to create regex logicblock: %v", err)
+	}
+	ts, ok := block.(TestTimeoutSetter)
+	if !ok {
+		t.Fatal("regex logicblock should implement TestTimeoutSetter")
+	}
+	ts.SetTestTimeout(50 * time.Millisecond)
+
+	// 破滅的バックトラックを起こす入力
+	post := &apibsky.FeedPost{Text: strings.Repeat("a", 40) + "b"}
+	start := time.Now()
+	if block.Test("testdid", "constantRkey", post) {
+		t.Error("expected timed out match not to pass")
+	}
+	if elapsed := time.Since(start); elapsed >= 2*time.Second {
+		t.Errorf("expected matching to abort after the timeout, took %v", elapsed)
+	}
+}
@@ -12,9 +12,10 @@ import (
 )
 
 var _ LogicBlock = (*RemoveLogicblock)(nil) //type check
+var _ Retestable = (*RemoveLogicblock)(nil)
 
 func init() {
-	FactoryInstance().RegisterCreator(BlockTypeRemove, NewRemoveLogicBlock)
+	FactoryInstance().MustRegisterCreator(BlockTypeRemove, NewRemoveLogicBlock)
 }
 
 const BlockTypeRemove = config.RemoveBlockType
@@ -75,6 +76,10 @@ func NewRemoveLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (Logic
 	}, nil
 }
 
+func (l *RemoveLogicblock) Retest(did string, rkey string, post *apibsky.FeedPost) bool {
+	return l.Test(did, rkey, post)
+}
+
 // Returns true if the post does not match the removal condition
 func (l *RemoveLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) (result bool) {
 	switch l.subject {
@@ -0,0 +1,118 @@
+package logicblock
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	config "github.com/nus25/yuge/feed/config/logic"
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+var _ LogicBlock = (*ReplyLogicblock)(nil) //type check
+var _ Retestable = (*ReplyLogicblock)(nil)
+
+func init() {
+	FactoryInstance().MustRegisterCreator(BlockTypeReply, NewReplyLogicBlock)
+}
+
+const BlockTypeReply = config.ReplyBlockType
+
+type ReplyLogicblock struct {
+	*BaseLogicblock
+	keep       string
+	replyToDid string
+}
+
+func NewReplyLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
+	if cfg.GetBlockType() != BlockTypeReply {
+		logger.Error("invalid block type", "type", cfg.GetBlockType())
+		return nil, errors.NewConfigError("block type", cfg.GetBlockType(), "invalid block type")
+	}
+	rcfg, ok := cfg.(*config.ReplyLogicBlockConfig)
+	if !ok {
+		logger.Error("invalid config type", "type", fmt.Sprintf("%T", cfg))
+		return nil, errors.NewConfigError("config type", fmt.Sprintf("%T", cfg), "invalid config type")
+	}
+	//keep
+	keep, ok := rcfg.GetStringOption(config.ReplyOptionKeep)
+	if !ok {
+		logger.Error("keep option not found")
+		return nil, errors.NewConfigError(config.ReplyOptionKeep, "", "keep option not found")
+	}
+	switch keep {
+	case config.ReplyKeepTopLevel, config.ReplyKeepReplies, config.ReplyKeepAll:
+	default:
+		logger.Error("invalid keep option", "keep", keep)
+		return nil, errors.NewConfigError(config.ReplyOptionKeep, keep, "invalid keep option")
+	}
+	//replyToDid (optional)
+	replyToDid, ok := rcfg.GetStringOption(config.ReplyOptionReplyToDid)
+	if ok {
+		if _, err := syntax.ParseDID(replyToDid); err != nil {
+			logger.Error("invalid replyToDid option", "replyToDid", replyToDid)
+			return nil, errors.NewConfigError(config.ReplyOptionReplyToDid, replyToDid, "replyToDid must be a valid did")
+		}
+		if keep == config.ReplyKeepTopLevel {
+			logger.Error("replyToDid cannot be used with keep: toplevel")
+			return nil, errors.NewConfigError(config.ReplyOptionReplyToDid, replyToDid, "replyToDid cannot be used with keep: toplevel")
+		}
+	}
+
+	return &ReplyLogicblock{
+		BaseLogicblock: &BaseLogicblock{
+			blockType: BlockTypeReply,
+			config:    cfg,
+			logger:    logger,
+		},
+		keep:       keep,
+		replyToDid: replyToDid,
+	}, nil
+}
+
+func (l *ReplyLogicblock) Retest(did string, rkey string, post *apibsky.FeedPost) bool {
+	return l.Test(did, rkey, post)
+}
+
+// Returns true if the post matches the configured reply state
+func (l *ReplyLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) (result bool) {
+	if post.Reply == nil {
+		return l.keep != config.ReplyKeepReplies
+	}
+	if l.keep == config.ReplyKeepTopLevel {
+		return false
+	}
+	if l.replyToDid == "" {
+		return true
+	}
+	return l.isReplyTo(post.Reply)
+}
+
+// isReplyTo checks whether the parent or root post is authored by replyToDid
+func (l *ReplyLogicblock) isReplyTo(reply *apibsky.FeedPost_ReplyRef) bool {
+	for _, ref := range []*comatproto.RepoStrongRef{reply.Parent, reply.Root} {
+		if ref == nil {
+			continue
+		}
+		aturi, err := syntax.ParseATURI(ref.Uri)
+		if err != nil {
+			continue
+		}
+		if aturi.Authority().String() == l.replyToDid {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *ReplyLogicblock) Reset() error {
+	return nil
+}
+
+func (l *ReplyLogicblock) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package logicblock
+
+import (
+	"log/slog"
+	"testing"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/nus25/yuge/feed/config/logic"
+)
+
+func newReplyRef(parentDid string, rootDid string) *apibsky.FeedPost_ReplyRef {
+	return &apibsky.FeedPost_ReplyRef{
+		Parent: &comatproto.RepoStrongRef{Uri: "at://" + parentDid + "/app.bsky.feed.post/parent"},
+		Root:   &comatproto.RepoStrongRef{Uri: "at://" + rootDid + "/app.bsky.feed.post/root"},
+	}
+}
+
+func TestReplyLogicblock(t *testing.T) {
+	topLevel := &apibsky.FeedPost{Text: "top level post"}
+	replyToA := &apibsky.FeedPost{Text: "reply", Reply: newReplyRef("did:plc:a", "did:plc:a")}
+	replyInThreadOfA := &apibsky.FeedPost{Text: "reply", Reply: newReplyRef("did:plc:b", "did:plc:a")}
+	replyToB := &apibsky.FeedPost{Text: "reply", Reply: newReplyRef("did:plc:b", "did:plc:b")}
+
+	tests := []struct {
+		name     string
+		options  map[string]interface{}
+		post     *apibsky.FeedPost
+		expected bool
+	}{
+		{
+			name:     "toplevel keeps non-reply",
+			options:  map[string]interface{}{"keep": "toplevel"},
+			post:     topLevel,
+			expected: true,
+		},
+		{
+			name:     "toplevel removes reply",
+			options:  map[string]interface{}{"keep": "toplevel"},
+			post:     replyToA,
+			expected: false,
+		},
+		{
+			name:     "replies removes non-reply",
+			options:  map[string]interface{}{"keep": "replies"},
+			post:     topLevel,
+			expected: false,
+		},
+		{
+			name:     "replies keeps reply",
+			options:  map[string]interface{}{"keep": "replies"},
+			post:     replyToB,
+			expected: true,
+		},
+		{
+			name:     "replies with replyToDid matches parent author",
+			options:  map[string]interface{}{"keep": "replies", "replyToDid": "did:plc:a"},
+			post:     replyToA,
+			expected: true,
+		},
+		{
+			name:     "replies with replyToDid matches root author",
+			options:  map[string]interface{}{"keep": "replies", "replyToDid": "did:plc:a"},
+			post:     replyInThreadOfA,
+			expected: true,
+		},
+		{
+			name:     "replies with replyToDid removes reply to other author",
+			options:  map[string]interface{}{"keep": "replies", "replyToDid": "did:plc:a"},
+			post:     replyToB,
+			expected: false,
+		},
+		{
+			name:     "all keeps non-reply",
+			options:  map[string]interface{}{"keep": "all"},
+			post:     topLevel,
+			expected: true,
+		},
+		{
+			name:     "all with replyToDid keeps non-reply",
+			options:  map[string]interface{}{"keep": "all", "replyToDid": "did:plc:a"},
+			post:     topLevel,
+			expected: true,
+		},
+		{
+			name:     "all with replyToDid removes reply to other author",
+			options:  map[string]interface{}{"keep": "all", "replyToDid": "did:plc:a"},
+			post:     replyToB,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &logic.ReplyLogicBlockConfig{
+				BaseLogicBlockConfig: logic.BaseLogicBlockConfig{
+					BlockType: "reply",
+					Options:   tt.options,
+				},
+			}
+			block, err := NewReplyLogicBlock(cfg, slog.Default())
+			if err != nil {
+				t.Fatalf("failed to create block: %v", err)
+			}
+			if result := block.Test("did:plc:test", "rkey", tt.post); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestReplyLogicblock_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+	}{
+		{
+			name:    "missing keep",
+			options: map[string]interface{}{},
+		},
+		{
+			name:    "toplevel with replyToDid",
+			options: map[string]interface{}{"keep": "toplevel", "replyToDid": "did:plc:a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&logic.ReplyLogicBlockFactory{}).Create(logic.BaseLogicBlockConfig{
+				BlockType: "reply",
+				Options:   tt.options,
+			})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err := NewReplyLogicBlock(cfg, slog.Default()); err == nil {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
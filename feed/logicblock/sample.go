@@ -0,0 +1,99 @@
+package logicblock
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	config "github.com/nus25/yuge/feed/config/logic"
+	"github.com/nus25/yuge/feed/config/types"
+	"github.com/nus25/yuge/feed/errors"
+)
+
+var _ LogicBlock = (*SampleLogicblock)(nil) //type check
+
+func init() {
+	FactoryInstance().MustRegisterCreator(BlockTypeSample, NewSampleLogicBlock)
+}
+
+const BlockTypeSample = config.SampleBlockType
+
+type SampleLogicblock struct {
+	*BaseLogicblock
+	rate          float64
+	deterministic bool
+	mu            sync.Mutex
+	rng           *rand.Rand
+}
+
+func NewSampleLogicBlock(cfg types.LogicBlockConfig, logger *slog.Logger) (LogicBlock, error) {
+	if cfg.GetBlockType() != BlockTypeSample {
+		logger.Error("invalid block type", "type", cfg.GetBlockType())
+		return nil, errors.NewConfigError("block type", cfg.GetBlockType(), "invalid block type")
+	}
+	scfg, ok := cfg.(*config.SampleLogicBlockConfig)
+	if !ok {
+		logger.Error("invalid config type", "type", fmt.Sprintf("%T", cfg))
+		return nil, errors.NewConfigError("config type", fmt.Sprintf("%T", cfg), "invalid config type")
+	}
+	//rate
+	rate, ok := scfg.GetFloatOption(config.SampleOptionRate)
+	if !ok {
+		logger.Error("rate option not found")
+		return nil, errors.NewConfigError(config.SampleOptionRate, "", "rate option not found")
+	}
+	if rate < 0 || rate > 1 {
+		logger.Error("rate must be between 0 and 1", "rate", rate)
+		return nil, errors.NewConfigError(config.SampleOptionRate, fmt.Sprintf("%v", rate), "rate must be between 0 and 1")
+	}
+	//deterministic (optional)
+	deterministic, _ := scfg.GetBoolOption(config.SampleOptionDeterministic)
+	//seed (optional)
+	seed, ok := scfg.GetIntOption(config.SampleOptionSeed)
+	if !ok {
+		seed = int(time.Now().UnixNano())
+	}
+
+	return &SampleLogicblock{
+		BaseLogicblock: &BaseLogicblock{
+			blockType: BlockTypeSample,
+			config:    cfg,
+			logger:    logger,
+		},
+		rate:          rate,
+		deterministic: deterministic,
+		rng:           rand.New(rand.NewPCG(uint64(seed), uint64(seed))),
+	}, nil
+}
+
+// Returns true with the configured probability
+func (l *SampleLogicblock) Test(did string, rkey string, post *apibsky.FeedPost) (result bool) {
+	if l.deterministic {
+		return sampleHash(did, rkey) < l.rate
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rng.Float64() < l.rate
+}
+
+// sampleHash maps did+rkey to [0, 1)
+func sampleHash(did string, rkey string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(did))
+	h.Write([]byte{'/'})
+	h.Write([]byte(rkey))
+	return float64(h.Sum64()>>11) / float64(uint64(1)<<53)
+}
+
+func (l *SampleLogicblock) Reset() error {
+	return nil
+}
+
+func (l *SampleLogicblock) Shutdown(ctx context.Context) error {
+	return nil
+}
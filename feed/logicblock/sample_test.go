@@ -0,0 +1,119 @@
+package logicblock
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+	"github.com/nus25/yuge/feed/config/logic"
+)
+
+func newSampleBlock(t *testing.T, options map[string]interface{}) LogicBlock {
+	t.Helper()
+	cfg, err := (&logic.SampleLogicBlockFactory{}).Create(logic.BaseLogicBlockConfig{
+		BlockType: "sample",
+		Options:   options,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	block, err := NewSampleLogicBlock(cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("failed to create block: %v", err)
+	}
+	return block
+}
+
+func TestSampleLogicblock(t *testing.T) {
+	post := &apibsky.FeedPost{Text: "sample"}
+	const n = 10000
+
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		min     int
+		max     int
+	}{
+		{name: "rate 0 passes nothing", options: map[string]interface{}{"rate": 0.0}, min: 0, max: 0},
+		{name: "rate 1 passes everything", options: map[string]interface{}{"rate": 1.0}, min: n, max: n},
+		{name: "rate 0.1", options: map[string]interface{}{"rate": 0.1, "seed": 1}, min: 800, max: 1200},
+		{name: "rate 0.1 deterministic", options: map[string]interface{}{"rate": 0.1, "deterministic": true}, min: 800, max: 1200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := newSampleBlock(t, tt.options)
+			passed := 0
+			for i := 0; i < n; i++ {
+				if block.Test("did:plc:test", fmt.Sprintf("rkey%d", i), post) {
+					passed++
+				}
+			}
+			if passed < tt.min || passed > tt.max {
+				t.Errorf("passed = %d, want between %d and %d", passed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestSampleLogicblock_Reproducible(t *testing.T) {
+	post := &apibsky.FeedPost{Text: "sample"}
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+	}{
+		{name: "deterministic", options: map[string]interface{}{"rate": 0.5, "deterministic": true}},
+		{name: "same seed", options: map[string]interface{}{"rate": 0.5, "seed": 42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 別インスタンスでも同じ結果になること
+			a := newSampleBlock(t, tt.options)
+			b := newSampleBlock(t, tt.options)
+			for i := 0; i < 1000; i++ {
+				rkey := fmt.Sprintf("rkey%d", i)
+				if ra, rb := a.Test("did:plc:test", rkey, post), b.Test("did:plc:test", rkey, post); ra != rb {
+					t.Fatalf("result differs for %s: %v != %v", rkey, ra, rb)
+				}
+			}
+		})
+	}
+
+	t.Run("deterministic is stable for the same post", func(t *testing.T) {
+		block := newSampleBlock(t, map[string]interface{}{"rate": 0.5, "deterministic": true})
+		first := block.Test("did:plc:test", "stable", post)
+		for i := 0; i < 100; i++ {
+			if block.Test("did:plc:test", "stable", post) != first {
+				t.Fatal("deterministic result changed for the same post")
+			}
+		}
+	})
+}
+
+func TestSampleLogicblock_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+	}{
+		{name: "missing rate", options: map[string]interface{}{}},
+		{name: "negative rate", options: map[string]interface{}{"rate": -0.5}},
+		{name: "rate greater than 1", options: map[string]interface{}{"rate": 2.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := (&logic.SampleLogicBlockFactory{}).Create(logic.BaseLogicBlockConfig{
+				BlockType: "sample",
+				Options:   tt.options,
+			})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err := NewSampleLogicBlock(cfg, slog.Default()); err == nil {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
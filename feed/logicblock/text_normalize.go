@@ -0,0 +1,57 @@
+package logicblock
+
+import (
+	"strings"
+
+	config "github.com/nus25/yuge/feed/config/logic"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TextNormalizer normalizes post text and configured words before text matching,
+// so that text based blocks treat width and case variants the same way.
+type TextNormalizer struct {
+	// NFKC applies unicode NFKC normalization, e.g. fullwidth "Ａ" to "A" and halfwidth "ｶ" to "カ"
+	NFKC bool
+	// LowerCase converts text to lower case for case-insensitive comparison
+	LowerCase bool
+	// CollapseWhitespace replaces runs of whitespace with a single space and trims both ends
+	CollapseWhitespace bool
+}
+
+type boolOptionGetter interface {
+	GetBoolOption(key string) (val bool, exists bool)
+}
+
+// NewTextNormalizer creates a TextNormalizer from the nfkc and collapseWhitespace options of cfg.
+// lowerCase is decided by each block.
+func NewTextNormalizer(cfg boolOptionGetter, lowerCase bool) TextNormalizer {
+	nfkc, _ := cfg.GetBoolOption(config.TextOptionNFKC)
+	collapse, _ := cfg.GetBoolOption(config.TextOptionCollapseWhitespace)
+	return TextNormalizer{
+		NFKC:               nfkc,
+		LowerCase:          lowerCase,
+		CollapseWhitespace: collapse,
+	}
+}
+
+func (n TextNormalizer) Normalize(s string) string {
+	if n.NFKC {
+		s = norm.NFKC.String(s)
+	}
+	if n.LowerCase {
+		s = strings.ToLower(s)
+	}
+	if n.CollapseWhitespace {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	return s
+}
+
+// NormalizeAll returns normalized copies of words
+func (n TextNormalizer) NormalizeAll(words []string) []string {
+	normalized := make([]string, len(words))
+	for i, w := range words {
+		normalized[i] = n.Normalize(w)
+	}
+	return normalized
+}
@@ -0,0 +1,70 @@
+package logicblock
+
+import (
+	"testing"
+
+	config "github.com/nus25/yuge/feed/config/logic"
+)
+
+func TestTextNormalizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalizer TextNormalizer
+		input      string
+		expected   string
+	}{
+		{
+			name:       "no normalization",
+			normalizer: TextNormalizer{},
+			input:      " ＡＢＣ  ｶﾀｶﾅ ",
+			expected:   " ＡＢＣ  ｶﾀｶﾅ ",
+		},
+		{
+			name:       "nfkc fullwidth alphabet",
+			normalizer: TextNormalizer{NFKC: true},
+			input:      "ＡＢＣ１２３",
+			expected:   "ABC123",
+		},
+		{
+			name:       "nfkc halfwidth katakana",
+			normalizer: TextNormalizer{NFKC: true},
+			input:      "ｶﾀｶﾅ ｶﾞｯｺｳ",
+			expected:   "カタカナ ガッコウ",
+		},
+		{
+			name:       "nfkc and lower case",
+			normalizer: TextNormalizer{NFKC: true, LowerCase: true},
+			input:      "ＡＢＣ",
+			expected:   "abc",
+		},
+		{
+			name:       "collapse whitespace",
+			normalizer: TextNormalizer{CollapseWhitespace: true},
+			input:      " hello　\n\tworld  ",
+			expected:   "hello world",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.normalizer.Normalize(tt.input); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewTextNormalizer(t *testing.T) {
+	cfg := &config.RegexLogicBlockConfig{
+		BaseLogicBlockConfig: config.BaseLogicBlockConfig{
+			BlockType: config.RegexBlockType,
+			Options: map[string]interface{}{
+				config.TextOptionNFKC: true,
+			},
+		},
+	}
+	n := NewTextNormalizer(cfg, true)
+	if !n.NFKC || !n.LowerCase || n.CollapseWhitespace {
+		t.Errorf("unexpected normalizer %+v", n)
+	}
+}
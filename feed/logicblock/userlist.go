@@ -15,6 +15,8 @@ import (
 
 var _ LogicBlock = (*UserListLogicblock)(nil) //type check
 var _ CommandProcessor = (*UserListLogicblock)(nil)
+var _ AuthorScoper = (*UserListLogicblock)(nil)
+var _ AuthorTester = (*UserListLogicblock)(nil)
 
 const (
 	BlockTypeUserList     = config.UserListBlockType
@@ -23,7 +25,7 @@ const (
 )
 
 func init() {
-	FactoryInstance().RegisterCreator(BlockTypeUserList, NewUserListLogicBlock)
+	FactoryInstance().MustRegisterCreator(BlockTypeUserList, NewUserListLogicBlock)
 }
 
 type UserListLogicblock struct {
@@ -90,6 +92,19 @@ func (l *UserListLogicblock) Test(did string, rkey string, post *apibsky.FeedPos
 	return l.allow == inList
 }
 
+// AuthorDids returns the DIDs in the list if the block passes only listed users (allow: true)
+func (l *UserListLogicblock) AuthorDids() (dids []string, scoped bool) {
+	if !l.allow {
+		return nil, false
+	}
+	return l.list.List(), true
+}
+
+// TestAuthor returns the same result as Test, which depends only on the author
+func (l *UserListLogicblock) TestAuthor(did string) (result bool, ok bool) {
+	return l.allow == l.list.Contain(did), true
+}
+
 func (l *UserListLogicblock) Reset() error {
 	l.list.Load()
 	return nil
@@ -186,6 +186,16 @@ func TestUserListLogicblock(t *testing.T) {
 			if got != tt.wantPass {
 				t.Errorf("Test() = %v, want %v", got, tt.wantPass)
 			}
+
+			// allow: trueの場合のみリストのDIDに限定される
+			allow := tt.config.(*logic.UserListLogicBlockConfig).Options["allow"].(bool)
+			dids, scoped := lb.(AuthorScoper).AuthorDids()
+			if scoped != allow {
+				t.Errorf("AuthorDids() scoped = %v, want %v", scoped, allow)
+			}
+			if scoped && len(dids) != 2 {
+				t.Errorf("AuthorDids() returned %d dids, want 2", len(dids))
+			}
 		})
 	}
 }
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Registry is the prometheus registry of yuge metrics.
+// it is used instead of the default registry so that only yuge metrics are served.
+var Registry = prometheus.NewRegistry()
+
+// Factory creates collectors registered to Registry
+var Factory = promauto.With(Registry)
+
+// Register registers c to Registry.
+// if a collector with the same descriptor is already registered, the existing one is returned instead,
+// so that collectors created for each feed do not collide.
+func Register(c prometheus.Collector) (prometheus.Collector, error) {
+	if err := Registry.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
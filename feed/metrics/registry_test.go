@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegister(t *testing.T) {
+	opts := prometheus.CounterOpts{Name: "test_register_total", Help: "test counter"}
+	first := prometheus.NewCounter(opts)
+	c, err := Register(first)
+	if err != nil {
+		t.Fatalf("failed to register collector: %v", err)
+	}
+	if c != first {
+		t.Error("expected the registered collector to be returned")
+	}
+
+	// 同名のコレクタは既存のものを返す
+	c, err = Register(prometheus.NewCounter(opts))
+	if err != nil {
+		t.Fatalf("expected no error for same collector, got %v", err)
+	}
+	if c != first {
+		t.Error("expected the existing collector to be returned")
+	}
+
+	// ラベルが異なる同名のコレクタは登録できない
+	if _, err := Register(prometheus.NewCounterVec(opts, []string{"feed_id"})); err == nil {
+		t.Error("expected error for inconsistent collector")
+	}
+
+	first.Inc()
+	mfs, err := Registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	found := false
+	for _, mf := range mfs {
+		if strings.HasPrefix(mf.GetName(), "go_") || strings.HasPrefix(mf.GetName(), "process_") {
+			t.Errorf("unexpected runtime metric %s in registry", mf.GetName())
+		}
+		if mf.GetName() == "test_register_total" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected registered metric to be gathered")
+	}
+}
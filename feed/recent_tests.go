@@ -0,0 +1,67 @@
+package feed
+
+import (
+	"sync"
+	"time"
+)
+
+// recentTextLength is the maximum number of runes of post text kept in a RecentTest
+const recentTextLength = 80
+
+// RecentTest is a post tested by the feed logic
+type RecentTest struct {
+	Did      string    `json:"did"`
+	Rkey     string    `json:"rkey"`
+	Text     string    `json:"text"` // first recentTextLength runes of the post text
+	Passed   bool      `json:"passed"`
+	TestedAt time.Time `json:"testedAt"`
+}
+
+// recentTests is a ring buffer of the last tested posts
+type recentTests struct {
+	mu      sync.Mutex
+	entries []RecentTest
+	next    int
+	full    bool
+}
+
+func newRecentTests(size int) *recentTests {
+	if size <= 0 {
+		return nil
+	}
+	return &recentTests{entries: make([]RecentTest, size)}
+}
+
+func (r *recentTests) add(t RecentTest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = t
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// list returns the entries from newest to oldest
+func (r *recentTests) list() []RecentTest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next
+	if r.full {
+		n = len(r.entries)
+	}
+	result := make([]RecentTest, 0, n)
+	for i := 1; i <= n; i++ {
+		result = append(result, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	return result
+}
+
+func textSnippet(text string) string {
+	runes := []rune(text)
+	if len(runes) <= recentTextLength {
+		return text
+	}
+	return string(runes[:recentTextLength]) + "…"
+}
@@ -0,0 +1,196 @@
+package feed
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+)
+
+// RichPost is the metadata of a matched post kept by the rich store, so that the post can be tested again
+type RichPost struct {
+	Uri         string    `json:"uri"`
+	Did         string    `json:"did"`
+	Rkey        string    `json:"rkey"`
+	Text        string    `json:"text"`
+	Langs       []string  `json:"langs,omitempty"`
+	ReplyParent string    `json:"replyParent,omitempty"` // uri of the parent post if the post is a reply
+	ReplyRoot   string    `json:"replyRoot,omitempty"`
+	Links       []string  `json:"links,omitempty"` // uris of link facets and external embeds
+	Mentions    []string  `json:"mentions,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	MatchedAt   time.Time `json:"matchedAt"`
+}
+
+func postUri(did string, rkey string) string {
+	return fmt.Sprintf("at://%s/app.bsky.feed.post/%s", did, rkey)
+}
+
+func newRichPost(did string, rkey string, post *apibsky.FeedPost) RichPost {
+	rp := RichPost{
+		Uri:       postUri(did, rkey),
+		Did:       did,
+		Rkey:      rkey,
+		Text:      post.Text,
+		Langs:     append([]string(nil), post.Langs...),
+		MatchedAt: time.Now(),
+	}
+	if post.Reply != nil {
+		if post.Reply.Parent != nil {
+			rp.ReplyParent = post.Reply.Parent.Uri
+		}
+		if post.Reply.Root != nil {
+			rp.ReplyRoot = post.Reply.Root.Uri
+		}
+	}
+	for _, facet := range post.Facets {
+		if facet == nil {
+			continue
+		}
+		for _, feature := range facet.Features {
+			switch {
+			case feature == nil:
+			case feature.RichtextFacet_Link != nil:
+				rp.Links = append(rp.Links, feature.RichtextFacet_Link.Uri)
+			case feature.RichtextFacet_Mention != nil:
+				rp.Mentions = append(rp.Mentions, feature.RichtextFacet_Mention.Did)
+			case feature.RichtextFacet_Tag != nil:
+				rp.Tags = append(rp.Tags, feature.RichtextFacet_Tag.Tag)
+			}
+		}
+	}
+	if post.Embed != nil {
+		external := post.Embed.EmbedExternal
+		if external == nil && post.Embed.EmbedRecordWithMedia != nil && post.Embed.EmbedRecordWithMedia.Media != nil {
+			external = post.Embed.EmbedRecordWithMedia.Media.EmbedExternal
+		}
+		if external != nil && external.External != nil {
+			rp.Links = append(rp.Links, external.External.Uri)
+		}
+	}
+	return rp
+}
+
+// FeedPost rebuilds a post from the metadata.
+// facets have no byte ranges and external embeds are rebuilt as link facets.
+func (rp RichPost) FeedPost() *apibsky.FeedPost {
+	post := &apibsky.FeedPost{
+		LexiconTypeID: "app.bsky.feed.post",
+		Text:          rp.Text,
+		Langs:         rp.Langs,
+		CreatedAt:     rp.MatchedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if rp.ReplyParent != "" || rp.ReplyRoot != "" {
+		post.Reply = &apibsky.FeedPost_ReplyRef{}
+		if rp.ReplyParent != "" {
+			post.Reply.Parent = &comatproto.RepoStrongRef{Uri: rp.ReplyParent}
+		}
+		if rp.ReplyRoot != "" {
+			post.Reply.Root = &comatproto.RepoStrongRef{Uri: rp.ReplyRoot}
+		}
+	}
+	var features []*apibsky.RichtextFacet_Features_Elem
+	for _, uri := range rp.Links {
+		features = append(features, &apibsky.RichtextFacet_Features_Elem{RichtextFacet_Link: &apibsky.RichtextFacet_Link{Uri: uri}})
+	}
+	for _, did := range rp.Mentions {
+		features = append(features, &apibsky.RichtextFacet_Features_Elem{RichtextFacet_Mention: &apibsky.RichtextFacet_Mention{Did: did}})
+	}
+	for _, tag := range rp.Tags {
+		features = append(features, &apibsky.RichtextFacet_Features_Elem{RichtextFacet_Tag: &apibsky.RichtextFacet_Tag{Tag: tag}})
+	}
+	for _, f := range features {
+		post.Facets = append(post.Facets, &apibsky.RichtextFacet{Features: []*apibsky.RichtextFacet_Features_Elem{f}, Index: &apibsky.RichtextFacet_ByteSlice{}})
+	}
+	return post
+}
+
+// richPosts keeps the metadata of the last matched posts keyed by uri.
+// the oldest entries are evicted when the number of entries exceeds the limit.
+type richPosts struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List // oldest first
+	entries map[string]*list.Element
+}
+
+func newRichPosts(limit int) *richPosts {
+	if limit <= 0 {
+		return nil
+	}
+	return &richPosts{
+		limit:   limit,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (r *richPosts) add(rp RichPost) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.entries[rp.Uri]; ok {
+		e.Value = rp
+		r.order.MoveToBack(e)
+		return
+	}
+	r.entries[rp.Uri] = r.order.PushBack(rp)
+	for r.order.Len() > r.limit {
+		oldest := r.order.Front()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(RichPost).Uri)
+	}
+}
+
+func (r *richPosts) get(uri string) (RichPost, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[uri]
+	if !ok {
+		return RichPost{}, false
+	}
+	return e.Value.(RichPost), true
+}
+
+func (r *richPosts) remove(uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.entries[uri]; ok {
+		r.order.Remove(e)
+		delete(r.entries, uri)
+	}
+}
+
+// removeByDid removes all entries of the did
+func (r *richPosts) removeByDid(did string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prefix := "at://" + did + "/"
+	for uri, e := range r.entries {
+		if strings.HasPrefix(uri, prefix) {
+			r.order.Remove(e)
+			delete(r.entries, uri)
+		}
+	}
+}
+
+func (r *richPosts) clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order.Init()
+	clear(r.entries)
+}
+
+// list returns the entries from newest to oldest
+func (r *richPosts) list() []RichPost {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]RichPost, 0, r.order.Len())
+	for e := r.order.Back(); e != nil; e = e.Prev() {
+		result = append(result, e.Value.(RichPost))
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"testing"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
+)
+
+func TestRichPosts(t *testing.T) {
+	if newRichPosts(0) != nil {
+		t.Fatal("expected nil rich store for limit 0")
+	}
+	r := newRichPosts(2)
+	r.add(RichPost{Uri: postUri("did:plc:a", "1"), Did: "did:plc:a", Rkey: "1"})
+	r.add(RichPost{Uri: postUri("did:plc:b", "2"), Did: "did:plc:b", Rkey: "2"})
+	r.add(RichPost{Uri: postUri("did:plc:a", "3"), Did: "did:plc:a", Rkey: "3"})
+	if _, ok := r.get(postUri("did:plc:a", "1")); ok {
+		t.Error("expected the oldest entry to be evicted")
+	}
+	if got := r.list(); len(got) != 2 || got[0].Rkey != "3" || got[1].Rkey != "2" {
+		t.Errorf("expected [3 2], got %+v", got)
+	}
+
+	// 再追加すると最新になる
+	r.add(RichPost{Uri: postUri("did:plc:b", "2"), Did: "did:plc:b", Rkey: "2", Text: "updated"})
+	if got := r.list(); got[0].Rkey != "2" || got[0].Text != "updated" {
+		t.Errorf("expected re-added entry first, got %+v", got)
+	}
+
+	r.removeByDid("did:plc:a")
+	if got := r.list(); len(got) != 1 || got[0].Did != "did:plc:b" {
+		t.Errorf("expected only did:plc:b, got %+v", got)
+	}
+	r.remove(postUri("did:plc:b", "2"))
+	if got := r.list(); len(got) != 0 {
+		t.Errorf("expected empty, got %+v", got)
+	}
+}
+
+func TestRichPostFeedPost(t *testing.T) {
+	post := &apibsky.FeedPost{
+		Text:  "hello #tag",
+		Langs: []string{"ja"},
+		Reply: &apibsky.FeedPost_ReplyRef{
+			Parent: &comatproto.RepoStrongRef{Uri: "at://did:plc:parent/app.bsky.feed.post/p"},
+			Root:   &comatproto.RepoStrongRef{Uri: "at://did:plc:root/app.bsky.feed.post/r"},
+		},
+		Facets: []*apibsky.RichtextFacet{{
+			Features: []*apibsky.RichtextFacet_Features_Elem{
+				{RichtextFacet_Link: &apibsky.RichtextFacet_Link{Uri: "https://example.com/a"}},
+				{RichtextFacet_Tag: &apibsky.RichtextFacet_Tag{Tag: "tag"}},
+				{RichtextFacet_Mention: &apibsky.RichtextFacet_Mention{Did: "did:plc:mention"}},
+			},
+		}},
+		Embed: &apibsky.FeedPost_Embed{
+			EmbedExternal: &apibsky.EmbedExternal{External: &apibsky.EmbedExternal_External{Uri: "https://example.org/b"}},
+		},
+	}
+	rp := newRichPost("did:plc:author", "rkey", post)
+	if rp.Uri != "at://did:plc:author/app.bsky.feed.post/rkey" {
+		t.Errorf("unexpected uri: %s", rp.Uri)
+	}
+	if len(rp.Links) != 2 || rp.Links[1] != "https://example.org/b" {
+		t.Errorf("expected facet and embed links, got %v", rp.Links)
+	}
+
+	rebuilt := rp.FeedPost()
+	if rebuilt.Text != post.Text || len(rebuilt.Langs) != 1 || rebuilt.Langs[0] != "ja" {
+		t.Errorf("unexpected text or langs: %+v", rebuilt)
+	}
+	if rebuilt.Reply == nil || rebuilt.Reply.Parent.Uri != post.Reply.Parent.Uri || rebuilt.Reply.Root.Uri != post.Reply.Root.Uri {
+		t.Errorf("unexpected reply: %+v", rebuilt.Reply)
+	}
+	roundTrip := newRichPost("did:plc:author", "rkey", rebuilt)
+	if len(roundTrip.Links) != 2 || len(roundTrip.Tags) != 1 || len(roundTrip.Mentions) != 1 {
+		t.Errorf("expected facets to survive a round trip, got %+v", roundTrip)
+	}
+}
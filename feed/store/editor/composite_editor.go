@@ -0,0 +1,231 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/nus25/yuge/types"
+)
+
+var _ StoreEditor = (*CompositeEditor)(nil)
+
+// mirrorQueueSize is the number of pending operations kept for each mirror.
+// operations are dropped if the mirror falls further behind.
+const mirrorQueueSize = 1000
+
+// CompositeEditor writes to a primary editor and mirrors the writes to additional editors.
+// reads are served by the primary only. mirror failures are logged and never fail the primary.
+// each mirror is opened and written by its own worker, so a slow or unavailable mirror does not block the primary.
+type CompositeEditor struct {
+	primary   StoreEditor
+	mirrors   []*mirrorEditor
+	logger    *slog.Logger
+	startOnce sync.Once
+}
+
+type mirrorOp struct {
+	name string
+	fn   func(e StoreEditor) error
+}
+
+type mirrorEditor struct {
+	editor StoreEditor
+	logger *slog.Logger
+	ops    chan mirrorOp
+	done   chan struct{}
+	cancel context.CancelFunc
+
+	mu      sync.RWMutex
+	started bool
+	opened  bool
+	closed  bool
+}
+
+func NewCompositeEditor(primary StoreEditor, mirrors []StoreEditor, logger *slog.Logger) (*CompositeEditor, error) {
+	if primary == nil {
+		return nil, fmt.Errorf("primary editor is nil")
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("component", "composite editor")
+	c := &CompositeEditor{
+		primary: primary,
+		mirrors: make([]*mirrorEditor, 0, len(mirrors)),
+		logger:  logger,
+	}
+	for i, m := range mirrors {
+		if m == nil {
+			return nil, fmt.Errorf("mirror editor %d is nil", i)
+		}
+		c.mirrors = append(c.mirrors, &mirrorEditor{
+			editor: m,
+			logger: logger.With("mirror", i),
+			ops:    make(chan mirrorOp, mirrorQueueSize),
+			done:   make(chan struct{}),
+		})
+	}
+	return c, nil
+}
+
+// Open opens the primary editor and starts the mirror workers.
+// mirrors are opened in background and a mirror that fails to open is skipped.
+func (c *CompositeEditor) Open(ctx context.Context) error {
+	if err := c.primary.Open(ctx); err != nil {
+		return err
+	}
+	c.startOnce.Do(func() {
+		for _, m := range c.mirrors {
+			m.start(context.WithoutCancel(ctx))
+		}
+	})
+	return nil
+}
+
+func (c *CompositeEditor) Load(ctx context.Context, params LoadParams) ([]types.Post, error) {
+	return c.primary.Load(ctx, params)
+}
+
+func (c *CompositeEditor) Save(ctx context.Context, params SaveParams) error {
+	if err := c.primary.Save(ctx, params); err != nil {
+		return err
+	}
+	// 保存は終了時に呼ばれるため、キャンセルされないcontextで反映する
+	saveCtx := context.WithoutCancel(ctx)
+	c.mirror("save", func(e StoreEditor) error { return e.Save(saveCtx, params) })
+	return nil
+}
+
+func (c *CompositeEditor) Add(params PostParams) error {
+	if err := c.primary.Add(params); err != nil {
+		return err
+	}
+	c.mirror("add", func(e StoreEditor) error { return e.Add(params) })
+	return nil
+}
+
+func (c *CompositeEditor) Delete(params DeleteParams) error {
+	if err := c.primary.Delete(params); err != nil {
+		return err
+	}
+	c.mirror("delete", func(e StoreEditor) error { return e.Delete(params) })
+	return nil
+}
+
+func (c *CompositeEditor) DeleteByDid(feedUri types.FeedUri, did string) error {
+	if err := c.primary.DeleteByDid(feedUri, did); err != nil {
+		return err
+	}
+	c.mirror("deleteByDid", func(e StoreEditor) error { return e.DeleteByDid(feedUri, did) })
+	return nil
+}
+
+func (c *CompositeEditor) Trim(params TrimParams) error {
+	if err := c.primary.Trim(params); err != nil {
+		return err
+	}
+	c.mirror("trim", func(e StoreEditor) error { return e.Trim(params) })
+	return nil
+}
+
+// Close closes the mirrors and then the primary editor
+func (c *CompositeEditor) Close(ctx context.Context) error {
+	mirrorErr := c.CloseMirrors(ctx)
+	if err := c.primary.Close(ctx); err != nil {
+		return errors.Join(mirrorErr, fmt.Errorf("failed to close primary editor: %w", err))
+	}
+	return mirrorErr
+}
+
+// CloseMirrors waits for the queued mirror operations and closes the mirror editors,
+// leaving the primary editor open. used when the primary is shared with other feeds.
+func (c *CompositeEditor) CloseMirrors(ctx context.Context) error {
+	var errs []error
+	for i, m := range c.mirrors {
+		if err := m.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close mirror editor %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *CompositeEditor) mirror(name string, fn func(e StoreEditor) error) {
+	for _, m := range c.mirrors {
+		m.enqueue(mirrorOp{name: name, fn: fn})
+	}
+}
+
+func (m *mirrorEditor) start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.mu.Lock()
+	m.started = true
+	m.mu.Unlock()
+	go m.run(ctx)
+}
+
+func (m *mirrorEditor) run(ctx context.Context) {
+	defer close(m.done)
+	if err := m.editor.Open(ctx); err != nil {
+		m.logger.Error("failed to open mirror editor. mirror is skipped", "error", err)
+		for op := range m.ops {
+			m.logger.Debug("mirror operation is skipped", "operation", op.name)
+			mirrorEditorFailures.WithLabelValues("unavailable").Inc()
+		}
+		return
+	}
+	m.mu.Lock()
+	m.opened = true
+	m.mu.Unlock()
+	for op := range m.ops {
+		if err := op.fn(m.editor); err != nil {
+			m.logger.Warn("mirror operation failed", "operation", op.name, "error", err)
+			mirrorEditorFailures.WithLabelValues("error").Inc()
+		}
+	}
+}
+
+func (m *mirrorEditor) enqueue(op mirrorOp) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+	select {
+	case m.ops <- op:
+	default:
+		m.logger.Warn("mirror queue is full. operation is dropped", "operation", op.name)
+		mirrorEditorFailures.WithLabelValues("dropped").Inc()
+	}
+}
+
+func (m *mirrorEditor) close(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	started := m.started
+	opened := m.opened
+	close(m.ops)
+	m.mu.Unlock()
+
+	if !started {
+		return nil
+	}
+	if !opened {
+		// the mirror is still being opened (e.g. retrying an unreachable endpoint). give it up.
+		m.cancel()
+	}
+	select {
+	case <-m.done:
+	case <-ctx.Done():
+		// stop opening the mirror and give up the queued operations
+		m.cancel()
+		return fmt.Errorf("queued operations are not completed: %w", ctx.Err())
+	}
+	return m.editor.Close(ctx)
+}
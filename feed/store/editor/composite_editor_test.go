@@ -0,0 +1,159 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/nus25/yuge/types"
+)
+
+// recordingEditor records the operations applied to it
+type recordingEditor struct {
+	StoreEditor
+	mu      sync.Mutex
+	ops     []string
+	openErr error
+	addErr  error
+	closed  bool
+}
+
+func (e *recordingEditor) record(op string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ops = append(e.ops, op)
+}
+
+func (e *recordingEditor) recorded() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.ops...)
+}
+
+func (e *recordingEditor) Open(ctx context.Context) error {
+	return e.openErr
+}
+
+func (e *recordingEditor) Load(ctx context.Context, params LoadParams) ([]types.Post, error) {
+	e.record("load")
+	return nil, nil
+}
+
+func (e *recordingEditor) Add(params PostParams) error {
+	e.record("add:" + params.Rkey)
+	return e.addErr
+}
+
+func (e *recordingEditor) Delete(params DeleteParams) error {
+	e.record("delete:" + params.Rkey)
+	return nil
+}
+
+func (e *recordingEditor) Close(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	return nil
+}
+
+func TestCompositeEditor(t *testing.T) {
+	ctx := context.Background()
+	feed := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+
+	t.Run("mirrors writes in order", func(t *testing.T) {
+		primary := &recordingEditor{}
+		mirror := &recordingEditor{}
+		failing := &recordingEditor{addErr: errors.New("mirror down")}
+		c, err := NewCompositeEditor(primary, []StoreEditor{mirror, failing}, slog.Default())
+		if err != nil {
+			t.Fatalf("failed to create composite editor: %v", err)
+		}
+		if err := c.Open(ctx); err != nil {
+			t.Fatalf("failed to open composite editor: %v", err)
+		}
+
+		if err := c.Add(PostParams{FeedUri: feed, Rkey: "a"}); err != nil {
+			t.Errorf("mirror failure must not fail the primary: %v", err)
+		}
+		if err := c.Delete(DeleteParams{FeedUri: feed, Rkey: "a"}); err != nil {
+			t.Errorf("failed to delete: %v", err)
+		}
+		if _, err := c.Load(ctx, LoadParams{FeedUri: feed}); err != nil {
+			t.Errorf("failed to load: %v", err)
+		}
+
+		if err := c.CloseMirrors(ctx); err != nil {
+			t.Fatalf("failed to close mirrors: %v", err)
+		}
+		want := []string{"add:a", "delete:a"}
+		for name, e := range map[string]*recordingEditor{"mirror": mirror, "failing": failing} {
+			if got := e.recorded(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+				t.Errorf("%s: expected %v, got %v", name, want, got)
+			}
+			if !e.closed {
+				t.Errorf("%s: expected mirror to be closed", name)
+			}
+		}
+		// loadはprimaryのみ
+		if got := primary.recorded(); len(got) != 3 || got[2] != "load" {
+			t.Errorf("unexpected primary operations %v", got)
+		}
+		if primary.closed {
+			t.Error("CloseMirrors must not close the primary")
+		}
+
+		// 終了後の書き込みはprimaryのみに反映される
+		if err := c.Add(PostParams{FeedUri: feed, Rkey: "b"}); err != nil {
+			t.Errorf("failed to add after mirrors closed: %v", err)
+		}
+		if got := mirror.recorded(); len(got) != 2 {
+			t.Errorf("expected no mirror writes after close, got %v", got)
+		}
+		if err := c.Close(ctx); err != nil {
+			t.Errorf("failed to close: %v", err)
+		}
+		if !primary.closed {
+			t.Error("expected primary to be closed")
+		}
+	})
+
+	t.Run("mirror fails to open", func(t *testing.T) {
+		primary := &recordingEditor{}
+		mirror := &recordingEditor{openErr: errors.New("unreachable")}
+		c, err := NewCompositeEditor(primary, []StoreEditor{mirror}, slog.Default())
+		if err != nil {
+			t.Fatalf("failed to create composite editor: %v", err)
+		}
+		if err := c.Open(ctx); err != nil {
+			t.Fatalf("mirror open failure must not fail the primary: %v", err)
+		}
+		if err := c.Add(PostParams{FeedUri: feed, Rkey: "a"}); err != nil {
+			t.Errorf("failed to add: %v", err)
+		}
+		if err := c.CloseMirrors(ctx); err != nil {
+			t.Fatalf("failed to close mirrors: %v", err)
+		}
+		if got := mirror.recorded(); len(got) != 0 {
+			t.Errorf("expected unavailable mirror to be skipped, got %v", got)
+		}
+		if got := primary.recorded(); len(got) != 1 {
+			t.Errorf("expected primary to be written, got %v", got)
+		}
+	})
+
+	t.Run("primary failure", func(t *testing.T) {
+		primary := &recordingEditor{openErr: errors.New("primary down")}
+		c, err := NewCompositeEditor(primary, []StoreEditor{&recordingEditor{}}, slog.Default())
+		if err != nil {
+			t.Fatalf("failed to create composite editor: %v", err)
+		}
+		if err := c.Open(ctx); err == nil {
+			t.Error("expected error when primary fails to open")
+		}
+		if _, err := NewCompositeEditor(nil, nil, slog.Default()); err == nil {
+			t.Error("expected error for nil primary")
+		}
+	})
+}
@@ -2,13 +2,19 @@ package editor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/nus25/yuge/types"
 )
@@ -17,12 +23,73 @@ var _ StoreEditor = (*FileEditor)(nil) //type check
 
 const (
 	StoreFileName = "store.json"
+	// checksumSuffix is appended to the store file name for its checksum file
+	checksumSuffix = ".sum"
+	// backupSuffix is appended to the store file name for the previous version of the store file
+	backupSuffix = ".bak"
+
+	defaultFileWriteMaxRetries    = 3
+	defaultFileWriteRetryWaitTime = 100 * time.Millisecond
 )
 
+// FileWriteError is returned when writing a store file failed after all retries
+type FileWriteError struct {
+	Path     string
+	Attempts int
+	Err      error
+}
+
+func (e *FileWriteError) Error() string {
+	return fmt.Sprintf("failed to write file %s after %d attempts: %v", e.Path, e.Attempts, e.Err)
+}
+
+func (e *FileWriteError) Unwrap() error {
+	return e.Err
+}
+
+// ErrChecksumMismatch is returned when a store file does not match its checksum file
+var ErrChecksumMismatch = errors.New("store file checksum mismatch")
+
+// storeChecksum is the content of the checksum file written alongside a store file
+type storeChecksum struct {
+	SHA256 string `json:"sha256"`
+	Count  int    `json:"count"`
+}
+
+func newStoreChecksum(data []byte, count int) storeChecksum {
+	sum := sha256.Sum256(data)
+	return storeChecksum{SHA256: hex.EncodeToString(sum[:]), Count: count}
+}
+
+// readChecksum reads the checksum file of path. returns nil if the checksum file does not exist.
+func readChecksum(path string) (*storeChecksum, error) {
+	data, err := os.ReadFile(path + checksumSuffix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checksum file: %w", err)
+	}
+	var sum storeChecksum
+	if err := json.Unmarshal(data, &sum); err != nil {
+		return nil, fmt.Errorf("%w: invalid checksum file: %v", ErrChecksumMismatch, err)
+	}
+	return &sum, nil
+}
+
+// isTransientWriteError reports whether a write error may succeed on retry
+func isTransientWriteError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.EAGAIN)
+}
+
 type FileEditor struct {
 	logger *slog.Logger
 	mu     sync.RWMutex
 	dir    string
+
+	writeFile     func(name string, data []byte, perm os.FileMode) error
+	maxRetries    int
+	retryWaitTime time.Duration
 }
 
 func NewFileEditor(dir string, logger *slog.Logger) (*FileEditor, error) {
@@ -30,9 +97,12 @@ func NewFileEditor(dir string, logger *slog.Logger) (*FileEditor, error) {
 		logger = slog.Default()
 	}
 	return &FileEditor{
-		dir:    dir,
-		logger: logger,
-		mu:     sync.RWMutex{},
+		dir:           dir,
+		logger:        logger,
+		mu:            sync.RWMutex{},
+		writeFile:     os.WriteFile,
+		maxRetries:    defaultFileWriteMaxRetries,
+		retryWaitTime: defaultFileWriteRetryWaitTime,
 	}, nil
 }
 
@@ -85,7 +155,8 @@ func (e *FileEditor) Load(ctx context.Context, params LoadParams) ([]types.Post,
 			return nil, fmt.Errorf("failed to create feed directory: %w", err)
 		}
 		filePath := filepath.Join(feedDir, StoreFileName)
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		backupPath := filePath + backupSuffix
+		if !fileExists(filePath) && !fileExists(backupPath) {
 			// Create feed directory if not exists
 			e.logger.Info("file editor: creating empty file", "path", filePath) // create empty file
 			if err := os.WriteFile(filePath, []byte("[]"), 0644); err != nil {
@@ -95,16 +166,23 @@ func (e *FileEditor) Load(ctx context.Context, params LoadParams) ([]types.Post,
 
 		e.logger.Info("loading feed file", "path", filePath)
 
-		data, err := os.ReadFile(filePath)
+		posts, err := readStoreFile(filePath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read file: %w", err)
+			// 破損または書き込み途中のファイルは読み込まずにバックアップを使う
+			if !fileExists(backupPath) {
+				return nil, err
+			}
+			e.logger.Warn("failed to load feed file, loading backup", "path", filePath, "backup", backupPath, "error", err)
+			var backupErr error
+			posts, backupErr = readStoreFile(backupPath)
+			if backupErr != nil {
+				return nil, fmt.Errorf("%w (backup: %v)", err, backupErr)
+			}
 		}
 
-		var posts []types.Post
-		if err := json.Unmarshal(data, &posts); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal posts: %w", err)
+		for i := range posts {
+			posts[i].IndexedAt = types.NormalizeIndexedAt(posts[i].IndexedAt)
 		}
-
 		// Sort posts by IndexedAt in descending order (newest first)
 		sort.Slice(posts, func(i, j int) bool {
 			return posts[i].IndexedAt > posts[j].IndexedAt
@@ -156,13 +234,102 @@ func (e *FileEditor) Save(ctx context.Context, params SaveParams) error {
 		if err != nil {
 			return fmt.Errorf("failed to marshal posts: %w", err)
 		}
+		sum, err := json.Marshal(newStoreChecksum(data, len(params.Posts)))
+		if err != nil {
+			return fmt.Errorf("failed to marshal checksum: %w", err)
+		}
 
-		if err := os.WriteFile(filePath, data, 0644); err != nil {
-			return fmt.Errorf("failed to write file: %w", err)
+		if err := e.backupStoreFile(filePath); err != nil {
+			return err
+		}
+		// チェックサムを先に書き、データの書き込みが途中で失敗した場合は不一致として検出する
+		if err := e.writeWithRetry(ctx, filePath+checksumSuffix, sum); err != nil {
+			return err
 		}
+		return e.writeWithRetry(ctx, filePath, data)
+	}
+}
+
+// readStoreFile reads the posts in path and verifies them with the checksum file.
+// files without a checksum file (written by older versions) are loaded without verification.
+func readStoreFile(path string) ([]types.Post, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	sum, err := readChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	if sum != nil && newStoreChecksum(data, sum.Count).SHA256 != sum.SHA256 {
+		return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, path)
+	}
+
+	var posts []types.Post
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal posts: %w", err)
+	}
+	if sum != nil && len(posts) != sum.Count {
+		return nil, fmt.Errorf("%w: %s has %d posts, expected %d", ErrChecksumMismatch, path, len(posts), sum.Count)
+	}
+	return posts, nil
+}
 
+// backupStoreFile moves the current store file and its checksum file to the backup files.
+// a store file not matching its checksum is not backed up so that the last valid backup is kept.
+func (e *FileEditor) backupStoreFile(path string) error {
+	if !fileExists(path) {
 		return nil
 	}
+	if _, err := readStoreFile(path); err != nil {
+		e.logger.Warn("current feed file is invalid, keeping previous backup", "path", path, "error", err)
+		return nil
+	}
+	backupPath := path + backupSuffix
+	if err := os.Rename(path, backupPath); err != nil {
+		return fmt.Errorf("failed to backup file: %w", err)
+	}
+	if err := os.Rename(path+checksumSuffix, backupPath+checksumSuffix); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to backup checksum file: %w", err)
+		}
+		// チェックサムのないファイルのバックアップに古いチェックサムが残らないようにする
+		if err := os.Remove(backupPath + checksumSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove old checksum file: %w", err)
+		}
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// writeWithRetry writes data to path, retrying transient errors (e.g. ENOSPC, EINTR) with backoff
+func (e *FileEditor) writeWithRetry(ctx context.Context, path string, data []byte) error {
+	var lastErr error
+	for attempt := 0; attempt <= e.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := calculateBackoffDelay(attempt, e.retryWaitTime)
+			e.logger.Info("retrying file write", "path", path, "attempt", attempt, "delay", delay)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		err := e.writeFile(path, data, 0644)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !isTransientWriteError(err) {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		e.logger.Warn("file write failed", "path", path, "attempt", attempt, "error", err)
+	}
+	return &FileWriteError{Path: path, Attempts: e.maxRetries + 1, Err: lastErr}
 }
 
 func (e *FileEditor) Close(ctx context.Context) error {
@@ -1,9 +1,14 @@
 package editor
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
@@ -205,8 +210,180 @@ func TestFileEditor(t *testing.T) {
 		if posts[0].Cid != testCid {
 			t.Errorf("expected Cid %s, got %s", testCid, posts[0].Cid)
 		}
-		if posts[0].IndexedAt != testIndexedAt.Format(time.RFC3339) {
-			t.Errorf("expected IndexedAt %s, got %s", testIndexedAt.Format(time.RFC3339), posts[0].IndexedAt)
+		if posts[0].IndexedAt != types.FormatIndexedAt(testIndexedAt.Truncate(time.Second)) {
+			t.Errorf("expected IndexedAt %s, got %s", types.FormatIndexedAt(testIndexedAt.Truncate(time.Second)), posts[0].IndexedAt)
+		}
+	})
+}
+
+func TestFileEditorWriteRetry(t *testing.T) {
+	ctx := context.Background()
+	l := slog.Default()
+	feed := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+
+	// failingWriter fails the first n writes with err
+	failingWriter := func(n int, err error, calls *int) func(string, []byte, os.FileMode) error {
+		return func(name string, data []byte, perm os.FileMode) error {
+			*calls++
+			if *calls <= n {
+				return &os.PathError{Op: "write", Path: name, Err: err}
+			}
+			return os.WriteFile(name, data, perm)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		failures      int
+		err           error
+		expectedCalls int
+		expectErr     bool
+		expectTyped   bool
+	}{
+		// the checksum file and the store file are written
+		{name: "recovers from ENOSPC", failures: 2, err: syscall.ENOSPC, expectedCalls: 4},
+		{name: "recovers from EINTR", failures: 1, err: syscall.EINTR, expectedCalls: 3},
+		{name: "fails after all retries", failures: 10, err: syscall.ENOSPC, expectedCalls: 4, expectErr: true, expectTyped: true},
+		{name: "does not retry permanent error", failures: 10, err: syscall.EACCES, expectedCalls: 1, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor, err := NewFileEditor(t.TempDir(), l)
+			if err != nil {
+				t.Fatalf("failed to create editor: %v", err)
+			}
+			calls := 0
+			editor.writeFile = failingWriter(tt.failures, tt.err, &calls)
+			editor.retryWaitTime = time.Millisecond
+
+			err = editor.Save(ctx, SaveParams{FeedId: "test", FeedUri: feed, Posts: []types.Post{}})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var writeErr *FileWriteError
+			if errors.As(err, &writeErr) != tt.expectTyped {
+				t.Errorf("expected FileWriteError %v, got %v", tt.expectTyped, err)
+			}
+			if tt.expectErr && !errors.Is(err, tt.err) {
+				t.Errorf("expected error to wrap %v, got %v", tt.err, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("expected %d write calls, got %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestFileEditorChecksum(t *testing.T) {
+	ctx := context.Background()
+	l := slog.Default()
+	feed := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+	post := func(rkey string) types.Post {
+		return types.Post{
+			Feed:      feed,
+			Uri:       types.PostUri("at://did:plc:test/app.bsky.feed.post/" + rkey),
+			Cid:       "bafyrei" + rkey,
+			IndexedAt: time.Now().Format(time.RFC3339),
+		}
+	}
+	// 2回保存して、1回目の内容がバックアップに残る状態にする
+	setup := func(t *testing.T) (*FileEditor, string) {
+		dir := t.TempDir()
+		editor, err := NewFileEditor(dir, l)
+		if err != nil {
+			t.Fatalf("failed to create editor: %v", err)
+		}
+		if err := editor.Save(ctx, SaveParams{FeedId: "test", FeedUri: feed, Posts: []types.Post{post("a")}}); err != nil {
+			t.Fatalf("failed to save posts: %v", err)
+		}
+		if err := editor.Save(ctx, SaveParams{FeedId: "test", FeedUri: feed, Posts: []types.Post{post("a"), post("b")}}); err != nil {
+			t.Fatalf("failed to save posts: %v", err)
+		}
+		return editor, filepath.Join(dir, "test", StoreFileName)
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(t *testing.T, path string)
+		wantCount int
+		wantErr   error
+	}{
+		{
+			name:      "valid file",
+			modify:    func(t *testing.T, path string) {},
+			wantCount: 2,
+		},
+		{
+			name: "corrupted file falls back to backup",
+			modify: func(t *testing.T, path string) {
+				data, _ := os.ReadFile(path)
+				data = bytes.Replace(data, []byte("bafyreib"), []byte("bafyreix"), 1)
+				os.WriteFile(path, data, 0644)
+			},
+			wantCount: 1,
+		},
+		{
+			name: "truncated file falls back to backup",
+			modify: func(t *testing.T, path string) {
+				os.Truncate(path, 10)
+			},
+			wantCount: 1,
+		},
+		{
+			name: "missing file falls back to backup",
+			modify: func(t *testing.T, path string) {
+				os.Remove(path)
+			},
+			wantCount: 1,
+		},
+		{
+			name: "legacy file without checksum is loaded",
+			modify: func(t *testing.T, path string) {
+				os.Remove(path + checksumSuffix)
+			},
+			wantCount: 2,
+		},
+		{
+			name: "corrupted file and backup",
+			modify: func(t *testing.T, path string) {
+				os.WriteFile(path, []byte("[]"), 0644)
+				os.WriteFile(path+backupSuffix, []byte("[]"), 0644)
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor, path := setup(t)
+			tt.modify(t, path)
+			posts, err := editor.Load(ctx, LoadParams{FeedId: "test", FeedUri: feed})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load posts: %v", err)
+			}
+			if len(posts) != tt.wantCount {
+				t.Errorf("expected %d posts, got %d", tt.wantCount, len(posts))
+			}
+		})
+	}
+
+	t.Run("invalid file does not overwrite backup", func(t *testing.T) {
+		editor, path := setup(t)
+		os.WriteFile(path, []byte("[]"), 0644)
+		if err := editor.Save(ctx, SaveParams{FeedId: "test", FeedUri: feed, Posts: []types.Post{post("c"), post("d"), post("e")}}); err != nil {
+			t.Fatalf("failed to save posts: %v", err)
+		}
+		backup, err := readStoreFile(path + backupSuffix)
+		if err != nil {
+			t.Fatalf("failed to read backup: %v", err)
+		}
+		if len(backup) != 1 {
+			t.Errorf("expected backup with 1 post, got %d", len(backup))
 		}
 	})
 }
@@ -26,9 +26,14 @@ const (
 	defaultMaxRetries          = 3
 	defaultRetryWaitTime       = 2 * time.Second
 	defaultBatchInterval       = 1 * time.Second
+	defaultQueueTimeout        = 5 * time.Second
+	requestQueueSize           = 100
 	maxBatchSize               = 25
 )
 
+// ErrEditorOverloaded is returned when a request cannot be queued to the gyoka editor worker within the queue timeout
+var ErrEditorOverloaded = errors.New("gyoka editor overloaded: request queue is full")
+
 func isRetryableError(statusCode int) bool {
 	return statusCode >= 500 || statusCode == 429 || statusCode == 408
 }
@@ -64,6 +69,9 @@ type GyokaEditor struct {
 	requestMu sync.RWMutex
 	closing   bool
 
+	// queueTimeout is how long a request waits for a free slot in requestCh before failing with ErrEditorOverloaded
+	queueTimeout time.Duration
+
 	// for batch add
 	batchPool       []PostParams
 	batchMu         sync.Mutex
@@ -99,6 +107,7 @@ type ClientOption struct {
 	idleConnTimeout     time.Duration
 	maxRetries          int
 	retryWaitTime       time.Duration
+	queueTimeout        time.Duration
 }
 
 type AuthType int
@@ -134,6 +143,65 @@ func WithRetryWaitTime(retryWaitTime time.Duration) ClientOptionFunc {
 	}
 }
 
+func WithMaxRetries(maxRetries int) ClientOptionFunc {
+	return func(opt *ClientOption) {
+		opt.maxRetries = maxRetries
+	}
+}
+
+// WithQueueTimeout sets how long a request waits for the worker queue when it is full
+func WithQueueTimeout(timeout time.Duration) ClientOptionFunc {
+	return func(opt *ClientOption) {
+		opt.queueTimeout = timeout
+	}
+}
+
+func WithHttpTimeout(timeout time.Duration) ClientOptionFunc {
+	return func(opt *ClientOption) {
+		opt.httpTimeout = timeout
+	}
+}
+
+func WithMaxIdleConns(n int) ClientOptionFunc {
+	return func(opt *ClientOption) {
+		opt.maxIdleConns = n
+	}
+}
+
+func WithMaxIdleConnsPerHost(n int) ClientOptionFunc {
+	return func(opt *ClientOption) {
+		opt.maxIdleConnsPerHost = n
+	}
+}
+
+func WithIdleConnTimeout(timeout time.Duration) ClientOptionFunc {
+	return func(opt *ClientOption) {
+		opt.idleConnTimeout = timeout
+	}
+}
+
+func (o *ClientOption) validate() error {
+	if o.maxRetries < 0 {
+		return fmt.Errorf("max retries must not be negative: %d", o.maxRetries)
+	}
+	if o.httpTimeout <= 0 {
+		return fmt.Errorf("http timeout must be positive: %s", o.httpTimeout)
+	}
+	if o.maxIdleConns <= 0 {
+		return fmt.Errorf("max idle conns must be positive: %d", o.maxIdleConns)
+	}
+	if o.maxIdleConnsPerHost <= 0 {
+		return fmt.Errorf("max idle conns per host must be positive: %d", o.maxIdleConnsPerHost)
+	}
+	if o.idleConnTimeout <= 0 {
+		return fmt.Errorf("idle conn timeout must be positive: %s", o.idleConnTimeout)
+	}
+	if o.queueTimeout <= 0 {
+		return fmt.Errorf("queue timeout must be positive: %s", o.queueTimeout)
+	}
+	return nil
+}
+
 func NewGyokaEditor(url string, logger *slog.Logger, opts ...ClientOptionFunc) (*GyokaEditor, error) {
 	if logger == nil {
 		logger = slog.Default()
@@ -142,13 +210,14 @@ func NewGyokaEditor(url string, logger *slog.Logger, opts ...ClientOptionFunc) (
 	if url == "" {
 		logger.Info("feed editor url is not set. client will skip syncing")
 		return &GyokaEditor{
-			client:    nil,
-			option:    nil,
-			logger:    logger,
-			requestCh: make(chan *feedRequest, 100),
-			done:      make(chan struct{}),
-			mu:        sync.RWMutex{},
-			requestMu: sync.RWMutex{},
+			client:       nil,
+			option:       nil,
+			logger:       logger,
+			requestCh:    make(chan *feedRequest, requestQueueSize),
+			queueTimeout: defaultQueueTimeout,
+			done:         make(chan struct{}),
+			mu:           sync.RWMutex{},
+			requestMu:    sync.RWMutex{},
 		}, nil
 	}
 
@@ -162,6 +231,7 @@ func NewGyokaEditor(url string, logger *slog.Logger, opts ...ClientOptionFunc) (
 		idleConnTimeout:     defaultIdleConnTimeout,
 		maxRetries:          defaultMaxRetries,
 		retryWaitTime:       defaultRetryWaitTime,
+		queueTimeout:        defaultQueueTimeout,
 	}
 
 	//Set custom auth headers
@@ -179,6 +249,10 @@ func NewGyokaEditor(url string, logger *slog.Logger, opts ...ClientOptionFunc) (
 		}
 	}
 
+	if err := opt.validate(); err != nil {
+		return nil, fmt.Errorf("invalid client option: %w", err)
+	}
+
 	// editor.ClientOptionの作成
 	baseTransport := &http.Transport{
 		MaxIdleConns:        opt.maxIdleConns,
@@ -205,7 +279,8 @@ func NewGyokaEditor(url string, logger *slog.Logger, opts ...ClientOptionFunc) (
 		client:          c,
 		option:          opt,
 		logger:          logger,
-		requestCh:       make(chan *feedRequest, 100),
+		requestCh:       make(chan *feedRequest, requestQueueSize),
+		queueTimeout:    opt.queueTimeout,
 		done:            make(chan struct{}),
 		mu:              sync.RWMutex{},
 		requestMu:       sync.RWMutex{},
@@ -456,7 +531,7 @@ func (e *GyokaEditor) executeRequest(ctx context.Context, req *feedRequest) erro
 		body := client.PostRemovePostJSONRequestBody{
 			Feed: string(params.FeedUri),
 			Post: client.RemovePostPostParam{
-				IndexedAt: nil, //delete all posts for URI
+				IndexedAt: params.IndexedAt, //nil deletes all posts for URI
 				Uri:       uri,
 			},
 		}
@@ -565,52 +640,123 @@ func (e *GyokaEditor) Load(ctx context.Context, params LoadParams) ([]types.Post
 	}
 }
 
+// executeLoadRequest follows the cursor until params.Limit posts are gathered or no cursor is returned.
+// if params.Limit <= 0, all pages are fetched.
 func (e *GyokaEditor) executeLoadRequest(ctx context.Context, params LoadParams) ([]types.Post, error) {
-	p := &client.GetGetPostsParams{
-		Feed:   string(params.FeedUri),
-		Limit:  &params.Limit,
-		Cursor: nil,
+	posts := make([]types.Post, 0)
+	var cursor *string
+	for {
+		p := &client.GetGetPostsParams{
+			Feed:   string(params.FeedUri),
+			Cursor: cursor,
+		}
+		if params.Limit > 0 {
+			remain := params.Limit - len(posts)
+			p.Limit = &remain
+		}
+		page, next, err := e.executeLoadPageRequest(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, page...)
+		if params.Limit > 0 && len(posts) >= params.Limit {
+			return posts[:params.Limit], nil
+		}
+		// stop if the server returns no cursor, an empty page or the same cursor again
+		if next == nil || *next == "" || len(page) == 0 || (cursor != nil && *next == *cursor) {
+			return posts, nil
+		}
+		cursor = next
 	}
+}
+
+func (e *GyokaEditor) executeLoadPageRequest(ctx context.Context, p *client.GetGetPostsParams) ([]types.Post, *string, error) {
 	resp, err := e.client.GetGetPostsWithResponse(ctx, p)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch resp.StatusCode() {
 	case 200:
-		e.logger.Info("load posts from gyoka succeed", "feed", resp.JSON200.Feed, "cursor", resp.JSON200.Cursor)
+		e.logger.Info("load posts from gyoka succeed", "feed", resp.JSON200.Feed, "count", len(resp.JSON200.Posts), "cursor", resp.JSON200.Cursor)
 		posts := make([]types.Post, len(resp.JSON200.Posts))
 		for i, p := range resp.JSON200.Posts {
 			posts[i] = types.Post{
 				Uri:       types.PostUri(p.Uri),
 				Cid:       p.Cid,
-				IndexedAt: p.IndexedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+				IndexedAt: types.FormatIndexedAt(p.IndexedAt),
 				//Langs is not supported in local cache
 			}
 		}
-		return posts, nil
+		return posts, resp.JSON200.Cursor, nil
 	case 400:
 		e.logger.Error("failed to load posts.", "error", resp.JSON400.Error, "message", resp.JSON400.Message)
-		return nil, &NonRetryableError{fmt.Errorf("bad request (non-retryable): %d", resp.StatusCode())}
+		return nil, nil, &NonRetryableError{fmt.Errorf("bad request (non-retryable): %d", resp.StatusCode())}
 	case 401:
 		e.logger.Error("failed to load posts.", "error", resp.JSON401.Error, "message", resp.JSON401.Message)
-		return nil, &NonRetryableError{fmt.Errorf("unauthorized (non-retryable): %d", resp.StatusCode())}
+		return nil, nil, &NonRetryableError{fmt.Errorf("unauthorized (non-retryable): %d", resp.StatusCode())}
 	case 404:
 		e.logger.Error("failed to load posts. Feed may not be registered in gyoka", "error", resp.JSON404.Error, "message", resp.JSON404.Message)
-		return nil, &NonRetryableError{fmt.Errorf("not found (non-retryable): %d", resp.StatusCode())}
+		return nil, nil, &NonRetryableError{fmt.Errorf("not found (non-retryable): %d", resp.StatusCode())}
 	default:
 		if isRetryableError(resp.StatusCode()) {
 			if resp.StatusCode() == 500 {
 				e.logger.Error("failed to load posts. Gyoka server has some problem", "error", resp.JSON500.Error, "message", resp.JSON500.Message)
 			}
-			return nil, fmt.Errorf("retryable error: status=%d", resp.StatusCode())
+			return nil, nil, fmt.Errorf("retryable error: status=%d", resp.StatusCode())
 		}
 		e.logger.Error("unexpected status code from GetGetPosts", "status", resp.StatusCode())
-		return nil, &NonRetryableError{fmt.Errorf("unexpected status code (non-retryable): %d", resp.StatusCode())}
+		return nil, nil, &NonRetryableError{fmt.Errorf("unexpected status code (non-retryable): %d", resp.StatusCode())}
+	}
+}
+
+// sendRequest queues req to the worker and waits for the result.
+// returns ctx.Err() if ctx is canceled before the request is queued or completed.
+func (e *GyokaEditor) sendRequest(ctx context.Context, req *feedRequest) error {
+	if err := e.enqueue(ctx, req); err != nil {
+		return err
+	}
+	return e.waitRequest(ctx, req)
+}
+
+// enqueue queues req to the worker.
+// if the queue stays full for queueTimeout, it gives up with ErrEditorOverloaded
+// so that a slow gyoka does not stall the callers (e.g. the jetstream ingest) indefinitely.
+func (e *GyokaEditor) enqueue(ctx context.Context, req *feedRequest) error {
+	select {
+	case e.requestCh <- req:
+		return nil
+	default:
+	}
+	timer := time.NewTimer(e.queueTimeout)
+	defer timer.Stop()
+	select {
+	case e.requestCh <- req:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		gyokaEditorQueueFull.Inc()
+		e.logger.Warn("request queue is full, request is rejected", "operation", req.operation, "queueTimeout", e.queueTimeout)
+		return ErrEditorOverloaded
+	}
+}
+
+func (e *GyokaEditor) waitRequest(ctx context.Context, req *feedRequest) error {
+	select {
+	case err := <-req.errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 func (e *GyokaEditor) Add(params PostParams) error {
+	return e.AddContext(context.Background(), params)
+}
+
+// AddContext is like Add but returns ctx.Err() if ctx is canceled while waiting for the worker
+func (e *GyokaEditor) AddContext(ctx context.Context, params PostParams) error {
 	if e.client == nil {
 		e.logger.Info("no feed editor url is set. add request is skipped.")
 		return fmt.Errorf("no feed editor url is set.add request is skipped")
@@ -629,11 +775,16 @@ func (e *GyokaEditor) Add(params PostParams) error {
 		e.batchMu.Unlock()
 
 		// 即座にリクエストを送信
-		errCh := make(chan error, 1)
-		e.requestCh <- &feedRequest{
+		req := &feedRequest{
 			operation: "add",
 			AddParams: params,
-			errCh:     errCh,
+			errCh:     make(chan error, 1),
+		}
+		if err := e.enqueue(ctx, req); err != nil {
+			e.batchMu.Lock()
+			e.firstAddInBatch = true
+			e.batchMu.Unlock()
+			return err
 		}
 
 		// タイマーを設定して次のバッチ処理を準備
@@ -646,11 +797,12 @@ func (e *GyokaEditor) Add(params PostParams) error {
 		})
 		e.batchMu.Unlock()
 
-		return <-errCh
+		return e.waitRequest(ctx, req)
 	}
 
 	// 2回目以降はプールに追加
 	e.batchPool = append(e.batchPool, params)
+	gyokaBatchPoolSize.Inc()
 
 	// タイマーがまだセットされていない場合は設定
 	if e.batchTimer == nil {
@@ -689,6 +841,8 @@ func (e *GyokaEditor) flushBatch() {
 	}
 
 	// プールをクリア
+	gyokaBatchPoolSize.Sub(float64(len(e.batchPool)))
+	gyokaBatchFlushes.Inc()
 	e.batchPool = e.batchPool[:0]
 	e.firstAddInBatch = true
 	e.batchTimer = nil
@@ -706,14 +860,17 @@ func (e *GyokaEditor) flushBatch() {
 		batchEntries := allEntries[i:end]
 
 		errCh := make(chan error, 1)
-		e.requestCh <- &feedRequest{
+		err := e.enqueue(context.Background(), &feedRequest{
 			operation:      "batchAdd",
 			BatchAddParams: BatchPostParams{Entries: batchEntries},
 			errCh:          errCh,
+		})
+		if err == nil {
+			err = <-errCh
 		}
 
 		// エラーをログに記録（非同期なので呼び出し元には返せない）
-		if err := <-errCh; err != nil {
+		if err != nil {
 			e.logger.Error("batch add failed", "error", err, "count", len(batchEntries), "batch", i/maxBatchSize+1)
 		} else {
 			e.logger.Info("batch add succeeded", "count", len(batchEntries), "batch", i/maxBatchSize+1, "total", totalCount)
@@ -762,13 +919,16 @@ func (e *GyokaEditor) BatchAdd(params BatchPostParams) error {
 			"batch_size", len(batchEntries))
 
 		errCh := make(chan error, 1)
-		e.requestCh <- &feedRequest{
+		err := e.enqueue(context.Background(), &feedRequest{
 			operation:      "batchAdd",
 			BatchAddParams: BatchPostParams{Entries: batchEntries},
 			errCh:          errCh,
+		})
+		if err == nil {
+			err = <-errCh
 		}
 
-		if err := <-errCh; err != nil {
+		if err != nil {
 			failureCount += len(batchEntries)
 			e.logger.Error("batch request failed",
 				"batch", batchNum,
@@ -804,6 +964,11 @@ func (e *GyokaEditor) BatchAdd(params BatchPostParams) error {
 }
 
 func (e *GyokaEditor) Delete(params DeleteParams) error {
+	return e.DeleteContext(context.Background(), params)
+}
+
+// DeleteContext is like Delete but returns ctx.Err() if ctx is canceled while waiting for the worker
+func (e *GyokaEditor) DeleteContext(ctx context.Context, params DeleteParams) error {
 	if e.client == nil {
 		e.logger.Info("No feed editor url is set. Delete request is skipped.")
 		return nil
@@ -812,16 +977,19 @@ func (e *GyokaEditor) Delete(params DeleteParams) error {
 		e.logger.Error("invalid feed uri", "error", err)
 		return fmt.Errorf("invalid feed uri: %w", err)
 	}
-	errCh := make(chan error, 1)
-	e.requestCh <- &feedRequest{
+	return e.sendRequest(ctx, &feedRequest{
 		operation:    "delete",
 		DeleteParams: params,
-		errCh:        errCh,
-	}
-	return <-errCh
+		errCh:        make(chan error, 1),
+	})
 }
 
 func (e *GyokaEditor) DeleteByDid(feedUri types.FeedUri, did string) error {
+	return e.DeleteByDidContext(context.Background(), feedUri, did)
+}
+
+// DeleteByDidContext is like DeleteByDid but returns ctx.Err() if ctx is canceled while waiting for the worker
+func (e *GyokaEditor) DeleteByDidContext(ctx context.Context, feedUri types.FeedUri, did string) error {
 	if e.client == nil {
 		e.logger.Info("No feed editor url is set. DeleteByDid request is skipped.")
 		return nil
@@ -831,17 +999,19 @@ func (e *GyokaEditor) DeleteByDid(feedUri types.FeedUri, did string) error {
 		return fmt.Errorf("invalid feed uri: %w", err)
 	}
 
-	errCh := make(chan error, 1)
-	e.requestCh <- &feedRequest{
+	return e.sendRequest(ctx, &feedRequest{
 		operation:         "deleteByDid",
 		DeleteByDidParams: DeleteByDidParams{FeedUri: feedUri, Did: did},
-		errCh:             errCh,
-	}
-
-	return <-errCh
+		errCh:             make(chan error, 1),
+	})
 }
 
 func (e *GyokaEditor) Trim(params TrimParams) error {
+	return e.TrimContext(context.Background(), params)
+}
+
+// TrimContext is like Trim but returns ctx.Err() if ctx is canceled while waiting for the worker
+func (e *GyokaEditor) TrimContext(ctx context.Context, params TrimParams) error {
 	f := params.FeedUri
 	count := params.Count
 	if e.client == nil {
@@ -857,13 +1027,11 @@ func (e *GyokaEditor) Trim(params TrimParams) error {
 		return fmt.Errorf("invalid feed uri: %w", err)
 	}
 
-	errCh := make(chan error, 1)
-	e.requestCh <- &feedRequest{
+	return e.sendRequest(ctx, &feedRequest{
 		operation:  "trim",
 		TrimParams: params,
-		errCh:      errCh,
-	}
-	return <-errCh
+		errCh:      make(chan error, 1),
+	})
 }
 
 func (e *GyokaEditor) Save(ctx context.Context, params SaveParams) error {
@@ -3,6 +3,7 @@ package editor
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,7 @@ import (
 	"log/slog"
 
 	"github.com/nus25/yuge/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestGyokaEditor(t *testing.T) {
@@ -312,6 +314,64 @@ func TestRetryFunctionality(t *testing.T) {
 		}
 	})
 
+	t.Run("AddPost_NoRetryWhenMaxRetriesZero", func(t *testing.T) {
+		var attemptCount int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/gyoka/ping" {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(map[string]any{
+					"message": "Gyoka is available",
+				})
+				return
+			}
+
+			atomic.AddInt32(&attemptCount, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":   "internal_error",
+				"message": "server error",
+			})
+		}))
+		defer server.Close()
+
+		client, err := NewGyokaEditor(server.URL, logger, WithRetryWaitTime(100*time.Microsecond), WithMaxRetries(0))
+		if err != nil {
+			t.Fatalf("failed to create editor: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := client.Open(ctx); err != nil {
+			t.Fatalf("failed to open client: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		err = client.Add(PostParams{
+			FeedUri:   types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test"),
+			Did:       "did:plc:test",
+			Rkey:      "test",
+			Cid:       "test-cid",
+			IndexedAt: time.Now(),
+			Langs:     []string{"en"},
+		})
+
+		if err == nil {
+			t.Error("expected error for server error, got nil")
+		}
+
+		finalAttempts := atomic.LoadInt32(&attemptCount)
+		if finalAttempts != 1 {
+			t.Errorf("expected 1 attempt (max retries 0), got %d", finalAttempts)
+		}
+	})
+
+	t.Run("NewEditor_NegativeMaxRetries", func(t *testing.T) {
+		if _, err := NewGyokaEditor("http://test.example", logger, WithMaxRetries(-1)); err == nil {
+			t.Error("expected error for negative max retries, got nil")
+		}
+	})
+
 	t.Run("Open_RetryOnServerError", func(t *testing.T) {
 		var attemptCount int32
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -696,6 +756,72 @@ func TestDeleteByDid(t *testing.T) {
 	})
 }
 
+func TestDeleteIndexedAt(t *testing.T) {
+	indexedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		indexedAt *time.Time
+		want      string
+	}{
+		{name: "delete all versions", indexedAt: nil, want: ""},
+		{name: "delete specific version", indexedAt: &indexedAt, want: "2025-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received map[string]any
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/gyoka/ping" {
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(map[string]any{
+						"message": "Gyoka is available",
+					})
+					return
+				}
+				var req struct {
+					Post map[string]any `json:"post"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("failed to decode body: %v", err)
+				}
+				received = req.Post
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(map[string]any{"message": "success"})
+			}))
+			defer ts.Close()
+
+			client, err := NewGyokaEditor(ts.URL, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create editor: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			if err = client.Open(ctx); err != nil {
+				t.Fatalf("failed to open client: %v", err)
+			}
+
+			err = client.Delete(DeleteParams{
+				FeedUri:   types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test"),
+				Did:       "did:plc:test",
+				Rkey:      "test",
+				IndexedAt: tt.indexedAt,
+			})
+			if err != nil {
+				t.Fatalf("failed to delete post: %v", err)
+			}
+			got, ok := received["indexedAt"]
+			if tt.want == "" {
+				if ok {
+					t.Errorf("expected no indexedAt, got %v", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("indexedAt = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGyokaEditorErrorMessages(t *testing.T) {
 	logger := slog.Default()
 
@@ -1000,6 +1126,8 @@ func TestBatchAdd(t *testing.T) {
 
 		// Add 3 posts in quick succession
 		feedUri := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+		poolSizeBefore := testutil.ToFloat64(gyokaBatchPoolSize)
+		flushesBefore := testutil.ToFloat64(gyokaBatchFlushes)
 
 		for i := 0; i < 3; i++ {
 			err = client.Add(PostParams{
@@ -1015,10 +1143,20 @@ func TestBatchAdd(t *testing.T) {
 			}
 			// Subsequent adds return immediately (batched)
 		}
+		if got := testutil.ToFloat64(gyokaBatchPoolSize) - poolSizeBefore; got != 2 {
+			t.Errorf("expected batch pool size 2, got %v", got)
+		}
 
 		// Wait for batch to be processed
 		time.Sleep(2 * time.Second)
 
+		if got := testutil.ToFloat64(gyokaBatchPoolSize) - poolSizeBefore; got != 0 {
+			t.Errorf("expected empty batch pool after flush, got %v", got)
+		}
+		if got := testutil.ToFloat64(gyokaBatchFlushes) - flushesBefore; got != 1 {
+			t.Errorf("expected 1 batch flush, got %v", got)
+		}
+
 		// Should have 2 requests: 1 individual add + 1 batch add
 		finalRequestCount := atomic.LoadInt32(&requestCount)
 		if finalRequestCount != 2 {
@@ -1254,3 +1392,264 @@ func TestBatchAdd(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadPagination(t *testing.T) {
+	const total = 25
+	const pageSize = 10
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newServer := func(t *testing.T, requestCount *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/gyoka/ping" {
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(map[string]any{
+					"message": "Gyoka is available",
+				})
+				return
+			}
+			if r.URL.Path != "/api/feed/getPosts" {
+				t.Errorf("path = %s, want /api/feed/getPosts", r.URL.Path)
+			}
+			atomic.AddInt32(requestCount, 1)
+			// cursor is the offset of the next page
+			offset := 0
+			if c := r.URL.Query().Get("cursor"); c != "" {
+				fmt.Sscanf(c, "%d", &offset)
+			}
+			limit := pageSize
+			if l := r.URL.Query().Get("limit"); l != "" {
+				fmt.Sscanf(l, "%d", &limit)
+				limit = min(limit, pageSize)
+			}
+			end := min(offset+limit, total)
+			posts := make([]map[string]any, 0, end-offset)
+			for i := offset; i < end; i++ {
+				posts = append(posts, map[string]any{
+					"uri":       fmt.Sprintf("at://did:plc:test/app.bsky.feed.post/%d", i),
+					"cid":       fmt.Sprintf("cid%d", i),
+					"indexedAt": base.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+					"langs":     []string{},
+				})
+			}
+			resp := map[string]any{
+				"feed":  r.URL.Query().Get("feed"),
+				"posts": posts,
+			}
+			if end < total {
+				resp["cursor"] = fmt.Sprintf("%d", end)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+		}))
+	}
+
+	tests := []struct {
+		name         string
+		limit        int
+		wantPosts    int
+		wantRequests int32
+	}{
+		{name: "limit within first page", limit: 5, wantPosts: 5, wantRequests: 1},
+		{name: "limit across pages", limit: 15, wantPosts: 15, wantRequests: 2},
+		{name: "limit exceeds total", limit: 100, wantPosts: total, wantRequests: 3},
+		{name: "no limit", limit: 0, wantPosts: total, wantRequests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requestCount int32
+			ts := newServer(t, &requestCount)
+			defer ts.Close()
+
+			client, err := NewGyokaEditor(ts.URL, nil)
+			if err != nil {
+				t.Fatalf("failed to create editor: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			if err := client.Open(ctx); err != nil {
+				t.Fatalf("failed to open client: %v", err)
+			}
+
+			posts, err := client.Load(ctx, LoadParams{
+				FeedId:  "test",
+				FeedUri: types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test"),
+				Limit:   tt.limit,
+			})
+			if err != nil {
+				t.Fatalf("failed to load posts: %v", err)
+			}
+			if len(posts) != tt.wantPosts {
+				t.Errorf("posts = %d, want %d", len(posts), tt.wantPosts)
+			}
+			for i, p := range posts {
+				if want := fmt.Sprintf("cid%d", i); p.Cid != want {
+					t.Errorf("posts[%d].Cid = %s, want %s", i, p.Cid, want)
+					break
+				}
+			}
+			if got := atomic.LoadInt32(&requestCount); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestConnectionOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ClientOptionFunc
+		wantErr bool
+	}{
+		{
+			name: "custom values",
+			opts: []ClientOptionFunc{
+				WithHttpTimeout(5 * time.Second),
+				WithMaxIdleConns(50),
+				WithMaxIdleConnsPerHost(20),
+				WithIdleConnTimeout(30 * time.Second),
+			},
+		},
+		{name: "zero http timeout", opts: []ClientOptionFunc{WithHttpTimeout(0)}, wantErr: true},
+		{name: "negative max idle conns", opts: []ClientOptionFunc{WithMaxIdleConns(-1)}, wantErr: true},
+		{name: "zero max idle conns per host", opts: []ClientOptionFunc{WithMaxIdleConnsPerHost(0)}, wantErr: true},
+		{name: "negative idle conn timeout", opts: []ClientOptionFunc{WithIdleConnTimeout(-time.Second)}, wantErr: true},
+		{name: "zero queue timeout", opts: []ClientOptionFunc{WithQueueTimeout(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewGyokaEditor("http://test.example", nil, tt.opts...)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if e.option.httpTimeout != 5*time.Second {
+				t.Errorf("httpTimeout = %s, want 5s", e.option.httpTimeout)
+			}
+			if e.option.maxIdleConns != 50 {
+				t.Errorf("maxIdleConns = %d, want 50", e.option.maxIdleConns)
+			}
+			if e.option.maxIdleConnsPerHost != 20 {
+				t.Errorf("maxIdleConnsPerHost = %d, want 20", e.option.maxIdleConnsPerHost)
+			}
+			if e.option.idleConnTimeout != 30*time.Second {
+				t.Errorf("idleConnTimeout = %s, want 30s", e.option.idleConnTimeout)
+			}
+		})
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	feed := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+	// worker is not started, so queued requests never complete
+	tests := []struct {
+		name string
+		call func(ctx context.Context, e *GyokaEditor) error
+	}{
+		{
+			name: "AddContext",
+			call: func(ctx context.Context, e *GyokaEditor) error {
+				return e.AddContext(ctx, PostParams{FeedUri: feed, Did: "did:plc:test", Rkey: "test", Cid: "cid", IndexedAt: time.Now()})
+			},
+		},
+		{
+			name: "DeleteContext",
+			call: func(ctx context.Context, e *GyokaEditor) error {
+				return e.DeleteContext(ctx, DeleteParams{FeedUri: feed, Did: "did:plc:test", Rkey: "test"})
+			},
+		},
+		{
+			name: "DeleteByDidContext",
+			call: func(ctx context.Context, e *GyokaEditor) error {
+				return e.DeleteByDidContext(ctx, feed, "did:plc:test")
+			},
+		},
+		{
+			name: "TrimContext",
+			call: func(ctx context.Context, e *GyokaEditor) error {
+				return e.TrimContext(ctx, TrimParams{FeedUri: feed, Count: 10})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewGyokaEditor("http://test.example", nil)
+			if err != nil {
+				t.Fatalf("failed to create editor: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- tt.call(ctx, e) }()
+			select {
+			case err := <-done:
+				if err != context.DeadlineExceeded {
+					t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("call did not return after context deadline")
+			}
+		})
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	feed := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+	// worker is not started, so the queue is never drained
+	e, err := NewGyokaEditor("http://test.example", nil, WithQueueTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create editor: %v", err)
+	}
+	for range requestQueueSize {
+		e.requestCh <- &feedRequest{operation: "dummy", errCh: make(chan error, 1)}
+	}
+
+	before := testutil.ToFloat64(gyokaEditorQueueFull)
+	start := time.Now()
+	err = e.DeleteContext(context.Background(), DeleteParams{FeedUri: feed, Did: "did:plc:test", Rkey: "test"})
+	if !errors.Is(err, ErrEditorOverloaded) {
+		t.Fatalf("err = %v, want %v", err, ErrEditorOverloaded)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("expected to wait for the queue timeout, waited %s", elapsed)
+	}
+	if got := testutil.ToFloat64(gyokaEditorQueueFull) - before; got != 1 {
+		t.Errorf("gyoka_editor_queue_full_total increased by %v, want 1", got)
+	}
+
+	// the first add is sent directly and must not leave the batch state changed on failure
+	err = e.AddContext(context.Background(), PostParams{FeedUri: feed, Did: "did:plc:test", Rkey: "test", Cid: "cid", IndexedAt: time.Now()})
+	if !errors.Is(err, ErrEditorOverloaded) {
+		t.Fatalf("err = %v, want %v", err, ErrEditorOverloaded)
+	}
+	e.batchMu.Lock()
+	firstAdd := e.firstAddInBatch
+	e.batchMu.Unlock()
+	if !firstAdd {
+		t.Error("expected next add to be sent directly after failure")
+	}
+
+	// a context canceled before the timeout is reported as is
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.TrimContext(ctx, TrimParams{FeedUri: feed, Count: 10}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+
+	// a free slot within the timeout is used
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		<-e.requestCh
+	}()
+	if err := e.enqueue(context.Background(), &feedRequest{operation: "trim", errCh: make(chan error, 1)}); err != nil {
+		t.Errorf("expected request to be queued, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package editor
+
+import (
+	"github.com/nus25/yuge/feed/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gyokaBatchPoolSize is the total number of posts waiting in the batch pools of gyoka editors
+var gyokaBatchPoolSize = metrics.Factory.NewGauge(prometheus.GaugeOpts{
+	Name: "gyoka_batch_pool_size",
+	Help: "The number of posts waiting in the gyoka editor batch pool",
+})
+
+var gyokaBatchFlushes = metrics.Factory.NewCounter(prometheus.CounterOpts{
+	Name: "gyoka_batch_flushes_total",
+	Help: "The total number of gyoka editor batch pool flushes",
+})
+
+// gyokaEditorQueueFull counts requests rejected because the gyoka editor request queue stayed full
+var gyokaEditorQueueFull = metrics.Factory.NewCounter(prometheus.CounterOpts{
+	Name: "gyoka_editor_queue_full_total",
+	Help: "The total number of gyoka editor requests rejected because the request queue was full",
+})
+
+// mirrorEditorFailures counts mirror writes of composite editors that were not applied
+var mirrorEditorFailures = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "mirror_editor_failures_total",
+	Help: "The total number of mirror editor operations that failed or were dropped",
+}, []string{"reason"})
@@ -0,0 +1,290 @@
+package editor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/nus25/yuge/types"
+	_ "modernc.org/sqlite"
+)
+
+var _ StoreEditor = (*SQLiteEditor)(nil) //type check
+
+const (
+	SQLiteFileName = "store.db"
+)
+
+// sqliteMigrations are applied in order on Open.
+// the number of applied migrations is tracked with PRAGMA user_version.
+var sqliteMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS posts (
+		feed       TEXT    NOT NULL,
+		uri        TEXT    NOT NULL,
+		did        TEXT    NOT NULL,
+		cid        TEXT    NOT NULL,
+		indexed_at INTEGER NOT NULL,
+		PRIMARY KEY (feed, uri)
+	);
+	CREATE INDEX IF NOT EXISTS idx_posts_feed_indexed_at ON posts (feed, indexed_at);
+	CREATE INDEX IF NOT EXISTS idx_posts_feed_did ON posts (feed, did);`,
+}
+
+// SQLiteEditor persists feed posts to a local SQLite database.
+// each Add/Delete/Trim is written immediately, so posts survive a crash without Save.
+type SQLiteEditor struct {
+	logger *slog.Logger
+	mu     sync.Mutex
+	path   string
+	db     *sql.DB
+}
+
+func NewSQLiteEditor(path string, logger *slog.Logger) (*SQLiteEditor, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite path is required")
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SQLiteEditor{
+		path:   path,
+		logger: logger,
+	}, nil
+}
+
+// Open opens the database and applies pending migrations.
+// calling Open on an already opened editor is a no-op.
+func (e *SQLiteEditor) Open(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.db != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	dsn := "file:" + e.path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// sqlite allows only one writer at a time
+	db.SetMaxOpenConns(1)
+	if err := migrateSQLite(ctx, db); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to migrate sqlite database: %w", err)
+	}
+	e.logger.Info("sqlite editor opened", "path", e.path)
+	e.db = db
+	return nil
+}
+
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to update schema version: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *SQLiteEditor) conn() (*sql.DB, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.db == nil {
+		return nil, fmt.Errorf("sqlite editor is not opened")
+	}
+	return e.db, nil
+}
+
+func (e *SQLiteEditor) Load(ctx context.Context, params LoadParams) ([]types.Post, error) {
+	if params.FeedUri == "" {
+		return nil, fmt.Errorf("feed uri is required")
+	}
+	db, err := e.conn()
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT uri, cid, indexed_at FROM posts WHERE feed = ? ORDER BY indexed_at DESC"
+	args := []any{string(params.FeedUri)}
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := make([]types.Post, 0)
+	for rows.Next() {
+		var uri, cid string
+		var indexedAt int64
+		if err := rows.Scan(&uri, &cid, &indexedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, types.Post{
+			Uri:       types.PostUri(uri),
+			Cid:       cid,
+			IndexedAt: types.FormatIndexedAt(time.Unix(0, indexedAt)),
+			// Langs is not supported in local cache
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read posts: %w", err)
+	}
+	e.logger.Info("sqlite editor: posts loaded", "feed", params.FeedUri, "count", len(posts))
+	return posts, nil
+}
+
+// Save upserts params.Posts into the database.
+// posts are already written through by Add/Delete/Trim, so Save doesn't delete rows that are not in params.Posts.
+// if any post has an invalid uri or indexedAt, nothing is written and an error is returned.
+func (e *SQLiteEditor) Save(ctx context.Context, params SaveParams) error {
+	if params.FeedUri == "" {
+		return fmt.Errorf("feed uri is required")
+	}
+	type row struct {
+		uri       string
+		did       string
+		cid       string
+		indexedAt int64
+	}
+	rows := make([]row, 0, len(params.Posts))
+	for _, p := range params.Posts {
+		t, err := time.Parse(time.RFC3339Nano, p.IndexedAt)
+		if err != nil {
+			return fmt.Errorf("invalid indexedAt %q of post %s: %w", p.IndexedAt, p.Uri, err)
+		}
+		aturi, err := syntax.ParseATURI(string(p.Uri))
+		if err != nil {
+			return fmt.Errorf("invalid post uri %s: %w", p.Uri, err)
+		}
+		rows = append(rows, row{uri: string(p.Uri), did: aturi.Authority().String(), cid: p.Cid, indexedAt: t.UnixNano()})
+	}
+
+	db, err := e.conn()
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO posts (feed, uri, did, cid, indexed_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (feed, uri) DO UPDATE SET did = excluded.did, cid = excluded.cid, indexed_at = excluded.indexed_at`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, string(params.FeedUri), r.uri, r.did, r.cid, r.indexedAt); err != nil {
+			return fmt.Errorf("failed to upsert post: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	e.logger.Info("sqlite editor: posts saved", "feed", params.FeedUri, "count", len(rows))
+	return nil
+}
+
+func (e *SQLiteEditor) Add(params PostParams) error {
+	db, err := e.conn()
+	if err != nil {
+		return err
+	}
+	uri := fmt.Sprintf("at://%s/app.bsky.feed.post/%s", params.Did, params.Rkey)
+	_, err = db.Exec("INSERT OR REPLACE INTO posts (feed, uri, did, cid, indexed_at) VALUES (?, ?, ?, ?, ?)",
+		string(params.FeedUri), uri, params.Did, params.Cid, params.IndexedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to add post: %w", err)
+	}
+	return nil
+}
+
+func (e *SQLiteEditor) Delete(params DeleteParams) error {
+	db, err := e.conn()
+	if err != nil {
+		return err
+	}
+	uri := fmt.Sprintf("at://%s/app.bsky.feed.post/%s", params.Did, params.Rkey)
+	query := "DELETE FROM posts WHERE feed = ? AND uri = ?"
+	args := []any{string(params.FeedUri), uri}
+	if params.IndexedAt != nil {
+		query += " AND indexed_at = ?"
+		args = append(args, params.IndexedAt.UnixNano())
+	}
+	if _, err := db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to delete post: %w", err)
+	}
+	return nil
+}
+
+func (e *SQLiteEditor) DeleteByDid(feedUri types.FeedUri, did string) error {
+	db, err := e.conn()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("DELETE FROM posts WHERE feed = ? AND did = ?", string(feedUri), did); err != nil {
+		return fmt.Errorf("failed to delete posts by did: %w", err)
+	}
+	return nil
+}
+
+// Trim keeps only the newest params.Count posts of the feed
+func (e *SQLiteEditor) Trim(params TrimParams) error {
+	if params.Count < 0 {
+		return fmt.Errorf("trim count must be non-negative")
+	}
+	db, err := e.conn()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`DELETE FROM posts WHERE feed = ? AND rowid NOT IN (
+		SELECT rowid FROM posts WHERE feed = ? ORDER BY indexed_at DESC LIMIT ?)`,
+		string(params.FeedUri), string(params.FeedUri), params.Count)
+	if err != nil {
+		return fmt.Errorf("failed to trim posts: %w", err)
+	}
+	return nil
+}
+
+func (e *SQLiteEditor) Close(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.db == nil {
+		return nil
+	}
+	err := e.db.Close()
+	e.db = nil
+	if err != nil {
+		return fmt.Errorf("failed to close sqlite database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package editor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nus25/yuge/types"
+)
+
+func TestSQLiteEditor(t *testing.T) {
+	ctx := context.Background()
+	l := slog.Default()
+	feed := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newEditor := func(t *testing.T, path string) *SQLiteEditor {
+		t.Helper()
+		editor, err := NewSQLiteEditor(path, l)
+		if err != nil {
+			t.Fatalf("failed to create editor: %v", err)
+		}
+		if err := editor.Open(ctx); err != nil {
+			t.Fatalf("failed to open editor: %v", err)
+		}
+		return editor
+	}
+	load := func(t *testing.T, editor *SQLiteEditor, limit int) []types.Post {
+		t.Helper()
+		posts, err := editor.Load(ctx, LoadParams{FeedId: "test", FeedUri: feed, Limit: limit})
+		if err != nil {
+			t.Fatalf("failed to load posts: %v", err)
+		}
+		return posts
+	}
+
+	t.Run("basic operations", func(t *testing.T) {
+		editor := newEditor(t, filepath.Join(t.TempDir(), SQLiteFileName))
+		defer editor.Close(ctx)
+
+		for i := 0; i < 3; i++ {
+			err := editor.Add(PostParams{
+				FeedUri:   feed,
+				Did:       fmt.Sprintf("did:plc:user%d", i%2),
+				Rkey:      fmt.Sprintf("rkey%d", i),
+				Cid:       fmt.Sprintf("cid%d", i),
+				IndexedAt: base.Add(time.Duration(i) * time.Minute),
+			})
+			if err != nil {
+				t.Fatalf("failed to add post: %v", err)
+			}
+		}
+		posts := load(t, editor, 0)
+		if len(posts) != 3 {
+			t.Fatalf("expected 3 posts, got %d", len(posts))
+		}
+		if posts[0].Cid != "cid2" {
+			t.Errorf("expected newest post first, got %s", posts[0].Cid)
+		}
+
+		// 異なるindexedAtを指定した場合は削除されない
+		other := base.Add(time.Hour)
+		if err := editor.Delete(DeleteParams{FeedUri: feed, Did: "did:plc:user1", Rkey: "rkey1", IndexedAt: &other}); err != nil {
+			t.Fatalf("failed to delete post: %v", err)
+		}
+		if posts := load(t, editor, 0); len(posts) != 3 {
+			t.Errorf("expected 3 posts after delete with other indexedAt, got %d", len(posts))
+		}
+		indexedAt := base.Add(time.Minute)
+		if err := editor.Delete(DeleteParams{FeedUri: feed, Did: "did:plc:user1", Rkey: "rkey1", IndexedAt: &indexedAt}); err != nil {
+			t.Fatalf("failed to delete post: %v", err)
+		}
+		if posts := load(t, editor, 0); len(posts) != 2 {
+			t.Errorf("expected 2 posts after delete, got %d", len(posts))
+		}
+
+		if err := editor.DeleteByDid(feed, "did:plc:user0"); err != nil {
+			t.Fatalf("failed to delete posts by did: %v", err)
+		}
+		if posts := load(t, editor, 0); len(posts) != 0 {
+			t.Errorf("expected 0 posts after delete by did, got %d", len(posts))
+		}
+	})
+
+	t.Run("trim posts", func(t *testing.T) {
+		editor := newEditor(t, filepath.Join(t.TempDir(), SQLiteFileName))
+		defer editor.Close(ctx)
+
+		for i := 0; i < 5; i++ {
+			err := editor.Add(PostParams{
+				FeedUri:   feed,
+				Did:       "did:plc:test",
+				Rkey:      fmt.Sprintf("test%d", i),
+				Cid:       fmt.Sprintf("bafyreia%d", i),
+				IndexedAt: base.Add(time.Duration(i) * time.Second),
+			})
+			if err != nil {
+				t.Fatalf("failed to add post: %v", err)
+			}
+		}
+		if err := editor.Trim(TrimParams{FeedUri: feed, Count: 3}); err != nil {
+			t.Fatalf("failed to trim posts: %v", err)
+		}
+		posts := load(t, editor, 0)
+		if len(posts) != 3 {
+			t.Fatalf("expected 3 posts, got %d", len(posts))
+		}
+		if posts[2].Cid != "bafyreia2" {
+			t.Errorf("expected oldest remaining post bafyreia2, got %s", posts[2].Cid)
+		}
+	})
+
+	t.Run("persistence", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), SQLiteFileName)
+		editor := newEditor(t, path)
+		err := editor.Save(ctx, SaveParams{
+			FeedId:  "test",
+			FeedUri: feed,
+			Posts: []types.Post{
+				{Uri: "at://did:plc:test/app.bsky.feed.post/old", Cid: "old", IndexedAt: base.Format(time.RFC3339)},
+				{Uri: "at://did:plc:test/app.bsky.feed.post/new", Cid: "new", IndexedAt: base.Add(time.Hour).Format(time.RFC3339Nano)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to save posts: %v", err)
+		}
+		editor.Close(ctx)
+
+		editor2 := newEditor(t, path)
+		defer editor2.Close(ctx)
+		posts := load(t, editor2, 1)
+		if len(posts) != 1 {
+			t.Fatalf("expected 1 post, got %d", len(posts))
+		}
+		if posts[0].Cid != "new" {
+			t.Errorf("expected Cid new, got %s", posts[0].Cid)
+		}
+		if posts[0].IndexedAt != types.FormatIndexedAt(base.Add(time.Hour)) {
+			t.Errorf("unexpected IndexedAt %s", posts[0].IndexedAt)
+		}
+	})
+
+	t.Run("save upserts", func(t *testing.T) {
+		editor := newEditor(t, filepath.Join(t.TempDir(), SQLiteFileName))
+		defer editor.Close(ctx)
+		if err := editor.Add(PostParams{FeedUri: feed, Did: "did:plc:test", Rkey: "kept", Cid: "kept", IndexedAt: base}); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+		if err := editor.Add(PostParams{FeedUri: feed, Did: "did:plc:test", Rkey: "updated", Cid: "old", IndexedAt: base}); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+		// 含まれないポストは削除せず、含まれるポストは更新する
+		err := editor.Save(ctx, SaveParams{
+			FeedId:  "test",
+			FeedUri: feed,
+			Posts: []types.Post{
+				{Uri: "at://did:plc:test/app.bsky.feed.post/updated", Cid: "new", IndexedAt: base.Add(time.Hour).Format(time.RFC3339Nano)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to save posts: %v", err)
+		}
+		posts := load(t, editor, 0)
+		if len(posts) != 2 {
+			t.Fatalf("expected 2 posts, got %d", len(posts))
+		}
+		if posts[0].Cid != "new" {
+			t.Errorf("expected updated post first with Cid new, got %s", posts[0].Cid)
+		}
+
+		// 不正なポストがあれば何も書き込まずにエラーを返す
+		for _, invalid := range []types.Post{
+			{Uri: "at://did:plc:test/app.bsky.feed.post/bad", Cid: "bad", IndexedAt: "not a time"},
+			{Uri: "not a uri", Cid: "bad", IndexedAt: base.Format(time.RFC3339)},
+		} {
+			err := editor.Save(ctx, SaveParams{
+				FeedId:  "test",
+				FeedUri: feed,
+				Posts: []types.Post{
+					{Uri: "at://did:plc:test/app.bsky.feed.post/other", Cid: "other", IndexedAt: base.Format(time.RFC3339)},
+					invalid,
+				},
+			})
+			if err == nil {
+				t.Errorf("expected error for invalid post %+v", invalid)
+			}
+		}
+		if posts := load(t, editor, 0); len(posts) != 2 {
+			t.Errorf("expected posts to be unchanged after failed save, got %d", len(posts))
+		}
+	})
+
+	t.Run("not opened", func(t *testing.T) {
+		editor, err := NewSQLiteEditor(filepath.Join(t.TempDir(), SQLiteFileName), l)
+		if err != nil {
+			t.Fatalf("failed to create editor: %v", err)
+		}
+		if err := editor.Add(PostParams{FeedUri: feed, Did: "did:plc:test", Rkey: "a"}); err == nil {
+			t.Error("expected error for unopened editor")
+		}
+	})
+}
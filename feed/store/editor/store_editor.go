@@ -35,6 +35,9 @@ type DeleteParams struct {
 	FeedUri types.FeedUri
 	Did     string
 	Rkey    string
+	// IndexedAt is optional. if set, only the post indexed at this time is deleted.
+	// if nil, all posts for the uri are deleted.
+	IndexedAt *time.Time
 }
 
 type DeleteByDidParams struct {
@@ -0,0 +1,190 @@
+package editor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nus25/yuge/types"
+)
+
+var _ StoreEditor = (*StreamEditor)(nil) //type check
+
+const (
+	// DefaultStreamSubject is the default subject StreamEditor publishes to
+	DefaultStreamSubject = "yuge.feed.events"
+
+	StreamEventAdd         = "add"
+	StreamEventDelete      = "delete"
+	StreamEventDeleteByDid = "deleteByDid"
+	StreamEventTrim        = "trim"
+)
+
+// StreamEvent is a feed mutation published by StreamEditor
+type StreamEvent struct {
+	Type    string        `json:"type"`
+	FeedUri types.FeedUri `json:"feed"`
+	Post    *types.Post   `json:"post,omitempty"` // add
+	Did     string        `json:"did,omitempty"`  // delete, deleteByDid
+	Rkey    string        `json:"rkey,omitempty"` // delete
+	// IndexedAt is set when only the post indexed at this time is deleted
+	IndexedAt string `json:"indexedAt,omitempty"`
+	Count     int    `json:"count,omitempty"` // trim: number of posts to keep
+	Time      string `json:"time"`
+}
+
+// StreamPublisher publishes messages to a message bus
+type StreamPublisher interface {
+	Publish(subject string, data []byte) error
+	Close() error
+}
+
+// StreamEditor publishes feed mutations to a message bus for downstream consumers.
+// it does not store posts, so Load returns no posts. use it as a mirror of another editor
+// (see CompositeEditor) to keep the posts in a store.
+type StreamEditor struct {
+	publisher StreamPublisher
+	subject   string
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+func NewStreamEditor(publisher StreamPublisher, subject string, logger *slog.Logger) (*StreamEditor, error) {
+	if publisher == nil {
+		return nil, fmt.Errorf("publisher is nil")
+	}
+	if subject == "" {
+		subject = DefaultStreamSubject
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &StreamEditor{
+		publisher: publisher,
+		subject:   subject,
+		logger:    logger.With("component", "stream editor"),
+		now:       time.Now,
+	}, nil
+}
+
+func (e *StreamEditor) publish(evt StreamEvent) error {
+	evt.Time = types.FormatIndexedAt(e.now())
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream event: %w", err)
+	}
+	if err := e.publisher.Publish(e.subject, data); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", evt.Type, err)
+	}
+	return nil
+}
+
+func (e *StreamEditor) Open(ctx context.Context) error {
+	return nil
+}
+
+// Load returns no posts because the stream does not keep them
+func (e *StreamEditor) Load(ctx context.Context, params LoadParams) ([]types.Post, error) {
+	return []types.Post{}, nil
+}
+
+// Save does nothing. posts are published when they are added
+func (e *StreamEditor) Save(ctx context.Context, params SaveParams) error {
+	return nil
+}
+
+func (e *StreamEditor) Add(params PostParams) error {
+	return e.publish(StreamEvent{
+		Type:    StreamEventAdd,
+		FeedUri: params.FeedUri,
+		Post: &types.Post{
+			Feed:      params.FeedUri,
+			Uri:       types.PostUri(fmt.Sprintf("at://%s/app.bsky.feed.post/%s", params.Did, params.Rkey)),
+			Cid:       params.Cid,
+			IndexedAt: types.FormatIndexedAt(params.IndexedAt),
+			Langs:     params.Langs,
+		},
+	})
+}
+
+func (e *StreamEditor) Delete(params DeleteParams) error {
+	evt := StreamEvent{
+		Type:    StreamEventDelete,
+		FeedUri: params.FeedUri,
+		Did:     params.Did,
+		Rkey:    params.Rkey,
+	}
+	if params.IndexedAt != nil {
+		evt.IndexedAt = types.FormatIndexedAt(*params.IndexedAt)
+	}
+	return e.publish(evt)
+}
+
+func (e *StreamEditor) DeleteByDid(feedUri types.FeedUri, did string) error {
+	return e.publish(StreamEvent{
+		Type:    StreamEventDeleteByDid,
+		FeedUri: feedUri,
+		Did:     did,
+	})
+}
+
+func (e *StreamEditor) Trim(params TrimParams) error {
+	return e.publish(StreamEvent{
+		Type:    StreamEventTrim,
+		FeedUri: params.FeedUri,
+		Count:   params.Count,
+	})
+}
+
+func (e *StreamEditor) Close(ctx context.Context) error {
+	return e.publisher.Close()
+}
+
+// NATSPublisher publishes messages to NATS.
+// the connection is retried in background, and messages published while disconnected
+// are buffered by the client up to its reconnect buffer size.
+type NATSPublisher struct {
+	conn *nats.Conn
+}
+
+func NewNATSPublisher(url string, logger *slog.Logger) (*NATSPublisher, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = logger.With("component", "nats publisher")
+	conn, err := nats.Connect(url,
+		nats.Name("yuge"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2*time.Second),
+		nats.ConnectHandler(func(c *nats.Conn) {
+			logger.Info("connected to nats", "url", c.ConnectedUrlRedacted())
+		}),
+		nats.DisconnectErrHandler(func(c *nats.Conn, err error) {
+			logger.Warn("disconnected from nats", "error", err)
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			logger.Info("reconnected to nats", "url", c.ConnectedUrlRedacted())
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	return &NATSPublisher{conn: conn}, nil
+}
+
+func (p *NATSPublisher) Publish(subject string, data []byte) error {
+	return p.conn.Publish(subject, data)
+}
+
+// Close flushes the buffered messages and closes the connection
+func (p *NATSPublisher) Close() error {
+	defer p.conn.Close()
+	if !p.conn.IsConnected() {
+		return nil
+	}
+	return p.conn.FlushTimeout(5 * time.Second)
+}
@@ -0,0 +1,135 @@
+package editor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nus25/yuge/types"
+)
+
+type fakePublisher struct {
+	subjects []string
+	messages [][]byte
+	err      error
+	closed   bool
+}
+
+func (p *fakePublisher) Publish(subject string, data []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.subjects = append(p.subjects, subject)
+	p.messages = append(p.messages, data)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestStreamEditor(t *testing.T) {
+	feed := types.FeedUri("at://did:plc:test/app.bsky.feed.generator/test")
+	indexedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		call func(e *StreamEditor) error
+		want StreamEvent
+	}{
+		{
+			name: "add",
+			call: func(e *StreamEditor) error {
+				return e.Add(PostParams{FeedUri: feed, Did: "did:plc:aaa", Rkey: "rkey", Cid: "cid", IndexedAt: indexedAt, Langs: []string{"ja"}})
+			},
+			want: StreamEvent{
+				Type:    StreamEventAdd,
+				FeedUri: feed,
+				Post: &types.Post{
+					Feed:      feed,
+					Uri:       "at://did:plc:aaa/app.bsky.feed.post/rkey",
+					Cid:       "cid",
+					IndexedAt: types.FormatIndexedAt(indexedAt),
+					Langs:     []string{"ja"},
+				},
+			},
+		},
+		{
+			name: "delete",
+			call: func(e *StreamEditor) error {
+				return e.Delete(DeleteParams{FeedUri: feed, Did: "did:plc:aaa", Rkey: "rkey"})
+			},
+			want: StreamEvent{Type: StreamEventDelete, FeedUri: feed, Did: "did:plc:aaa", Rkey: "rkey"},
+		},
+		{
+			name: "delete version",
+			call: func(e *StreamEditor) error {
+				return e.Delete(DeleteParams{FeedUri: feed, Did: "did:plc:aaa", Rkey: "rkey", IndexedAt: &indexedAt})
+			},
+			want: StreamEvent{Type: StreamEventDelete, FeedUri: feed, Did: "did:plc:aaa", Rkey: "rkey", IndexedAt: types.FormatIndexedAt(indexedAt)},
+		},
+		{
+			name: "delete by did",
+			call: func(e *StreamEditor) error { return e.DeleteByDid(feed, "did:plc:aaa") },
+			want: StreamEvent{Type: StreamEventDeleteByDid, FeedUri: feed, Did: "did:plc:aaa"},
+		},
+		{
+			name: "trim",
+			call: func(e *StreamEditor) error { return e.Trim(TrimParams{FeedUri: feed, Count: 10}) },
+			want: StreamEvent{Type: StreamEventTrim, FeedUri: feed, Count: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &fakePublisher{}
+			e, err := NewStreamEditor(pub, "", slog.Default())
+			if err != nil {
+				t.Fatalf("failed to create editor: %v", err)
+			}
+			e.now = func() time.Time { return now }
+			if err := tt.call(e); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(pub.messages) != 1 || pub.subjects[0] != DefaultStreamSubject {
+				t.Fatalf("expected 1 message to %s, got %v", DefaultStreamSubject, pub.subjects)
+			}
+			var got StreamEvent
+			if err := json.Unmarshal(pub.messages[0], &got); err != nil {
+				t.Fatalf("failed to unmarshal event: %v", err)
+			}
+			tt.want.Time = types.FormatIndexedAt(now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("event = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamEditor_PublishError(t *testing.T) {
+	pubErr := errors.New("not connected")
+	pub := &fakePublisher{err: pubErr}
+	e, err := NewStreamEditor(pub, "custom.subject", nil)
+	if err != nil {
+		t.Fatalf("failed to create editor: %v", err)
+	}
+	if err := e.Trim(TrimParams{FeedUri: "at://did:plc:test/app.bsky.feed.generator/test", Count: 1}); !errors.Is(err, pubErr) {
+		t.Errorf("err = %v, want %v", err, pubErr)
+	}
+	posts, err := e.Load(context.Background(), LoadParams{FeedId: "test"})
+	if err != nil || len(posts) != 0 {
+		t.Errorf("Load() = %v, %v, want no posts", posts, err)
+	}
+	if err := e.Close(context.Background()); err != nil || !pub.closed {
+		t.Errorf("expected publisher to be closed, err = %v", err)
+	}
+	if _, err := NewStreamEditor(nil, "", nil); err == nil {
+		t.Error("expected error for nil publisher")
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/nus25/yuge/feed/config/store"
 	cfgTypes "github.com/nus25/yuge/feed/config/types"
@@ -17,7 +19,17 @@ import (
 
 var _ Store = (*StoreImpl)(nil) // Type check
 
-const fitstCapacity = 1500
+// firstCapacity is the initial capacity of posts when trimAt is not configured
+const firstCapacity = 1500
+
+// initialCapacity returns the initial capacity of posts.
+// posts grow up to trimAt+1 before trimming, so some headroom is added.
+func initialCapacity(cfg cfgTypes.StoreConfig) int {
+	if cfg == nil || cfg.GetTrimAt() <= 0 {
+		return firstCapacity
+	}
+	return cfg.GetTrimAt() + cfg.GetTrimAt()/10 + 1
+}
 
 // Store is an interface for managing feed posts
 type Store interface {
@@ -30,8 +42,15 @@ type Store interface {
 	// Add a new post
 	Add(did string, rkey string, cid string, t time.Time, langs []string) error
 
-	// Delete specified post
-	Delete(did string, rkey string) error
+	// Update cid and indexedAt of an existing post, e.g. when the post is edited
+	// Does nothing if the post is not stored
+	Update(did string, rkey string, cid string, t time.Time, langs []string) error
+
+	// Delete specified post. deleted is false if the post is not in the store, which is not an error
+	Delete(did string, rkey string) (deleted bool, err error)
+
+	// Delete specified post only if it was indexed at indexedAt. deleted is false if no post with indexedAt is in the store
+	DeleteVersion(did string, rkey string, indexedAt time.Time) (deleted bool, err error)
 
 	// Delete posts by DID
 	DeleteByDid(did string) (deleted []types.Post, err error)
@@ -42,14 +61,32 @@ type Store interface {
 
 	// Get specified post
 	// Returns nil if not found
+	// The returned post is a snapshot copy and is not affected by later changes to the store
 	GetPost(did string, rkey string) (post *types.Post, exists bool)
 
 	// Returns post count
 	PostCount() int
 
+	// Returns the number of distinct author DIDs in the store
+	UniqueAuthorCount() int
+
+	// Returns the number of posts grouped by author DID
+	AuthorCounts() map[string]int
+
+	// Returns store internals for debugging
+	Stats() StoreStats
+
 	// Trim posts to specified count
 	Trim(remain int) error
 
+	// Rebuild post index from stored posts
+	// Returns index size before and after rebuilding
+	RebuildIndex() (before int, after int)
+
+	// Validate stored posts
+	// Returns a list of problems such as duplicate or invalid URIs
+	Validate() []string
+
 	// Safely shutdown store
 	Shutdown(ctx context.Context) error
 }
@@ -60,6 +97,7 @@ type StoreImpl struct {
 	feedUri   types.FeedUri
 	posts     []types.Post
 	postIndex map[types.PostUri]struct{} // Index for faster searching
+	postBytes int                        // estimated size of posts, used for trimBytes
 	editor    editor.StoreEditor
 	mu        sync.RWMutex
 	config    cfgTypes.StoreConfig
@@ -104,7 +142,7 @@ func NewStore(ctx context.Context, options StoreOptions) (Store, error) {
 		feedId:    options.FeedId,
 		feedUri:   options.FeedUri,
 		editor:    e,
-		posts:     make([]types.Post, 0, fitstCapacity),
+		posts:     make([]types.Post, 0, initialCapacity(cfg)),
 		postIndex: make(map[types.PostUri]struct{}),
 		config:    cfg,
 		logger:    l,
@@ -149,7 +187,7 @@ func (s *StoreImpl) Load(ctx context.Context) error {
 	if err := s.feedUri.Validate(); err != nil {
 		return fmt.Errorf("invalid feed uri: %w", err)
 	}
-	s.posts = make([]types.Post, 0, fitstCapacity)
+	s.posts = make([]types.Post, 0, initialCapacity(s.config))
 	s.postIndex = make(map[types.PostUri]struct{})
 
 	posts, err := s.editor.Load(ctx, editor.LoadParams{
@@ -165,11 +203,20 @@ func (s *StoreImpl) Load(ctx context.Context) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
+		// エディタごとに異なる形式をそろえて文字列比較で並べられるようにする
+		for i := range posts {
+			posts[i].IndexedAt = types.NormalizeIndexedAt(posts[i].IndexedAt)
+		}
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].IndexedAt > posts[j].IndexedAt
+		})
 		s.posts = posts
+		s.postBytes = 0
 		for _, post := range posts {
 			s.postIndex[post.Uri] = struct{}{}
+			s.postBytes += estimatePostSize(post)
 		}
-		s.logger.Info("loaded posts", "count", len(posts))
+		s.logger.Info("loaded posts", "count", len(posts), "bytes", s.postBytes)
 		return nil
 	}
 }
@@ -225,14 +272,15 @@ func (s *StoreImpl) Add(did string, rkey string, cid string, t time.Time, langs
 	post := types.Post{
 		Uri:       types.PostUri(uri),
 		Cid:       cid,
-		IndexedAt: t.UTC().Format(time.RFC3339Nano),
-		//Language is not supported in cache
+		IndexedAt: types.FormatIndexedAt(t),
+		Langs:     slices.Clone(langs),
 	}
 
 	s.posts = append(s.posts, post)
 	s.postIndex[post.Uri] = struct{}{}
+	s.postBytes += estimatePostSize(post)
 
-	if s.editor != nil {
+	if s.syncEnabled() {
 		if err := s.editor.Add(editor.PostParams{
 			FeedUri:   s.feedUri,
 			Did:       did,
@@ -245,20 +293,72 @@ func (s *StoreImpl) Add(did string, rkey string, cid string, t time.Time, langs
 		}
 	}
 
-	// Check if trim needed
-	if s.config != nil && s.config.GetTrimAt() > 0 && len(s.posts) > s.config.GetTrimAt() {
-		if err := s.trim(s.config.GetTrimRemain()); err != nil {
+	return s.trimIfNeeded()
+}
+
+func (s *StoreImpl) Update(did string, rkey string, cid string, t time.Time, langs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uri := types.PostUri(fmt.Sprintf("at://%s/app.bsky.feed.post/%s", did, rkey))
+	if _, exists := s.postIndex[uri]; !exists {
+		return nil
+	}
+	var oldIndexedAt *time.Time
+	for i, post := range s.posts {
+		if post.Uri == uri {
+			if old, err := time.Parse(time.RFC3339Nano, post.IndexedAt); err == nil {
+				oldIndexedAt = &old
+			}
+			s.postBytes -= estimatePostSize(post)
+			post.Cid = cid
+			post.IndexedAt = types.FormatIndexedAt(t)
+			post.Langs = slices.Clone(langs)
+			s.postBytes += estimatePostSize(post)
+			// indexedAtが変わるので元の位置から外し、Addと同じく新しく追加されたポストとして末尾に置き直す
+			s.posts = append(slices.Delete(s.posts, i, i+1), post)
+			break
+		}
+	}
+
+	if s.syncEnabled() {
+		// エディタによってはindexedAtごとにポストを保持するので、古い版を削除してから追加し直す
+		if err := s.editor.Delete(editor.DeleteParams{
+			FeedUri:   s.feedUri,
+			Did:       did,
+			Rkey:      rkey,
+			IndexedAt: oldIndexedAt,
+		}); err != nil {
+			return err
+		}
+		if err := s.editor.Add(editor.PostParams{
+			FeedUri:   s.feedUri,
+			Did:       did,
+			Rkey:      rkey,
+			Cid:       cid,
+			IndexedAt: t,
+			Langs:     langs,
+		}); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return s.trimIfNeeded()
+}
+
+func (s *StoreImpl) Delete(did string, rkey string) (deleted bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deletePost(did, rkey, nil)
 }
 
-func (s *StoreImpl) Delete(did string, rkey string) error {
+// DeleteVersion deletes the post only if it was indexed at indexedAt.
+// the request is passed to the editor even if the post in memory has another indexedAt,
+// because the editor may hold other versions of the post.
+func (s *StoreImpl) DeleteVersion(did string, rkey string, indexedAt time.Time) (deleted bool, err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.deletePost(did, rkey)
+	return s.deletePost(did, rkey, &indexedAt)
 }
 
 func (s *StoreImpl) DeleteByDid(did string) (deleted []types.Post, err error) {
@@ -271,13 +371,14 @@ func (s *StoreImpl) DeleteByDid(did string) (deleted []types.Post, err error) {
 		if strings.HasPrefix(string(post.Uri), uriPrefix) {
 			deleted = append(deleted, post)
 			delete(s.postIndex, post.Uri)
+			s.postBytes -= estimatePostSize(post)
 		} else {
 			remainingPosts = append(remainingPosts, post)
 		}
 	}
 	s.posts = remainingPosts
 
-	if s.editor != nil {
+	if s.syncEnabled() {
 		err := s.editor.DeleteByDid(s.feedUri, did)
 		if err != nil {
 			return nil, err
@@ -287,39 +388,53 @@ func (s *StoreImpl) DeleteByDid(did string) (deleted []types.Post, err error) {
 	return deleted, nil
 }
 
-func (s *StoreImpl) deletePost(did string, rkey string) error {
+// deletePost returns whether the post was removed from memory
+func (s *StoreImpl) deletePost(did string, rkey string, indexedAt *time.Time) (deleted bool, err error) {
 	uri := fmt.Sprintf("at://%s/app.bsky.feed.post/%s", did, rkey)
 	if _, exists := s.postIndex[types.PostUri(uri)]; !exists {
-		return nil
+		return false, nil
 	}
 
 	for i, post := range s.posts {
 		if post.Uri == types.PostUri(uri) {
+			if indexedAt != nil && !indexedAtEqual(post.IndexedAt, *indexedAt) {
+				break
+			}
 			s.posts = append(s.posts[:i], s.posts[i+1:]...)
 			delete(s.postIndex, post.Uri)
+			s.postBytes -= estimatePostSize(post)
+			deleted = true
 			break
 		}
 	}
-	if s.editor != nil {
-		return s.editor.Delete(editor.DeleteParams{
-			FeedUri: s.feedUri,
-			Did:     did,
-			Rkey:    rkey,
-		})
+	if s.syncEnabled() {
+		if err := s.editor.Delete(editor.DeleteParams{
+			FeedUri:   s.feedUri,
+			Did:       did,
+			Rkey:      rkey,
+			IndexedAt: indexedAt,
+		}); err != nil {
+			return deleted, err
+		}
 	}
-	return nil
+	return deleted, nil
 }
 
+// GetPost returns a copy of the post made under the read lock.
+// the copy does not share memory with s.posts, so it stays valid while the store is trimmed or updated.
 func (s *StoreImpl) GetPost(did string, rkey string) (post *types.Post, exists bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	uri := types.PostUri(fmt.Sprintf("at://%s/app.bsky.feed.post/%s", did, rkey))
-	if _, exists = s.postIndex[uri]; exists {
-		for _, post := range s.posts {
-			if post.Uri == uri {
-				return &post, true
-			}
+	if _, exists = s.postIndex[uri]; !exists {
+		return nil, false
+	}
+	for i := range s.posts {
+		if s.posts[i].Uri == uri {
+			p := s.posts[i]
+			p.Langs = slices.Clone(p.Langs)
+			return &p, true
 		}
 	}
 	return nil, false
@@ -347,14 +462,17 @@ func (s *StoreImpl) trim(remain int) error {
 
 	// Recreate index with minimum required size
 	newIndex := make(map[types.PostUri]struct{}, remain)
+	newBytes := 0
 	for _, post := range newPosts {
 		newIndex[post.Uri] = struct{}{}
+		newBytes += estimatePostSize(post)
 	}
 
 	s.posts = newPosts
 	s.postIndex = newIndex
+	s.postBytes = newBytes
 
-	if s.editor != nil {
+	if s.syncEnabled() {
 		return s.editor.Trim(editor.TrimParams{
 			FeedUri: s.feedUri,
 			Count:   remain,
@@ -363,8 +481,198 @@ func (s *StoreImpl) trim(remain int) error {
 	return nil
 }
 
+// trimBytesRemainRatio is the ratio of trimBytes kept after trimming by size,
+// so that trimming does not run on every add once the limit is reached
+const trimBytesRemainRatio = 0.9
+
+// trimIfNeeded trims posts by count (trimAt) and then by estimated size (trimBytes)
+func (s *StoreImpl) trimIfNeeded() error {
+	if s.config == nil {
+		return nil
+	}
+	if s.config.GetTrimAt() > 0 && len(s.posts) > s.config.GetTrimAt() {
+		if err := s.trim(s.config.GetTrimRemain()); err != nil {
+			return err
+		}
+	}
+	if limit := s.config.GetTrimBytes(); limit > 0 && s.postBytes > limit {
+		s.logger.Info("posts exceed trimBytes", "bytes", s.postBytes, "trimBytes", limit)
+		if err := s.trim(s.remainWithin(int(float64(limit) * trimBytesRemainRatio))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remainWithin sorts posts from newest and returns how many of them fit in limit bytes
+func (s *StoreImpl) remainWithin(limit int) int {
+	sort.Slice(s.posts, func(i, j int) bool {
+		return s.posts[i].IndexedAt > s.posts[j].IndexedAt
+	})
+	total := 0
+	for i, post := range s.posts {
+		total += estimatePostSize(post)
+		if total > limit {
+			return i
+		}
+	}
+	return len(s.posts)
+}
+
+// estimatePostSize estimates the serialized size of a post from its uri, cid and indexedAt
+func estimatePostSize(post types.Post) int {
+	size := len(post.Uri) + len(post.Cid) + len(post.IndexedAt)
+	for _, l := range post.Langs {
+		size += len(l)
+	}
+	return size
+}
+
+func (s *StoreImpl) RebuildIndex() (before int, after int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before = len(s.postIndex)
+	s.logger.Info("rebuilding post index", "index", before, "posts", len(s.posts))
+
+	newIndex := make(map[types.PostUri]struct{}, len(s.posts))
+	for _, post := range s.posts {
+		newIndex[post.Uri] = struct{}{}
+	}
+	s.postIndex = newIndex
+	after = len(s.postIndex)
+
+	s.logger.Info("post index rebuilt", "before", before, "after", after, "posts", len(s.posts))
+	return before, after
+}
+
+func (s *StoreImpl) Validate() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	problems := make([]string, 0)
+	seen := make(map[types.PostUri]int, len(s.posts))
+	for i, post := range s.posts {
+		if err := post.Uri.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid uri at index %d: %s: %v", i, post.Uri, err))
+		}
+		if first, exists := seen[post.Uri]; exists {
+			problems = append(problems, fmt.Sprintf("duplicate uri at index %d (first at %d): %s", i, first, post.Uri))
+			continue
+		}
+		seen[post.Uri] = i
+	}
+	if len(problems) > 0 {
+		s.logger.Warn("store validation found problems", "count", len(problems), "posts", len(s.posts))
+	}
+	return problems
+}
+
 func (s *StoreImpl) PostCount() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return len(s.posts)
 }
+
+// UniqueAuthorCount counts distinct DIDs from the index keys.
+// it is computed on each call, so call it only when needed (e.g. metrics).
+func (s *StoreImpl) UniqueAuthorCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	authors := make(map[string]struct{})
+	for uri := range s.postIndex {
+		authors[didFromUri(uri)] = struct{}{}
+	}
+	return len(authors)
+}
+
+// AuthorCounts groups posts by author DID parsed from the post uri
+func (s *StoreImpl) AuthorCounts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, post := range s.posts {
+		counts[didFromUri(post.Uri)]++
+	}
+	return counts
+}
+
+// StoreStats describes the internal state of a store for debugging trim and memory issues
+type StoreStats struct {
+	PostCount int `json:"postCount"`
+	IndexSize int `json:"indexSize"`
+	// Capacity is the capacity of the post slice
+	Capacity int `json:"capacity"`
+	// OldestIndexedAt and NewestIndexedAt are nil if the store has no posts with a valid indexedAt
+	OldestIndexedAt *time.Time `json:"oldestIndexedAt,omitempty"`
+	NewestIndexedAt *time.Time `json:"newestIndexedAt,omitempty"`
+	// ApproxMemoryBytes is a rough estimate of the memory used by posts and the index
+	ApproxMemoryBytes int64 `json:"approxMemoryBytes"`
+	// PostBytes is the estimated size of posts compared with trimBytes
+	PostBytes int `json:"postBytes"`
+}
+
+// approximate overhead of a map entry (hash bucket slot, tophash, etc.)
+const mapEntryOverhead = 16
+
+// Stats scans the posts to find the oldest and newest indexedAt and estimates memory usage.
+// it takes O(n), so call it only when needed.
+func (s *StoreImpl) Stats() StoreStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := StoreStats{
+		PostCount: len(s.posts),
+		IndexSize: len(s.postIndex),
+		Capacity:  cap(s.posts),
+		PostBytes: s.postBytes,
+	}
+
+	var oldest, newest time.Time
+	mem := int64(cap(s.posts)) * int64(unsafe.Sizeof(types.Post{}))
+	for _, post := range s.posts {
+		mem += int64(len(post.Feed) + len(post.Uri) + len(post.Cid) + len(post.IndexedAt))
+		mem += int64(cap(post.Langs)) * int64(unsafe.Sizeof(""))
+		for _, l := range post.Langs {
+			mem += int64(len(l))
+		}
+		t, err := time.Parse(time.RFC3339Nano, post.IndexedAt)
+		if err != nil {
+			continue
+		}
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+		if newest.IsZero() || t.After(newest) {
+			newest = t
+		}
+	}
+	// index keys share the uri strings with posts
+	mem += int64(len(s.postIndex)) * int64(unsafe.Sizeof(types.PostUri(""))+mapEntryOverhead)
+	stats.ApproxMemoryBytes = mem
+
+	if !oldest.IsZero() {
+		stats.OldestIndexedAt = &oldest
+		stats.NewestIndexedAt = &newest
+	}
+	return stats
+}
+
+// syncEnabled reports whether changes should be synced to the editor.
+// with syncDisabled, posts are still loaded from and saved to the editor.
+func (s *StoreImpl) syncEnabled() bool {
+	return s.editor != nil && (s.config == nil || !s.config.GetSyncDisabled())
+}
+
+// indexedAtEqual reports whether the RFC3339 timestamp s is the same instant as t
+func indexedAtEqual(s string, t time.Time) bool {
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return false
+	}
+	return parsed.Equal(t)
+}
+
+// didFromUri returns the authority part of "at://did/collection/rkey"
+func didFromUri(uri types.PostUri) string {
+	did := strings.TrimPrefix(string(uri), "at://")
+	did, _, _ = strings.Cut(did, "/")
+	return did
+}
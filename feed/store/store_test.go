@@ -3,19 +3,25 @@ package store
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"log/slog"
+	"maps"
 
+	"github.com/nus25/yuge/feed/config/store"
+	cfgTypes "github.com/nus25/yuge/feed/config/types"
 	"github.com/nus25/yuge/feed/store/editor"
 	"github.com/nus25/yuge/types"
 )
 
 // Mocks
 type MockEditor struct {
-	posts []types.Post
+	posts      []types.Post
+	lastDelete editor.DeleteParams
 }
 
 func (m *MockEditor) Open(ctx context.Context) error {
@@ -36,12 +42,13 @@ func (m *MockEditor) Add(params editor.PostParams) error {
 		Feed:      params.FeedUri,
 		Uri:       types.PostUri("at://" + params.Did + "/app.bsky.feed.post/" + params.Rkey),
 		Cid:       params.Cid,
-		IndexedAt: params.IndexedAt.Format(time.RFC3339),
+		IndexedAt: types.FormatIndexedAt(params.IndexedAt),
 	})
 	return nil
 }
 
 func (m *MockEditor) Delete(params editor.DeleteParams) error {
+	m.lastDelete = params
 	for i, p := range m.posts {
 		if string(p.Uri) == "at://"+params.Did+"/app.bsky.feed.post/"+params.Rkey {
 			m.posts = append(m.posts[:i], m.posts[i+1:]...)
@@ -105,17 +112,48 @@ func TestStore(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to add post: %v", err)
 		}
+		post, exists := s.GetPost(did, rkey)
+		if !exists {
+			t.Fatal("post should exist after add")
+		}
+		if !reflect.DeepEqual(post.Langs, langs) {
+			t.Errorf("expected langs %v, got %v", langs, post.Langs)
+		}
+		langs[0] = "fr"
+		if post, _ := s.GetPost(did, rkey); post.Langs[0] != "jp" {
+			t.Errorf("langs in store should not be affected by the caller, got %v", post.Langs)
+		}
+
+		// Test Update
+		if err := s.Update(did, rkey, cid, now, []string{"de"}); err != nil {
+			t.Fatalf("failed to update post: %v", err)
+		}
+		if post, _ := s.GetPost(did, rkey); !reflect.DeepEqual(post.Langs, []string{"de"}) {
+			t.Errorf("expected langs [de] after update, got %v", post.Langs)
+		}
 
 		// Test Delete
-		err = s.Delete(did, rkey)
+		deleted, err := s.Delete(did, rkey)
 		if err != nil {
 			t.Fatalf("failed to delete post: %v", err)
 		}
+		if !deleted {
+			t.Error("expected deleted to be true")
+		}
 
-		_, exists := s.GetPost(did, rkey)
+		_, exists = s.GetPost(did, rkey)
 		if exists {
 			t.Error("post should not exist after deletion")
 		}
+
+		// 存在しないポストの削除はエラーにならずdeletedがfalseになる
+		deleted, err = s.Delete(did, rkey)
+		if err != nil {
+			t.Fatalf("deleting absent post should not fail: %v", err)
+		}
+		if deleted {
+			t.Error("expected deleted to be false for absent post")
+		}
 	})
 
 	t.Run("load with no feed uri", func(t *testing.T) {
@@ -348,3 +386,520 @@ func TestDeleteByDid(t *testing.T) {
 		}
 	})
 }
+
+func TestRebuildIndex(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  &MockEditor{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := s.Add("did:plc:1234", fmt.Sprintf("rkey%d", i), "cid", time.Now(), nil); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+	}
+
+	// break the index
+	impl := s.(*StoreImpl)
+	delete(impl.postIndex, types.PostUri("at://did:plc:1234/app.bsky.feed.post/rkey0"))
+	impl.postIndex[types.PostUri("at://did:plc:1234/app.bsky.feed.post/ghost")] = struct{}{}
+	delete(impl.postIndex, types.PostUri("at://did:plc:1234/app.bsky.feed.post/rkey1"))
+
+	before, after := s.RebuildIndex()
+	if before != 2 {
+		t.Errorf("before = %d, want 2", before)
+	}
+	if after != 3 {
+		t.Errorf("after = %d, want 3", after)
+	}
+	if _, exists := s.GetPost("did:plc:1234", "rkey0"); !exists {
+		t.Error("expected rkey0 to be found after rebuild")
+	}
+	if _, exists := s.GetPost("did:plc:1234", "ghost"); exists {
+		t.Error("expected ghost entry to be removed after rebuild")
+	}
+}
+
+func TestUniqueAuthorCount(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  &MockEditor{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if got := s.UniqueAuthorCount(); got != 0 {
+		t.Errorf("UniqueAuthorCount() = %d, want 0", got)
+	}
+	for i, did := range []string{"did:plc:aaa", "did:plc:bbb", "did:plc:aaa", "did:web:example.com"} {
+		if err := s.Add(did, fmt.Sprintf("rkey%d", i), "cid", time.Now(), nil); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+	}
+	if got := s.UniqueAuthorCount(); got != 3 {
+		t.Errorf("UniqueAuthorCount() = %d, want 3", got)
+	}
+	if _, err := s.DeleteByDid("did:plc:aaa"); err != nil {
+		t.Fatalf("failed to delete posts: %v", err)
+	}
+	if got := s.UniqueAuthorCount(); got != 2 {
+		t.Errorf("UniqueAuthorCount() after delete = %d, want 2", got)
+	}
+}
+
+func TestInitialCapacity(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *store.StoreConfigImpl
+		want   int
+	}{
+		{name: "nil config", config: nil, want: firstCapacity},
+		{name: "no trim", config: &store.StoreConfigImpl{}, want: firstCapacity},
+		{name: "trimAt 100", config: &store.StoreConfigImpl{TrimAt: 100, TrimRemain: 50}, want: 111},
+		{name: "trimAt 5000", config: &store.StoreConfigImpl{TrimAt: 5000, TrimRemain: 4000}, want: 5501},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg cfgTypes.StoreConfig
+			if tt.config != nil {
+				cfg = tt.config
+			}
+			if got := initialCapacity(cfg); got != tt.want {
+				t.Errorf("initialCapacity() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteVersion(t *testing.T) {
+	ctx := context.Background()
+	e := &MockEditor{}
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  e,
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	indexedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.Add("did:plc:aaa", "rkey", "cid", indexedAt, nil); err != nil {
+		t.Fatalf("failed to add post: %v", err)
+	}
+
+	// 異なるindexedAtの場合はメモリ上のポストは残るが、エディタには削除要求を送る
+	other := indexedAt.Add(time.Hour)
+	deleted, err := s.DeleteVersion("did:plc:aaa", "rkey", other)
+	if err != nil {
+		t.Fatalf("failed to delete post: %v", err)
+	}
+	if deleted {
+		t.Error("expected deleted to be false for other indexedAt")
+	}
+	if _, exists := s.GetPost("did:plc:aaa", "rkey"); !exists {
+		t.Error("post with other indexedAt should not be deleted")
+	}
+	if e.lastDelete.IndexedAt == nil || !e.lastDelete.IndexedAt.Equal(other) {
+		t.Errorf("editor received indexedAt %v, want %v", e.lastDelete.IndexedAt, other)
+	}
+
+	if deleted, err := s.DeleteVersion("did:plc:aaa", "rkey", indexedAt); err != nil || !deleted {
+		t.Fatalf("failed to delete post: deleted=%v, err=%v", deleted, err)
+	}
+	if _, exists := s.GetPost("did:plc:aaa", "rkey"); exists {
+		t.Error("post should be deleted")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	e := &MockEditor{}
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  e,
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	indexedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.Add("did:plc:aaa", "rkey", "cid1", indexedAt, nil); err != nil {
+		t.Fatalf("failed to add post: %v", err)
+	}
+
+	// 保存されていないポストは追加されない
+	if err := s.Update("did:plc:aaa", "other", "cid", indexedAt, nil); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+	if s.PostCount() != 1 || len(e.posts) != 1 {
+		t.Fatalf("unknown post should be ignored, store %d editor %d", s.PostCount(), len(e.posts))
+	}
+
+	updatedAt := indexedAt.Add(time.Hour)
+	if err := s.Update("did:plc:aaa", "rkey", "cid2", updatedAt, []string{"ja"}); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+	post, exists := s.GetPost("did:plc:aaa", "rkey")
+	if !exists {
+		t.Fatal("updated post not found")
+	}
+	if post.Cid != "cid2" || post.IndexedAt != types.FormatIndexedAt(updatedAt) {
+		t.Errorf("unexpected post after update: %+v", post)
+	}
+	if s.PostCount() != 1 {
+		t.Errorf("expected 1 post, got %d", s.PostCount())
+	}
+	// エディタでは古い版が削除され、新しい版が追加される
+	if e.lastDelete.IndexedAt == nil || !e.lastDelete.IndexedAt.Equal(indexedAt) {
+		t.Errorf("editor received delete indexedAt %v, want %v", e.lastDelete.IndexedAt, indexedAt)
+	}
+	if len(e.posts) != 1 || e.posts[0].Cid != "cid2" {
+		t.Errorf("unexpected editor posts: %+v", e.posts)
+	}
+
+	// 更新したポストは新しいindexedAtの位置に移動する
+	if err := s.Add("did:plc:bbb", "rkey", "cid3", indexedAt.Add(time.Minute), nil); err != nil {
+		t.Fatalf("failed to add post: %v", err)
+	}
+	if err := s.Update("did:plc:bbb", "rkey", "cid4", updatedAt.Add(time.Minute), nil); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+	if err := s.Update("did:plc:aaa", "rkey", "cid5", updatedAt.Add(2*time.Minute), nil); err != nil {
+		t.Fatalf("failed to update post: %v", err)
+	}
+	posts := s.List("")
+	if len(posts) != 2 || posts[0].Cid != "cid4" || posts[1].Cid != "cid5" {
+		t.Errorf("updated posts should be ordered by new indexedAt: %+v", posts)
+	}
+	// 古い版ではなく新しく更新されたポストが残る
+	if err := s.Trim(1); err != nil {
+		t.Fatalf("failed to trim: %v", err)
+	}
+	if post, exists := s.GetPost("did:plc:aaa", "rkey"); !exists || post.Cid != "cid5" {
+		t.Errorf("trim should keep the most recently updated post, got %+v", post)
+	}
+}
+
+func TestSyncDisabled(t *testing.T) {
+	ctx := context.Background()
+	e := &MockEditor{posts: []types.Post{
+		{Uri: "at://did:plc:aaa/app.bsky.feed.post/loaded", Cid: "cid", IndexedAt: "2025-01-01T00:00:00Z"},
+	}}
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  e,
+		Config:  &store.StoreConfigImpl{TrimAt: 2, TrimRemain: 1, SyncDisabled: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	// Loadはエディタから読み込む
+	if err := s.Load(ctx); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if s.PostCount() != 1 {
+		t.Fatalf("expected 1 loaded post, got %d", s.PostCount())
+	}
+
+	for i := range 3 {
+		if err := s.Add("did:plc:bbb", fmt.Sprintf("rkey%d", i), "cid", time.Now(), nil); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+	}
+	if _, err := s.Delete("did:plc:bbb", "rkey2"); err != nil {
+		t.Fatalf("failed to delete post: %v", err)
+	}
+	if _, err := s.DeleteByDid("did:plc:aaa"); err != nil {
+		t.Fatalf("failed to delete posts: %v", err)
+	}
+	if s.PostCount() == 0 {
+		t.Error("in-memory posts should be kept")
+	}
+	if len(e.posts) != 1 || e.posts[0].Uri != "at://did:plc:aaa/app.bsky.feed.post/loaded" {
+		t.Errorf("editor should not be modified, got %v", e.posts)
+	}
+}
+
+func TestLoadNormalizesIndexedAt(t *testing.T) {
+	ctx := context.Background()
+	// エディタごとに異なる形式の indexedAt を混在させる
+	e := &MockEditor{posts: []types.Post{
+		{Uri: "at://did:plc:aaa/app.bsky.feed.post/gyoka", Cid: "cid", IndexedAt: "2025-01-01T00:00:00.500Z"},
+		{Uri: "at://did:plc:aaa/app.bsky.feed.post/nano", Cid: "cid", IndexedAt: "2025-01-01T00:00:00.1Z"},
+		{Uri: "at://did:plc:aaa/app.bsky.feed.post/second", Cid: "cid", IndexedAt: "2025-01-01T00:00:01Z"},
+		{Uri: "at://did:plc:aaa/app.bsky.feed.post/offset", Cid: "cid", IndexedAt: "2025-01-01T09:00:00.9+09:00"},
+	}}
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  e,
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if err := s.Load(ctx); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := s.Add("did:plc:aaa", "added", "cid", base.Add(700*time.Millisecond), nil); err != nil {
+		t.Fatalf("failed to add post: %v", err)
+	}
+	for _, p := range s.List("") {
+		if len(p.IndexedAt) != len(types.IndexedAtFormat) {
+			t.Errorf("indexedAt %q of %s is not normalized", p.IndexedAt, p.Uri)
+		}
+	}
+
+	if err := s.Trim(3); err != nil {
+		t.Fatalf("failed to trim: %v", err)
+	}
+	var got []string
+	for _, p := range s.List("") {
+		got = append(got, string(p.Uri)[strings.LastIndex(string(p.Uri), "/")+1:])
+	}
+	want := []string{"second", "offset", "added"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("posts after trim = %v, want %v", got, want)
+	}
+}
+
+func TestAuthorCounts(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  &MockEditor{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	if got := s.AuthorCounts(); len(got) != 0 {
+		t.Errorf("AuthorCounts() = %v, want empty", got)
+	}
+	for i, did := range []string{"did:plc:aaa", "did:plc:bbb", "did:plc:aaa", "did:web:example.com"} {
+		if err := s.Add(did, fmt.Sprintf("rkey%d", i), "cid", time.Now(), nil); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+	}
+	want := map[string]int{"did:plc:aaa": 2, "did:plc:bbb": 1, "did:web:example.com": 1}
+	if got := s.AuthorCounts(); !maps.Equal(got, want) {
+		t.Errorf("AuthorCounts() = %v, want %v", got, want)
+	}
+}
+
+// go test -race で GetPost と Trim の同時実行を検査する
+func TestGetPostConcurrentTrim(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  &MockEditor{},
+		Config:  &store.StoreConfigImpl{TrimAt: 1000, TrimRemain: 500},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 100 {
+		if err := s.Add("did:plc:aaa", fmt.Sprintf("rkey%d", i), "cid", base.Add(time.Duration(i)*time.Second), []string{"ja"}); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				if p, exists := s.GetPost("did:plc:aaa", fmt.Sprintf("rkey%d", i)); exists {
+					// 返された投稿を変更してもストアには影響しない
+					p.Langs[0] = "en"
+					p.Cid = "changed"
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 100; i > 0; i -= 10 {
+			if err := s.Trim(i); err != nil {
+				t.Errorf("failed to trim: %v", err)
+			}
+			if err := s.Add("did:plc:bbb", fmt.Sprintf("rkey%d", i), "cid", base.Add(time.Hour), []string{"ja"}); err != nil {
+				t.Errorf("failed to add post: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	for _, p := range s.List("") {
+		if p.Cid != "cid" || !reflect.DeepEqual(p.Langs, []string{"ja"}) {
+			t.Errorf("stored post was modified through GetPost: %+v", p)
+		}
+	}
+}
+
+func TestTrimBytes(t *testing.T) {
+	ctx := context.Background()
+	e := &MockEditor{}
+	// 1ポストあたり uri(41) + cid(3) + indexedAt(30) = 74バイト
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  e,
+		Config:  &store.StoreConfigImpl{TrimBytes: 350},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		if err := s.Add("did:plc:aaa", fmt.Sprintf("rkey%d", i), "cid", base.Add(time.Duration(i)*time.Minute), nil); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+	}
+	if got := s.Stats().PostBytes; got != 296 {
+		t.Fatalf("expected 296 bytes, got %d", got)
+	}
+	if s.PostCount() != 4 {
+		t.Fatalf("expected no trimming under trimBytes, got %d posts", s.PostCount())
+	}
+
+	// 370バイトで上限を超え、上限の90%(315バイト)以内の4件に削減される
+	if err := s.Add("did:plc:aaa", "rkey4", "cid", base.Add(4*time.Minute), nil); err != nil {
+		t.Fatalf("failed to add post: %v", err)
+	}
+	if s.PostCount() != 4 {
+		t.Fatalf("expected 4 posts after trimming, got %d", s.PostCount())
+	}
+	if _, exists := s.GetPost("did:plc:aaa", "rkey0"); exists {
+		t.Error("expected oldest post to be trimmed")
+	}
+	if _, exists := s.GetPost("did:plc:aaa", "rkey4"); !exists {
+		t.Error("expected newest post to be kept")
+	}
+	if got := s.Stats().PostBytes; got != 296 {
+		t.Errorf("expected 296 bytes after trimming, got %d", got)
+	}
+	if len(e.posts) != 4 {
+		t.Errorf("expected editor to be trimmed to 4 posts, got %d", len(e.posts))
+	}
+
+	// 削除でサイズが減る
+	if _, err := s.Delete("did:plc:aaa", "rkey4"); err != nil {
+		t.Fatalf("failed to delete post: %v", err)
+	}
+	if got := s.Stats().PostBytes; got != 222 {
+		t.Errorf("expected 222 bytes after delete, got %d", got)
+	}
+}
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewStore(ctx, StoreOptions{
+		Logger:  slog.Default(),
+		FeedId:  "test",
+		FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+		Editor:  &MockEditor{},
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	stats := s.Stats()
+	if stats.PostCount != 0 || stats.OldestIndexedAt != nil || stats.NewestIndexedAt != nil {
+		t.Errorf("unexpected stats for empty store: %+v", stats)
+	}
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 追加順とindexedAtの順序は一致しない
+	for i, offset := range []time.Duration{time.Hour, 2 * time.Hour, 0} {
+		if err := s.Add("did:plc:aaa", fmt.Sprintf("rkey%d", i), "cid", base.Add(offset), []string{"ja"}); err != nil {
+			t.Fatalf("failed to add post: %v", err)
+		}
+	}
+	stats = s.Stats()
+	if stats.PostCount != 3 || stats.IndexSize != 3 {
+		t.Errorf("expected 3 posts and index entries, got %+v", stats)
+	}
+	if stats.OldestIndexedAt == nil || !stats.OldestIndexedAt.Equal(base) {
+		t.Errorf("OldestIndexedAt = %v, want %v", stats.OldestIndexedAt, base)
+	}
+	if stats.NewestIndexedAt == nil || !stats.NewestIndexedAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("NewestIndexedAt = %v, want %v", stats.NewestIndexedAt, base.Add(2*time.Hour))
+	}
+	if stats.Capacity < stats.PostCount || stats.ApproxMemoryBytes <= 0 {
+		t.Errorf("unexpected capacity or memory: %+v", stats)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name         string
+		posts        []types.Post
+		wantProblems int
+	}{
+		{
+			name: "valid posts",
+			posts: []types.Post{
+				{Uri: "at://did:plc:1234/app.bsky.feed.post/a", Cid: "a", IndexedAt: "2025-01-01T00:00:00Z"},
+				{Uri: "at://did:plc:1234/app.bsky.feed.post/b", Cid: "b", IndexedAt: "2025-01-01T00:00:01Z"},
+			},
+			wantProblems: 0,
+		},
+		{
+			name: "duplicate uri",
+			posts: []types.Post{
+				{Uri: "at://did:plc:1234/app.bsky.feed.post/a", Cid: "a", IndexedAt: "2025-01-01T00:00:00Z"},
+				{Uri: "at://did:plc:1234/app.bsky.feed.post/a", Cid: "a", IndexedAt: "2025-01-01T00:00:01Z"},
+			},
+			wantProblems: 1,
+		},
+		{
+			name: "invalid uri",
+			posts: []types.Post{
+				{Uri: "not-an-aturi", Cid: "a", IndexedAt: "2025-01-01T00:00:00Z"},
+				{Uri: "at://did:plc:1234/app.bsky.feed.like/a", Cid: "b", IndexedAt: "2025-01-01T00:00:01Z"},
+			},
+			wantProblems: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewStore(ctx, StoreOptions{
+				Logger:  slog.Default(),
+				FeedId:  "test",
+				FeedUri: types.FeedUri("at://did:plc:1234/app.bsky.feed.generator/test"),
+				Editor:  &MockEditor{posts: tt.posts},
+			})
+			if err != nil {
+				t.Fatalf("failed to create store: %v", err)
+			}
+			if err := s.Load(ctx); err != nil {
+				t.Fatalf("failed to load store: %v", err)
+			}
+			problems := s.Validate()
+			if len(problems) != tt.wantProblems {
+				t.Errorf("problems = %v, want %d problems", problems, tt.wantProblems)
+			}
+		})
+	}
+}
@@ -2,14 +2,19 @@ package watchlist
 
 import (
 	"log/slog"
+	"maps"
+	"sync"
 	"time"
 )
 
 // Watchlist は監視対象のDIDとその有効期限を管理する
+// 取り込み処理とAPIから同時に呼ばれるため、全てのメソッドはmuで保護する
 type Watchlist struct {
+	mu             sync.Mutex
 	logger         *slog.Logger
 	items          map[string]WatchItem
 	expireDuration time.Duration
+	maxEntries     int // 0は無制限
 	stopChan       chan struct{}
 }
 
@@ -33,8 +38,23 @@ func NewWatchlist(expireDuration time.Duration) (*Watchlist, error) {
 	return w, nil
 }
 
+// SetMaxEntries は監視対象の最大件数を設定する。0は無制限
+// 超過している場合は有効期限の近いアイテムから削除する
+func (w *Watchlist) SetMaxEntries(n int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.maxEntries = n
+	w.evictOverflow(0)
+}
+
 // Add は監視対象のDIDを追加・更新する
+// 最大件数に達している場合は有効期限の最も近いアイテムを削除してから追加する
 func (w *Watchlist) Add(did string, rkey string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.items[did]; !exists {
+		w.evictOverflow(1)
+	}
 	expireAt := time.Now().Add(w.expireDuration)
 	w.items[did] = WatchItem{
 		ExpireAt: expireAt,
@@ -45,6 +65,8 @@ func (w *Watchlist) Add(did string, rkey string) {
 
 // Delete は指定されたDIDを監視対象から削除する
 func (w *Watchlist) Delete(did string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	if _, exists := w.items[did]; !exists {
 		w.logger.Info("attempted to remove non-existent did from watchlist", "did", did)
 		return false
@@ -56,6 +78,8 @@ func (w *Watchlist) Delete(did string) bool {
 }
 
 func (w *Watchlist) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	w.items = make(map[string]WatchItem)
 	w.logger.Info("cleared watchlist")
 }
@@ -63,6 +87,8 @@ func (w *Watchlist) Clear() {
 // Contains は指定されたDIDが監視対象に含まれているかを確認する
 // 有効期限内のitemが存在する場合はそのアイテムを返し、ない場合はnilを返す
 func (w *Watchlist) Contains(did string) *WatchItem {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	item, ok := w.items[did]
 	if !ok {
 		return nil
@@ -77,14 +103,53 @@ func (w *Watchlist) Contains(did string) *WatchItem {
 	return nil
 }
 
+// Restore はエクスポートされたアイテムで監視対象を置き換える
+// 有効期限切れのアイテムは取り込まない。itemsはコピーして保持する
+func (w *Watchlist) Restore(items map[string]WatchItem) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	restored := make(map[string]WatchItem, len(items))
+	for did, item := range items {
+		if now.Before(item.ExpireAt) {
+			restored[did] = item
+		}
+	}
+	w.items = restored
+	w.evictOverflow(0)
+	w.logger.Info("restored watchlist", "count", len(w.items), "skipped", len(items)-len(w.items))
+}
+
+// evictOverflow はmuを保持した状態で呼ぶこと
+// evictOverflow は追加予定のreserve件分の空きができるまで有効期限の近いアイテムを削除する
+func (w *Watchlist) evictOverflow(reserve int) {
+	if w.maxEntries <= 0 {
+		return
+	}
+	for len(w.items) > 0 && len(w.items)+reserve > w.maxEntries {
+		var oldestDid string
+		var oldest time.Time
+		for did, item := range w.items {
+			if oldestDid == "" || item.ExpireAt.Before(oldest) {
+				oldestDid = did
+				oldest = item.ExpireAt
+			}
+		}
+		delete(w.items, oldestDid)
+		w.logger.Info("evicted did from watchlist", "did", oldestDid, "maxEntries", w.maxEntries)
+	}
+}
+
 func (w *Watchlist) Save() error {
 	return nil
 }
 
 func (w *Watchlist) UpdatExpireDuration(d time.Duration) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	w.logger.Info("updating expire duration")
 	//期限切れのwatchitemは事前に削除
-	w.Reflesh()
+	w.reflesh()
 	// 既存のアイテムの有効期限を更新
 	diff := d - w.expireDuration
 	for did, item := range w.items {
@@ -99,6 +164,12 @@ func (w *Watchlist) UpdatExpireDuration(d time.Duration) error {
 }
 
 func (w *Watchlist) Reflesh() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reflesh()
+}
+
+func (w *Watchlist) reflesh() error {
 	w.logger.Info("refreshing watchlist")
 	now := time.Now()
 	for did, item := range w.items {
@@ -129,9 +200,18 @@ func (w *Watchlist) startPeriodicRefresh() {
 	}
 }
 
-// List はwatchlistの全アイテムを返す
+// List はwatchlistの全アイテムのコピーを返す
 func (w *Watchlist) List() map[string]WatchItem {
-	return w.items
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return maps.Clone(w.items)
+}
+
+// Len はwatchlistのアイテム数を返す
+func (w *Watchlist) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.items)
 }
 
 func (w *Watchlist) Stop() {
@@ -1,6 +1,8 @@
 package watchlist
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 )
@@ -193,3 +195,56 @@ func TestWatchlist(t *testing.T) {
 		}
 	})
 }
+
+func TestWatchlistCopies(t *testing.T) {
+	w, err := NewWatchlist(time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create watchlist: %v", err)
+	}
+	defer w.Stop()
+
+	items := map[string]WatchItem{"did:plc:a": {ExpireAt: time.Now().Add(time.Hour), RKey: "rkey1"}}
+	w.Restore(items)
+	items["did:plc:b"] = WatchItem{ExpireAt: time.Now().Add(time.Hour), RKey: "rkey2"}
+	if w.Contains("did:plc:b") != nil {
+		t.Error("changing the restored map should not change the watchlist")
+	}
+
+	list := w.List()
+	delete(list, "did:plc:a")
+	if w.Contains("did:plc:a") == nil {
+		t.Error("changing the listed map should not change the watchlist")
+	}
+}
+
+func TestWatchlistConcurrentAccess(t *testing.T) {
+	w, err := NewWatchlist(time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create watchlist: %v", err)
+	}
+	defer w.Stop()
+
+	// -raceで検出できるよう取り込みとエクスポートを並行して行う
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				did := fmt.Sprintf("did:plc:%d-%d", i, j)
+				w.Add(did, "rkey")
+				w.Contains(did)
+				w.Delete(did)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for range w.List() {
+				}
+				w.Len()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,140 @@
+package subscriber
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+)
+
+type compressEncoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var gzipPool = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+var zstdPool = sync.Pool{
+	New: func() any {
+		enc, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return enc
+	},
+}
+
+func encoderPool(encoding string) *sync.Pool {
+	if encoding == encodingZstd {
+		return &zstdPool
+	}
+	return &gzipPool
+}
+
+// negotiateEncoding picks a response encoding from the Accept-Encoding header.
+// the encoding with the highest q-value is chosen and zstd is preferred over gzip on a tie.
+// encodings with q=0 are not acceptable. returns "" if neither is acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	qvalues := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f >= 0 && f <= 1 {
+					q = f
+				}
+			}
+		}
+		qvalues[name] = q
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range []string{encodingZstd, encodingGzip} {
+		q, ok := qvalues[enc]
+		if !ok {
+			// 明示されていないエンコーディングは"*"の指定に従う
+			q = qvalues["*"]
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+type compressResponseWriter struct {
+	gin.ResponseWriter
+	encoding string
+	encoder  compressEncoder
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if w.encoder == nil {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.encoding)
+		w.encoder = encoderPool(w.encoding).Get().(compressEncoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+	return w.encoder.Write(b)
+}
+
+func (w *compressResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush flushes compressed data so streaming responses reach the client
+func (w *compressResponseWriter) Flush() {
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressResponseWriter) close() {
+	if w.encoder == nil {
+		return
+	}
+	_ = w.encoder.Close()
+	w.encoder.Reset(io.Discard)
+	encoderPool(w.encoding).Put(w.encoder)
+	w.encoder = nil
+}
+
+// CompressionMiddleware compresses responses with gzip or zstd according to Accept-Encoding
+func CompressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == "HEAD" {
+			c.Next()
+			return
+		}
+
+		cw := &compressResponseWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+		}
+		c.Writer = cw
+		defer func() {
+			cw.close()
+			c.Writer = cw.ResponseWriter
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,113 @@
+package subscriber
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "gzip", want: "gzip"},
+		{header: "gzip, deflate, br", want: "gzip"},
+		{header: "gzip, zstd", want: "zstd"},
+		{header: "zstd;q=0, gzip", want: "gzip"},
+		{header: "GZIP;q=0.5", want: "gzip"},
+		{header: "deflate, br", want: ""},
+		{header: "zstd;q=0.5, gzip;q=0.8", want: "gzip"},
+		{header: "gzip;q=0.8, zstd;q=0.8", want: "zstd"},
+		{header: "gzip;q=0, zstd;q=0", want: ""},
+		{header: "zstd;q=0.1, gzip;q=0.2, br", want: "gzip"},
+		{header: "*", want: "zstd"},
+		{header: "*;q=0.5, zstd;q=0.2", want: "gzip"},
+		{header: "*, zstd;q=0", want: "gzip"},
+		{header: "gzip;q=invalid, zstd;q=0.5", want: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := negotiateEncoding(tt.header); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func newCompressionTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CompressionMiddleware())
+	r.GET("/json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": strings.Repeat("yuge", 100)})
+	})
+	r.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "application/x-ndjson")
+		for i := 0; i < 3; i++ {
+			c.Writer.WriteString("{\"n\":1}\n")
+			c.Writer.Flush()
+		}
+	})
+	return r
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	r := newCompressionTestRouter()
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+		"":     func(r io.Reader) (io.Reader, error) { return r, nil },
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{name: "gzip json", path: "/json", acceptEncoding: "gzip", wantEncoding: "gzip", wantBody: "yugeyuge"},
+		{name: "zstd json", path: "/json", acceptEncoding: "zstd, gzip", wantEncoding: "zstd", wantBody: "yugeyuge"},
+		{name: "no compression", path: "/json", acceptEncoding: "", wantEncoding: "", wantBody: "yugeyuge"},
+		{name: "gzip stream", path: "/stream", acceptEncoding: "gzip", wantEncoding: "gzip", wantBody: strings.Repeat("{\"n\":1}\n", 3)},
+		{name: "zstd stream", path: "/stream", acceptEncoding: "zstd", wantEncoding: "zstd", wantBody: strings.Repeat("{\"n\":1}\n", 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status: %d", w.Code)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			dr, err := decoders[tt.wantEncoding](w.Body)
+			if err != nil {
+				t.Fatalf("failed to create decoder: %v", err)
+			}
+			body, err := io.ReadAll(dr)
+			if err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %q, want to contain %q", string(body), tt.wantBody)
+			}
+		})
+	}
+}
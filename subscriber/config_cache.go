@@ -0,0 +1,95 @@
+package subscriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nus25/yuge/feed/config/provider"
+	"github.com/nus25/yuge/feed/config/types"
+	"golang.org/x/sync/singleflight"
+)
+
+// configFileStamp identifies a version of a config file.
+// the version directory is included because the file provider loads the latest version file in it.
+type configFileStamp struct {
+	modTime        int64
+	size           int64
+	versionModTime int64
+}
+
+type configCacheEntry struct {
+	stamp  configFileStamp
+	config types.FeedConfig
+}
+
+// feedConfigCache caches parsed config files so that feeds sharing a config file parse it only once.
+// entries are keyed by path and reloaded when the modification time of the file changes.
+// each caller gets its own deep copy of the cached config.
+type feedConfigCache struct {
+	mu      sync.Mutex
+	entries map[string]configCacheEntry
+	group   singleflight.Group
+	load    func(path string) (types.FeedConfig, error)
+}
+
+func newFeedConfigCache() *feedConfigCache {
+	return &feedConfigCache{
+		entries: make(map[string]configCacheEntry),
+		load:    loadFeedConfigFile,
+	}
+}
+
+func loadFeedConfigFile(path string) (types.FeedConfig, error) {
+	cp, err := provider.NewFileFeedConfigProvider(path)
+	if err != nil {
+		return nil, err
+	}
+	return cp.FeedConfig(), nil
+}
+
+func statConfigFile(path string) (configFileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return configFileStamp{}, fmt.Errorf("config file not found: %s", path)
+		}
+		return configFileStamp{}, err
+	}
+	stamp := configFileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+	if vinfo, err := os.Stat(filepath.Join(filepath.Dir(path), "version")); err == nil {
+		stamp.versionModTime = vinfo.ModTime().UnixNano()
+	}
+	return stamp, nil
+}
+
+// Get returns a copy of the config parsed from path
+func (c *feedConfigCache) Get(path string) (types.FeedConfig, error) {
+	stamp, err := statConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && entry.stamp == stamp {
+		return entry.config.DeepCopy(), nil
+	}
+
+	// 同じファイルの同時読み込みは1回にまとめる
+	v, err, _ := c.group.Do(path, func() (any, error) {
+		cfg, err := c.load(path)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.entries[path] = configCacheEntry{stamp: stamp, config: cfg}
+		c.mu.Unlock()
+		return cfg, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(types.FeedConfig).DeepCopy(), nil
+}
@@ -0,0 +1,72 @@
+package subscriber
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nus25/yuge/feed/config/types"
+)
+
+func TestFeedConfigCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shared.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	c := newFeedConfigCache()
+	loads := 0
+	var mu sync.Mutex
+	c.load = func(path string) (types.FeedConfig, error) {
+		mu.Lock()
+		loads++
+		mu.Unlock()
+		return loadFeedConfigFile(path)
+	}
+
+	// 同じファイルを参照するフィードは一度だけ読み込む
+	var wg sync.WaitGroup
+	configs := make([]types.FeedConfig, 5)
+	for i := range configs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg, err := c.Get(path)
+			if err != nil {
+				t.Errorf("Failed to get config: %v", err)
+				return
+			}
+			configs[i] = cfg
+		}()
+	}
+	wg.Wait()
+	if loads != 1 {
+		t.Errorf("expected config to be loaded once, got %d", loads)
+	}
+	// フィード毎に別のコピーを返す
+	if configs[0] == configs[1] {
+		t.Error("expected each feed to get its own copy of the config")
+	}
+	if err := configs[0].ValidateAll(); err != nil {
+		t.Errorf("copied config should be valid: %v", err)
+	}
+
+	// 更新されたファイルは読み直す
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Failed to change mtime: %v", err)
+	}
+	if _, err := c.Get(path); err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("expected config to be reloaded after modification, got %d loads", loads)
+	}
+
+	if _, err := c.Get(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected error for missing config file")
+	}
+}
@@ -0,0 +1,42 @@
+package subscriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CursorFileName is the file name under the data directory where the last jetstream cursor is persisted
+const CursorFileName = "cursor"
+
+// readCursorFile reads the persisted jetstream cursor.
+// returns os.ErrNotExist (wrapped) if the file does not exist.
+func readCursorFile(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor file %s: %w", path, err)
+	}
+	return cursor, nil
+}
+
+// writeCursorFile persists the jetstream cursor.
+// the cursor is written to a temp file and renamed so a crash never leaves a broken file.
+func writeCursorFile(path string, cursor int64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(cursor, 10)), 0644); err != nil {
+		return fmt.Errorf("failed to write cursor file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to rename cursor file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package subscriber
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCursorFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", CursorFileName)
+
+	if _, err := readCursorFile(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected not exist error, got %v", err)
+	}
+
+	if err := writeCursorFile(path, 1725911162329308); err != nil {
+		t.Fatalf("failed to write cursor: %v", err)
+	}
+	cursor, err := readCursorFile(path)
+	if err != nil {
+		t.Fatalf("failed to read cursor: %v", err)
+	}
+	if cursor != 1725911162329308 {
+		t.Errorf("expected cursor 1725911162329308, got %d", cursor)
+	}
+
+	if err := os.WriteFile(path, []byte("invalid"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := readCursorFile(path); err == nil {
+		t.Error("expected error for invalid cursor file")
+	}
+}
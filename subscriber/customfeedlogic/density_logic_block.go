@@ -20,7 +20,7 @@ const BlockTypeDensity = "density"
 
 // Register custom logic block
 func init() {
-	logicblock.FactoryInstance().RegisterCreator(BlockTypeDensity, NewDensityLogicBlock)
+	logicblock.FactoryInstance().MustRegisterCreator(BlockTypeDensity, NewDensityLogicBlock)
 }
 
 type DensityLogicblock struct {
@@ -0,0 +1,50 @@
+package subscriber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ValidateConfigDirectory checks that the config directory of the file feed definition provider exists
+func ValidateConfigDirectory(dir string) error {
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("config directory %q does not exist: create it and put %s in it, or set --config-directory-path (CONFIG_DIR) to an existing directory", dir, FILE_NAME)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to access config directory %q: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("config directory %q is not a directory: set --config-directory-path (CONFIG_DIR) to a directory", dir)
+	}
+	return nil
+}
+
+// PrepareDataDirectory creates the data directory if it does not exist and checks that it is writable
+func PrepareDataDirectory(dir string) (created bool, err error) {
+	if dir == "" {
+		return false, errors.New("data directory is not set: set --data-directory-path (DATA_DIR)")
+	}
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return false, fmt.Errorf("failed to create data directory %q: %w", dir, err)
+		}
+		created = true
+	case err != nil:
+		return false, fmt.Errorf("failed to access data directory %q: %w", dir, err)
+	case !info.IsDir():
+		return false, fmt.Errorf("data directory %q is not a directory: set --data-directory-path (DATA_DIR) to a directory", dir)
+	}
+
+	// ストアやカーソルを書き込めるか確認する
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return created, fmt.Errorf("data directory %q is not writable: check its permissions: %w", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return created, nil
+}
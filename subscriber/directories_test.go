@@ -0,0 +1,84 @@
+package subscriber
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "file")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr string
+	}{
+		{name: "exists", dir: tmpDir},
+		{name: "missing", dir: filepath.Join(tmpDir, "missing"), wantErr: "does not exist"},
+		{name: "not a directory", dir: file, wantErr: "is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfigDirectory(tt.dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPrepareDataDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "file")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		dir         string
+		wantCreated bool
+		wantErr     string
+	}{
+		{name: "exists", dir: tmpDir},
+		{name: "missing is created", dir: filepath.Join(tmpDir, "a", "b"), wantCreated: true},
+		{name: "empty", dir: "", wantErr: "not set"},
+		{name: "not a directory", dir: file, wantErr: "is not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := PrepareDataDirectory(tt.dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+			entries, err := os.ReadDir(tt.dir)
+			if err != nil {
+				t.Fatalf("failed to read dir: %v", err)
+			}
+			for _, e := range entries {
+				if strings.HasPrefix(e.Name(), ".write-check-") {
+					t.Errorf("write check file was not removed: %s", e.Name())
+				}
+			}
+		})
+	}
+}
@@ -4,25 +4,48 @@ package subscriber
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/nus25/yuge/feed"
+	feedConfig "github.com/nus25/yuge/feed/config/feed"
 	"github.com/nus25/yuge/feed/metrics"
 	"github.com/nus25/yuge/types"
 )
 
 // APIハンドラー
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	idempotencyCacheSize = 1000
+	idempotencyCacheTTL  = 10 * time.Minute
+)
+
 type FeedApiHandler struct {
 	feedService *FeedService
+	// idempotencyMu guards addPostResults and addPostPending so that checking and reserving a key is atomic
+	idempotencyMu sync.Mutex
+	// results of AddPost requests with Idempotency-Key, keyed by feed id and the key
+	addPostResults *expirable.LRU[string, AddPostResponse]
+	// post uris of AddPost requests in progress, keyed the same as addPostResults
+	addPostPending map[string]types.PostUri
 }
 
 // NewAPIHandler はフィードを操作するAPIハンドラーを作成します
 func NewFeedApiHandler(fs *FeedService) *FeedApiHandler {
 	return &FeedApiHandler{
-		feedService: fs,
+		feedService:    fs,
+		addPostResults: expirable.NewLRU[string, AddPostResponse](idempotencyCacheSize, nil, idempotencyCacheTTL),
+		addPostPending: make(map[string]types.PostUri),
 	}
 }
 
@@ -54,15 +77,18 @@ func (h *FeedApiHandler) ValidateFeedId() gin.HandlerFunc {
 
 // //////////////////
 // // feed APIs
+// JSON fields of API requests and responses are in camelCase (e.g. lastStatus, lastUpdated)
 type ListFeedResponse struct {
 	ID         string         `json:"id"`
 	Definition FeedDefinition `json:"definition"`
 	Status     *FeedStatus    `json:"status"`
+	Error      string         `json:"error,omitempty"` // why the feed failed to initialize
 }
 
+// FeedStatusResponse uses the same field names as FeedStatus
 type FeedStatusResponse struct {
-	LastStatus  string `json:"last_status"`
-	LastUpdated string `json:"last_updated"`
+	LastStatus  string `json:"lastStatus"`
+	LastUpdated string `json:"lastUpdated"`
 	Error       string `json:"error,omitempty"`
 }
 
@@ -71,32 +97,86 @@ func (h *FeedApiHandler) ListFeed(c *gin.Context) {
 	response := make([]ListFeedResponse, 0, len(feeds))
 
 	for id, fi := range feeds {
-		if fi.Feed != nil {
-			response = append(response, ListFeedResponse{
-				ID:         id,
-				Definition: fi.Definition,
-				Status:     &fi.Status,
-			})
-		} else {
-			response = append(response, ListFeedResponse{
-				ID:         id,
-				Definition: fi.Definition,
-				Status:     &fi.Status,
-			})
+		r := ListFeedResponse{
+			ID:         id,
+			Definition: fi.Definition,
+			Status:     &fi.Status,
+		}
+		if fi.Feed == nil {
+			r.Error = fi.Status.Error
 		}
+		response = append(response, r)
 	}
 
 	c.JSON(200, response)
 }
 
+type FeedSummaryResponse struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	IngestPaused bool   `json:"ingestPaused,omitempty"`
+	PostCount    *int   `json:"postCount,omitempty"`
+}
+
+// Summary returns id, status and post count of all feeds in one call.
+// post count is omitted for feeds in error or pending state.
+func (h *FeedApiHandler) Summary(c *gin.Context) {
+	feeds := h.feedService.GetAllFeeds()
+	response := make([]FeedSummaryResponse, 0, len(feeds))
+	for id, fi := range feeds {
+		summary := FeedSummaryResponse{
+			ID:           id,
+			Status:       fi.Status.LastStatus.String(),
+			IngestPaused: fi.Status.IngestPaused,
+		}
+		if !fi.Status.IsUnavailable() && fi.Feed != nil {
+			count := fi.Feed.PostCount()
+			summary.PostCount = &count
+		}
+		response = append(response, summary)
+	}
+	sort.Slice(response, func(i, j int) bool {
+		return response[i].ID < response[j].ID
+	})
+	c.JSON(http.StatusOK, response)
+}
+
+// flexibleBool is a bool that also accepts the strings "true" and "false" in JSON,
+// e.g. inactiveStart converted from a feed list in YAML
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*b = false
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid boolean string %q", v)
+		}
+		*b = flexibleBool(parsed)
+	default:
+		return fmt.Errorf("invalid boolean value %s", string(data))
+	}
+	return nil
+}
+
 // RegisterFeed - PUT /api/feed/:feedid に変更し、冪等性を持たせる
 func (h *FeedApiHandler) RegisterFeed(c *gin.Context) {
 	feedId := c.Param("feedid")
 
 	var req struct {
-		FeedURI       string `json:"uri"`
-		ConfigFile    string `json:"configFile"`
-		InactiveStart bool   `json:"inactiveStart"`
+		FeedURI       string                     `json:"uri"`
+		ConfigFile    string                     `json:"configFile"`
+		InactiveStart flexibleBool               `json:"inactiveStart"`
+		Config        *feedConfig.FeedConfigImpl `json:"config"`
+		Mirrors       []string                   `json:"mirrors"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -105,6 +185,13 @@ func (h *FeedApiHandler) RegisterFeed(c *gin.Context) {
 		})
 		return
 	}
+	if fieldErrors := h.validateRegisterFeed(req.FeedURI, req.ConfigFile, req.Config, req.Mirrors); len(fieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Invalid request",
+			"fields": fieldErrors,
+		})
+		return
+	}
 
 	status := FeedStatusActive
 	if req.InactiveStart {
@@ -116,6 +203,8 @@ func (h *FeedApiHandler) RegisterFeed(c *gin.Context) {
 		URI:           req.FeedURI,
 		ConfigFile:    req.ConfigFile,
 		InactiveStart: "false",
+		Config:        req.Config,
+		Mirrors:       req.Mirrors,
 	}
 	if req.InactiveStart {
 		def.InactiveStart = "true"
@@ -143,6 +232,10 @@ func (h *FeedApiHandler) RegisterFeed(c *gin.Context) {
 			if h.feedService.definitionProvider != nil {
 				h.feedService.definitionProvider.AddFeedDefinition(def)
 			}
+			// PDSが利用できない場合はpendingで登録される
+			if fi, ok := h.feedService.GetFeedInfo(feedId); ok {
+				status = fi.Status.LastStatus
+			}
 			c.JSON(http.StatusCreated, gin.H{
 				"message": "Feed created successfully",
 				"feedId":  feedId,
@@ -159,6 +252,29 @@ func (h *FeedApiHandler) RegisterFeed(c *gin.Context) {
 	})
 }
 
+// validateRegisterFeed returns errors by field name of the RegisterFeed request
+func (h *FeedApiHandler) validateRegisterFeed(feedUri string, configFile string, config *feedConfig.FeedConfigImpl, mirrors []string) map[string]string {
+	fieldErrors := make(map[string]string)
+	if err := types.FeedUri(feedUri).Validate(); err != nil {
+		fieldErrors["uri"] = "invalid feed uri: " + err.Error()
+	}
+	if config != nil {
+		if configFile != "" {
+			fieldErrors["config"] = "config and configFile cannot be specified together"
+		} else if err := config.ValidateAll(); err != nil {
+			fieldErrors["config"] = "invalid config: " + err.Error()
+		}
+	} else if configFile != "" && h.feedService.configDir != "" {
+		if err := h.feedService.checkConfigFile(configFile); err != nil {
+			fieldErrors["configFile"] = err.Error()
+		}
+	}
+	if err := validateMirrors(mirrors); err != nil {
+		fieldErrors["mirrors"] = err.Error()
+	}
+	return fieldErrors
+}
+
 func (h *FeedApiHandler) UnregisterFeed(c *gin.Context) {
 	feedId := c.Param("feedid")
 	// Check if feed exists
@@ -184,26 +300,24 @@ func (h *FeedApiHandler) UnregisterFeed(c *gin.Context) {
 	})
 }
 
+// FeedInfoResponse is the response of GetFeedInfo.
+// config and metrics are omitted for feeds which failed to initialize, and error tells why.
 type FeedInfoResponse struct {
 	ID      string           `json:"id"`
 	URI     string           `json:"uri"`
 	Status  *FeedStatus      `json:"status"`
-	Config  any              `json:"config"`
-	Metrics *metrics.Metrics `json:"metrics"`
+	Error   string           `json:"error,omitempty"`
+	Config  any              `json:"config,omitempty"`
+	Metrics *metrics.Metrics `json:"metrics,omitempty"`
 }
 
 func (h *FeedApiHandler) GetFeedInfo(c *gin.Context) {
 	feedId := c.Param("feedid")
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": fmt.Sprintf("feed %s is in error state: %s", feedId, fi.Status.Error),
-		})
-		return
-	}
 
 	response := FeedInfoResponse{
 		ID:     feedId,
+		URI:    fi.Definition.URI,
 		Status: &fi.Status,
 	}
 
@@ -211,6 +325,8 @@ func (h *FeedApiHandler) GetFeedInfo(c *gin.Context) {
 		response.URI = fi.Feed.FeedUri()
 		response.Metrics = fi.Feed.Metrics()
 		response.Config = fi.Feed.Config()
+	} else {
+		response.Error = fmt.Sprintf("feed %s is in %s state: %s", feedId, fi.Status.LastStatus, fi.Status.Error)
 	}
 
 	c.JSON(200, response)
@@ -233,13 +349,27 @@ func (h *FeedApiHandler) GetFeedStatus(c *gin.Context) {
 	})
 }
 
+// parseUpdateStatus returns the status which can be set through the API
+func parseUpdateStatus(s string) (Status, bool) {
+	switch s {
+	case "active":
+		return FeedStatusActive, true
+	case "inactive":
+		return FeedStatusInactive, true
+	case "error":
+		return FeedStatusError, true
+	default:
+		return FeedStatusUnknown, false
+	}
+}
+
 func (h *FeedApiHandler) UpdateFeedStatus(c *gin.Context) {
 	feedId := c.Param("feedid")
 
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError || fi.Feed == nil {
+	if fi.Status.IsUnavailable() || fi.Feed == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot update status: feed is in error state or not initialized",
+			"error": "cannot update status: feed is in error or pending state or not initialized",
 		})
 		return
 	}
@@ -252,18 +382,8 @@ func (h *FeedApiHandler) UpdateFeedStatus(c *gin.Context) {
 		return
 	}
 
-	var status Status
-	switch req.Status {
-	case "active":
-		status = FeedStatusActive
-	case "inactive":
-		status = FeedStatusInactive
-	case "error":
-		status = FeedStatusError
-	default:
-		status = FeedStatusUnknown
-	}
-	if status != FeedStatusActive && status != FeedStatusInactive && status != FeedStatusError {
+	status, ok := parseUpdateStatus(req.Status)
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "invalid status: must be one of active, inactive, error",
 		})
@@ -282,6 +402,99 @@ func (h *FeedApiHandler) UpdateFeedStatus(c *gin.Context) {
 	})
 }
 
+type BulkUpdateStatusRequest struct {
+	Feeds  []string `json:"feeds" binding:"required,min=1"`
+	Status string   `json:"status" binding:"required,oneof=active inactive error"`
+}
+
+type BulkUpdateStatusResult struct {
+	ID     string      `json:"id"`
+	Status *FeedStatus `json:"status,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type BulkUpdateStatusResponse struct {
+	Results []BulkUpdateStatusResult `json:"results"`
+}
+
+// BulkUpdateFeedStatus updates the status of multiple feeds at once.
+// each feed is validated and updated independently, and the result is returned per feed.
+func (h *FeedApiHandler) BulkUpdateFeedStatus(c *gin.Context) {
+	var req BulkUpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body: " + err.Error(),
+		})
+		return
+	}
+	status, ok := parseUpdateStatus(req.Status)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid status: must be one of active, inactive, error",
+		})
+		return
+	}
+
+	results := make([]BulkUpdateStatusResult, 0, len(req.Feeds))
+	for _, feedId := range req.Feeds {
+		result := BulkUpdateStatusResult{ID: feedId}
+		fi, exists := h.feedService.GetFeedInfo(feedId)
+		switch {
+		case !exists:
+			result.Error = "feed not found"
+		case fi.Status.IsUnavailable() || fi.Feed == nil:
+			result.Error = "cannot update status: feed is in error or pending state or not initialized"
+		default:
+			if err := h.feedService.UpdateStatus(feedId, status); err != nil {
+				result.Error = "failed to update status: " + err.Error()
+			} else if fi, exists := h.feedService.GetFeedInfo(feedId); exists {
+				result.Status = &fi.Status
+			}
+		}
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, BulkUpdateStatusResponse{
+		Results: results,
+	})
+}
+
+type UpdateIngestRequest struct {
+	Paused *bool `json:"paused" binding:"required"`
+}
+
+// UpdateIngest pauses or resumes adding posts from jetstream.
+// a paused feed keeps its status and continues to serve posts.
+func (h *FeedApiHandler) UpdateIngest(c *gin.Context) {
+	feedId := c.Param("feedid")
+
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() || fi.Feed == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot update ingest: feed is in error or pending state or not initialized",
+		})
+		return
+	}
+
+	var req UpdateIngestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.feedService.SetIngestPaused(feedId, *req.Paused); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update ingest: " + err.Error(),
+		})
+		return
+	}
+	fi, _ = h.feedService.GetFeedInfo(feedId)
+	c.JSON(http.StatusOK, StatusResponse{
+		Status: &fi.Status,
+	})
+}
+
 func (h *FeedApiHandler) ReloadFeed(c *gin.Context) {
 	feedId := c.Param("feedid")
 
@@ -301,9 +514,9 @@ func (h *FeedApiHandler) ReloadFeed(c *gin.Context) {
 func (h *FeedApiHandler) ClearFeed(c *gin.Context) {
 	feedId := c.Param("feedid")
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot clear feed: feed is in error state",
+			"error": "cannot clear feed: feed is in error or pending state",
 		})
 		return
 	}
@@ -316,15 +529,136 @@ func (h *FeedApiHandler) ClearFeed(c *gin.Context) {
 	})
 }
 
+func (h *FeedApiHandler) ReindexFeed(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot reindex feed: feed is in error or pending state",
+		})
+		return
+	}
+	before, after := fi.Feed.RebuildIndex()
+	c.JSON(200, gin.H{
+		"message": "Reindex feed completed.",
+		"before":  before,
+		"after":   after,
+	})
+}
+
+// ReevaluateFeed removes stored posts excluded by the current feed logic.
+// blocks decided by the author alone (userlist) are re-evaluated for all posts.
+// blocks testing the post content are re-evaluated only for posts kept by the rich store.
+func (h *FeedApiHandler) ReevaluateFeed(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot reevaluate feed: feed is in error or pending state",
+		})
+		return
+	}
+	result, err := fi.Feed.Reevaluate()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  "failed to reevaluate feed: " + err.Error(),
+			"result": result,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetStoreStats returns store internals (post count, index size, indexedAt range, approximate memory) for debugging
+func (h *FeedApiHandler) GetStoreStats(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot get store stats: feed is in error or pending state",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, fi.Feed.StoreStats())
+}
+
+type RecentTestsResponse struct {
+	Enabled bool              `json:"enabled"`
+	Tests   []feed.RecentTest `json:"tests"`
+}
+
+// GetRecentTests returns the last posts tested by the feed logic, newest first.
+// posts are recorded only if the subscriber runs with --feed-recent-tests.
+func (h *FeedApiHandler) GetRecentTests(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot get recent tests: feed is in error or pending state",
+		})
+		return
+	}
+	tests, enabled := fi.Feed.RecentTests()
+	if tests == nil {
+		tests = []feed.RecentTest{}
+	}
+	c.JSON(http.StatusOK, RecentTestsResponse{
+		Enabled: enabled,
+		Tests:   tests,
+	})
+}
+
+type RecentMatchesResponse struct {
+	Enabled bool            `json:"enabled"`
+	Posts   []feed.RichPost `json:"posts"`
+}
+
+// GetRecentMatches returns the metadata of stored posts kept by the rich store, newest first.
+// posts are kept only if richPostLimit is set in the store config.
+func (h *FeedApiHandler) GetRecentMatches(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot get recent matches: feed is in error or pending state",
+		})
+		return
+	}
+	posts, enabled := fi.Feed.RecentMatches()
+	if posts == nil {
+		posts = []feed.RichPost{}
+	}
+	c.JSON(http.StatusOK, RecentMatchesResponse{
+		Enabled: enabled,
+		Posts:   posts,
+	})
+}
+
+func (h *FeedApiHandler) ValidateFeed(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot validate feed: feed is in error or pending state",
+		})
+		return
+	}
+	problems := fi.Feed.Validate()
+	c.JSON(200, gin.H{
+		"valid":    len(problems) == 0,
+		"problems": problems,
+	})
+}
+
 ////////////////////
 //// feedconfig apis
 
 func (h *FeedApiHandler) GetConfig(c *gin.Context) {
 	feedId := c.Param("feedid")
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot get config: feed is in error state",
+			"error": "cannot get config: feed is in error or pending state",
 		})
 		return
 	}
@@ -332,6 +666,29 @@ func (h *FeedApiHandler) GetConfig(c *gin.Context) {
 	c.JSON(200, config)
 }
 
+// DiffConfig returns the difference between the current config of the feed and the config in the request body.
+// the feed is not changed.
+func (h *FeedApiHandler) DiffConfig(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot diff config: feed is in error or pending state",
+		})
+		return
+	}
+	var candidate feedConfig.FeedConfigImpl
+	if err := c.ShouldBindJSON(&candidate); err != nil {
+		respondWithError(c, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+	if err := candidate.ValidateAll(); err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid config", err)
+		return
+	}
+	c.JSON(http.StatusOK, feedConfig.Diff(fi.Feed.Config(), &candidate))
+}
+
 type GetAllPostsResponse struct {
 	Posts []types.Post `json:"posts"`
 }
@@ -339,9 +696,9 @@ type GetAllPostsResponse struct {
 func (h *FeedApiHandler) GetAllPosts(c *gin.Context) {
 	feedId := c.Param("feedid")
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot get posts: feed is in error state",
+			"error": "cannot get posts: feed is in error or pending state",
 		})
 		return
 	}
@@ -351,6 +708,87 @@ func (h *FeedApiHandler) GetAllPosts(c *gin.Context) {
 	})
 }
 
+type AuthorCount struct {
+	Did   string `json:"did"`
+	Count int    `json:"count"`
+}
+
+type GetAuthorsResponse struct {
+	Authors []AuthorCount `json:"authors"`
+}
+
+// GetAuthors lists distinct authors of the feed with their post counts, most posts first.
+// ?limit= limits the number of authors returned.
+func (h *FeedApiHandler) GetAuthors(c *gin.Context) {
+	feedId := c.Param("feedid")
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			respondWithError(c, http.StatusBadRequest, "invalid limit", nil)
+			return
+		}
+	}
+
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot get authors: feed is in error or pending state",
+		})
+		return
+	}
+	counts := fi.Feed.AuthorCounts()
+	authors := make([]AuthorCount, 0, len(counts))
+	for did, count := range counts {
+		authors = append(authors, AuthorCount{Did: did, Count: count})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if authors[i].Count != authors[j].Count {
+			return authors[i].Count > authors[j].Count
+		}
+		return authors[i].Did < authors[j].Did
+	})
+	if limit > 0 && len(authors) > limit {
+		authors = authors[:limit]
+	}
+	c.JSON(http.StatusOK, GetAuthorsResponse{
+		Authors: authors,
+	})
+}
+
+type GetPostCountResponse struct {
+	Count int `json:"count"`
+}
+
+// GetPostCount returns the number of posts in the feed.
+// ?did= counts only the posts of the author, validated the same way as GetPostsByDid.
+func (h *FeedApiHandler) GetPostCount(c *gin.Context) {
+	feedId := c.Param("feedid")
+	did := c.Query("did")
+	if _, hasDid := c.GetQuery("did"); hasDid {
+		if _, err := syntax.ParseDID(did); err != nil {
+			respondWithError(c, http.StatusBadRequest, "Invalid DID format", err)
+			return
+		}
+	}
+
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot count posts: feed is in error or pending state",
+		})
+		return
+	}
+	count := fi.Feed.PostCount()
+	if did != "" {
+		count = len(fi.Feed.ListPost(did))
+	}
+	c.JSON(http.StatusOK, GetPostCountResponse{
+		Count: count,
+	})
+}
+
 type GetPostsByDidResponse struct {
 	Posts []types.Post `json:"posts"`
 }
@@ -386,9 +824,9 @@ func (h *FeedApiHandler) GetPostByRkey(c *gin.Context) {
 	}
 
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot get post: feed is in error state",
+			"error": "cannot get post: feed is in error or pending state",
 		})
 		return
 	}
@@ -436,16 +874,56 @@ func (h *FeedApiHandler) AddPost(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "invalid cid format: cid must not be empty"})
 		return
 	}
+	if _, err := syntax.ParseCID(req.CID); err != nil {
+		c.JSON(400, gin.H{"error": "invalid cid format: " + err.Error()})
+		return
+	}
+
+	// 同じIdempotency-Keyのリクエストは前回の結果を返し、エディタへの重複書き込みを避ける
+	uri := types.PostUri("at://" + did + "/app.bsky.feed.post/" + rkey)
+	idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+	cacheKey := feedId + "\x00" + idempotencyKey
+	var added *AddPostResponse
+	if idempotencyKey != "" {
+		// 確認と予約を同じロックの中で行い、同時に届いた再送が両方とも書き込むのを防ぐ
+		h.idempotencyMu.Lock()
+		prev, done := h.addPostResults.Get(cacheKey)
+		pendingUri, pending := h.addPostPending[cacheKey]
+		if !done && !pending {
+			h.addPostPending[cacheKey] = uri
+		}
+		h.idempotencyMu.Unlock()
+		switch {
+		case done && prev.Post.Uri != uri, pending && pendingUri != uri:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency key is already used for another post"})
+			return
+		case done:
+			c.JSON(200, prev)
+			return
+		case pending:
+			c.JSON(http.StatusConflict, gin.H{"error": "a request with the same idempotency key is in progress"})
+			return
+		}
+		// 失敗した場合は予約だけを解除し、同じキーで再試行できるようにする
+		defer func() {
+			h.idempotencyMu.Lock()
+			defer h.idempotencyMu.Unlock()
+			delete(h.addPostPending, cacheKey)
+			if added != nil {
+				h.addPostResults.Add(cacheKey, *added)
+			}
+		}()
+	}
 
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot add post: feed is in error state",
+			"error": "cannot add post: feed is in error or pending state",
 		})
 		return
 	}
 	var t time.Time
-	fmt.Println("ind:" + req.IndexedAt)
+	h.feedService.logger.Debug("adding post", "feedId", feedId, "uri", uri, "indexedAt", req.IndexedAt)
 	if req.IndexedAt != "" {
 		var err error
 		t, err = time.Parse(time.RFC3339Nano, req.IndexedAt)
@@ -462,14 +940,17 @@ func (h *FeedApiHandler) AddPost(c *gin.Context) {
 		return
 	}
 	post := types.Post{
-		Uri:       types.PostUri("at://" + did + "/app.bsky.feed.post/" + rkey),
+		Uri:       uri,
 		Cid:       req.CID,
-		IndexedAt: t.UTC().Format(time.RFC3339Nano),
+		IndexedAt: types.FormatIndexedAt(t),
+		Langs:     req.Langs,
 	}
-	c.JSON(200, AddPostResponse{
+	resp := AddPostResponse{
 		Message: "post added successfully",
 		Post:    post,
-	})
+	}
+	added = &resp
+	c.JSON(200, resp)
 }
 
 type DeletePostByDidResponse struct {
@@ -488,9 +969,9 @@ func (h *FeedApiHandler) DeletePostByDid(c *gin.Context) {
 	}
 
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot delete post: feed is in error state",
+			"error": "cannot delete post: feed is in error or pending state",
 		})
 		return
 	}
@@ -511,6 +992,9 @@ func (h *FeedApiHandler) DeletePostByDid(c *gin.Context) {
 type DeletePostByRkeyResponse struct {
 	Message string     `json:"message"`
 	Deleted types.Post `json:"deleted"`
+	// RemovedFromMemory is false if indexedAt didn't match the post in memory.
+	// the version is deleted from the storage anyway
+	RemovedFromMemory bool `json:"removedFromMemory"`
 }
 
 func (h *FeedApiHandler) DeletePost(c *gin.Context) {
@@ -526,9 +1010,9 @@ func (h *FeedApiHandler) DeletePost(c *gin.Context) {
 	}
 
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot delete post: feed is in error state",
+			"error": "cannot delete post: feed is in error or pending state",
 		})
 		return
 	}
@@ -538,6 +1022,16 @@ func (h *FeedApiHandler) DeletePost(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "rkey must not be empty"})
 		return
 	}
+	// indexedAtを指定した場合はそのバージョンのみ削除する
+	var indexedAt *time.Time
+	if v := c.Query("indexedAt"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid indexedAt format"})
+			return
+		}
+		indexedAt = &t
+	}
 	post, exists := fi.Feed.GetPost(did, rkey)
 	if !exists {
 		c.JSON(404, gin.H{"error": "post not found"})
@@ -545,11 +1039,77 @@ func (h *FeedApiHandler) DeletePost(c *gin.Context) {
 	}
 
 	// ストアから削除
-	fi.Feed.DeletePost(did, rkey)
+	var deleted bool
+	var err error
+	if indexedAt != nil {
+		deleted, err = fi.Feed.DeletePostVersion(did, rkey, *indexedAt)
+	} else {
+		deleted, err = fi.Feed.DeletePost(did, rkey)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to delete post: %v", err)})
+		return
+	}
+	if !deleted && indexedAt == nil {
+		// 存在確認の後に削除された
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+	message := "post deleted successfully"
+	if !deleted {
+		// indexedAtがメモリ上のポストと一致しなくてもストレージからは削除されている
+		message = "post version deleted from storage"
+	}
 
 	c.JSON(200, DeletePostByRkeyResponse{
-		Message: "post deleted successfully",
-		Deleted: post,
+		Message:           message,
+		Deleted:           post,
+		RemovedFromMemory: deleted,
+	})
+}
+
+type TestPostResponse struct {
+	Passed bool               `json:"passed"`
+	Blocks []feed.BlockResult `json:"blocks"`
+	// SkippedBlocks is the number of blocks not evaluated because their Test has side effects.
+	// Passed assumes they pass
+	SkippedBlocks int `json:"skippedBlocks"`
+}
+
+// TestPost evaluates a candidate post against the feed logic without adding it.
+// the request body is a app.bsky.feed.post record. did and rkey can be given as query parameters.
+// stateful blocks (e.g. dedupe, limiter, sample) are skipped so that the test doesn't change their state.
+func (h *FeedApiHandler) TestPost(c *gin.Context) {
+	feedId := c.Param("feedid")
+	did := c.DefaultQuery("did", "did:plc:test")
+	rkey := c.DefaultQuery("rkey", "test")
+
+	var post apibsky.FeedPost
+	if err := c.ShouldBindJSON(&post); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot test post: feed is in error or pending state",
+		})
+		return
+	}
+	passed, results := fi.Feed.DryRun(did, rkey, &post)
+	skipped := 0
+	for _, r := range results {
+		if r.Skipped {
+			skipped++
+		}
+	}
+	c.JSON(http.StatusOK, TestPostResponse{
+		Passed:        passed,
+		Blocks:        results,
+		SkippedBlocks: skipped,
 	})
 }
 
@@ -557,6 +1117,8 @@ type ProcessLogicBlockCommandRequest struct {
 	Args map[string]string `json:"args,omitempty"`
 }
 
+// ProcessLogicBlockCommand runs a command on a logic block.
+// a block without a name can be addressed by its index as "#N" (url encoded as %23N).
 func (h *FeedApiHandler) ProcessLogicBlockCommand(c *gin.Context) {
 	feedId := c.Param("feedid")
 	logicBlockName := c.Param("logicblockname")
@@ -575,9 +1137,9 @@ func (h *FeedApiHandler) ProcessLogicBlockCommand(c *gin.Context) {
 	}
 
 	fi, _ := h.feedService.GetFeedInfo(feedId)
-	if fi.Status.LastStatus == FeedStatusError {
+	if fi.Status.IsUnavailable() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "cannot process command: feed is in error state",
+			"error": "cannot process command: feed is in error or pending state",
 		})
 		return
 	}
@@ -588,3 +1150,78 @@ func (h *FeedApiHandler) ProcessLogicBlockCommand(c *gin.Context) {
 	}
 	c.JSON(200, gin.H{"message": msg})
 }
+
+type ListLogicBlocksResponse struct {
+	Blocks []feed.BlockInfo `json:"blocks"`
+}
+
+// ListLogicBlocks lists the logic blocks of the feed and which optional interfaces they implement.
+// blocks with commandProcessor can be used with ProcessLogicBlockCommand.
+func (h *FeedApiHandler) ListLogicBlocks(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot list logic blocks: feed is in error or pending state",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, ListLogicBlocksResponse{
+		Blocks: fi.Feed.LogicBlocks(),
+	})
+}
+
+// FeedStateRequest and FeedStateResponse hold the runtime state of logic blocks keyed by block name (or "#N")
+type FeedStateRequest struct {
+	Blocks map[string]feed.BlockState `json:"blocks" binding:"required"`
+}
+
+type FeedStateResponse struct {
+	Blocks map[string]feed.BlockState `json:"blocks"`
+}
+
+// GetFeedState exports the runtime state of stateful logic blocks (e.g. dropin watchlist)
+// so that it can be imported with ImportFeedState on another host.
+func (h *FeedApiHandler) GetFeedState(c *gin.Context) {
+	feedId := c.Param("feedid")
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot export state: feed is in error or pending state",
+		})
+		return
+	}
+	states, err := fi.Feed.ExportState()
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, "failed to export state", err)
+		return
+	}
+	c.JSON(http.StatusOK, FeedStateResponse{Blocks: states})
+}
+
+// ImportFeedState restores the runtime state exported by GetFeedState
+func (h *FeedApiHandler) ImportFeedState(c *gin.Context) {
+	feedId := c.Param("feedid")
+	var req FeedStateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request format: " + err.Error(),
+		})
+		return
+	}
+	fi, _ := h.feedService.GetFeedInfo(feedId)
+	if fi.Status.IsUnavailable() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot import state: feed is in error or pending state",
+		})
+		return
+	}
+	if err := fi.Feed.ImportState(req.Blocks); err != nil {
+		respondWithError(c, http.StatusBadRequest, "failed to import state", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Import state completed.",
+		"imported": len(req.Blocks),
+	})
+}
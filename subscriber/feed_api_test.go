@@ -2,16 +2,27 @@ package subscriber
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/gin-gonic/gin"
+	"github.com/nus25/yuge/feed"
+	"github.com/nus25/yuge/feed/store"
 	"github.com/nus25/yuge/feed/store/editor"
 )
 
@@ -69,6 +80,7 @@ func TestAPIHandler_feedOperation(t *testing.T) {
 		GET("", api.GetFeedInfo).
 		GET("/status", api.GetFeedStatus).
 		PUT("/status", api.UpdateFeedStatus).
+		PATCH("/ingest", api.UpdateIngest).
 		DELETE("", api.UnregisterFeed)
 
 	//register feed
@@ -227,6 +239,11 @@ func TestAPIHandler_feedOperation(t *testing.T) {
 				"metricName":  "feed_post_count",
 				"metricType":  "int",
 			},
+			{
+				"description": "distinct authors of the feed",
+				"metricName":  "feed_unique_authors",
+				"metricType":  "int",
+			},
 		}
 		expectedMetricsJSON, _ := json.Marshal(expectedMetrics)
 		actualMetricsJSON, _ := json.Marshal(actualMetrics["metrics"])
@@ -275,6 +292,35 @@ func TestAPIHandler_feedOperation(t *testing.T) {
 
 	body = recorder.Body.String()
 
+	//// test pause ingest
+	for _, tc := range []struct {
+		body       string
+		wantCode   int
+		wantPaused any
+	}{
+		{`{"paused":true}`, http.StatusOK, true},
+		{`{}`, http.StatusBadRequest, nil},
+		{`{"paused":false}`, http.StatusOK, nil},
+	} {
+		req, _ = http.NewRequest("PATCH", "/api/feed/test-feed/ingest", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != tc.wantCode {
+			t.Errorf("ingest %s: expected status code %d, but got %d", tc.body, tc.wantCode, recorder.Code)
+			continue
+		}
+		if tc.wantCode != http.StatusOK {
+			continue
+		}
+		var ingestResp map[string]map[string]any
+		json.Unmarshal(recorder.Body.Bytes(), &ingestResp)
+		// 取り込みの一時停止はフィードのステータスとは独立している
+		if ingestResp["status"]["lastStatus"] != "active" || ingestResp["status"]["ingestPaused"] != tc.wantPaused {
+			t.Errorf("ingest %s: unexpected status %v", tc.body, ingestResp["status"])
+		}
+	}
+
 	//// test update feed status
 	updateStatusBody := map[string]any{
 		"status": "inactive",
@@ -428,6 +474,8 @@ func TestAPIHandler_PostOperations(t *testing.T) {
 	router.POST("/api2/feed/:feedid", api.RegisterFeed)
 	router.Group("/api2/feed/:feedid").Use(api.ValidateFeedId()).
 		POST("/post/:did/:rkey", api.AddPost).
+		GET("/authors", api.GetAuthors).
+		GET("/count", api.GetPostCount).
 		GET("/post", api.GetAllPosts).
 		GET("/post/:did", api.GetPostsByDid).
 		GET("/post/:did/:rkey", api.GetPostByRkey).
@@ -512,6 +560,71 @@ func TestAPIHandler_PostOperations(t *testing.T) {
 		t.Errorf("Expected 1 post for DID, but got %d", len(didPosts.Posts))
 	}
 
+	// get authors
+	for _, tc := range []struct {
+		query    string
+		wantCode int
+		wantLen  int
+	}{
+		{"", http.StatusOK, 1},
+		{"?limit=1", http.StatusOK, 1},
+		{"?limit=-1", http.StatusBadRequest, 0},
+		{"?limit=abc", http.StatusBadRequest, 0},
+	} {
+		req, _ = http.NewRequest("GET", "/api2/feed/test-feed/authors"+tc.query, nil)
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != tc.wantCode {
+			t.Errorf("authors%s: expected status code %d, but got %d", tc.query, tc.wantCode, recorder.Code)
+			continue
+		}
+		if tc.wantCode != http.StatusOK {
+			continue
+		}
+		var authorsResp GetAuthorsResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &authorsResp); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if len(authorsResp.Authors) != tc.wantLen {
+			t.Errorf("authors%s: expected %d authors, but got %d", tc.query, tc.wantLen, len(authorsResp.Authors))
+			continue
+		}
+		if authorsResp.Authors[0].Did != testDid || authorsResp.Authors[0].Count != 1 {
+			t.Errorf("authors%s: unexpected author %+v", tc.query, authorsResp.Authors[0])
+		}
+	}
+
+	// count posts
+	for _, tc := range []struct {
+		query     string
+		wantCode  int
+		wantCount int
+	}{
+		{"", http.StatusOK, 1},
+		{"?did=" + testDid, http.StatusOK, 1},
+		{"?did=did:plc:other", http.StatusOK, 0},
+		{"?did=invalid-did", http.StatusBadRequest, 0},
+		{"?did=", http.StatusBadRequest, 0},
+	} {
+		req, _ = http.NewRequest("GET", "/api2/feed/test-feed/count"+tc.query, nil)
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != tc.wantCode {
+			t.Errorf("count%s: expected status code %d, but got %d", tc.query, tc.wantCode, recorder.Code)
+			continue
+		}
+		if tc.wantCode != http.StatusOK {
+			continue
+		}
+		var countResp GetPostCountResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &countResp); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if countResp.Count != tc.wantCount {
+			t.Errorf("count%s: expected %d, but got %d", tc.query, tc.wantCount, countResp.Count)
+		}
+	}
+
 	// get post by RKey
 	req, _ = http.NewRequest("GET", "/api2/feed/test-feed/post/"+testDid+"/"+testRkey, nil)
 	recorder = httptest.NewRecorder()
@@ -530,6 +643,24 @@ func TestAPIHandler_PostOperations(t *testing.T) {
 		t.Errorf("Expected to get a post by rkey, but got %s", string(post.Post.Uri))
 	}
 
+	// delete another version of the post
+	req, _ = http.NewRequest("DELETE", "/api2/feed/test-feed/post/"+testDid+"/"+testRkey+"?indexedAt=2000-01-01T00:00:00Z", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var deleteVersionResponse DeletePostByRkeyResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &deleteVersionResponse); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if deleteVersionResponse.RemovedFromMemory {
+		t.Error("Expected post with another indexedAt not to be removed from memory")
+	}
+	if fi, _ := fs.GetFeedInfo("test-feed"); fi.Feed.PostCount() != 1 {
+		t.Error("Expected post to remain in memory")
+	}
+
 	// delete post
 	req, _ = http.NewRequest("DELETE", "/api2/feed/test-feed/post/"+testDid+"/"+testRkey, nil)
 	recorder = httptest.NewRecorder()
@@ -543,6 +674,9 @@ func TestAPIHandler_PostOperations(t *testing.T) {
 	if deletePostResponse.Message != "post deleted successfully" {
 		t.Errorf("Expected message to be 'post deleted successfully', but got %s", deletePostResponse.Message)
 	}
+	if !deletePostResponse.RemovedFromMemory {
+		t.Error("Expected post to be removed from memory")
+	}
 	if string(deletePostResponse.Deleted.Uri) != testUri {
 		t.Errorf("Expected to delete a post, but got %s", deletePostResponse.Deleted.Uri)
 	}
@@ -578,6 +712,9 @@ func TestAPIHandler_ReloadAndClearFeed(t *testing.T) {
 	router.POST("/api/feed/:feedid", api.RegisterFeed)
 	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
 		POST("/reload", api.ReloadFeed).
+		POST("/reindex", api.ReindexFeed).
+		POST("/reevaluate", api.ReevaluateFeed).
+		GET("/store/stats", api.GetStoreStats).
 		POST("/clear", api.ClearFeed).
 		POST("/post/:did/:rkey", api.AddPost).
 		GET("/post", api.GetAllPosts)
@@ -626,6 +763,54 @@ func TestAPIHandler_ReloadAndClearFeed(t *testing.T) {
 		t.Errorf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
 	}
 
+	// インデックスを再構築
+	req, _ = http.NewRequest("POST", "/api/feed/test-feed/reindex", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var reindexResp struct {
+		Before int `json:"before"`
+		After  int `json:"after"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &reindexResp)
+	if reindexResp.After != 1 {
+		t.Errorf("Expected 1 indexed post after reindex, but got %d", reindexResp.After)
+	}
+
+	// 著者で判定するブロックがないので投稿は削除されない
+	req, _ = http.NewRequest("POST", "/api/feed/test-feed/reevaluate", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var reevaluateResp feed.ReevaluateResult
+	json.Unmarshal(recorder.Body.Bytes(), &reevaluateResp)
+	if reevaluateResp.AuthorBlocks != 0 || reevaluateResp.RemovedPosts != 0 {
+		t.Errorf("Expected no post removed by reevaluate, but got %+v", reevaluateResp)
+	}
+
+	// ストアの統計を取得
+	req, _ = http.NewRequest("GET", "/api/feed/test-feed/store/stats", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var stats store.StoreStats
+	json.Unmarshal(recorder.Body.Bytes(), &stats)
+	if stats.PostCount != 1 || stats.IndexSize != 1 {
+		t.Errorf("Expected 1 post and index entry, but got %+v", stats)
+	}
+	if stats.OldestIndexedAt == nil || !stats.OldestIndexedAt.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected oldestIndexedAt %v", stats.OldestIndexedAt)
+	}
+
 	// フィードをクリア
 	req, _ = http.NewRequest("POST", "/api/feed/test-feed/clear", nil)
 	recorder = httptest.NewRecorder()
@@ -646,3 +831,1171 @@ func TestAPIHandler_ReloadAndClearFeed(t *testing.T) {
 		t.Errorf("Expected 0 posts after clear, but got %d", len(posts))
 	}
 }
+
+func TestAPIHandler_TestPost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	// create config file
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	router := gin.Default()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		POST("/test", api.TestPost)
+
+	// register feed
+	req, _ := http.NewRequest("POST", "/api/feed/test-feed", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Body = io.NopCloser(createJSONBody(t, map[string]any{
+		"uri":           "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed",
+		"configFile":    "test-config.yaml",
+		"inactiveStart": false,
+	}))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusCreated, recorder.Code)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedPassed bool
+	}{
+		{
+			name:           "日本語のポストは通過",
+			body:           `{"text":"こんにちは","langs":["ja"],"createdAt":"2024-01-01T00:00:00Z"}`,
+			expectedStatus: http.StatusOK,
+			expectedPassed: true,
+		},
+		{
+			name:           "英語のポストは除外",
+			body:           `{"text":"hello","langs":["en"],"createdAt":"2024-01-01T00:00:00Z"}`,
+			expectedStatus: http.StatusOK,
+			expectedPassed: false,
+		},
+		{
+			name:           "不正なリクエスト",
+			body:           `invalid`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/feed/test-feed/test?did=did:plc:test123&rkey=abc", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d", tt.expectedStatus, recorder.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp TestPostResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if resp.Passed != tt.expectedPassed {
+				t.Errorf("Expected passed %v, but got %v", tt.expectedPassed, resp.Passed)
+			}
+			if len(resp.Blocks) != 1 || resp.Blocks[0].Type != "remove" || resp.Blocks[0].Passed != tt.expectedPassed {
+				t.Errorf("unexpected block results: %+v", resp.Blocks)
+			}
+		})
+	}
+}
+
+func TestAPIHandler_Summary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	// create config file
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	router := gin.Default()
+	router.GET("/api/summary", api.Summary)
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		POST("/post/:did/:rkey", api.AddPost)
+
+	// register feeds
+	for _, f := range []struct {
+		id       string
+		inactive bool
+	}{{"feed-b", true}, {"feed-a", false}} {
+		req, _ := http.NewRequest("POST", "/api/feed/"+f.id, nil)
+		req.Header.Set("Content-Type", "application/json")
+		req.Body = io.NopCloser(createJSONBody(t, map[string]any{
+			"uri":           "at://did:plc:abcdefg/app.bsky.feed.generator/" + f.id,
+			"configFile":    "test-config.yaml",
+			"inactiveStart": f.inactive,
+		}))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusCreated, recorder.Code)
+		}
+	}
+
+	// add post
+	req, _ := http.NewRequest("POST", "/api/feed/feed-a/post/did:plc:test123/rkey1",
+		strings.NewReader(`{"cid":"bafyreia1","indexedAt":"2024-01-01T00:00:00Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/summary", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var resp []FeedSummaryResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(resp) != 2 {
+		t.Fatalf("Expected 2 feeds, but got %d", len(resp))
+	}
+	expected := []struct {
+		id     string
+		status string
+		count  int
+	}{{"feed-a", "active", 1}, {"feed-b", "inactive", 0}}
+	for i, e := range expected {
+		if resp[i].ID != e.id || resp[i].Status != e.status {
+			t.Errorf("Expected %s (%s), but got %+v", e.id, e.status, resp[i])
+		}
+		if resp[i].PostCount == nil || *resp[i].PostCount != e.count {
+			t.Errorf("Expected post count %d for %s, but got %v", e.count, e.id, resp[i].PostCount)
+		}
+	}
+}
+
+func TestAPIHandler_ListLogicBlocks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	// create config file
+	configFile := filepath.Join(tempDir, "config", "blocks-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(`logic:
+    blocks:
+      - name: lang
+        type: remove
+        options:
+          subject: language
+          language: ja
+          operator: '!='
+      - name: dropin
+        type: dropin
+        options:
+          targetWord: ["join"]
+          expireDuration: 1h
+`), 0644)
+
+	router := gin.Default()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		GET("/logicblocks", api.ListLogicBlocks).
+		GET("/state", api.GetFeedState).
+		POST("/state", api.ImportFeedState).
+		POST("/logicblock/:logicblockname/:command", api.ProcessLogicBlockCommand)
+
+	// register feed
+	req, _ := http.NewRequest("POST", "/api/feed/test-feed", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Body = io.NopCloser(createJSONBody(t, map[string]any{
+		"uri":           "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed",
+		"configFile":    "blocks-config.yaml",
+		"inactiveStart": false,
+	}))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusCreated, recorder.Code, recorder.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/api/feed/test-feed/logicblocks", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var resp ListLogicBlocksResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	expected := []feed.BlockInfo{
+		{Name: "lang", Type: "remove"},
+		{Name: "dropin", Type: "dropin", CommandProcessor: true, MetricProvider: true, PreDeleteHandler: true, StateExporter: true},
+	}
+	if len(resp.Blocks) != len(expected) {
+		t.Fatalf("Expected %d blocks, but got %d", len(expected), len(resp.Blocks))
+	}
+	for i, e := range expected {
+		if resp.Blocks[i] != e {
+			t.Errorf("block %d: expected %+v, but got %+v", i, e, resp.Blocks[i])
+		}
+	}
+
+	// dropinの状態をエクスポートしてインポートし直す
+	req, _ = http.NewRequest("POST", "/api/feed/test-feed/logicblock/dropin/add", createJSONBody(t, map[string]any{
+		"args": map[string]string{"did": "did:plc:watched", "rkey": "rkey1"},
+	}))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	req, _ = http.NewRequest("GET", "/api/feed/test-feed/state", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	exported := recorder.Body.Bytes()
+	var stateResp FeedStateResponse
+	if err := json.Unmarshal(exported, &stateResp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(stateResp.Blocks) != 1 || stateResp.Blocks["dropin"].Type != "dropin" ||
+		!strings.Contains(string(stateResp.Blocks["dropin"].State), "did:plc:watched") {
+		t.Fatalf("Unexpected exported state: %s", exported)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/feed/test-feed/logicblock/dropin/reset", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	req, _ = http.NewRequest("POST", "/api/feed/test-feed/state", bytes.NewReader(exported))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	fi, _ := fs.GetFeedInfo("test-feed")
+	if msg, _ := fi.Feed.ProcessCommand("dropin", "list", nil); !strings.Contains(msg, "did:plc:watched") {
+		t.Errorf("Expected watchlist to be restored, got %s", msg)
+	}
+
+	// 存在しないブロックや種類の異なるブロックへのインポートは失敗する
+	for _, body := range []map[string]any{
+		{"blocks": map[string]any{"missing": map[string]any{"type": "dropin", "state": map[string]any{}}}},
+		{"blocks": map[string]any{"lang": map[string]any{"type": "dropin", "state": map[string]any{}}}},
+		{"blocks": map[string]any{"#0": map[string]any{"type": "remove", "state": map[string]any{}}}},
+	} {
+		req, _ = http.NewRequest("POST", "/api/feed/test-feed/state", createJSONBody(t, body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %v, but got %d", http.StatusBadRequest, body, recorder.Code)
+		}
+	}
+}
+
+func TestAPIHandler_AddPostIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	// create config file
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	router := gin.Default()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		POST("/post/:did/:rkey", api.AddPost).
+		GET("/post/:did/:rkey", api.GetPostByRkey)
+
+	// register feed
+	req, _ := http.NewRequest("POST", "/api/feed/test-feed", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Body = io.NopCloser(createJSONBody(t, map[string]any{
+		"uri":           "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed",
+		"configFile":    "test-config.yaml",
+		"inactiveStart": false,
+	}))
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusCreated, recorder.Code)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		key            string
+		cid            string
+		expectedStatus int
+		expectedCid    string
+	}{
+		{name: "first request", path: "/post/did:plc:test123/rkey1", key: "key1", cid: "bafyreicid1", expectedStatus: http.StatusOK, expectedCid: "bafyreicid1"},
+		{name: "retry returns prior result", path: "/post/did:plc:test123/rkey1", key: "key1", cid: "bafyreicid2", expectedStatus: http.StatusOK, expectedCid: "bafyreicid1"},
+		{name: "key reused for another post", path: "/post/did:plc:test123/rkey2", key: "key1", cid: "bafyreicid3", expectedStatus: http.StatusUnprocessableEntity},
+		{name: "without key", path: "/post/did:plc:test123/rkey3", cid: "bafyreicid4", expectedStatus: http.StatusOK, expectedCid: "bafyreicid4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/feed/test-feed"+tt.path, strings.NewReader(`{"cid":"`+tt.cid+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.key != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.key)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d", tt.expectedStatus, recorder.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp AddPostResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if resp.Post.Cid != tt.expectedCid {
+				t.Errorf("Expected cid %s in response, but got %s", tt.expectedCid, resp.Post.Cid)
+			}
+
+			// ストアの内容も確認する
+			req, _ = http.NewRequest("GET", "/api/feed/test-feed"+tt.path, nil)
+			recorder = httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			var post GetPostByRkeyResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &post); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if post.Post.Cid != tt.expectedCid {
+				t.Errorf("Expected stored cid %s, but got %s", tt.expectedCid, post.Post.Cid)
+			}
+		})
+	}
+
+	// 処理中のキーへの再送は書き込まずに競合として返す
+	api.addPostPending["test-feed\x00pending"] = "at://did:plc:test123/app.bsky.feed.post/rkey4"
+	for path, expectedStatus := range map[string]int{
+		"/post/did:plc:test123/rkey4": http.StatusConflict,
+		"/post/did:plc:test123/rkey5": http.StatusUnprocessableEntity,
+	} {
+		req, _ := http.NewRequest("POST", "/api/feed/test-feed"+path, strings.NewReader(`{"cid":"bafyreicid5"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "pending")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != expectedStatus {
+			t.Errorf("%s: expected status code %d, but got %d", path, expectedStatus, recorder.Code)
+		}
+	}
+
+	// 同時に届いた同じキーのリクエストは一度だけ書き込まれ、成功したレスポンスは同じ結果を返す
+	var wg sync.WaitGroup
+	cids := make(chan string, 10)
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("POST", "/api/feed/test-feed/post/did:plc:test123/rkey6", strings.NewReader(fmt.Sprintf(`{"cid":"bafyreicid%d"}`, 10+i)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(IdempotencyKeyHeader, "concurrent")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			switch recorder.Code {
+			case http.StatusOK:
+				var resp AddPostResponse
+				if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+					t.Errorf("Failed to parse response: %v", err)
+					return
+				}
+				cids <- resp.Post.Cid
+			case http.StatusConflict:
+			default:
+				t.Errorf("unexpected status code %d", recorder.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	close(cids)
+	fi, _ := fs.GetFeedInfo("test-feed")
+	stored, _ := fi.Feed.GetPost("did:plc:test123", "rkey6")
+	for cid := range cids {
+		if cid != stored.Cid {
+			t.Errorf("response cid %s differs from stored cid %s", cid, stored.Cid)
+		}
+	}
+}
+
+func TestAPIHandler_AddPostCIDValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	router := gin.Default()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		POST("/post/:did/:rkey", api.AddPost)
+
+	req, _ := http.NewRequest("POST", "/api/feed/test-feed", createJSONBody(t, map[string]any{
+		"uri":        "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed",
+		"configFile": "test-config.yaml",
+	}))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusCreated, recorder.Code)
+	}
+
+	tests := []struct {
+		name           string
+		cid            string
+		expectedStatus int
+	}{
+		{name: "valid cid", cid: "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm", expectedStatus: http.StatusOK},
+		{name: "empty cid", cid: "", expectedStatus: http.StatusBadRequest},
+		{name: "too short", cid: "bafy", expectedStatus: http.StatusBadRequest},
+		{name: "invalid characters", cid: "bafyrei-not/a:cid", expectedStatus: http.StatusBadRequest},
+		{name: "cidv0", cid: "QmbWqxBEKC3P8tqsKc98xmWNzrzDtRLMiMPL8wBuTGsMnR", expectedStatus: http.StatusBadRequest},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := fmt.Sprintf("/api/feed/test-feed/post/did:plc:test123/rkey%d", i)
+			req, _ := http.NewRequest("POST", path, strings.NewReader(`{"cid":"`+tt.cid+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, but got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.expectedStatus == http.StatusBadRequest && !strings.Contains(recorder.Body.String(), "invalid cid format") {
+				t.Errorf("Expected invalid cid message, but got %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIHandler_RegisterFeedValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	// create config file
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	router := gin.Default()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+
+	tests := []struct {
+		name           string
+		uri            string
+		configFile     string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "正常系",
+			uri:            "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed",
+			configFile:     "test-config.yaml",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "不正なURI",
+			uri:            "invalid",
+			configFile:     "test-config.yaml",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"uri"},
+		},
+		{
+			name:           "フィード以外のコレクション",
+			uri:            "at://did:plc:abcdefg/app.bsky.feed.post/test-feed",
+			configFile:     "test-config.yaml",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"uri"},
+		},
+		{
+			name:           "存在しない設定ファイル",
+			uri:            "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed",
+			configFile:     "missing.yaml",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"configFile"},
+		},
+		{
+			name:           "設定ディレクトリ外のファイル",
+			uri:            "invalid",
+			configFile:     "../test-config.yaml",
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"configFile", "uri"},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/api/feed/feed-%d", i), nil)
+			req.Header.Set("Content-Type", "application/json")
+			req.Body = io.NopCloser(createJSONBody(t, map[string]any{
+				"uri":        tt.uri,
+				"configFile": tt.configFile,
+			}))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.expectedStatus != http.StatusBadRequest {
+				return
+			}
+			var resp struct {
+				Fields map[string]string `json:"fields"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			fields := slices.Sorted(maps.Keys(resp.Fields))
+			if !slices.Equal(fields, tt.expectedFields) {
+				t.Errorf("Expected field errors %v, but got %v", tt.expectedFields, resp.Fields)
+			}
+		})
+	}
+}
+
+func TestAPIHandler_RegisterFeedInactiveStart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	router := gin.Default()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+
+	tests := []struct {
+		name           string
+		inactiveStart  string // raw json value. empty means omitted
+		expectedStatus int
+		wantStatus     Status
+		wantDefinition string
+	}{
+		{name: "bool true", inactiveStart: `true`, expectedStatus: http.StatusCreated, wantStatus: FeedStatusInactive, wantDefinition: "true"},
+		{name: "bool false", inactiveStart: `false`, expectedStatus: http.StatusCreated, wantStatus: FeedStatusActive, wantDefinition: "false"},
+		{name: "string true", inactiveStart: `"true"`, expectedStatus: http.StatusCreated, wantStatus: FeedStatusInactive, wantDefinition: "true"},
+		{name: "string false", inactiveStart: `"false"`, expectedStatus: http.StatusCreated, wantStatus: FeedStatusActive, wantDefinition: "false"},
+		{name: "string with spaces and case", inactiveStart: `" TRUE "`, expectedStatus: http.StatusCreated, wantStatus: FeedStatusInactive, wantDefinition: "true"},
+		{name: "null", inactiveStart: `null`, expectedStatus: http.StatusCreated, wantStatus: FeedStatusActive, wantDefinition: "false"},
+		{name: "omitted", expectedStatus: http.StatusCreated, wantStatus: FeedStatusActive, wantDefinition: "false"},
+		{name: "invalid string", inactiveStart: `"yes please"`, expectedStatus: http.StatusBadRequest},
+		{name: "number", inactiveStart: `1`, expectedStatus: http.StatusBadRequest},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedId := fmt.Sprintf("feed-%d", i)
+			body := fmt.Sprintf(`{"uri":"at://did:plc:abcdefg/app.bsky.feed.generator/%s","configFile":"test-config.yaml"`, feedId)
+			if tt.inactiveStart != "" {
+				body += `,"inactiveStart":` + tt.inactiveStart
+			}
+			body += "}"
+			req, _ := http.NewRequest("POST", "/api/feed/"+feedId, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+			fi, ok := fs.GetFeedInfo(feedId)
+			if !ok {
+				t.Fatal("feed not registered")
+			}
+			if fi.Status.LastStatus != tt.wantStatus {
+				t.Errorf("Expected status %s, but got %s", tt.wantStatus, fi.Status.LastStatus)
+			}
+			if fi.Definition.InactiveStart != tt.wantDefinition {
+				t.Errorf("Expected inactiveStart %q in definition, but got %q", tt.wantDefinition, fi.Definition.InactiveStart)
+			}
+		})
+	}
+}
+
+func TestAPIHandler_RegisterFeedInlineConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	router := gin.Default()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		GET("/config", api.GetConfig)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "インライン設定で作成",
+			body: `{"uri":"at://did:plc:abcdefg/app.bsky.feed.generator/inline","config":{
+				"logic":{"blocks":[{"type":"remove","options":{"subject":"item","value":"reply"}}]},
+				"store":{"trimAt":30,"trimRemain":10}}}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "不正なインライン設定",
+			body:           `{"uri":"at://did:plc:abcdefg/app.bsky.feed.generator/invalid","config":{"logic":{"blocks":[]},"store":{"trimAt":-1}}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "configFileとの同時指定",
+			body:           `{"uri":"at://did:plc:abcdefg/app.bsky.feed.generator/both","configFile":"test-config.yaml","config":{"logic":{"blocks":[]}}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/api/feed/feed-%d", i), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+
+	// 作成したフィードにインライン設定が反映されている
+	req, _ := http.NewRequest("GET", "/api/feed/feed-0/config", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var cfg struct {
+		Store struct {
+			TrimAt int `json:"trimAt"`
+		} `json:"store"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &cfg); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if cfg.Store.TrimAt != 30 {
+		t.Errorf("Expected trimAt 30, but got %d", cfg.Store.TrimAt)
+	}
+
+	// 定義にも保存され、リロード後も同じ設定が使われる
+	def, err := fs.definitionProvider.GetFeedDefinition("feed-0")
+	if err != nil {
+		t.Fatalf("Failed to get feed definition: %v", err)
+	}
+	if def.Config == nil || def.Config.Store().GetTrimAt() != 30 {
+		t.Errorf("Expected inline config in definition, but got %+v", def.Config)
+	}
+	if err := fs.ReloadFeed(context.Background(), "feed-0"); err != nil {
+		t.Fatalf("Failed to reload feed: %v", err)
+	}
+	fi, _ := fs.GetFeedInfo("feed-0")
+	if got := fi.Feed.Config().Store().GetTrimAt(); got != 30 {
+		t.Errorf("Expected trimAt 30 after reload, but got %d", got)
+	}
+}
+
+func TestAPIHandler_BulkUpdateFeedStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	router := gin.Default()
+	feedRoutes := router.Group("/api/feed")
+	feedRoutes.PUT("/:feedid", api.RegisterFeed)
+	feedRoutes.PATCH("/status", api.BulkUpdateFeedStatus)
+
+	for _, id := range []string{"feed-a", "feed-b"} {
+		req, _ := http.NewRequest("PUT", "/api/feed/"+id, createJSONBody(t, map[string]any{
+			"uri":        "at://did:plc:abcdefg/app.bsky.feed.generator/" + id,
+			"configFile": "test-config.yaml",
+		}))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusCreated, recorder.Code)
+		}
+	}
+	// エラー状態のフィード
+	errStatus := FeedStatus{FeedID: "feed-err"}
+	errStatus.SetError(fmt.Errorf("broken"))
+	fs.registerFeed(FeedDefinition{ID: "feed-err"}, nil, errStatus)
+
+	tests := []struct {
+		name        string
+		body        map[string]any
+		wantCode    int
+		wantResults map[string]string // id -> lastStatus or error
+	}{
+		{
+			name:     "update multiple feeds",
+			body:     map[string]any{"feeds": []string{"feed-a", "feed-b", "missing", "feed-err"}, "status": "inactive"},
+			wantCode: http.StatusOK,
+			wantResults: map[string]string{
+				"feed-a":   "inactive",
+				"feed-b":   "inactive",
+				"missing":  "feed not found",
+				"feed-err": "cannot update status: feed is in error or pending state or not initialized",
+			},
+		},
+		{
+			name:     "invalid status",
+			body:     map[string]any{"feeds": []string{"feed-a"}, "status": "paused"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "no feeds",
+			body:     map[string]any{"feeds": []string{}, "status": "active"},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("PATCH", "/api/feed/status", createJSONBody(t, tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.wantCode, recorder.Code, recorder.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Results []struct {
+					ID     string         `json:"id"`
+					Status map[string]any `json:"status"`
+					Error  string         `json:"error"`
+				} `json:"results"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if len(resp.Results) != len(tt.wantResults) {
+				t.Fatalf("expected %d results, got %d", len(tt.wantResults), len(resp.Results))
+			}
+			for _, r := range resp.Results {
+				got := r.Error
+				if r.Status != nil {
+					got, _ = r.Status["lastStatus"].(string)
+				}
+				if got != tt.wantResults[r.ID] {
+					t.Errorf("%s: expected %q, got %q", r.ID, tt.wantResults[r.ID], got)
+				}
+			}
+			if status, _ := fs.GetFeedStatus("feed-a"); status.LastStatus != FeedStatusInactive {
+				t.Errorf("expected feed-a to be inactive, got %v", status.LastStatus)
+			}
+		})
+	}
+}
+
+// handlers must log through slog, because the log pipeline parses only JSON slog lines
+func TestAPIHandler_AddPostNoStdout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	os.MkdirAll(filepath.Dir(configFile), 0755)
+	os.WriteFile(configFile, []byte(testConfig), 0644)
+
+	// gin.Default()はアクセスログを標準出力に書くため使わない
+	router := gin.New()
+	router.POST("/api/feed/:feedid", api.RegisterFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		POST("/post/:did/:rkey", api.AddPost)
+
+	req, _ := http.NewRequest("POST", "/api/feed/test-feed", createJSONBody(t, map[string]any{
+		"uri":        "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed",
+		"configFile": "test-config.yaml",
+	}))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusCreated, recorder.Code)
+	}
+
+	// 標準出力を差し替えてハンドラの出力を捕捉する
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	req, _ = http.NewRequest("POST", "/api/feed/test-feed/post/did:plc:test123/rkey1",
+		strings.NewReader(`{"cid":"bafyreicid1","indexedAt":"2024-01-01T00:00:00Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	if len(out) != 0 {
+		t.Errorf("Expected no output to stdout, but got %q", out)
+	}
+}
+
+func TestFeedStatusResponse_JSONCasing(t *testing.T) {
+	// FeedStatusResponseのフィールド名はFeedStatusと揃える
+	status := FeedStatus{FeedID: "test-feed", LastUpdated: time.Now(), LastStatus: FeedStatusError, Error: "failed"}
+	statusData, err := json.Marshal(&status)
+	if err != nil {
+		t.Fatalf("failed to marshal status: %v", err)
+	}
+	respData, err := json.Marshal(FeedStatusResponse{
+		LastStatus:  status.LastStatus.String(),
+		LastUpdated: status.LastUpdated.UTC().Format(time.RFC3339),
+		Error:       status.Error,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal response: %v", err)
+	}
+	var statusMap, respMap map[string]any
+	if err := json.Unmarshal(statusData, &statusMap); err != nil {
+		t.Fatalf("failed to unmarshal status: %v", err)
+	}
+	if err := json.Unmarshal(respData, &respMap); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	for key, value := range respMap {
+		if statusMap[key] != value {
+			t.Errorf("field %q: expected %v as in FeedStatus, got %v", key, statusMap[key], value)
+		}
+	}
+}
+
+func TestAPIHandler_ErrorFeedInfo(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+	router := gin.New()
+	router.GET("/api/feed", api.ListFeed)
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).GET("", api.GetFeedInfo)
+
+	def := FeedDefinition{ID: "broken", URI: "at://did:plc:abcdefg/app.bsky.feed.generator/broken", ConfigFile: "missing.yaml"}
+	status := FeedStatus{FeedID: def.ID}
+	status.SetError(errors.New("config file not found"))
+	fs.registerFeed(def, nil, status)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantFields map[string]any
+		omitted    []string
+	}{
+		{
+			name: "feed info",
+			path: "/api/feed/broken",
+			wantFields: map[string]any{
+				"id":    "broken",
+				"uri":   def.URI,
+				"error": "feed broken is in error state: config file not found",
+			},
+			omitted: []string{"config", "metrics"},
+		},
+		{
+			name: "feed list",
+			path: "/api/feed",
+			wantFields: map[string]any{
+				"id":    "broken",
+				"error": "config file not found",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+			}
+			var data map[string]any
+			if strings.HasPrefix(recorder.Body.String(), "[") {
+				var list []map[string]any
+				if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil || len(list) != 1 {
+					t.Fatalf("unexpected feed list %s: %v", recorder.Body.String(), err)
+				}
+				data = list[0]
+			} else if err := json.Unmarshal(recorder.Body.Bytes(), &data); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			for key, want := range tt.wantFields {
+				if data[key] != want {
+					t.Errorf("%s: expected %v, got %v", key, want, data[key])
+				}
+			}
+			for _, key := range tt.omitted {
+				if _, ok := data[key]; ok {
+					t.Errorf("expected %s to be omitted, got %v", key, data[key])
+				}
+			}
+		})
+	}
+}
+
+func TestAPIHandler_GetRecentTests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		size        int
+		wantEnabled bool
+		wantTests   int
+	}{
+		{name: "disabled by default", size: 0, wantEnabled: false, wantTests: 0},
+		{name: "enabled", size: 5, wantEnabled: true, wantTests: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, tempDir, err := createFeedService(t)
+			defer os.RemoveAll(tempDir)
+			if err != nil {
+				t.Fatalf("Failed to create feed service: %v", err)
+			}
+			fs.SetRecentTestsSize(tt.size)
+			configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+			if err := os.WriteFile(configFile, []byte(testConfig), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+			def := FeedDefinition{ID: "test-feed", URI: "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed", ConfigFile: "test-config.yaml"}
+			if err := fs.CreateFeed(context.Background(), def, FeedStatusActive); err != nil {
+				t.Fatalf("Failed to create feed: %v", err)
+			}
+			fi, _ := fs.GetFeedInfo(def.ID)
+			fi.Feed.Test("did:plc:user", "rkey1", &apibsky.FeedPost{Text: "hello"})
+			fi.Feed.Test("did:plc:user", "rkey2", &apibsky.FeedPost{Text: "world"})
+
+			api := NewFeedApiHandler(fs)
+			router := gin.New()
+			router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).GET("/recent", api.GetRecentTests)
+			req, _ := http.NewRequest("GET", "/api/feed/test-feed/recent", nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+			}
+			var resp RecentTestsResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+			if resp.Enabled != tt.wantEnabled || len(resp.Tests) != tt.wantTests {
+				t.Errorf("expected enabled=%v with %d tests, got %+v", tt.wantEnabled, tt.wantTests, resp)
+			}
+			if tt.wantTests > 0 && resp.Tests[0].Rkey != "rkey2" {
+				t.Errorf("expected newest test first, got %+v", resp.Tests[0])
+			}
+		})
+	}
+}
+
+func TestAPIHandler_RecentMatchesAndReevaluate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	richConfig := strings.Replace(testConfig, "trimRemain: 20", "trimRemain: 20\n  richPostLimit: 10", 1)
+	if err := os.WriteFile(configFile, []byte(richConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	def := FeedDefinition{ID: "test-feed", URI: "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed", ConfigFile: "test-config.yaml"}
+	if err := fs.CreateFeed(context.Background(), def, FeedStatusActive); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	fi, _ := fs.GetFeedInfo(def.ID)
+	for _, rkey := range []string{"rkey1", "rkey2"} {
+		if !fi.Feed.Test("did:plc:user", rkey, &apibsky.FeedPost{Text: "こんにちは", Langs: []string{"ja"}}) {
+			t.Fatalf("expected post %s to pass", rkey)
+		}
+		if err := fi.Feed.AddPost("did:plc:user", rkey, "cid", time.Now(), []string{"ja"}); err != nil {
+			t.Fatalf("Failed to add post: %v", err)
+		}
+	}
+
+	api := NewFeedApiHandler(fs)
+	router := gin.New()
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).
+		GET("/matches", api.GetRecentMatches).
+		POST("/reevaluate", api.ReevaluateFeed)
+
+	req, _ := http.NewRequest("GET", "/api/feed/test-feed/matches", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var resp RecentMatchesResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if !resp.Enabled || len(resp.Posts) != 2 || resp.Posts[0].Rkey != "rkey2" || resp.Posts[0].Text != "こんにちは" {
+		t.Errorf("expected 2 matches newest first, got %+v", resp)
+	}
+
+	// 設定を変えていないので削除されない
+	req, _ = http.NewRequest("POST", "/api/feed/test-feed/reevaluate", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var result feed.ReevaluateResult
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if result.PostBlocks != 1 || result.RetestedPosts != 2 || result.RemovedPosts != 0 {
+		t.Errorf("unexpected reevaluate result: %+v", result)
+	}
+}
+
+func TestAPIHandler_DiffConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fs, tempDir, err := createFeedService(t)
+	defer os.RemoveAll(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	if err := os.WriteFile(configFile, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	def := FeedDefinition{ID: "test-feed", URI: "at://did:plc:abcdefg/app.bsky.feed.generator/test-feed", ConfigFile: "test-config.yaml"}
+	if err := fs.CreateFeed(context.Background(), def, FeedStatusActive); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	api := NewFeedApiHandler(fs)
+	router := gin.New()
+	router.Group("/api/feed/:feedid").Use(api.ValidateFeedId()).POST("/config/diff", api.DiffConfig)
+
+	removeBlock := `{"type":"remove","options":{"subject":"language","language":"ja","operator":"!="}}`
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedDiff   string
+	}{
+		{
+			name:           "変更なし",
+			body:           `{"logic":{"blocks":[` + removeBlock + `]},"store":{"trimAt":24,"trimRemain":20},"detailedLog":false}`,
+			expectedStatus: http.StatusOK,
+			expectedDiff:   `{"changed":false,"blocksAdded":[],"blocksRemoved":[],"blocksChanged":[],"store":[]}`,
+		},
+		{
+			name:           "ストア設定の変更",
+			body:           `{"logic":{"blocks":[` + removeBlock + `]},"store":{"trimAt":48,"trimRemain":20}}`,
+			expectedStatus: http.StatusOK,
+			expectedDiff:   `{"changed":true,"blocksAdded":[],"blocksRemoved":[],"blocksChanged":[],"store":[{"key":"trimAt","old":24,"new":48}]}`,
+		},
+		{
+			name:           "不正な設定",
+			body:           `{"logic":{"blocks":[{"type":"regex","options":{}}]}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "不正なリクエスト",
+			body:           `invalid`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/feed/test-feed/config/diff", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && recorder.Body.String() != tt.expectedDiff {
+				t.Errorf("Expected diff %s, but got %s", tt.expectedDiff, recorder.Body.String())
+			}
+		})
+	}
+}
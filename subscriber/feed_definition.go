@@ -1,7 +1,9 @@
 package subscriber
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -10,9 +12,12 @@ import (
 	"time"
 
 	"github.com/goccy/go-yaml"
+	feedConfig "github.com/nus25/yuge/feed/config/feed"
 )
 
-var _ FeedDefinitionProvider = (*FileFeedDefinitionProvider)(nil) //type check
+var _ FeedDefinitionProvider = (*FileFeedDefinitionProvider)(nil)          //type check
+var _ VersionedFeedDefinitionProvider = (*FileFeedDefinitionProvider)(nil) //type check
+var _ FeedListFileImporter = (*FileFeedDefinitionProvider)(nil)            //type check
 
 const FILE_NAME = "feedlist.yaml"
 
@@ -24,11 +29,59 @@ type FeedDefinitionProvider interface {
 	DeleteFeedDefinition(feedId string) error
 }
 
+// VersionedFeedDefinitionProvider is implemented by providers that keep snapshots of the feed list
+type VersionedFeedDefinitionProvider interface {
+	ListVersions() ([]FeedDefinitionVersion, error)
+	GetVersion(version int) (FeedDefinitionVersion, []byte, error)
+	RestoreVersion(version int) (FeedDefinitionVersion, error)
+}
+
+// FeedListFileImporter is implemented by providers that read the feed list from versions
+// instead of the file edited by operators
+type FeedListFileImporter interface {
+	// ImportFeedListFile saves the feed list file as the latest version if it was edited after the latest version
+	ImportFeedListFile() (imported bool, err error)
+}
+
+// FeedDefinitionVersion describes a snapshot of the feed list
+type FeedDefinitionVersion struct {
+	Version   int       `json:"version"`
+	FileName  string    `json:"fileName"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ErrVersionNotFound is returned when the requested snapshot does not exist
+var ErrVersionNotFound = errors.New("version not found")
+
 type FeedDefinition struct {
 	ID            string `yaml:"id" json:"id"`
 	URI           string `yaml:"uri" json:"uri"`
 	ConfigFile    string `yaml:"configFile,omitempty" json:"configFile,omitempty"`
 	InactiveStart string `yaml:"inactiveStart,omitempty" json:"inactiveStart,omitempty"`
+	// Config is an inline feed config. if set, it is used instead of ConfigFile and PDS.
+	Config *feedConfig.FeedConfigImpl `yaml:"config,omitempty" json:"config,omitempty"`
+	// Mirrors are additional gyoka endpoints the feed's posts are also written to.
+	// mirror write failures are logged and do not fail the feed.
+	Mirrors []string `yaml:"mirrors,omitempty" json:"mirrors,omitempty"`
+}
+
+// validateMirrors checks that mirrors are unique http(s) endpoint urls
+func validateMirrors(mirrors []string) error {
+	seen := make(map[string]struct{}, len(mirrors))
+	for _, m := range mirrors {
+		u, err := url.Parse(m)
+		if err != nil {
+			return fmt.Errorf("invalid mirror url %q: %w", m, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid mirror url %q: must be an http or https url", m)
+		}
+		if _, ok := seen[m]; ok {
+			return fmt.Errorf("duplicate mirror url %q", m)
+		}
+		seen[m] = struct{}{}
+	}
+	return nil
 }
 
 type FeedDefinitionList struct {
@@ -123,6 +176,89 @@ func (p *FileFeedDefinitionProvider) getLatestVersionFile() (string, error) {
 	return filepath.Join(p.versionDir, versionFiles[0]), nil
 }
 
+// parseVersionFileName parses "feedlist_v1_20230101_120000.yaml"
+func parseVersionFileName(name string) (FeedDefinitionVersion, bool) {
+	prefix := FILE_NAME[:len(FILE_NAME)-5] + "_v"
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".yaml") {
+		return FeedDefinitionVersion{}, false
+	}
+	parts := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".yaml"), "_", 2)
+	if len(parts) != 2 {
+		return FeedDefinitionVersion{}, false
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return FeedDefinitionVersion{}, false
+	}
+	// saveVersionFileはローカル時刻で保存している
+	ts, err := time.ParseInLocation("20060102_150405", parts[1], time.Local)
+	if err != nil {
+		return FeedDefinitionVersion{}, false
+	}
+	return FeedDefinitionVersion{Version: version, FileName: name, Timestamp: ts}, true
+}
+
+// ListVersions returns the snapshots of the feed list, newest first
+func (p *FileFeedDefinitionProvider) ListVersions() ([]FeedDefinitionVersion, error) {
+	files, err := os.ReadDir(p.versionDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []FeedDefinitionVersion{}, nil
+		}
+		return nil, fmt.Errorf("failed to read version directory: %w", err)
+	}
+	versions := make([]FeedDefinitionVersion, 0, len(files))
+	for _, file := range files {
+		if v, ok := parseVersionFileName(file.Name()); ok {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+	return versions, nil
+}
+
+// GetVersion returns the snapshot of the given version and its contents
+func (p *FileFeedDefinitionProvider) GetVersion(version int) (FeedDefinitionVersion, []byte, error) {
+	versions, err := p.ListVersions()
+	if err != nil {
+		return FeedDefinitionVersion{}, nil, err
+	}
+	for _, v := range versions {
+		if v.Version != version {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(p.versionDir, v.FileName))
+		if err != nil {
+			return FeedDefinitionVersion{}, nil, fmt.Errorf("failed to read version file: %w", err)
+		}
+		return v, data, nil
+	}
+	return FeedDefinitionVersion{}, nil, fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+}
+
+// RestoreVersion saves the snapshot of the given version as a new latest version.
+// returns the newly created version.
+func (p *FileFeedDefinitionProvider) RestoreVersion(version int) (FeedDefinitionVersion, error) {
+	_, data, err := p.GetVersion(version)
+	if err != nil {
+		return FeedDefinitionVersion{}, err
+	}
+	var list FeedDefinitionList
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return FeedDefinitionVersion{}, fmt.Errorf("failed to parse version file: %w", err)
+	}
+	if err := p.saveVersionFile(data); err != nil {
+		return FeedDefinitionVersion{}, fmt.Errorf("failed to save version file: %w", err)
+	}
+	versions, err := p.ListVersions()
+	if err != nil {
+		return FeedDefinitionVersion{}, err
+	}
+	return versions[0], nil
+}
+
 func (p *FileFeedDefinitionProvider) GetFeedDefinitionList() (*FeedDefinitionList, error) {
 	// パスの検証
 	if _, err := os.Stat(p.baseDir); os.IsNotExist(err) {
@@ -169,6 +305,44 @@ func (p *FileFeedDefinitionProvider) GetFeedDefinitionList() (*FeedDefinitionLis
 	return &list, nil
 }
 
+// ImportFeedListFile saves feedlist.yaml as a new version if it was modified after the latest version file,
+// so that the file edited out-of-band (e.g. deployed from git) takes effect.
+// the file is not imported if it cannot be parsed.
+func (p *FileFeedDefinitionProvider) ImportFeedListFile() (bool, error) {
+	info, err := os.Stat(filepath.Join(p.baseDir, FILE_NAME))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	latest, err := p.getLatestVersionFile()
+	if err != nil {
+		return false, fmt.Errorf("failed to get latest version file: %w", err)
+	}
+	if latest != "" {
+		vinfo, err := os.Stat(latest)
+		if err != nil {
+			return false, err
+		}
+		if !info.ModTime().After(vinfo.ModTime()) {
+			return false, nil
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(p.baseDir, FILE_NAME))
+	if err != nil {
+		return false, fmt.Errorf("failed to read feed list file: %w", err)
+	}
+	var list FeedDefinitionList
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return false, fmt.Errorf("failed to parse feed list yaml: %w", err)
+	}
+	if err := p.saveVersionFile(data); err != nil {
+		return false, fmt.Errorf("failed to save version file: %w", err)
+	}
+	return true, nil
+}
+
 func (p *FileFeedDefinitionProvider) getNextVersionNumber() (int, error) {
 	// バージョンディレクトリ内のファイルを取得
 	files, err := os.ReadDir(p.versionDir)
@@ -18,6 +18,9 @@ type FeedStatus struct {
 	LastUpdated time.Time `json:"lastUpdated"`
 	LastStatus  Status    `json:"lastStatus"`
 	Error       string    `json:"error,omitempty"`
+	// IngestPaused stops adding new posts from jetstream while the feed keeps serving posts.
+	// unlike FeedStatusInactive, the feed stays active and deletions are still applied.
+	IngestPaused bool `json:"ingestPaused,omitempty"`
 }
 
 func (fs *FeedStatus) MarshalJSON() ([]byte, error) {
@@ -29,6 +32,9 @@ func (fs *FeedStatus) MarshalJSON() ([]byte, error) {
 	if fs.Error != "" {
 		m["error"] = fs.Error
 	}
+	if fs.IngestPaused {
+		m["ingestPaused"] = true
+	}
 	return json.Marshal(m)
 }
 
@@ -42,6 +48,18 @@ func (fs *FeedStatus) SetError(err error) {
 	}
 }
 
+// SetPending marks the feed as waiting for a retriable dependency such as PDS.
+// a pending feed has no Feed instance and can be recovered by ReloadFeed.
+func (fs *FeedStatus) SetPending(err error) {
+	fs.SetError(err)
+	fs.LastStatus = FeedStatusPending
+}
+
+// IsUnavailable returns true if the feed cannot serve requests (error or pending)
+func (fs *FeedStatus) IsUnavailable() bool {
+	return fs.LastStatus == FeedStatusError || fs.LastStatus == FeedStatusPending
+}
+
 type Status int
 
 const (
@@ -49,6 +67,7 @@ const (
 	FeedStatusActive
 	FeedStatusInactive
 	FeedStatusError
+	FeedStatusPending
 )
 
 func (s Status) String() string {
@@ -59,6 +78,8 @@ func (s Status) String() string {
 		return "inactive"
 	case FeedStatusError:
 		return "error"
+	case FeedStatusPending:
+		return "pending"
 	default:
 		return "unknown"
 	}
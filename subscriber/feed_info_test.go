@@ -45,6 +45,21 @@ func TestFeedStatus_MarshalJSON(t *testing.T) {
 				"error":       "something went wrong",
 			},
 		},
+		{
+			name: "取り込み一時停止中のステータス",
+			status: FeedStatus{
+				FeedID:       "paused-feed",
+				LastUpdated:  now,
+				LastStatus:   FeedStatusActive,
+				IngestPaused: true,
+			},
+			expected: map[string]any{
+				"feedId":       "paused-feed",
+				"lastUpdated":  now.UTC().Format(time.RFC3339),
+				"lastStatus":   "active",
+				"ingestPaused": true,
+			},
+		},
 	}
 
 	// テストの実行
@@ -125,6 +140,20 @@ func TestFeedStatus_SetError(t *testing.T) {
 	}
 }
 
+func TestFeedStatus_SetPending(t *testing.T) {
+	fs := FeedStatus{FeedID: "test-feed", LastStatus: FeedStatusActive}
+	fs.SetPending(errors.New("pds unavailable"))
+	if fs.LastStatus != FeedStatusPending {
+		t.Errorf("Expected LastStatus to be FeedStatusPending, got %v", fs.LastStatus)
+	}
+	if fs.Error != "pds unavailable" {
+		t.Errorf("Expected Error to be %q, got %q", "pds unavailable", fs.Error)
+	}
+	if !fs.IsUnavailable() {
+		t.Error("pending feed should be unavailable")
+	}
+}
+
 func TestStatus_String(t *testing.T) {
 	// テストケースの設定
 	tests := []struct {
@@ -134,6 +163,7 @@ func TestStatus_String(t *testing.T) {
 		{FeedStatusActive, "active"},
 		{FeedStatusInactive, "inactive"},
 		{FeedStatusError, "error"},
+		{FeedStatusPending, "pending"},
 		{FeedStatusUnknown, "unknown"},
 		{Status(999), "unknown"}, // 未定義の値
 	}
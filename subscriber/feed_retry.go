@@ -0,0 +1,116 @@
+package subscriber
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultFeedRetryInterval    = time.Minute
+	DefaultFeedRetryMaxAttempts = 10
+	// backoff between retries of a feed is capped at 2^maxFeedRetryBackoffShift intervals
+	maxFeedRetryBackoffShift = 5
+)
+
+type feedRetryState struct {
+	attempts int
+	skip     int // number of ticks to wait before the next retry
+}
+
+// FeedRetrier periodically reloads feeds in error or pending state that failed to be created,
+// so that feeds failed by a transient PDS or store backend outage recover by themselves.
+// running feeds set to error by an operator are not retried.
+// retries of each feed are backed off exponentially and stop after maxAttempts (0: unlimited).
+type FeedRetrier struct {
+	logger      *slog.Logger
+	fs          *FeedService
+	interval    time.Duration
+	maxAttempts int
+
+	mu     sync.Mutex
+	states map[string]*feedRetryState
+}
+
+func NewFeedRetrier(logger *slog.Logger, fs *FeedService, interval time.Duration, maxAttempts int) *FeedRetrier {
+	if interval <= 0 {
+		interval = DefaultFeedRetryInterval
+	}
+	if maxAttempts < 0 {
+		maxAttempts = 0
+	}
+	return &FeedRetrier{
+		logger:      logger.With("source", "feed-retry"),
+		fs:          fs,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		states:      make(map[string]*feedRetryState),
+	}
+}
+
+// Run retries unavailable feeds every interval until ctx is canceled
+func (r *FeedRetrier) Run(ctx context.Context) {
+	r.logger.Info("starting feed retry loop", "interval", r.interval, "maxAttempts", r.maxAttempts)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Retry(ctx)
+		}
+	}
+}
+
+// Retry is called on each tick and reloads feeds without a running instance whose backoff has elapsed
+func (r *FeedRetrier) Retry(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unavailable := make(map[string]struct{})
+	for id, fi := range r.fs.GetAllFeeds() {
+		// 実行中のフィードがエラーなのはオペレーターが設定した場合なので再起動しない
+		if !fi.Status.IsUnavailable() || fi.Feed != nil {
+			continue
+		}
+		unavailable[id] = struct{}{}
+		state, ok := r.states[id]
+		if !ok {
+			state = &feedRetryState{}
+			r.states[id] = state
+		}
+		if r.maxAttempts > 0 && state.attempts >= r.maxAttempts {
+			continue
+		}
+		if state.skip > 0 {
+			state.skip--
+			continue
+		}
+
+		state.attempts++
+		// 1, 2, 4, ... tick(s) after this attempt
+		state.skip = 1<<min(state.attempts-1, maxFeedRetryBackoffShift) - 1
+		r.logger.Info("retrying feed", "feedId", id, "status", fi.Status.LastStatus.String(), "attempt", state.attempts)
+		if err := r.fs.ReloadFeed(ctx, id); err != nil {
+			if r.maxAttempts > 0 && state.attempts >= r.maxAttempts {
+				r.logger.Error("giving up retrying feed", "feedId", id, "attempts", state.attempts, "error", err)
+			} else {
+				r.logger.Warn("failed to retry feed", "feedId", id, "attempt", state.attempts, "nextRetryIn", r.interval*time.Duration(state.skip+1), "error", err)
+			}
+			continue
+		}
+		if fi, ok := r.fs.GetFeedInfo(id); ok && !fi.Status.IsUnavailable() {
+			r.logger.Info("feed recovered", "feedId", id, "attempts", state.attempts)
+			delete(unavailable, id)
+		}
+	}
+
+	// 回復または削除されたフィードの状態は破棄する
+	for id := range r.states {
+		if _, ok := unavailable[id]; !ok {
+			delete(r.states, id)
+		}
+	}
+}
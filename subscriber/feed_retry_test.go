@@ -0,0 +1,114 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/nus25/yuge/feed/config/feed"
+	"github.com/nus25/yuge/feed/store/editor"
+)
+
+func TestFeedRetrier(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	dataDir := filepath.Join(tempDir, "data")
+	logger := slog.Default()
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	dp, err := NewFileFeedDefinitionProvider(configDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	e, err := editor.NewFileEditor(dataDir, logger)
+	if err != nil {
+		t.Fatalf("Failed to create editor: %v", err)
+	}
+	service, err := NewFeedService(configDir, dataDir, dp, e, logger)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	// 設定ファイルが読めずにエラー状態になったフィード
+	def := FeedDefinition{ID: "retry", URI: "at://did:plc:1234567890/app.bsky.feed.generator/retry", ConfigFile: "retry.yaml"}
+	if err := dp.AddFeedDefinition(def); err != nil {
+		t.Fatalf("Failed to add feed definition: %v", err)
+	}
+	status := FeedStatus{FeedID: def.ID}
+	status.SetError(errors.New("config not found"))
+	service.registerFeed(def, nil, status)
+
+	r := NewFeedRetrier(logger, service, 0, 3)
+	ctx := context.Background()
+	attempts := func() int {
+		if s, ok := r.states[def.ID]; ok {
+			return s.attempts
+		}
+		return 0
+	}
+
+	// tick毎の試行回数: 1回目の失敗後は次のtick、2回目の失敗後は1tick空けて再試行する
+	for i, want := range []int{1, 2, 2, 3} {
+		r.Retry(ctx)
+		if got := attempts(); got != want {
+			t.Fatalf("tick %d: expected %d attempts, got %d", i+1, want, got)
+		}
+	}
+	// maxAttemptsに達したら再試行しない
+	for range 10 {
+		r.Retry(ctx)
+	}
+	if got := attempts(); got != 3 {
+		t.Fatalf("expected retries to stop at 3 attempts, got %d", got)
+	}
+
+	// 上限なしの場合は設定ファイルが用意されれば回復する
+	r = NewFeedRetrier(logger, service, 0, 0)
+	r.Retry(ctx)
+	cfg, err := feed.NewFeedConfigFromJSON(`{"logic":{"blocks":[]}}`)
+	if err != nil {
+		t.Fatalf("Failed to create feed config: %v", err)
+	}
+	yamlStr, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Failed to marshal feed config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "retry.yaml"), yamlStr, 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	r.Retry(ctx)
+	fi, exists := service.GetFeedInfo(def.ID)
+	if !exists {
+		t.Fatal("Expected feed to exist")
+	}
+	if fi.Status.LastStatus != FeedStatusActive || fi.Feed == nil {
+		t.Errorf("expected feed to recover, got status %v", fi.Status.LastStatus)
+	}
+	if _, ok := r.states[def.ID]; ok {
+		t.Error("expected retry state to be cleared after recovery")
+	}
+}
+
+func TestFeedRetrier_SkipsOperatorSetError(t *testing.T) {
+	fs := &FeedService{feeds: make(map[string]FeedInfo), logger: slog.Default()}
+	running := &handlerTestFeed{}
+	fs.registerFeed(FeedDefinition{ID: "operator-error"}, running, FeedStatus{FeedID: "operator-error", LastStatus: FeedStatusActive})
+	if err := fs.UpdateStatus("operator-error", FeedStatusError); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+
+	r := NewFeedRetrier(slog.Default(), fs, 0, 0)
+	r.Retry(context.Background())
+	if _, ok := r.states["operator-error"]; ok {
+		t.Error("expected running feed set to error not to be retried")
+	}
+	fi, _ := fs.GetFeedInfo("operator-error")
+	if fi.Status.LastStatus != FeedStatusError || fi.Feed == nil {
+		t.Errorf("expected feed to stay in error state, got %v", fi.Status.LastStatus)
+	}
+}
@@ -2,11 +2,16 @@ package subscriber
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nus25/yuge/feed"
@@ -15,14 +20,34 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	// DefaultFeedCreateTimeout is the default timeout for creating a feed, including loading its posts
+	DefaultFeedCreateTimeout = 30 * time.Second
+	// DefaultFeedShutdownTimeout is the default timeout for shutting down a feed on reload or removal
+	DefaultFeedShutdownTimeout = 30 * time.Second
+)
+
 type FeedService struct {
-	definitionProvider FeedDefinitionProvider
-	configDir          string
-	dataDir            string
-	storeEditor        editor.StoreEditor
-	feeds              map[string]FeedInfo
-	logger             *slog.Logger
-	mu                 sync.RWMutex
+	definitionProvider  FeedDefinitionProvider
+	configDir           string
+	dataDir             string
+	storeEditor         editor.StoreEditor
+	mirrorEditorOptions []editor.ClientOptionFunc          // options for gyoka editors of feed mirrors
+	mirrorEditors       map[string]*editor.CompositeEditor // feed id -> editor of feeds with mirrors
+	pdsConfigCacheDir   string                             // if empty, PDS configs are not cached
+	configCache         *feedConfigCache                   // parsed config files shared by feeds
+	statusFile          *feedStatusFile                    // statuses restored on startup. nil: not persisted
+	feedCreateTimeout   time.Duration
+	feedShutdownTimeout time.Duration
+	recentTestsSize     int                 // number of recently tested posts kept by each feed. 0: disabled
+	blockTestTimeout    time.Duration       // maximum time each logic block may take to test a post. 0: disabled
+	feedFilter          map[string]struct{} // if not nil, only these feeds are loaded from the definition list
+	feeds               map[string]FeedInfo
+	logger              *slog.Logger
+	mu                  sync.RWMutex
+	reloadMu            sync.Mutex // serializes reloads of the feed list
+	onFeedsChanged      func()     // called after feeds are added, removed or change status
+	feedsLoaded         atomic.Bool
 }
 
 func NewFeedService(configDir string, dataDir string, definitionProvider FeedDefinitionProvider, storeEditor editor.StoreEditor, logger *slog.Logger) (*FeedService, error) {
@@ -45,17 +70,94 @@ func NewFeedService(configDir string, dataDir string, definitionProvider FeedDef
 			return nil, fmt.Errorf("failed to create file editor: %w", err)
 		}
 	}
+	statusFile, err := loadFeedStatusFile(filepath.Join(dataDir, FeedStatusFileName))
+	if err != nil {
+		logger.Warn("failed to load persisted feed statuses", "error", err)
+	}
 	return &FeedService{
-		configDir:          configDir,
-		dataDir:            dataDir,
-		definitionProvider: definitionProvider,
-		storeEditor:        storeEditor,
-		feeds:              make(map[string]FeedInfo),
-		logger:             logger,
+		configDir:           configDir,
+		dataDir:             dataDir,
+		definitionProvider:  definitionProvider,
+		storeEditor:         storeEditor,
+		pdsConfigCacheDir:   filepath.Join(dataDir, provider.PDSConfigCacheDirName),
+		configCache:         newFeedConfigCache(),
+		statusFile:          statusFile,
+		feedCreateTimeout:   DefaultFeedCreateTimeout,
+		feedShutdownTimeout: DefaultFeedShutdownTimeout,
+		feeds:               make(map[string]FeedInfo),
+		logger:              logger,
 	}, nil
 }
 
+// SetMirrorEditorOptions sets the client options (e.g. credentials) for the gyoka editors
+// created for the mirrors of feed definitions. must be called before loading feeds.
+func (s *FeedService) SetMirrorEditorOptions(opts ...editor.ClientOptionFunc) {
+	s.mirrorEditorOptions = opts
+}
+
+// SetFeedTimeouts sets the timeouts for creating and shutting down a feed.
+// zero or negative values keep the current timeout. must be called before loading feeds.
+func (s *FeedService) SetFeedTimeouts(create time.Duration, shutdown time.Duration) {
+	if create > 0 {
+		s.feedCreateTimeout = create
+	}
+	if shutdown > 0 {
+		s.feedShutdownTimeout = shutdown
+	}
+}
+
+// SetRecentTestsSize sets the number of recently tested posts kept by each feed for debugging.
+// 0 disables recording. must be called before loading feeds.
+func (s *FeedService) SetRecentTestsSize(size int) {
+	s.recentTestsSize = max(size, 0)
+}
+
+// SetBlockTestTimeout sets the maximum time each logic block may take to test a post.
+// a block exceeding it is treated as not passed. 0 disables the timeout. must be called before loading feeds.
+func (s *FeedService) SetBlockTestTimeout(timeout time.Duration) {
+	s.blockTestTimeout = max(timeout, 0)
+}
+
+// SetFeedFilter limits the feeds loaded from the definition list to ids.
+// an empty ids loads all feeds. must be called before loading feeds.
+func (s *FeedService) SetFeedFilter(ids []string) {
+	if len(ids) == 0 {
+		s.feedFilter = nil
+		return
+	}
+	s.feedFilter = make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			s.feedFilter[id] = struct{}{}
+		}
+	}
+}
+
+// DisablePDSConfigCache stops caching configs fetched from PDS under the data directory.
+// must be called before loading feeds.
+func (s *FeedService) DisablePDSConfigCache() {
+	s.pdsConfigCacheDir = ""
+}
+
+// SetFeedsChangedHandler sets a function called after feeds are added, removed or change status.
+func (s *FeedService) SetFeedsChangedHandler(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onFeedsChanged = fn
+}
+
+func (s *FeedService) notifyFeedsChanged() {
+	s.mu.RLock()
+	fn := s.onFeedsChanged
+	s.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
+
 func (s *FeedService) LoadFeeds(ctx context.Context) error {
+	// 失敗したフィードがあっても読み込み処理は完了したものとする
+	defer s.feedsLoaded.Store(true)
 	if s.definitionProvider == nil {
 		return fmt.Errorf("feed definition provider is nil")
 	}
@@ -64,15 +166,23 @@ func (s *FeedService) LoadFeeds(ctx context.Context) error {
 		return fmt.Errorf("failed to get feed definition list: %w", err)
 	}
 
+	// フィルター外のフィード（APIで登録したものなど）は削除対象にしない
 	currentFeeds := make(map[string]bool)
+	s.mu.RLock()
 	for id := range s.feeds {
-		currentFeeds[id] = true
+		if s.inFeedFilter(id) {
+			currentFeeds[id] = true
+		}
 	}
+	s.mu.RUnlock()
 
-	g, ctx := errgroup.WithContext(ctx)
+	feeds := s.filterFeedDefinitions(fdl.Feeds)
+
+	// 1つのフィードの失敗で他のフィードの読み込みを中断しないようにctxは共有しない
+	var g errgroup.Group
 	g.SetLimit(10) // Limit the number of concurrent executions
 
-	for _, f := range fdl.Feeds {
+	for _, f := range feeds {
 		def := f // capture loop variable
 		g.Go(func() error {
 			_, exists := s.GetFeedInfo(def.ID)
@@ -91,9 +201,19 @@ func (s *FeedService) LoadFeeds(ctx context.Context) error {
 				} else {
 					initialStatus = FeedStatusActive
 				}
+				// 前回の起動時にオペレーターが設定したステータスを優先する
+				ps, persisted := s.statusFile.get(def.ID)
+				if status, ok := parseUpdateStatus(ps.Status); persisted && ok {
+					initialStatus = status
+				}
 				if err := s.CreateFeed(ctx, def, initialStatus); err != nil {
 					return fmt.Errorf("failed to create feed %s: %w", def.ID, err)
 				}
+				if ps.IngestPaused {
+					if err := s.SetIngestPaused(def.ID, true); err != nil {
+						return err
+					}
+				}
 			}
 
 			s.mu.Lock()
@@ -108,17 +228,147 @@ func (s *FeedService) LoadFeeds(ctx context.Context) error {
 	}
 
 	// delete unnecessary feeds
+	// definitions are already removed from the list, so only stop the feeds
 	for id := range currentFeeds {
-		if err := s.DeleteFeed(id); err != nil {
-			s.logger.Error("failed to delete feed",
-				slog.String("feed_id", id),
-				slog.String("error", err.Error()))
-		}
+		s.removeFeed(id)
 	}
 
 	return nil
 }
 
+// inFeedFilter reports whether the feed is loaded from the definition list under the feed filter
+func (s *FeedService) inFeedFilter(feedId string) bool {
+	if s.feedFilter == nil {
+		return true
+	}
+	_, ok := s.feedFilter[feedId]
+	return ok
+}
+
+// filterFeedDefinitions returns the definitions of the feed filter.
+// ids in the filter but not in the definition list are logged and ignored.
+func (s *FeedService) filterFeedDefinitions(defs []FeedDefinition) []FeedDefinition {
+	if s.feedFilter == nil {
+		return defs
+	}
+	filtered := make([]FeedDefinition, 0, len(s.feedFilter))
+	found := make(map[string]struct{}, len(s.feedFilter))
+	for _, def := range defs {
+		if _, ok := s.feedFilter[def.ID]; ok {
+			filtered = append(filtered, def)
+			found[def.ID] = struct{}{}
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(s.feedFilter)) {
+		if _, ok := found[id]; !ok {
+			s.logger.Warn("feed in the feed filter is not defined", "feedId", id)
+		}
+	}
+	s.logger.Info("loading filtered feeds", "feeds", len(filtered), "defined", len(defs))
+	return filtered
+}
+
+// FeedsLoaded reports whether LoadFeeds has completed at least once
+func (s *FeedService) FeedsLoaded() bool {
+	return s.feedsLoaded.Load()
+}
+
+// RollbackFeedList saves the feed list snapshot of the given version as the latest version
+// and reconciles running feeds with it. returns the ids of feeds added and removed.
+// if reconciling fails, the rollback is kept and the error is returned with the diff.
+func (s *FeedService) RollbackFeedList(ctx context.Context, version int) (restored FeedDefinitionVersion, added []string, removed []string, err error) {
+	vp, ok := s.definitionProvider.(VersionedFeedDefinitionProvider)
+	if !ok {
+		return FeedDefinitionVersion{}, nil, nil, fmt.Errorf("feed definition provider does not support versions")
+	}
+	// 保存から反映までをReloadFeedListと同じロックで行い、別の再読み込みが間に入らないようにする
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	restored, err = vp.RestoreVersion(version)
+	if err != nil {
+		return FeedDefinitionVersion{}, nil, nil, err
+	}
+	s.logger.Info("feed list rolled back", "version", version, "restored", restored.Version)
+
+	result, err := s.reconcileFeeds(ctx)
+	return restored, result.Added, result.Removed, err
+}
+
+// FeedListReloadResult is the ids of feeds changed by ReloadFeedList
+type FeedListReloadResult struct {
+	Imported bool     `json:"imported"` // the edited feed list file was saved as the latest version
+	Added    []string `json:"added"`
+	Reloaded []string `json:"reloaded"` // feeds defined before and after the reload
+	Removed  []string `json:"removed"`
+}
+
+// ReloadFeedList loads the feed list again and reconciles running feeds with it.
+// if the provider keeps versions, the feed list file edited after the latest version is imported first.
+// if loading fails, the feeds changed so far are returned with the error.
+func (s *FeedService) ReloadFeedList(ctx context.Context) (FeedListReloadResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	imported := false
+	if im, ok := s.definitionProvider.(FeedListFileImporter); ok {
+		var err error
+		imported, err = im.ImportFeedListFile()
+		if err != nil {
+			return FeedListReloadResult{Added: []string{}, Reloaded: []string{}, Removed: []string{}}, fmt.Errorf("failed to import feed list file: %w", err)
+		}
+		if imported {
+			s.logger.Info("imported edited feed list file")
+		}
+	}
+	result, err := s.reconcileFeeds(ctx)
+	result.Imported = imported
+	if err == nil {
+		s.logger.Info("feed list reloaded", "added", result.Added, "removed", result.Removed, "reloaded", len(result.Reloaded))
+	}
+	return result, err
+}
+
+// reconcileFeeds runs LoadFeeds and returns the ids of feeds changed by it.
+// callers must hold reloadMu.
+func (s *FeedService) reconcileFeeds(ctx context.Context) (FeedListReloadResult, error) {
+	before := s.feedIDs()
+	loadErr := s.LoadFeeds(ctx)
+	after := s.feedIDs()
+	result := FeedListReloadResult{
+		Added:    make([]string, 0),
+		Reloaded: make([]string, 0),
+		Removed:  make([]string, 0),
+	}
+	for id := range after {
+		if _, ok := before[id]; ok {
+			result.Reloaded = append(result.Reloaded, id)
+		} else {
+			result.Added = append(result.Added, id)
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			result.Removed = append(result.Removed, id)
+		}
+	}
+	slices.Sort(result.Added)
+	slices.Sort(result.Reloaded)
+	slices.Sort(result.Removed)
+	if loadErr != nil {
+		return result, fmt.Errorf("failed to load feeds: %w", loadErr)
+	}
+	return result, nil
+}
+
+func (s *FeedService) feedIDs() map[string]struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make(map[string]struct{}, len(s.feeds))
+	for id := range s.feeds {
+		ids[id] = struct{}{}
+	}
+	return ids
+}
+
 func (s *FeedService) ReloadFeed(ctx context.Context, feedId string) error {
 	s.logger.Info("reloading feed", "feedId", feedId)
 
@@ -129,29 +379,47 @@ func (s *FeedService) ReloadFeed(ctx context.Context, feedId string) error {
 	}
 
 	// read feed definition list
+	if s.definitionProvider == nil {
+		return fmt.Errorf("no feed definition provider")
+	}
 	def, err := s.definitionProvider.GetFeedDefinition(feedId)
 	if err != nil {
 		return fmt.Errorf("failed to get feed definition: %w", err)
 	}
 
 	// shutdown existing feed
+	var matches []feed.RichPost
 	if fi.Feed != nil {
-		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		// 再評価に使えるようにリッチストアの内容を引き継ぐ
+		matches, _ = fi.Feed.RecentMatches()
+		ctx, cancel := context.WithTimeout(ctx, s.feedShutdownTimeout)
 		defer cancel()
 		if err := fi.Feed.Shutdown(ctx); err != nil {
 			s.logger.Error("failed to shutdown existing feed", "feedId", feedId, "error", err)
 			// even if shutdown fails, continue processing
 		}
+		if err := s.closeMirrorEditors(ctx, feedId); err != nil {
+			s.logger.Error("failed to close mirror editors", "feedId", feedId, "error", err)
+		}
 	}
 
 	// delete from feedlist
 	s.unregisterFeed(feedId)
 	var newStatus Status
-	if fi.Status.LastStatus == FeedStatusInactive {
+	ps, persisted := s.statusFile.get(feedId)
+	savedStatus, saved := parseUpdateStatus(ps.Status)
+	switch {
+	case fi.Status.LastStatus == FeedStatusInactive:
 		//inactive
 		newStatus = FeedStatusInactive
-	} else {
-		//error,active
+	case persisted && saved:
+		// オペレーターが保存したステータスを優先する
+		newStatus = savedStatus
+	case fi.Status.LastStatus == FeedStatusPending && def.InactiveStart == "true":
+		//pending feed never started, follow the definition
+		newStatus = FeedStatusInactive
+	default:
+		//error,pending,active
 		newStatus = FeedStatusActive
 	}
 
@@ -159,45 +427,78 @@ func (s *FeedService) ReloadFeed(ctx context.Context, feedId string) error {
 	if err := s.CreateFeed(ctx, def, newStatus); err != nil {
 		return fmt.Errorf("failed to create new feed: %w", err)
 	}
+	if nfi, ok := s.GetFeedInfo(feedId); ok && nfi.Feed != nil && len(matches) > 0 {
+		nfi.Feed.RestoreMatches(matches)
+	}
+	if fi.Status.IngestPaused || ps.IngestPaused {
+		// keep ingest paused across reloads
+		if err := s.SetIngestPaused(feedId, true); err != nil {
+			return err
+		}
+	}
 
 	s.logger.Info("feed reloaded successfully", "feedId", feedId)
 	return nil
 }
 
+// Shutdown shuts down all feeds and then closes the mirror editors and the store editor.
+// feeds save their stores through the editor on shutdown, so the editor is closed
+// only after every feed has finished, even if some of them failed.
 func (s *FeedService) Shutdown(ctx context.Context) error {
 	var mu sync.Mutex
 	var errs []error
 	var wg sync.WaitGroup
 
+	s.mu.RLock()
+	feeds := make([]feed.Feed, 0, len(s.feeds))
 	for _, fi := range s.feeds {
 		if fi.Feed != nil {
-			wg.Add(1)
-			go func(feed feed.Feed) {
-				defer wg.Done()
-				if err := feed.Shutdown(ctx); err != nil {
-					s.logger.Error("failed to shutdown feed",
-						"feedId", feed.FeedId(),
-						"error", err)
-
-					mu.Lock()
-					errs = append(errs, fmt.Errorf("feed %s: %w", feed.FeedId(), err))
-					mu.Unlock()
-				}
-			}(fi.Feed)
+			feeds = append(feeds, fi.Feed)
 		}
 	}
+	s.mu.RUnlock()
+
+	for _, f := range feeds {
+		wg.Add(1)
+		go func(feed feed.Feed) {
+			defer wg.Done()
+			if err := feed.Shutdown(ctx); err != nil {
+				s.logger.Error("failed to shutdown feed",
+					"feedId", feed.FeedId(),
+					"error", err)
+
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("feed %s: %w", feed.FeedId(), err))
+				mu.Unlock()
+			}
+		}(f)
+	}
 
 	wg.Wait()
 
-	if len(errs) > 0 {
-		return fmt.Errorf("multiple feeds failed to shutdown: %v", errs)
+	// close mirror editors, then the store editor
+	s.mu.RLock()
+	feedIds := make([]string, 0, len(s.mirrorEditors))
+	for id := range s.mirrorEditors {
+		feedIds = append(feedIds, id)
+	}
+	s.mu.RUnlock()
+	for _, id := range feedIds {
+		if err := s.closeMirrorEditors(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close mirror editors of feed %s: %w", id, err))
+		}
 	}
 
 	// close store editor
-	if err := s.storeEditor.Close(ctx); err != nil {
-		return fmt.Errorf("failed to close store editor: %w", err)
+	if s.storeEditor != nil {
+		if err := s.storeEditor.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close store editor: %w", err))
+		}
 	}
 
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to shutdown feed service: %w", errors.Join(errs...))
+	}
 	return nil
 }
 
@@ -219,6 +520,14 @@ func (s *FeedService) CreateFeed(ctx context.Context, def FeedDefinition, status
 		Error:       "",
 	}
 	defer func() {
+		//if PDS is temporarily unavailable, register as pending so that ReloadFeed can recover it
+		if errors.Is(err, provider.ErrPDSUnavailable) {
+			s.logger.Warn("feed config is not available yet, registered as pending", "feedId", feedId, "error", err)
+			feedStatus.SetPending(err)
+			s.registerFeed(def, nil, feedStatus)
+			err = nil
+			return
+		}
 		//if failed to create feed, set error log
 		if err != nil {
 			feedStatus.SetError(err)
@@ -228,111 +537,216 @@ func (s *FeedService) CreateFeed(ctx context.Context, def FeedDefinition, status
 
 	// load feedConfig
 	var cp provider.FeedConfigProvider
-	if s.configDir != "" && configFile != "" {
-		// load from file
+	if def.Config != nil {
+		// use inline config
+		cp, err = provider.NewInMemoryFeedConfigProvider(def.Config)
+		if err != nil {
+			return fmt.Errorf("failed to create feed config: %w", err)
+		}
+	} else if s.configDir != "" && configFile != "" {
+		// load from file. feeds sharing the file reuse the parsed config
 		path := filepath.Join(s.configDir, configFile)
-		var err error
-		cp, err = provider.NewFileFeedConfigProvider(path)
+		cfg, err := s.configCache.Get(path)
+		if err != nil {
+			return fmt.Errorf("failed to create feed config: %w", err)
+		}
+		cp, err = provider.NewInMemoryFeedConfigProvider(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to create feed config: %w", err)
 		}
 	} else {
 		// if no file specified, get config from PDS
-		cp, err = provider.NewPDSFeedConfigProvider(feedUri)
+		cp, err = provider.NewPDSFeedConfigProvider(ctx, feedUri, provider.WithPDSCacheDir(s.pdsConfigCacheDir))
 		if err != nil {
 			return fmt.Errorf("failed to create feed config: %w", err)
 		}
 	}
 
+	//store editor
+	storeEditor := s.storeEditor
+	var mirrored *editor.CompositeEditor
+	if len(def.Mirrors) > 0 {
+		mirrored, err = s.newMirroredEditor(def)
+		if err != nil {
+			return fmt.Errorf("failed to create mirror editors: %w", err)
+		}
+		storeEditor = mirrored
+	}
+
 	//feed
-	initctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	initctx, cancel := context.WithTimeout(ctx, s.feedCreateTimeout)
 	defer cancel()
 	newFeed, err := feed.NewFeedWithOptions(initctx, feedId, feedUri, feed.FeedOptions{
-		Config:      cp.FeedConfig(),
-		StoreEditor: s.storeEditor,
-		Logger:      s.logger,
+		Config:           cp.FeedConfig(),
+		StoreEditor:      storeEditor,
+		Logger:           s.logger,
+		RecentTestsSize:  s.recentTestsSize,
+		BlockTestTimeout: s.blockTestTimeout,
 	})
 
 	if err != nil {
+		if mirrored != nil {
+			if cerr := mirrored.CloseMirrors(ctx); cerr != nil {
+				s.logger.Error("failed to close mirror editors", "feedId", feedId, "error", cerr)
+			}
+		}
 		return fmt.Errorf("failed to create feed: %w", err)
 	} else {
 		s.logger.Info("success to create feed", "feedId", feedId)
 	}
+	if mirrored != nil {
+		s.mu.Lock()
+		if s.mirrorEditors == nil {
+			s.mirrorEditors = make(map[string]*editor.CompositeEditor)
+		}
+		s.mirrorEditors[feedId] = mirrored
+		s.mu.Unlock()
+	}
 	s.registerFeed(def, newFeed, feedStatus)
 	return nil
 }
 
-func (s *FeedService) DeleteFeed(feedId string) error {
+// newMirroredEditor creates an editor that writes to the store editor and mirrors the writes
+// to the gyoka editors of def.Mirrors
+func (s *FeedService) newMirroredEditor(def FeedDefinition) (*editor.CompositeEditor, error) {
+	if err := validateMirrors(def.Mirrors); err != nil {
+		return nil, err
+	}
+	mirrors := make([]editor.StoreEditor, 0, len(def.Mirrors))
+	for i, u := range def.Mirrors {
+		m, err := editor.NewGyokaEditor(u, s.logger, s.mirrorEditorOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mirror editor %s: %w", u, err)
+		}
+		s.logger.Info("mirroring feed", "feedId", def.ID, "mirror", i, "endpoint", u)
+		mirrors = append(mirrors, m)
+	}
+	return editor.NewCompositeEditor(s.storeEditor, mirrors, s.logger.With("feedId", def.ID))
+}
+
+// closeMirrorEditors closes the mirror editors of the feed, if any.
+// the store editor shared by feeds is left open.
+func (s *FeedService) closeMirrorEditors(ctx context.Context, feedId string) error {
 	s.mu.Lock()
-	fi, exists := s.feeds[feedId]
+	mirrored, exists := s.mirrorEditors[feedId]
+	delete(s.mirrorEditors, feedId)
 	s.mu.Unlock()
-
 	if !exists {
+		return nil
+	}
+	return mirrored.CloseMirrors(ctx)
+}
+
+func (s *FeedService) DeleteFeed(feedId string) error {
+	if !s.removeFeed(feedId) {
 		// if already deleted, treat as success
 		s.logger.Info("feed already deleted", "feedId", feedId)
 		return nil
 	}
 
+	// delete from definition provider
+	if s.definitionProvider != nil {
+		if err := s.definitionProvider.DeleteFeedDefinition(feedId); err != nil {
+			s.logger.Error("failed to delete feed definition", "feedId", feedId, "error", err)
+			return fmt.Errorf("failed to delete feed definition: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// removeFeed shuts down the feed and removes it from the service.
+// returns false if the feed does not exist.
+func (s *FeedService) removeFeed(feedId string) bool {
+	s.mu.Lock()
+	fi, exists := s.feeds[feedId]
+	s.mu.Unlock()
+	if !exists {
+		return false
+	}
+
 	// shutdown feed
 	if fi.Feed != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), s.feedShutdownTimeout)
 		defer cancel()
 		if err := fi.Feed.Shutdown(ctx); err != nil {
 			s.logger.Error("failed to shutdown feed", "feedId", feedId, "error", err)
 			// even if shutdown fails, continue deleting
 		}
+		if err := s.closeMirrorEditors(ctx, feedId); err != nil {
+			s.logger.Error("failed to close mirror editors", "feedId", feedId, "error", err)
+		}
 	}
 
 	// delete from service
 	s.unregisterFeed(feedId)
-
-	// delete from definition provider
-	if s.definitionProvider != nil {
-		if err := s.definitionProvider.DeleteFeedDefinition(feedId); err != nil {
-			s.logger.Error("failed to delete feed definition", "feedId", feedId, "error", err)
-			return fmt.Errorf("failed to delete feed definition: %w", err)
-		}
+	if err := s.statusFile.remove(feedId); err != nil {
+		s.logger.Error("failed to remove persisted feed status", "feedId", feedId, "error", err)
 	}
-
-	return nil
+	return true
 }
 
 func (s *FeedService) registerFeed(def FeedDefinition, feed feed.Feed, status FeedStatus) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.logger.Info("adding new feed", "feedId", def.ID)
 	s.feeds[def.ID] = FeedInfo{Definition: def, Feed: feed, Status: status}
+	s.mu.Unlock()
+	s.notifyFeedsChanged()
 }
 
 func (s *FeedService) unregisterFeed(feedId string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	if _, exists := s.feeds[feedId]; !exists {
+		s.mu.Unlock()
 		s.logger.Info("feed not found", "feedId", feedId)
 		return
 	}
 	s.logger.Info("deleting feed", "feedId", feedId)
 	delete(s.feeds, feedId)
+	s.mu.Unlock()
+	s.notifyFeedsChanged()
 }
 
 func (s *FeedService) UpdateStatus(feedId string, status Status) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	fi, exists := s.feeds[feedId]
 	if !exists {
+		s.mu.Unlock()
 		return fmt.Errorf("feed not found: %s", feedId)
 	}
 	fi.Status.LastStatus = status
 	fi.Status.LastUpdated = time.Now()
 	s.feeds[feedId] = fi
+	s.mu.Unlock()
 	s.logger.Info("feed status updated", "feedId", feedId, "status", fi.Status.LastStatus)
+	if status == FeedStatusActive || status == FeedStatusInactive {
+		if err := s.statusFile.update(feedId, func(ps *persistedFeedStatus) { ps.Status = status.String() }); err != nil {
+			s.logger.Error("failed to persist feed status", "feedId", feedId, "error", err)
+		}
+	}
+	s.notifyFeedsChanged()
+	return nil
+}
+
+// SetIngestPaused pauses or resumes adding posts from jetstream to the feed
+func (s *FeedService) SetIngestPaused(feedId string, paused bool) error {
+	s.mu.Lock()
+	fi, exists := s.feeds[feedId]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("feed not found: %s", feedId)
+	}
+	fi.Status.IngestPaused = paused
+	s.feeds[feedId] = fi
+	s.mu.Unlock()
+	s.logger.Info("feed ingest updated", "feedId", feedId, "paused", paused)
+	if err := s.statusFile.update(feedId, func(ps *persistedFeedStatus) { ps.IngestPaused = paused }); err != nil {
+		s.logger.Error("failed to persist feed ingest status", "feedId", feedId, "error", err)
+	}
 	return nil
 }
 
 func (s *FeedService) GetFeedStatus(feedId string) (status FeedStatus, exists bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
 	fi, exists := s.GetFeedInfo(feedId)
 	if !exists {
 		return FeedStatus{}, false
@@ -346,18 +760,69 @@ func (s *FeedService) GetActiveFeedIDs() []string {
 
 	feedIds := make([]string, 0)
 	for id, f := range s.feeds {
-		if f.Status.LastStatus != FeedStatusError {
+		if !f.Status.IsUnavailable() {
 			feedIds = append(feedIds, id)
 		}
 	}
 	return feedIds
 }
 
+// WantedDids returns the union of author DIDs of all active feeds.
+// scoped is false if there is no active feed or any active feed may accept posts from any author.
+func (s *FeedService) WantedDids() (dids []string, scoped bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := make(map[string]struct{})
+	active := 0
+	for _, fi := range s.feeds {
+		if fi.Status.LastStatus != FeedStatusActive || fi.Feed == nil {
+			continue
+		}
+		active++
+		feedDids, ok := fi.Feed.AuthorDids()
+		if !ok {
+			return nil, false
+		}
+		for _, did := range feedDids {
+			set[did] = struct{}{}
+		}
+	}
+	if active == 0 {
+		return nil, false
+	}
+	return slices.Sorted(maps.Keys(set)), true
+}
+
+// checkConfigFile checks that the config file exists under configDir
+func (s *FeedService) checkConfigFile(configFile string) error {
+	if !filepath.IsLocal(configFile) {
+		return fmt.Errorf("config file must be a relative path under the config directory")
+	}
+	info, err := os.Stat(filepath.Join(s.configDir, configFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("config file not found: %s", configFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to access config file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("config file is a directory: %s", configFile)
+	}
+	return nil
+}
+
+// GetAllFeeds returns a copy of the feeds, so that callers can iterate it while feeds are added or removed
 func (s *FeedService) GetAllFeeds() map[string]FeedInfo {
-	return s.feeds
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.feeds)
 }
 
+// GetFeedInfo returns a copy of the feed info
 func (s *FeedService) GetFeedInfo(feedId string) (info *FeedInfo, exists bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if fi, ok := s.feeds[feedId]; ok {
 		return &fi, true
 	}
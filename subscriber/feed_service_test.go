@@ -2,16 +2,22 @@ package subscriber
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/goccy/go-yaml"
+	yugeFeed "github.com/nus25/yuge/feed"
 	"github.com/nus25/yuge/feed/config/feed"
 	"github.com/nus25/yuge/feed/store/editor"
+	"github.com/nus25/yuge/types"
 )
 
 // MockFeed implements feed.Feed for testing
@@ -344,6 +350,85 @@ func TestFeedService_CreateFeed(t *testing.T) {
 	}
 }
 
+func TestFeedService_ReloadPendingFeed(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	dataDir := filepath.Join(tempDir, "data")
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	cfg, err := feed.NewFeedConfigFromJSON(`{"logic":{"blocks":[]}}`)
+	if err != nil {
+		t.Fatalf("Failed to create feed config: %v", err)
+	}
+	yamlStr, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Failed to marshal feed config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "sample.yaml"), yamlStr, 0644); err != nil {
+		t.Fatalf("Failed to write sample config: %v", err)
+	}
+	dp, err := NewFileFeedDefinitionProvider(configDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	e, err := editor.NewFileEditor(dataDir, logger)
+	if err != nil {
+		t.Fatalf("Failed to create editor: %v", err)
+	}
+	service, err := NewFeedService(configDir, dataDir, dp, e, logger)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		inactiveStart string
+		savedStatus   string // status saved by the operator
+		expected      Status
+	}{
+		{name: "pending to active", inactiveStart: "false", expected: FeedStatusActive},
+		{name: "pending to inactive", inactiveStart: "true", expected: FeedStatusInactive},
+		{name: "saved inactive", inactiveStart: "false", savedStatus: "inactive", expected: FeedStatusInactive},
+		{name: "saved active", inactiveStart: "true", savedStatus: "active", expected: FeedStatusActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := FeedDefinition{ID: "pending-" + tt.inactiveStart + tt.savedStatus, URI: "at://did:plc:1234567890/app.bsky.feed.generator/test", ConfigFile: "sample.yaml", InactiveStart: tt.inactiveStart}
+			if err := dp.AddFeedDefinition(def); err != nil {
+				t.Fatalf("Failed to add feed definition: %v", err)
+			}
+			// PDSが利用できなかった状態を再現
+			status := FeedStatus{FeedID: def.ID}
+			status.SetPending(errors.New("pds unavailable"))
+			service.registerFeed(def, nil, status)
+			if tt.savedStatus != "" {
+				if err := service.statusFile.update(def.ID, func(ps *persistedFeedStatus) { ps.Status = tt.savedStatus }); err != nil {
+					t.Fatalf("Failed to save status: %v", err)
+				}
+			}
+			if ids := service.GetActiveFeedIDs(); slices.Contains(ids, def.ID) {
+				t.Error("pending feed should not be listed as active")
+			}
+
+			if err := service.ReloadFeed(context.Background(), def.ID); err != nil {
+				t.Fatalf("Failed to reload feed: %v", err)
+			}
+			fi, exists := service.GetFeedInfo(def.ID)
+			if !exists {
+				t.Fatal("Expected feed to exist")
+			}
+			if fi.Status.LastStatus != tt.expected {
+				t.Errorf("Expected status %v, got %v", tt.expected, fi.Status.LastStatus)
+			}
+			if fi.Feed == nil {
+				t.Error("Expected feed to be initialized")
+			}
+		})
+	}
+}
+
 func TestFeedService_DeleteFeed(t *testing.T) {
 	// Setup
 
@@ -533,3 +618,287 @@ func TestFeedService_GetFeedStatus(t *testing.T) {
 		})
 	}
 }
+
+// shutdownRecorder records the order of feed shutdowns and editor close
+type shutdownRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *shutdownRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+type recordingShutdownFeed struct {
+	yugeFeed.Feed
+	id  string
+	rec *shutdownRecorder
+	err error
+}
+
+func (f *recordingShutdownFeed) FeedId() string {
+	return f.id
+}
+
+func (f *recordingShutdownFeed) Shutdown(ctx context.Context) error {
+	// store.Saveがエディタを使う時間を模擬する
+	time.Sleep(20 * time.Millisecond)
+	f.rec.record("feed:" + f.id)
+	return f.err
+}
+
+type recordingCloseEditor struct {
+	editor.StoreEditor
+	rec *shutdownRecorder
+}
+
+func (e *recordingCloseEditor) Close(ctx context.Context) error {
+	e.rec.record("editor")
+	return nil
+}
+
+func TestFeedService_ShutdownOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		feedErr error
+		wantErr bool
+	}{
+		{name: "all feeds succeed"},
+		{name: "feed fails", feedErr: errors.New("save failed"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &shutdownRecorder{}
+			s := &FeedService{
+				feeds:       make(map[string]FeedInfo),
+				storeEditor: &recordingCloseEditor{rec: rec},
+				logger:      slog.Default(),
+			}
+			for _, id := range []string{"a", "b", "c"} {
+				s.feeds[id] = FeedInfo{Feed: &recordingShutdownFeed{id: id, rec: rec, err: tt.feedErr}}
+			}
+
+			err := s.Shutdown(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Shutdown() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(rec.events) != 4 {
+				t.Fatalf("expected 4 events, got %v", rec.events)
+			}
+			// エディタはすべてのフィードの終了後に閉じられる
+			if rec.events[3] != "editor" {
+				t.Errorf("expected editor to be closed last, got %v", rec.events)
+			}
+		})
+	}
+}
+
+// slowLoadEditor blocks Load until the context is done
+type slowLoadEditor struct {
+	editor.StoreEditor
+}
+
+func (e *slowLoadEditor) Load(ctx context.Context, params editor.LoadParams) ([]types.Post, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestFeedService_FeedCreateTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	logger := slog.Default()
+	fe, err := editor.NewFileEditor(tempDir, logger)
+	if err != nil {
+		t.Fatalf("Failed to create editor: %v", err)
+	}
+	service, err := NewFeedService("", tempDir, nil, &slowLoadEditor{StoreEditor: fe}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	if service.feedCreateTimeout != DefaultFeedCreateTimeout || service.feedShutdownTimeout != DefaultFeedShutdownTimeout {
+		t.Errorf("unexpected default timeouts: %v, %v", service.feedCreateTimeout, service.feedShutdownTimeout)
+	}
+	// 0以下の値は無視される
+	service.SetFeedTimeouts(50*time.Millisecond, 0)
+	if service.feedShutdownTimeout != DefaultFeedShutdownTimeout {
+		t.Errorf("expected shutdown timeout to be kept, got %v", service.feedShutdownTimeout)
+	}
+
+	cfg, err := feed.NewFeedConfigFromJSON(`{"logic":{"blocks":[{"type":"regex","options":{"value":"a","invert":false,"caseSensitive":false}}]}}`)
+	if err != nil {
+		t.Fatalf("Failed to create feed config: %v", err)
+	}
+	def := FeedDefinition{ID: "slow-feed", URI: "at://did:plc:1234567890/app.bsky.feed.generator/slow", Config: cfg}
+	start := time.Now()
+	err = service.CreateFeed(context.Background(), def, FeedStatusActive)
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("CreateFeed took %v, expected to time out after 50ms", elapsed)
+	}
+	if info, exists := service.GetFeedInfo("slow-feed"); !exists || info.Status.LastStatus != FeedStatusError {
+		t.Errorf("expected feed in error state, got %+v", info.Status)
+	}
+}
+
+func TestFeedService_CreateFeedWithMirrors(t *testing.T) {
+	tempDir := t.TempDir()
+	logger := slog.Default()
+	service, err := NewFeedService("", tempDir, nil, nil, logger)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	cfg, err := feed.NewFeedConfigFromJSON(`{"logic":{"blocks":[]}}`)
+	if err != nil {
+		t.Fatalf("Failed to create feed config: %v", err)
+	}
+
+	// 不正なミラーURLはエラー状態になる
+	invalid := FeedDefinition{ID: "invalid-mirror", URI: "at://did:plc:1234567890/app.bsky.feed.generator/invalid", Config: cfg, Mirrors: []string{"ftp://mirror.example.com"}}
+	if err := service.CreateFeed(context.Background(), invalid, FeedStatusActive); err == nil {
+		t.Error("expected error for invalid mirror url")
+	}
+	if info, _ := service.GetFeedInfo(invalid.ID); info.Status.LastStatus != FeedStatusError {
+		t.Errorf("expected feed in error state, got %v", info.Status.LastStatus)
+	}
+
+	// 接続できないミラーがあってもフィードは作成される
+	def := FeedDefinition{ID: "mirrored", URI: "at://did:plc:1234567890/app.bsky.feed.generator/mirrored", Config: cfg, Mirrors: []string{"http://127.0.0.1:1"}}
+	if err := service.CreateFeed(context.Background(), def, FeedStatusActive); err != nil {
+		t.Fatalf("failed to create feed with unreachable mirror: %v", err)
+	}
+	if info, _ := service.GetFeedInfo(def.ID); info.Status.LastStatus != FeedStatusActive {
+		t.Errorf("expected feed to be active, got %v", info.Status.LastStatus)
+	}
+	if _, ok := service.mirrorEditors[def.ID]; !ok {
+		t.Fatal("expected mirror editor to be registered")
+	}
+
+	start := time.Now()
+	if err := service.DeleteFeed(def.ID); err != nil {
+		t.Fatalf("failed to delete feed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("DeleteFeed took %v, expected opening mirror to be canceled", elapsed)
+	}
+	if _, ok := service.mirrorEditors[def.ID]; ok {
+		t.Error("expected mirror editor to be removed")
+	}
+}
+
+func TestValidateMirrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mirrors []string
+		wantErr bool
+	}{
+		{name: "empty", mirrors: nil},
+		{name: "valid", mirrors: []string{"https://gyoka1.example.com", "http://localhost:8080/api"}},
+		{name: "no scheme", mirrors: []string{"gyoka.example.com"}, wantErr: true},
+		{name: "unsupported scheme", mirrors: []string{"ws://gyoka.example.com"}, wantErr: true},
+		{name: "no host", mirrors: []string{"https://"}, wantErr: true},
+		{name: "duplicate", mirrors: []string{"https://gyoka.example.com", "https://gyoka.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateMirrors(tt.mirrors); (err != nil) != tt.wantErr {
+				t.Errorf("validateMirrors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFeedService_FeedFilter(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	dp, err := NewFileFeedDefinitionProvider(configDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "test-config.yaml"), []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	for _, id := range []string{"feed1", "feed2", "feed3"} {
+		if err := dp.AddFeedDefinition(FeedDefinition{ID: id, URI: "at://did:plc:test/app.bsky.feed.generator/" + id, ConfigFile: "test-config.yaml"}); err != nil {
+			t.Fatalf("Failed to add feed definition: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter []string
+		want   []string
+	}{
+		{name: "no filter", filter: nil, want: []string{"feed1", "feed2", "feed3"}},
+		{name: "subset", filter: []string{"feed1", " feed3"}, want: []string{"feed1", "feed3"}},
+		{name: "unknown id is ignored", filter: []string{"feed2", "unknown"}, want: []string{"feed2"}},
+		{name: "only unknown ids", filter: []string{"unknown"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, err := NewFeedService(configDir, filepath.Join(t.TempDir(), "data"), dp, nil, slog.Default())
+			if err != nil {
+				t.Fatalf("Failed to create feed service: %v", err)
+			}
+			fs.SetFeedFilter(tt.filter)
+			if err := fs.LoadFeeds(context.Background()); err != nil {
+				t.Fatalf("Failed to load feeds: %v", err)
+			}
+			got := slices.Sorted(maps.Keys(fs.GetAllFeeds()))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("loaded feeds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedService_FeedFilterKeepsRegisteredFeeds(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	dp, err := NewFileFeedDefinitionProvider(configDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "test-config.yaml"), []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	newDef := func(id string) FeedDefinition {
+		return FeedDefinition{ID: id, URI: "at://did:plc:test/app.bsky.feed.generator/" + id, ConfigFile: "test-config.yaml"}
+	}
+	if err := dp.AddFeedDefinition(newDef("feed1")); err != nil {
+		t.Fatalf("Failed to add feed definition: %v", err)
+	}
+	fs, err := NewFeedService(configDir, filepath.Join(tempDir, "data"), dp, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	fs.SetFeedFilter([]string{"feed1"})
+	if err := fs.LoadFeeds(context.Background()); err != nil {
+		t.Fatalf("Failed to load feeds: %v", err)
+	}
+
+	// APIでフィルター外のフィードを登録する
+	registered := newDef("registered")
+	if err := fs.CreateFeed(context.Background(), registered, FeedStatusInactive); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if err := dp.AddFeedDefinition(registered); err != nil {
+		t.Fatalf("Failed to add feed definition: %v", err)
+	}
+	if err := fs.UpdateStatus(registered.ID, FeedStatusInactive); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+
+	if err := fs.LoadFeeds(context.Background()); err != nil {
+		t.Fatalf("Failed to reload feeds: %v", err)
+	}
+	got := slices.Sorted(maps.Keys(fs.GetAllFeeds()))
+	if want := []string{"feed1", "registered"}; !slices.Equal(got, want) {
+		t.Errorf("loaded feeds = %v, want %v", got, want)
+	}
+	if ps, ok := fs.statusFile.get(registered.ID); !ok || ps.Status != "inactive" {
+		t.Errorf("expected saved status of the registered feed to be kept, got %+v", ps)
+	}
+}
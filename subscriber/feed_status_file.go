@@ -0,0 +1,104 @@
+package subscriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FeedStatusFileName is the file name under the data directory where the feed statuses set by operators are persisted
+const FeedStatusFileName = "feed_status.json"
+
+// persistedFeedStatus is the status of a feed restored on startup.
+// only active and inactive are persisted; error and pending are runtime states.
+type persistedFeedStatus struct {
+	Status       string `json:"status,omitempty"`
+	IngestPaused bool   `json:"ingestPaused,omitempty"`
+}
+
+// feedStatusFile keeps the persisted statuses of feeds in memory and writes them to path on every change.
+// a nil *feedStatusFile disables persistence.
+type feedStatusFile struct {
+	path     string
+	mu       sync.Mutex
+	statuses map[string]persistedFeedStatus
+}
+
+// loadFeedStatusFile reads the persisted statuses from path.
+// a missing file is not an error and results in no statuses.
+func loadFeedStatusFile(path string) (*feedStatusFile, error) {
+	f := &feedStatusFile{path: path, statuses: make(map[string]persistedFeedStatus)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return f, fmt.Errorf("failed to read feed status file: %w", err)
+	}
+	if err := json.Unmarshal(data, &f.statuses); err != nil {
+		f.statuses = make(map[string]persistedFeedStatus)
+		return f, fmt.Errorf("invalid feed status file %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func (f *feedStatusFile) get(feedId string) (persistedFeedStatus, bool) {
+	if f == nil {
+		return persistedFeedStatus{}, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ps, ok := f.statuses[feedId]
+	return ps, ok
+}
+
+// update applies fn to the status of the feed and writes the file
+func (f *feedStatusFile) update(feedId string, fn func(ps *persistedFeedStatus)) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ps := f.statuses[feedId]
+	fn(&ps)
+	if ps == (persistedFeedStatus{}) {
+		delete(f.statuses, feedId)
+	} else {
+		f.statuses[feedId] = ps
+	}
+	return f.writeLocked()
+}
+
+func (f *feedStatusFile) remove(feedId string) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.statuses[feedId]; !ok {
+		return nil
+	}
+	delete(f.statuses, feedId)
+	return f.writeLocked()
+}
+
+// writeLocked writes the statuses to a temp file and renames it so a crash never leaves a broken file
+func (f *feedStatusFile) writeLocked() error {
+	data, err := json.MarshalIndent(f.statuses, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal feed statuses: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write feed status file: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("failed to rename feed status file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package subscriber
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFeedService_PersistStatus(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	dataDir := filepath.Join(tempDir, "data")
+	dp, err := NewFileFeedDefinitionProvider(configDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "test-config.yaml"), []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	defs := []FeedDefinition{
+		{ID: "paused", InactiveStart: "false"},
+		{ID: "activated", InactiveStart: "true"},
+		{ID: "ingest", InactiveStart: "false"},
+		{ID: "untouched", InactiveStart: "true"},
+		{ID: "deleted", InactiveStart: "false"},
+	}
+	for _, def := range defs {
+		def.URI = "at://did:plc:test/app.bsky.feed.generator/" + def.ID
+		def.ConfigFile = "test-config.yaml"
+		if err := dp.AddFeedDefinition(def); err != nil {
+			t.Fatalf("Failed to add feed definition: %v", err)
+		}
+	}
+	newService := func() *FeedService {
+		fs, err := NewFeedService(configDir, dataDir, dp, nil, slog.Default())
+		if err != nil {
+			t.Fatalf("Failed to create feed service: %v", err)
+		}
+		if err := fs.LoadFeeds(context.Background()); err != nil {
+			t.Fatalf("Failed to load feeds: %v", err)
+		}
+		return fs
+	}
+
+	fs := newService()
+	if err := fs.UpdateStatus("paused", FeedStatusInactive); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	if err := fs.UpdateStatus("activated", FeedStatusActive); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	if err := fs.SetIngestPaused("ingest", true); err != nil {
+		t.Fatalf("Failed to pause ingest: %v", err)
+	}
+	if err := fs.UpdateStatus("deleted", FeedStatusInactive); err != nil {
+		t.Fatalf("Failed to update status: %v", err)
+	}
+	if err := fs.DeleteFeed("deleted"); err != nil {
+		t.Fatalf("Failed to delete feed: %v", err)
+	}
+	if err := fs.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shutdown: %v", err)
+	}
+
+	// 削除したフィードを定義に戻しても、保存されたステータスは復元されない
+	if err := dp.AddFeedDefinition(FeedDefinition{ID: "deleted", URI: "at://did:plc:test/app.bsky.feed.generator/deleted", ConfigFile: "test-config.yaml"}); err != nil {
+		t.Fatalf("Failed to add feed definition: %v", err)
+	}
+
+	restarted := newService()
+	tests := []struct {
+		feedId       string
+		status       Status
+		ingestPaused bool
+	}{
+		{feedId: "paused", status: FeedStatusInactive},
+		{feedId: "activated", status: FeedStatusActive},
+		{feedId: "ingest", status: FeedStatusActive, ingestPaused: true},
+		{feedId: "untouched", status: FeedStatusInactive},
+		{feedId: "deleted", status: FeedStatusActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.feedId, func(t *testing.T) {
+			status, exists := restarted.GetFeedStatus(tt.feedId)
+			if !exists {
+				t.Fatalf("feed %s not found", tt.feedId)
+			}
+			if status.LastStatus != tt.status {
+				t.Errorf("expected status %v, got %v", tt.status, status.LastStatus)
+			}
+			if status.IngestPaused != tt.ingestPaused {
+				t.Errorf("expected ingestPaused %v, got %v", tt.ingestPaused, status.IngestPaused)
+			}
+		})
+	}
+}
+
+func TestLoadFeedStatusFile(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := loadFeedStatusFile(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("expected no error for missing file, got %v", err)
+	}
+	if _, ok := f.get("feed1"); ok {
+		t.Error("expected no status for missing file")
+	}
+
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err = loadFeedStatusFile(broken)
+	if err == nil {
+		t.Error("expected error for broken file")
+	}
+	// 壊れたファイルでも更新はできる
+	if err := f.update("feed1", func(ps *persistedFeedStatus) { ps.Status = "inactive" }); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	f, err = loadFeedStatusFile(broken)
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if ps, ok := f.get("feed1"); !ok || ps.Status != "inactive" {
+		t.Errorf("unexpected status %+v", ps)
+	}
+
+	var nilFile *feedStatusFile
+	if err := nilFile.update("feed1", func(ps *persistedFeedStatus) { ps.IngestPaused = true }); err != nil {
+		t.Errorf("expected nil file to ignore updates, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-yaml"
+)
+
+type FeedListApiHandler struct {
+	feedService *FeedService
+}
+
+func NewFeedListApiHandler(fs *FeedService) *FeedListApiHandler {
+	return &FeedListApiHandler{feedService: fs}
+}
+
+type FeedListVersionResponse struct {
+	FeedDefinitionVersion
+	Feeds []FeedDefinition `json:"feeds"`
+}
+
+func (h *FeedListApiHandler) versionedProvider(c *gin.Context) (VersionedFeedDefinitionProvider, bool) {
+	p, ok := h.feedService.definitionProvider.(VersionedFeedDefinitionProvider)
+	if !ok {
+		respondWithError(c, http.StatusNotImplemented, "feed list versions are not supported by the definition provider", nil)
+		return nil, false
+	}
+	return p, true
+}
+
+// ListVersions returns the snapshots of the feed list, newest first
+func (h *FeedListApiHandler) ListVersions(c *gin.Context) {
+	p, ok := h.versionedProvider(c)
+	if !ok {
+		return
+	}
+	versions, err := p.ListVersions()
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, "failed to list versions", err)
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// GetVersion returns the contents of a snapshot.
+// with ?format=yaml the snapshot file is returned as is.
+func (h *FeedListApiHandler) GetVersion(c *gin.Context) {
+	p, ok := h.versionedProvider(c)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		respondWithError(c, http.StatusBadRequest, "invalid version", nil)
+		return
+	}
+	v, data, err := p.GetVersion(version)
+	if err != nil {
+		if errors.Is(err, ErrVersionNotFound) {
+			respondWithError(c, http.StatusNotFound, "version not found", nil)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, "failed to get version", err)
+		return
+	}
+
+	if c.Query("format") == "yaml" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", v.FileName))
+		c.Data(http.StatusOK, "application/yaml", data)
+		return
+	}
+	var list FeedDefinitionList
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		respondWithError(c, http.StatusInternalServerError, "failed to parse version file", err)
+		return
+	}
+	c.JSON(http.StatusOK, FeedListVersionResponse{
+		FeedDefinitionVersion: v,
+		Feeds:                 list.Feeds,
+	})
+}
+
+type RollbackResponse struct {
+	Version int      `json:"version"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Rollback restores a snapshot as the latest feed list and reconciles running feeds
+func (h *FeedListApiHandler) Rollback(c *gin.Context) {
+	if _, ok := h.versionedProvider(c); !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		respondWithError(c, http.StatusBadRequest, "invalid version", nil)
+		return
+	}
+	restored, added, removed, err := h.feedService.RollbackFeedList(context.Background(), version)
+	if errors.Is(err, ErrVersionNotFound) {
+		respondWithError(c, http.StatusNotFound, "version not found", nil)
+		return
+	}
+	if err != nil && restored.Version == 0 {
+		respondWithError(c, http.StatusInternalServerError, "failed to rollback feed list", err)
+		return
+	}
+	resp := RollbackResponse{
+		Version: restored.Version,
+		Added:   added,
+		Removed: removed,
+	}
+	if err != nil {
+		// ロールバックは完了しているが一部フィードの読み込みに失敗
+		resp.Error = err.Error()
+		c.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type FeedListReloadResponse struct {
+	FeedListReloadResult
+	Error string `json:"error,omitempty"`
+}
+
+// ReloadFeedList loads the feed list from the definition provider again, e.g. after feedlist.yaml is edited,
+// and reconciles running feeds with it
+func (h *FeedListApiHandler) ReloadFeedList(c *gin.Context) {
+	if h.feedService.definitionProvider == nil {
+		respondWithError(c, http.StatusNotImplemented, "no feed definition provider", nil)
+		return
+	}
+	result, err := h.feedService.ReloadFeedList(context.Background())
+	resp := FeedListReloadResponse{FeedListReloadResult: result}
+	if err != nil {
+		resp.Error = err.Error()
+		c.JSON(http.StatusInternalServerError, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
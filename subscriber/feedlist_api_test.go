@@ -0,0 +1,300 @@
+package subscriber
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeedListApiHandler_Versions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tempDir := t.TempDir()
+	dp, err := NewFileFeedDefinitionProvider(filepath.Join(tempDir, "config"))
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	fs, err := NewFeedService("", filepath.Join(tempDir, "data"), dp, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	// v1, v2のスナップショットを作成
+	for _, id := range []string{"feed1", "feed2"} {
+		if err := dp.AddFeedDefinition(FeedDefinition{ID: id, URI: "at://did:plc:test/app.bsky.feed.generator/" + id}); err != nil {
+			t.Fatalf("Failed to add feed definition: %v", err)
+		}
+	}
+
+	api := NewFeedListApiHandler(fs)
+	router := gin.New()
+	router.GET("/api/feedlist/versions", api.ListVersions)
+	router.GET("/api/feedlist/versions/:version", api.GetVersion)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := get("/api/feedlist/versions")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+	}
+	var versions []FeedDefinitionVersion
+	if err := json.Unmarshal(recorder.Body.Bytes(), &versions); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].Version != 1 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	if versions[0].Timestamp.IsZero() {
+		t.Error("timestamp is not parsed")
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedFeeds  int
+	}{
+		{name: "v1", path: "/api/feedlist/versions/1", expectedStatus: http.StatusOK, expectedFeeds: 1},
+		{name: "v2", path: "/api/feedlist/versions/2", expectedStatus: http.StatusOK, expectedFeeds: 2},
+		{name: "存在しないバージョン", path: "/api/feedlist/versions/99", expectedStatus: http.StatusNotFound},
+		{name: "不正なバージョン", path: "/api/feedlist/versions/abc", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := get(tt.path)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d", tt.expectedStatus, recorder.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp FeedListVersionResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(resp.Feeds) != tt.expectedFeeds {
+				t.Errorf("Expected %d feeds, but got %d", tt.expectedFeeds, len(resp.Feeds))
+			}
+		})
+	}
+
+	t.Run("yaml形式でダウンロード", func(t *testing.T) {
+		recorder := get("/api/feedlist/versions/2?format=yaml")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, but got %d", http.StatusOK, recorder.Code)
+		}
+		if !strings.Contains(recorder.Header().Get("Content-Disposition"), versions[0].FileName) {
+			t.Errorf("unexpected Content-Disposition: %s", recorder.Header().Get("Content-Disposition"))
+		}
+		if !strings.Contains(recorder.Body.String(), "feed2") {
+			t.Errorf("unexpected body: %s", recorder.Body.String())
+		}
+	})
+}
+
+func TestFeedListApiHandler_Rollback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	dp, err := NewFileFeedDefinitionProvider(configDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "test-config.yaml"), []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	fs, err := NewFeedService(configDir, filepath.Join(tempDir, "data"), dp, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	for _, id := range []string{"feed1", "feed2"} {
+		if err := dp.AddFeedDefinition(FeedDefinition{ID: id, URI: "at://did:plc:test/app.bsky.feed.generator/" + id, ConfigFile: "test-config.yaml"}); err != nil {
+			t.Fatalf("Failed to add feed definition: %v", err)
+		}
+	}
+	if err := fs.LoadFeeds(context.Background()); err != nil {
+		t.Fatalf("Failed to load feeds: %v", err)
+	}
+	defer fs.Shutdown(context.Background())
+
+	api := NewFeedListApiHandler(fs)
+	router := gin.New()
+	router.POST("/api/feedlist/rollback/:version", api.Rollback)
+
+	tests := []struct {
+		name            string
+		version         string
+		expectedStatus  int
+		expectedVersion int
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{name: "v1に戻す", version: "1", expectedStatus: http.StatusOK, expectedVersion: 3, expectedAdded: []string{}, expectedRemoved: []string{"feed2"}},
+		{name: "v2に戻す", version: "2", expectedStatus: http.StatusOK, expectedVersion: 4, expectedAdded: []string{"feed2"}, expectedRemoved: []string{}},
+		{name: "存在しないバージョン", version: "99", expectedStatus: http.StatusNotFound},
+		{name: "不正なバージョン", version: "0", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/feedlist/rollback/"+tt.version, nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp RollbackResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if resp.Version != tt.expectedVersion {
+				t.Errorf("Expected version %d, but got %d", tt.expectedVersion, resp.Version)
+			}
+			if !slices.Equal(resp.Added, tt.expectedAdded) || !slices.Equal(resp.Removed, tt.expectedRemoved) {
+				t.Errorf("unexpected diff: added=%v removed=%v", resp.Added, resp.Removed)
+			}
+		})
+	}
+	if _, exists := fs.GetFeedInfo("feed2"); !exists {
+		t.Error("feed2 should be running after rollback to v2")
+	}
+
+	// 再読み込み中はロールバックによるフィードリストの切り替えを待たせる
+	fs.reloadMu.Lock()
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := fs.RollbackFeedList(context.Background(), 1)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if versions, _ := dp.(VersionedFeedDefinitionProvider).ListVersions(); len(versions) != 4 {
+		t.Errorf("feed list should not be restored while reloading, got %d versions", len(versions))
+	}
+	fs.reloadMu.Unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("Failed to rollback: %v", err)
+	}
+	if versions, _ := dp.(VersionedFeedDefinitionProvider).ListVersions(); len(versions) != 5 {
+		t.Errorf("Expected 5 versions after rollback, but got %d", len(versions))
+	}
+}
+
+func TestFeedListApiHandler_Reload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	dp, err := NewFileFeedDefinitionProvider(configDir)
+	if err != nil {
+		t.Fatalf("Failed to create feed definition provider: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "test-config.yaml"), []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	fs, err := NewFeedService(configDir, filepath.Join(tempDir, "data"), dp, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	for _, id := range []string{"feed1", "feed2"} {
+		if err := dp.AddFeedDefinition(FeedDefinition{ID: id, URI: "at://did:plc:test/app.bsky.feed.generator/" + id, ConfigFile: "test-config.yaml"}); err != nil {
+			t.Fatalf("Failed to add feed definition: %v", err)
+		}
+	}
+	if err := fs.LoadFeeds(context.Background()); err != nil {
+		t.Fatalf("Failed to load feeds: %v", err)
+	}
+	defer fs.Shutdown(context.Background())
+
+	api := NewFeedListApiHandler(fs)
+	router := gin.New()
+	router.POST("/api/feedlist/reload", api.ReloadFeedList)
+
+	// バージョンファイルより新しい日時でfeedlist.yamlを編集する
+	writeFeedList := func(content string) {
+		path := filepath.Join(configDir, FILE_NAME)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write feed list: %v", err)
+		}
+		future := time.Now().Add(time.Minute)
+		if err := os.Chtimes(path, future, future); err != nil {
+			t.Fatalf("Failed to change mtime: %v", err)
+		}
+	}
+	feedList := func(ids ...string) string {
+		var b strings.Builder
+		b.WriteString("feeds:\n")
+		for _, id := range ids {
+			b.WriteString("  - id: " + id + "\n    uri: at://did:plc:test/app.bsky.feed.generator/" + id + "\n    configFile: test-config.yaml\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name             string
+		feedList         string // if empty, feedlist.yaml is not changed
+		expectedStatus   int
+		expectedImported bool
+		expectedAdded    []string
+		expectedReloaded []string
+		expectedRemoved  []string
+	}{
+		{name: "編集なし", expectedStatus: http.StatusOK, expectedAdded: []string{}, expectedReloaded: []string{"feed1", "feed2"}, expectedRemoved: []string{}},
+		{name: "追加と削除", feedList: feedList("feed1", "feed3"), expectedStatus: http.StatusOK, expectedImported: true, expectedAdded: []string{"feed3"}, expectedReloaded: []string{"feed1"}, expectedRemoved: []string{"feed2"}},
+		{name: "不正なyaml", feedList: "feeds: [", expectedStatus: http.StatusInternalServerError, expectedAdded: []string{}, expectedReloaded: []string{}, expectedRemoved: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.feedList != "" {
+				writeFeedList(tt.feedList)
+			}
+			req, _ := http.NewRequest("POST", "/api/feedlist/reload", nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, but got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			var resp FeedListReloadResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if resp.Imported != tt.expectedImported {
+				t.Errorf("Expected imported %v, but got %v", tt.expectedImported, resp.Imported)
+			}
+			if !slices.Equal(resp.Added, tt.expectedAdded) || !slices.Equal(resp.Reloaded, tt.expectedReloaded) || !slices.Equal(resp.Removed, tt.expectedRemoved) {
+				t.Errorf("unexpected diff: %+v", resp.FeedListReloadResult)
+			}
+		})
+	}
+	// 不正なyamlは取り込まれず、フィードはそのまま
+	if _, exists := fs.GetFeedInfo("feed3"); !exists {
+		t.Error("feed3 should still be running after the invalid feed list")
+	}
+
+	// プロバイダーがない場合
+	noProvider, err := NewFeedService("", filepath.Join(tempDir, "data2"), nil, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	router = gin.New()
+	router.POST("/api/feedlist/reload", NewFeedListApiHandler(noProvider).ReloadFeedList)
+	req, _ := http.NewRequest("POST", "/api/feedlist/reload", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status code %d, but got %d", http.StatusNotImplemented, recorder.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package subscriber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+// feedListEntry is a feed in the response of GET /api/feed
+type feedListEntry struct {
+	ID     string `json:"id"`
+	Status struct {
+		LastStatus   string `json:"lastStatus"`
+		Error        string `json:"error"`
+		IngestPaused bool   `json:"ingestPaused"`
+	} `json:"status"`
+}
+
+// feedsClient calls the feed api of a running subscriber
+type feedsClient struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+func (c *feedsClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, errResp.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// ListFeedsCommand prints the feeds of a running subscriber with their status and post counts
+func ListFeedsCommand(cctx *cli.Context) error {
+	u, err := url.Parse(cctx.String("api"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid api url: %s", cctx.String("api"))
+	}
+	c := &feedsClient{
+		apiURL: strings.TrimSuffix(u.String(), "/"),
+		token:  cctx.String("api-token"),
+		client: &http.Client{Timeout: cctx.Duration("timeout")},
+	}
+	return printFeeds(cctx.Context, c, os.Stdout)
+}
+
+// printFeeds writes a table of feeds sorted by id.
+// post counts of unavailable feeds are shown as "-".
+func printFeeds(ctx context.Context, c *feedsClient, w io.Writer) error {
+	var feeds []feedListEntry
+	if err := c.get(ctx, "/api/feed", &feeds); err != nil {
+		return fmt.Errorf("failed to list feeds: %w", err)
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].ID < feeds[j].ID })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tPOSTS\tERROR")
+	for _, f := range feeds {
+		status := f.Status.LastStatus
+		if f.Status.IngestPaused {
+			status += " (ingest paused)"
+		}
+		posts := "-"
+		if f.Status.LastStatus != FeedStatusError.String() && f.Status.LastStatus != FeedStatusPending.String() {
+			var count GetPostCountResponse
+			if err := c.get(ctx, "/api/feed/"+url.PathEscape(f.ID)+"/count", &count); err != nil {
+				posts = "?"
+				if f.Status.Error == "" {
+					f.Status.Error = "failed to count posts: " + err.Error()
+				}
+			} else {
+				posts = strconv.Itoa(count.Count)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.ID, status, posts, f.Status.Error)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,96 @@
+package subscriber
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrintFeeds(t *testing.T) {
+	fs, tempDir, err := createFeedService(t)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	configFile := filepath.Join(tempDir, "config", "test-config.yaml")
+	if err := os.WriteFile(configFile, []byte(testConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	ctx := context.Background()
+	def := FeedDefinition{ID: "b-feed", URI: "at://did:plc:abcdefg/app.bsky.feed.generator/b-feed", ConfigFile: "test-config.yaml"}
+	if err := fs.CreateFeed(ctx, def, FeedStatusActive); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	fi, _ := fs.GetFeedInfo(def.ID)
+	for i, rkey := range []string{"a", "b"} {
+		if err := fi.Feed.AddPost("did:plc:user", rkey, "bafyreicid", time.Now().Add(time.Duration(i)*time.Second), nil); err != nil {
+			t.Fatalf("Failed to add post: %v", err)
+		}
+	}
+	status := FeedStatus{FeedID: "a-feed"}
+	status.SetError(errors.New("config not found"))
+	fs.registerFeed(FeedDefinition{ID: "a-feed"}, nil, status)
+
+	gin.SetMode(gin.TestMode)
+	api := NewFeedApiHandler(fs)
+	router := gin.New()
+	feedRoutes := router.Group("/api/feed")
+	feedRoutes.Use(BearerAuth("secret", false))
+	feedRoutes.GET("", api.ListFeed)
+	feedRoutes.Group("/:feedid").Use(api.ValidateFeedId()).GET("/count", api.GetPostCount)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "feeds with counts",
+			token: "secret",
+			want: []string{
+				"ID      STATUS  POSTS  ERROR",
+				"a-feed  error   -      config not found",
+				"b-feed  active  2      ",
+			},
+		},
+		{
+			name:    "unauthorized",
+			token:   "wrong",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := &feedsClient{apiURL: server.URL, token: tt.token, client: http.DefaultClient}
+			err := printFeeds(ctx, c, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("printFeeds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d lines, got %q", len(tt.want), got)
+			}
+			for i := range got {
+				if strings.TrimRight(got[i], " ") != strings.TrimRight(tt.want[i], " ") {
+					t.Errorf("line %d: expected %q, got %q", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
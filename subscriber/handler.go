@@ -16,11 +16,21 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// account event statuses that remove the posts of the account
+const (
+	accountStatusDeleted   = "deleted"
+	accountStatusTakendown = "takendown"
+)
+
 type Handler struct {
 	logger      *slog.Logger
 	FeedService *FeedService
 	Jsc         *jetstreamClient.Client
 	nextMet     int64
+	// RemoveDeletedAccounts removes all posts of accounts that are deleted or taken down
+	RemoveDeletedAccounts bool
+	// IngestFilter skips created posts before parsing when set
+	IngestFilter *IngestFilter
 }
 
 func NewHandler(l *slog.Logger, fl *FeedService) *Handler {
@@ -36,29 +46,6 @@ func NewHandler(l *slog.Logger, fl *FeedService) *Handler {
 // 接続が閉じられた場合はlast cursorを返す
 func (h *Handler) HandleJetstream(ctx context.Context, log *slog.Logger, cursor int64) (int64, error) {
 	h.logger.Info("starting jetstream handler", "cursor", cursor)
-	// 30秒おきにpingで回線チェック
-	done := make(chan struct{})
-	go func() {
-		t := time.NewTicker(time.Second * 30)
-		defer t.Stop()
-
-		for {
-			select {
-			case <-t.C:
-				log.Info("send ping to jetstream.")
-				if h.Jsc != nil {
-					if err := h.Jsc.SendPing(); err != nil {
-						log.Warn("failed to ping", "error", err)
-					}
-				}
-			case <-done:
-				log.Info("jetstream ping loop finished.")
-				return
-			}
-		}
-	}()
-	defer close(done)
-
 	//接続開始
 	if err := h.Jsc.ConnectAndRead(ctx, cursor); err != nil {
 		h.logger.Error("jetstream connection failed",
@@ -84,6 +71,10 @@ func (h *Handler) HandlePostEvent(ctx context.Context, evt *models.Event) error
 	if evt == nil {
 		return errors.New("received nil event")
 	}
+	if evt.Kind == models.EventKindAccount {
+		h.handleAccountEvent(evt)
+		return nil
+	}
 	if evt.Commit == nil {
 		return nil
 	}
@@ -95,8 +86,12 @@ func (h *Handler) HandlePostEvent(ctx context.Context, evt *models.Event) error
 	postsProcessed.Inc()
 	switch evt.Commit.Operation {
 	case models.CommitOperationCreate:
+		if !h.IngestFilter.Allow(evt.Commit.Record) {
+			postsPrefiltered.Inc()
+			return nil
+		}
 		for id, fi := range h.FeedService.GetAllFeeds() {
-			if fi.Status.LastStatus != FeedStatusActive || fi.Feed == nil {
+			if fi.Status.LastStatus != FeedStatusActive || fi.Status.IngestPaused || fi.Feed == nil {
 				continue
 			}
 			sd, post, err := func() (bool, *apibsky.FeedPost, error) {
@@ -121,28 +116,54 @@ func (h *Handler) HandlePostEvent(ctx context.Context, evt *models.Event) error
 			}
 			if sd {
 				go func(feedID string, feed feed.Feed, evt *models.Event, post *apibsky.FeedPost) {
-					postsAdded.WithLabelValues(feedID).Inc()
 					h.logger.Info("adding post", "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey, "Langs", post.Langs)
 					if err := feed.AddPost(evt.Did, evt.Commit.RKey, evt.Commit.CID, time.Now(), post.Langs); err != nil {
-						h.logger.Error("failed to add post", "error", err, "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey, "Langs", post.Langs)
+						// 判定は通過しているのでフィードから漏れたことを記録する
+						feedAddFailures.WithLabelValues(feedID).Inc()
+						h.logger.Warn("failed to add matched post", "error", err, "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey, "Langs", post.Langs)
 						return
 					}
 				}(id, fi.Feed, evt, post)
 			}
 		}
+	case models.CommitOperationUpdate:
+		// 編集されたポストはフィードに含まれている場合のみCIDを更新する
+		for id, fi := range h.FeedService.GetAllFeeds() {
+			if fi.Status.IsUnavailable() || fi.Feed == nil {
+				continue
+			}
+			if _, exists := fi.Feed.GetPost(evt.Did, evt.Commit.RKey); exists {
+				go func(feedID string, feed feed.Feed, evt *models.Event) {
+					var post apibsky.FeedPost
+					if err := json.Unmarshal(evt.Commit.Record, &post); err != nil {
+						h.logger.Error("failed to unmarshal post", "error", err, "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey)
+						return
+					}
+					h.logger.Info("updating post", "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey, "cid", evt.Commit.CID)
+					if err := feed.UpdatePost(evt.Did, evt.Commit.RKey, evt.Commit.CID, time.Now(), post.Langs); err != nil {
+						h.logger.Error("failed to update post", "error", err, "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey)
+						return
+					}
+				}(id, fi.Feed, evt)
+			}
+		}
 	case models.CommitOperationDelete:
 		for id, fi := range h.FeedService.GetAllFeeds() {
-			if fi.Status.LastStatus == FeedStatusError || fi.Feed == nil {
+			if fi.Status.IsUnavailable() || fi.Feed == nil {
 				continue
 			}
 			if _, exists := fi.Feed.GetPost(evt.Did, evt.Commit.RKey); exists {
 				go func(feedID string, feed feed.Feed, evt *models.Event) {
-					postsDeleted.WithLabelValues(feedID).Inc()
 					h.logger.Info("deleting post", "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey)
-					if err := feed.DeletePost(evt.Did, evt.Commit.RKey); err != nil {
+					deleted, err := feed.DeletePost(evt.Did, evt.Commit.RKey)
+					if err != nil {
 						h.logger.Error("failed to delete post", "error", err, "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey)
 						return
 					}
+					if !deleted {
+						// 確認後にトリムなどで既に削除されていた
+						h.logger.Debug("post already deleted", "feed", feedID, "did", evt.Did, "rkey", evt.Commit.RKey)
+					}
 				}(id, fi.Feed, evt)
 			}
 		}
@@ -150,6 +171,32 @@ func (h *Handler) HandlePostEvent(ctx context.Context, evt *models.Event) error
 	return nil
 }
 
+// アカウントが削除またはテイクダウンされた場合、全フィードからそのアカウントのポストを削除する
+func (h *Handler) handleAccountEvent(evt *models.Event) {
+	if !h.RemoveDeletedAccounts || evt.Account == nil || evt.Account.Active || evt.Account.Status == nil {
+		return
+	}
+	status := *evt.Account.Status
+	if status != accountStatusDeleted && status != accountStatusTakendown {
+		return
+	}
+	for id, fi := range h.FeedService.GetAllFeeds() {
+		if fi.Status.IsUnavailable() || fi.Feed == nil {
+			continue
+		}
+		if len(fi.Feed.ListPost(evt.Did)) == 0 {
+			continue
+		}
+		go func(feedID string, feed feed.Feed, did string) {
+			h.logger.Info("deleting posts of inactive account", "feed", feedID, "did", did, "status", status)
+			if _, err := feed.DeletePostByDid(did); err != nil {
+				h.logger.Error("failed to delete posts of inactive account", "error", err, "feed", feedID, "did", did)
+				return
+			}
+		}(id, fi.Feed, evt.Did)
+	}
+}
+
 // フィードで定義された判定ロジックでevtをフィルタする
 func (h *Handler) shouldAdd(feed feed.Feed, did string, rkey string, post *apibsky.FeedPost) (shuldAdd bool, err error) {
 	defer func() {
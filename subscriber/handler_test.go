@@ -2,10 +2,20 @@ package subscriber
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
 	"testing"
+	"time"
 
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	apibsky "github.com/bluesky-social/indigo/api/bsky"
 	"github.com/bluesky-social/jetstream/pkg/models"
+	"github.com/nus25/yuge/feed"
+	feedconfig "github.com/nus25/yuge/feed/config/feed"
+	"github.com/nus25/yuge/feed/store/editor"
+	"github.com/nus25/yuge/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestHandlePostEvent(t *testing.T) {
@@ -77,3 +87,295 @@ func TestHandlePostEvent(t *testing.T) {
 		})
 	}
 }
+
+// handlerTestFeed records DeletePostByDid and UpdatePost calls
+type handlerTestFeed struct {
+	feed.Feed
+	dids    map[string]bool
+	mu      sync.Mutex
+	deleted []string
+	updated []string
+	tested  int
+}
+
+func (f *handlerTestFeed) FeedId() string {
+	return "test"
+}
+
+func (f *handlerTestFeed) Test(did string, rkey string, post *apibsky.FeedPost) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tested++
+	return false
+}
+
+func (f *handlerTestFeed) GetPost(did string, rkey string) (types.Post, bool) {
+	if f.dids[did] {
+		return types.Post{Uri: types.PostUri("at://" + did + "/app.bsky.feed.post/" + rkey)}, true
+	}
+	return types.Post{}, false
+}
+
+func (f *handlerTestFeed) UpdatePost(did string, rkey string, cid string, t time.Time, langs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updated = append(f.updated, cid)
+	return nil
+}
+
+func (f *handlerTestFeed) updatedCids() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.updated...)
+}
+
+func (f *handlerTestFeed) ListPost(did string) []types.Post {
+	if f.dids[did] {
+		return []types.Post{{Uri: types.PostUri("at://" + did + "/app.bsky.feed.post/a")}}
+	}
+	return nil
+}
+
+func (f *handlerTestFeed) DeletePostByDid(did string) ([]types.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, did)
+	return nil, nil
+}
+
+func (f *handlerTestFeed) deletedDids() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+func TestHandleAccountEvent(t *testing.T) {
+	status := func(s string) *string { return &s }
+	const did = "did:plc:author"
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		account    *comatproto.SyncSubscribeRepos_Account
+		wantDelete bool
+	}{
+		{
+			name:       "deleted account",
+			enabled:    true,
+			account:    &comatproto.SyncSubscribeRepos_Account{Did: did, Active: false, Status: status("deleted")},
+			wantDelete: true,
+		},
+		{
+			name:       "taken down account",
+			enabled:    true,
+			account:    &comatproto.SyncSubscribeRepos_Account{Did: did, Active: false, Status: status("takendown")},
+			wantDelete: true,
+		},
+		{
+			name:    "deactivated account",
+			enabled: true,
+			account: &comatproto.SyncSubscribeRepos_Account{Did: did, Active: false, Status: status("deactivated")},
+		},
+		{
+			name:    "active account",
+			enabled: true,
+			account: &comatproto.SyncSubscribeRepos_Account{Did: did, Active: true},
+		},
+		{
+			name:    "disabled",
+			enabled: false,
+			account: &comatproto.SyncSubscribeRepos_Account{Did: did, Active: false, Status: status("deleted")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &FeedService{feeds: make(map[string]FeedInfo), logger: slog.Default()}
+			withPosts := &handlerTestFeed{dids: map[string]bool{did: true}}
+			withoutPosts := &handlerTestFeed{}
+			fs.registerFeed(FeedDefinition{ID: "a"}, withPosts, FeedStatus{FeedID: "a", LastStatus: FeedStatusActive})
+			fs.registerFeed(FeedDefinition{ID: "b"}, withoutPosts, FeedStatus{FeedID: "b", LastStatus: FeedStatusActive})
+			h := NewHandler(slog.Default(), fs)
+			h.RemoveDeletedAccounts = tt.enabled
+
+			evt := &models.Event{Did: did, Kind: models.EventKindAccount, Account: tt.account}
+			if err := h.HandlePostEvent(context.Background(), evt); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// 削除は非同期に行われる
+			deadline := time.Now().Add(time.Second)
+			for tt.wantDelete && len(withPosts.deletedDids()) == 0 && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if !tt.wantDelete {
+				time.Sleep(50 * time.Millisecond)
+			}
+			if got := withPosts.deletedDids(); tt.wantDelete != (len(got) == 1 && got[0] == did) {
+				t.Errorf("deleted dids = %v, wantDelete %v", got, tt.wantDelete)
+			}
+			if got := withoutPosts.deletedDids(); len(got) != 0 {
+				t.Errorf("expected no deletion for feed without posts, got %v", got)
+			}
+		})
+	}
+}
+
+func TestHandleUpdateEvent(t *testing.T) {
+	const did = "did:plc:author"
+	fs := &FeedService{feeds: make(map[string]FeedInfo), logger: slog.Default()}
+	withPost := &handlerTestFeed{dids: map[string]bool{did: true}}
+	withoutPost := &handlerTestFeed{}
+	fs.registerFeed(FeedDefinition{ID: "a"}, withPost, FeedStatus{FeedID: "a", LastStatus: FeedStatusActive})
+	fs.registerFeed(FeedDefinition{ID: "b"}, withoutPost, FeedStatus{FeedID: "b", LastStatus: FeedStatusActive})
+	h := NewHandler(slog.Default(), fs)
+
+	evt := &models.Event{
+		Did:  did,
+		Kind: models.EventKindCommit,
+		Commit: &models.Commit{
+			Operation:  models.CommitOperationUpdate,
+			Collection: "app.bsky.feed.post",
+			RKey:       "rkey",
+			CID:        "newcid",
+			Record:     []byte(`{"$type":"app.bsky.feed.post","text":"edited","createdAt":"2025-01-01T00:00:00Z"}`),
+		},
+	}
+	if err := h.HandlePostEvent(context.Background(), evt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 更新は非同期に行われる
+	deadline := time.Now().Add(time.Second)
+	for len(withPost.updatedCids()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := withPost.updatedCids(); len(got) != 1 || got[0] != "newcid" {
+		t.Errorf("updated cids = %v, want [newcid]", got)
+	}
+	if got := withoutPost.updatedCids(); len(got) != 0 {
+		t.Errorf("expected no update for feed without the post, got %v", got)
+	}
+}
+
+func TestHandleCreateEventIngestPaused(t *testing.T) {
+	fs := &FeedService{feeds: make(map[string]FeedInfo), logger: slog.Default()}
+	running := &handlerTestFeed{}
+	paused := &handlerTestFeed{}
+	fs.registerFeed(FeedDefinition{ID: "running"}, running, FeedStatus{FeedID: "running", LastStatus: FeedStatusActive})
+	fs.registerFeed(FeedDefinition{ID: "paused"}, paused, FeedStatus{FeedID: "paused", LastStatus: FeedStatusActive})
+	if err := fs.SetIngestPaused("paused", true); err != nil {
+		t.Fatalf("failed to pause ingest: %v", err)
+	}
+	h := NewHandler(slog.Default(), fs)
+
+	evt := &models.Event{
+		Did:  "did:plc:author",
+		Kind: models.EventKindCommit,
+		Commit: &models.Commit{
+			Operation:  models.CommitOperationCreate,
+			Collection: "app.bsky.feed.post",
+			RKey:       "rkey",
+			CID:        "cid",
+			Record:     []byte(`{"$type":"app.bsky.feed.post","text":"hello","createdAt":"2025-01-01T00:00:00Z"}`),
+		},
+	}
+	if err := h.HandlePostEvent(context.Background(), evt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if running.tested != 1 {
+		t.Errorf("expected running feed to test the post once, got %d", running.tested)
+	}
+	if paused.tested != 0 {
+		t.Errorf("expected paused feed to skip the post, got %d tests", paused.tested)
+	}
+}
+
+func TestHandleCreateEventIngestFilter(t *testing.T) {
+	fs := &FeedService{feeds: make(map[string]FeedInfo), logger: slog.Default()}
+	f := &handlerTestFeed{}
+	fs.registerFeed(FeedDefinition{ID: "a"}, f, FeedStatus{FeedID: "a", LastStatus: FeedStatusActive})
+	h := NewHandler(slog.Default(), fs)
+	h.IngestFilter = NewIngestFilter([]string{"ja"})
+
+	records := []string{
+		`{"$type":"app.bsky.feed.post","text":"hello","langs":["en"],"createdAt":"2025-01-01T00:00:00Z"}`,
+		`{"$type":"app.bsky.feed.post","text":"no langs","createdAt":"2025-01-01T00:00:00Z"}`,
+		`{"$type":"app.bsky.feed.post","text":"こんにちは","langs":["en","ja-JP"],"createdAt":"2025-01-01T00:00:00Z"}`,
+	}
+	for _, r := range records {
+		evt := &models.Event{
+			Did:  "did:plc:author",
+			Kind: models.EventKindCommit,
+			Commit: &models.Commit{
+				Operation:  models.CommitOperationCreate,
+				Collection: "app.bsky.feed.post",
+				RKey:       "rkey",
+				CID:        "cid",
+				Record:     []byte(r),
+			},
+		}
+		if err := h.HandlePostEvent(context.Background(), evt); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if f.tested != 1 {
+		t.Errorf("expected only the japanese post to be tested, got %d tests", f.tested)
+	}
+}
+
+// failingAddEditor fails every Add
+type failingAddEditor struct {
+	editor.StoreEditor
+}
+
+func (e *failingAddEditor) Add(params editor.PostParams) error {
+	return errors.New("add failed")
+}
+
+func TestHandleCreateEventAddFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	logger := slog.Default()
+	fe, err := editor.NewFileEditor(tempDir, logger)
+	if err != nil {
+		t.Fatalf("Failed to create editor: %v", err)
+	}
+	fs, err := NewFeedService("", tempDir, nil, &failingAddEditor{StoreEditor: fe}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	cfg, err := feedconfig.NewFeedConfigFromJSON(`{"logic":{"blocks":[{"type":"regex","options":{"value":"hello","invert":false,"caseSensitive":false}}]}}`)
+	if err != nil {
+		t.Fatalf("Failed to create feed config: %v", err)
+	}
+	def := FeedDefinition{ID: "add-failure", URI: "at://did:plc:1234567890/app.bsky.feed.generator/add-failure", Config: cfg}
+	if err := fs.CreateFeed(context.Background(), def, FeedStatusActive); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	h := NewHandler(logger, fs)
+
+	before := testutil.ToFloat64(feedAddFailures.WithLabelValues(def.ID))
+	evt := &models.Event{
+		Did:  "did:plc:author",
+		Kind: models.EventKindCommit,
+		Commit: &models.Commit{
+			Operation:  models.CommitOperationCreate,
+			Collection: "app.bsky.feed.post",
+			RKey:       "rkey",
+			CID:        "cid",
+			Record:     []byte(`{"$type":"app.bsky.feed.post","text":"hello","langs":["ja"],"createdAt":"2025-01-01T00:00:00Z"}`),
+		},
+	}
+	if err := h.HandlePostEvent(context.Background(), evt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 追加は非同期に行われるので反映を待つ
+	deadline := time.Now().Add(5 * time.Second)
+	for testutil.ToFloat64(feedAddFailures.WithLabelValues(def.ID)) != before+1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected feed_add_failures_total to be incremented, got %v", testutil.ToFloat64(feedAddFailures.WithLabelValues(def.ID)))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
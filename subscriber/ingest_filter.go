@@ -0,0 +1,69 @@
+package subscriber
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// IngestFilter is a cheap predicate applied to created posts before they are parsed and tested by the feeds.
+// posts failing the predicate are skipped for all feeds.
+type IngestFilter struct {
+	langs map[string]struct{}
+}
+
+// NewIngestFilter returns a filter that passes posts having at least one of langs.
+// a lang matches the post language itself or its subtags (e.g. "ja" matches "ja-JP").
+// returns nil if no lang is specified.
+func NewIngestFilter(langs []string) *IngestFilter {
+	f := &IngestFilter{langs: make(map[string]struct{})}
+	for _, l := range langs {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l == "" {
+			continue
+		}
+		f.langs[l] = struct{}{}
+	}
+	if len(f.langs) == 0 {
+		return nil
+	}
+	return f
+}
+
+// Langs returns the langs of the filter
+func (f *IngestFilter) Langs() []string {
+	if f == nil {
+		return nil
+	}
+	langs := make([]string, 0, len(f.langs))
+	for l := range f.langs {
+		langs = append(langs, l)
+	}
+	return langs
+}
+
+// Allow reports whether the record should be passed to the feeds.
+// only the fields used by the predicate are decoded. a nil filter allows all records.
+func (f *IngestFilter) Allow(record json.RawMessage) bool {
+	if f == nil {
+		return true
+	}
+	var hint struct {
+		Langs []string `json:"langs"`
+	}
+	if err := json.Unmarshal(record, &hint); err != nil {
+		// 壊れたレコードはフィードごとの解析でエラーとして扱う
+		return true
+	}
+	for _, l := range hint.Langs {
+		l = strings.ToLower(l)
+		if _, ok := f.langs[l]; ok {
+			return true
+		}
+		if i := strings.IndexByte(l, '-'); i > 0 {
+			if _, ok := f.langs[l[:i]]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
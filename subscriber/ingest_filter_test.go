@@ -0,0 +1,31 @@
+package subscriber
+
+import "testing"
+
+func TestIngestFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		langs  []string
+		record string
+		want   bool
+	}{
+		{name: "nil filter", langs: nil, record: `{"text":"hello"}`, want: true},
+		{name: "empty langs", langs: []string{" ", ""}, record: `{"text":"hello"}`, want: true},
+		{name: "match", langs: []string{"ja"}, record: `{"langs":["ja"]}`, want: true},
+		{name: "match subtag", langs: []string{"ja"}, record: `{"langs":["ja-JP"]}`, want: true},
+		{name: "case insensitive", langs: []string{"JA"}, record: `{"langs":["Ja"]}`, want: true},
+		{name: "one of langs", langs: []string{"ja", "ko"}, record: `{"langs":["en","ko"]}`, want: true},
+		{name: "no match", langs: []string{"ja"}, record: `{"langs":["en"]}`, want: false},
+		{name: "full tag does not match primary", langs: []string{"ja-jp"}, record: `{"langs":["ja"]}`, want: false},
+		{name: "no langs", langs: []string{"ja"}, record: `{"text":"hello"}`, want: false},
+		{name: "invalid record", langs: []string{"ja"}, record: `{`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewIngestFilter(tt.langs)
+			if got := f.Allow([]byte(tt.record)); got != tt.want {
+				t.Errorf("Allow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+
+	jetstreamClient "github.com/nus25/yuge/subscriber/pkg/client"
 )
 
 var ErrJetstreamControllerUnavailable = errors.New("jetstream controller is not configured")
@@ -58,6 +62,7 @@ type RuntimeJetstreamController struct {
 
 	mu         sync.Mutex
 	currentURL string
+	endpoints  []string // 接続失敗時に順に切り替えるエンドポイント
 	cursor     int64
 	cancel     context.CancelFunc
 	done       chan struct{}
@@ -68,10 +73,60 @@ func NewRuntimeJetstreamController(logger *slog.Logger, h *Handler, defaultURL s
 		logger:     logger.With("source", "jetstream-controller"),
 		h:          h,
 		currentURL: defaultURL,
+		endpoints:  []string{defaultURL},
 		cursor:     initialCursor,
 	}
 }
 
+// ParseJetstreamURLs parses a comma-separated list of jetstream websocket urls
+func ParseJetstreamURLs(s string) ([]string, error) {
+	var urls []string
+	for _, raw := range strings.Split(s, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jetstream url %q: %w", raw, err)
+		}
+		if (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+			return nil, fmt.Errorf("invalid jetstream url %q: must be a ws or wss url", raw)
+		}
+		urls = append(urls, u.String())
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("no jetstream url specified")
+	}
+	return urls, nil
+}
+
+// SetEndpoints sets the endpoints used for failover. the first endpoint becomes the current url.
+// it takes effect on the next connection.
+func (c *RuntimeJetstreamController) SetEndpoints(endpoints []string) {
+	if len(endpoints) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endpoints = slices.Clone(endpoints)
+	c.currentURL = c.endpoints[0]
+}
+
+// rotateEndpointLocked switches the current url to the next endpoint and returns it.
+// returns false if there is no other endpoint or the current url was set manually and is not in the endpoints.
+func (c *RuntimeJetstreamController) rotateEndpointLocked() (string, bool) {
+	if len(c.endpoints) < 2 {
+		return "", false
+	}
+	i := slices.Index(c.endpoints, c.currentURL)
+	if i < 0 {
+		return "", false
+	}
+	c.currentURL = c.endpoints[(i+1)%len(c.endpoints)]
+	return c.currentURL, true
+}
+
 func (c *RuntimeJetstreamController) Connect(req JetstreamConnectRequest) (JetstreamStatusResponse, error) {
 	if req.URL != nil {
 		u, err := url.Parse(*req.URL)
@@ -147,6 +202,30 @@ func (c *RuntimeJetstreamController) Disconnect() (JetstreamStatusResponse, erro
 	return c.Status(), nil
 }
 
+// SetWantedDids changes the DIDs to subscribe.
+// if connected, the client reconnects from the current cursor to apply the change.
+func (c *RuntimeJetstreamController) SetWantedDids(dids []string) error {
+	c.mu.Lock()
+	connected := c.cancel != nil
+	c.mu.Unlock()
+	if connected {
+		if _, err := c.Disconnect(); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.h == nil || c.h.Jsc == nil {
+		return errors.New("jetstream client is not initialized")
+	}
+	c.h.Jsc.SetWantedDids(dids)
+	if connected {
+		c.startLocked()
+	}
+	return nil
+}
+
 func (c *RuntimeJetstreamController) Status() JetstreamStatusResponse {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -176,6 +255,7 @@ func (c *RuntimeJetstreamController) run(ctx context.Context, done chan struct{}
 	}()
 
 	for {
+		c.logger.Info("connecting to jetstream endpoint", "url", c.h.Jsc.WebsocketURL(), "cursor", cursor)
 		lastCursor, err := c.h.HandleJetstream(ctx, c.logger, cursor)
 		c.mu.Lock()
 		c.cursor = lastCursor
@@ -191,6 +271,18 @@ func (c *RuntimeJetstreamController) run(ctx context.Context, done chan struct{}
 
 		jetstreamErrorCount.Inc()
 		c.logger.Error("jetstream client returned unexpectedly, retrying in 5 seconds", "error", err)
+		// 接続できなかった場合のみ次のエンドポイントへ切り替える。カーソルはそのまま引き継ぐ
+		c.mu.Lock()
+		if !shouldRotateEndpoint(err) {
+			c.logger.Info("reconnecting to the same jetstream endpoint", "url", c.h.Jsc.WebsocketURL())
+		} else if next, ok := c.rotateEndpointLocked(); ok {
+			if err := c.h.Jsc.SetWebsocketURL(next); err != nil {
+				c.logger.Error("failed to switch jetstream endpoint", "url", next, "error", err)
+			} else {
+				c.logger.Warn("switching jetstream endpoint", "url", next, "cursor", cursor)
+			}
+		}
+		c.mu.Unlock()
 		select {
 		case <-ctx.Done():
 			return
@@ -199,6 +291,13 @@ func (c *RuntimeJetstreamController) run(ctx context.Context, done chan struct{}
 	}
 }
 
+// shouldRotateEndpoint reports whether err means the endpoint could not be connected.
+// read or decode errors on an established connection are retried with the same endpoint.
+func shouldRotateEndpoint(err error) bool {
+	var dialErr *jetstreamClient.DialError
+	return errors.As(err, &dialErr)
+}
+
 func (c *RuntimeJetstreamController) statusLocked() JetstreamStatusResponse {
 	resp := JetstreamStatusResponse{
 		Connected:    c.cancel != nil,
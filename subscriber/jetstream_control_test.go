@@ -2,9 +2,14 @@ package subscriber
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"testing"
+
+	jetstreamClient "github.com/nus25/yuge/subscriber/pkg/client"
 )
 
 func TestRuntimeJetstreamController_ConnectWarnsOnInvalidCursor(t *testing.T) {
@@ -44,3 +49,80 @@ func TestRuntimeJetstreamController_ConnectKeepsRequestedCursorOnReconnect(t *te
 		t.Fatalf("expected cursor %d after reconnect request, got %d", requested, actual)
 	}
 }
+
+func TestParseJetstreamURLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"single", "ws://localhost:6008/subscribe", []string{"ws://localhost:6008/subscribe"}, false},
+		{"multiple with spaces", "wss://a.example.com/subscribe, wss://b.example.com/subscribe", []string{"wss://a.example.com/subscribe", "wss://b.example.com/subscribe"}, false},
+		{"trailing comma", "ws://localhost:6008/subscribe,", []string{"ws://localhost:6008/subscribe"}, false},
+		{"empty", "", nil, true},
+		{"http scheme", "ws://localhost:6008/subscribe,http://localhost:6008/subscribe", nil, true},
+		{"no host", "ws:///subscribe", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJetstreamURLs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseJetstreamURLs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseJetstreamURLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeJetstreamController_RotateEndpoint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	ctrl := NewRuntimeJetstreamController(logger, nil, "ws://a/subscribe", 100)
+
+	ctrl.mu.Lock()
+	if _, ok := ctrl.rotateEndpointLocked(); ok {
+		t.Error("expected no rotation with a single endpoint")
+	}
+	ctrl.mu.Unlock()
+
+	ctrl.SetEndpoints([]string{"ws://a/subscribe", "ws://b/subscribe", "ws://c/subscribe"})
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+	for _, want := range []string{"ws://b/subscribe", "ws://c/subscribe", "ws://a/subscribe"} {
+		got, ok := ctrl.rotateEndpointLocked()
+		if !ok || got != want || ctrl.currentURL != want {
+			t.Fatalf("rotateEndpointLocked() = %q, %v, want %q", got, ok, want)
+		}
+	}
+	if ctrl.cursor != 100 {
+		t.Errorf("expected cursor to be kept, got %d", ctrl.cursor)
+	}
+
+	// 手動で指定されたURLはローテーションしない
+	ctrl.currentURL = "ws://manual/subscribe"
+	if _, ok := ctrl.rotateEndpointLocked(); ok {
+		t.Error("expected no rotation for a url not in the endpoints")
+	}
+}
+
+func TestShouldRotateEndpoint(t *testing.T) {
+	dialErr := &jetstreamClient.DialError{URL: "ws://a/subscribe", Err: errors.New("connection refused")}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "dial error", err: dialErr, want: true},
+		{name: "wrapped dial error", err: fmt.Errorf("failed to connect and read: %w", dialErr), want: true},
+		{name: "read error", err: errors.New("failed to read message: unexpected EOF"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRotateEndpoint(tt.err); got != tt.want {
+				t.Errorf("shouldRotateEndpoint(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package subscriber
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nus25/yuge/feed/config/logic"
+	"github.com/nus25/yuge/feed/logicblock"
+)
+
+type LogicBlockApiHandler struct {
+	factory *logicblock.LogicBlockFactory
+}
+
+// NewLogicBlockApiHandler returns a handler listing the block types of factory.
+// if factory is nil, the global factory is used.
+func NewLogicBlockApiHandler(factory *logicblock.LogicBlockFactory) *LogicBlockApiHandler {
+	if factory == nil {
+		factory = logicblock.FactoryInstance()
+	}
+	return &LogicBlockApiHandler{factory: factory}
+}
+
+// LogicBlockOptionInfo is an option accepted by a logic block type
+type LogicBlockOptionInfo struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+	Default     any    `json:"default,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// LogicBlockTypeInfo is a logic block type supported by the running binary.
+// Options is nil if the type doesn't declare its options, e.g. custom blocks without a schema.
+type LogicBlockTypeInfo struct {
+	Type    string                 `json:"type"`
+	Options []LogicBlockOptionInfo `json:"options"`
+}
+
+type ListLogicBlockTypesResponse struct {
+	Types []LogicBlockTypeInfo `json:"types"`
+}
+
+// ListLogicBlockTypes lists the registered logic block types, built-in and custom, sorted by name
+// with the definitions of their options where available.
+func (h *LogicBlockApiHandler) ListLogicBlockTypes(c *gin.Context) {
+	resp := ListLogicBlockTypesResponse{Types: []LogicBlockTypeInfo{}}
+	for _, blockType := range h.factory.Types() {
+		info := LogicBlockTypeInfo{Type: blockType}
+		if definitions, ok := logic.BlockTypeDefinitions(blockType); ok {
+			info.Options = []LogicBlockOptionInfo{}
+			for _, key := range slices.Sorted(maps.Keys(definitions)) {
+				def := definitions[key]
+				info.Options = append(info.Options, LogicBlockOptionInfo{
+					Key:         key,
+					Type:        string(def.Type),
+					Required:    def.Required,
+					Default:     def.DefaultValue,
+					Description: def.Description,
+				})
+			}
+		}
+		resp.Types = append(resp.Types, info)
+	}
+	c.JSON(http.StatusOK, resp)
+}
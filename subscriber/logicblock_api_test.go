@@ -0,0 +1,80 @@
+package subscriber
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nus25/yuge/feed/logicblock"
+)
+
+func TestLogicBlockApiHandler_ListLogicBlockTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/logicblocks/types", NewLogicBlockApiHandler(nil).ListLogicBlockTypes)
+
+	req, _ := http.NewRequest("GET", "/api/logicblocks/types", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, but got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	var resp ListLogicBlockTypesResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	names := make([]string, len(resp.Types))
+	types := make(map[string]LogicBlockTypeInfo)
+	for i, info := range resp.Types {
+		names[i] = info.Type
+		types[info.Type] = info
+	}
+	if !slices.Equal(names, logicblock.FactoryInstance().Types()) {
+		t.Errorf("expected registered types %v, got %v", logicblock.FactoryInstance().Types(), names)
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("expected types sorted by name, got %v", names)
+	}
+
+	tests := []struct {
+		blockType string
+		wantKeys  []string // nil: options are not declared
+		required  string
+	}{
+		{blockType: "regex", wantKeys: []string{"caseSensitive", "collapseWhitespace", "invert", "nfkc", "value"}, required: "value"},
+		{blockType: "remove", wantKeys: []string{"language", "operator", "subject", "value"}, required: "subject"},
+		// スキーマを登録していないカスタムブロック
+		{blockType: "density", wantKeys: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.blockType, func(t *testing.T) {
+			info, ok := types[tt.blockType]
+			if !ok {
+				t.Fatalf("block type %s not listed", tt.blockType)
+			}
+			if tt.wantKeys == nil {
+				if info.Options != nil {
+					t.Errorf("expected options not declared, got %+v", info.Options)
+				}
+				return
+			}
+			keys := make([]string, len(info.Options))
+			for i, opt := range info.Options {
+				keys[i] = opt.Key
+				if opt.Type == "" {
+					t.Errorf("expected type of option %s", opt.Key)
+				}
+				if opt.Key == tt.required && !opt.Required {
+					t.Errorf("expected option %s to be required", opt.Key)
+				}
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("expected options %v, got %v", tt.wantKeys, keys)
+			}
+		})
+	}
+}
@@ -1,42 +1,51 @@
 package subscriber
 
 import (
+	"sync"
+	"time"
+
 	"github.com/nus25/yuge/feed"
 	"github.com/nus25/yuge/feed/logicblock"
+	"github.com/nus25/yuge/feed/metrics"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 var (
 	// 投稿の処理数
-	postsProcessed = promauto.NewCounter(prometheus.CounterOpts{
+	postsProcessed = metrics.Factory.NewCounter(prometheus.CounterOpts{
 		Name: "subscriber_posts_processed_total",
 		Help: "The total number of processed posts",
 	})
 
-	jetstreamErrorCount = promauto.NewCounter(prometheus.CounterOpts{
+	postsPrefiltered = metrics.Factory.NewCounter(prometheus.CounterOpts{
+		Name: "subscriber_posts_prefiltered_total",
+		Help: "The total number of created posts skipped by the ingest filter before parsing",
+	})
+
+	jetstreamErrorCount = metrics.Factory.NewCounter(prometheus.CounterOpts{
 		Name: "jetstream_error_total",
 		Help: "The total number of jetstream errors",
 	})
-	// フィードに追加された投稿数
-	postsAdded = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "feed_posts_added_total",
-		Help: "The total number of posts added to feed",
-	}, []string{"feed_id"})
+	// フィードへの投稿追加・削除数は feed パッケージで計測する
 
-	// 削除された投稿数
-	postsDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "feed_posts_deleted_total",
-		Help: "The total number of deleted posts",
+	// 判定を通過したがフィードへの追加に失敗した投稿数
+	feedAddFailures = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "feed_add_failures_total",
+		Help: "The total number of matched posts that failed to be added to the feed",
 	}, []string{"feed_id"})
 
 	// フィード内の投稿数
-	feedPosts = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	feedPosts = metrics.Factory.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "feed_posts",
 		Help: "The current number of posts in feed",
 	}, []string{"feed_id"})
+	// フィード内の投稿者数
+	feedUniqueAuthors = metrics.Factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "feed_unique_authors",
+		Help: "The current number of distinct authors in feed",
+	}, []string{"feed_id"})
 	// フィード判定速度
-	feedLogicLatency = promauto.NewHistogramVec(
+	feedLogicLatency = metrics.Factory.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "feed_logic_latency_seconds",
 			Help:    "Feed logic processing latency",
@@ -44,7 +53,7 @@ var (
 		},
 		[]string{"feed_id"},
 	)
-	dropinListUserCount = promauto.NewGaugeVec(
+	dropinListUserCount = metrics.Factory.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "feed_logic_dropin_list_user_count",
 			Help: "The current number of users in dropin list",
@@ -59,8 +68,48 @@ func updateMetrics(f feed.Feed) {
 		switch m.MetricName {
 		case feed.FeedMetricNamePostCount:
 			feedPosts.WithLabelValues(f.FeedId()).Set(float64(m.IntValue))
+		case feed.FeedMetricNameUniqueAuthors:
+			feedUniqueAuthors.WithLabelValues(f.FeedId()).Set(float64(m.IntValue))
 		case logicblock.DropInLogicMetricDropinListUserCount:
 			dropinListUserCount.WithLabelValues(f.FeedId(), m.MetricLabel).Set(float64(m.IntValue))
 		}
 	}
 }
+
+// feedMetricsUpdater updates feed metrics on scrapes.
+// updates are skipped until feeds are loaded, and computed metrics are reused for ttl
+// so that frequent scrapes don't count posts and authors of every feed each time.
+type feedMetricsUpdater struct {
+	fs          *FeedService
+	ttl         time.Duration
+	now         func() time.Time
+	mu          sync.Mutex
+	lastUpdated time.Time
+}
+
+func newFeedMetricsUpdater(fs *FeedService, ttl time.Duration) *feedMetricsUpdater {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return &feedMetricsUpdater{fs: fs, ttl: ttl, now: time.Now}
+}
+
+// Update updates the metrics of available feeds. returns false if the update is skipped
+func (u *feedMetricsUpdater) Update() bool {
+	if !u.fs.FeedsLoaded() {
+		return false
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := u.now()
+	if !u.lastUpdated.IsZero() && now.Sub(u.lastUpdated) < u.ttl {
+		return false
+	}
+	for _, f := range u.fs.GetAllFeeds() {
+		if !f.Status.IsUnavailable() && f.Feed != nil {
+			updateMetrics(f.Feed)
+		}
+	}
+	u.lastUpdated = now
+	return true
+}
@@ -0,0 +1,54 @@
+package subscriber
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFeedMetricsUpdater(t *testing.T) {
+	fs, tempDir, err := createFeedService(t)
+	if err != nil {
+		t.Fatalf("Failed to create feed service: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	u := newFeedMetricsUpdater(fs, 10*time.Second)
+	u.now = func() time.Time { return now }
+
+	// フィード読み込み前は更新しない
+	if u.Update() {
+		t.Error("expected update to be skipped before feeds are loaded")
+	}
+	if err := fs.LoadFeeds(context.Background()); err != nil {
+		t.Fatalf("Failed to load feeds: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    bool
+	}{
+		{name: "first scrape after load", elapsed: 0, want: true},
+		{name: "within ttl", elapsed: 5 * time.Second, want: false},
+		{name: "still within ttl", elapsed: 4 * time.Second, want: false},
+		{name: "ttl expired", elapsed: time.Second, want: true},
+		{name: "within ttl after update", elapsed: 9 * time.Second, want: false},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.elapsed)
+		if got := u.Update(); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// ttlが0の場合は毎回更新する
+	u = newFeedMetricsUpdater(fs, 0)
+	for range 2 {
+		if !u.Update() {
+			t.Error("expected update on every scrape with zero ttl")
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package subscriber
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger logs each api request through the given logger
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	logger = logger.With("component", "api")
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", path,
+			"status", c.Writer.Status(),
+			"latency", time.Since(start),
+			"clientIP", c.ClientIP(),
+		}
+		if feedId := c.Param("feedid"); feedId != "" {
+			attrs = append(attrs, "feedId", feedId)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "error", c.Errors.String())
+		}
+
+		switch {
+		case c.Writer.Status() >= 500:
+			logger.Error("api request", attrs...)
+		case c.Writer.Status() >= 400:
+			logger.Warn("api request", attrs...)
+		default:
+			logger.Info("api request", attrs...)
+		}
+	}
+}
+
+// BearerAuth requires "Authorization: Bearer <token>" on requests.
+// if exemptReadOnly is true, GET and HEAD requests pass without a token.
+func BearerAuth(token string, exemptReadOnly bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if exemptReadOnly && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+			c.Next()
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			respondWithError(c, http.StatusUnauthorized, "unauthorized", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CORS adds CORS headers for requests from allowed origins and answers preflight requests.
+// "*" in allowedOrigins allows any origin.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
+	allowAny := slices.Contains(allowedOrigins, "*")
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || (!allowAny && !slices.Contains(allowedOrigins, origin)) {
+			c.Next()
+			return
+		}
+		h := c.Writer.Header()
+		if allowAny {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+		}
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			h.Set("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
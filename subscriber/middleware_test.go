@@ -0,0 +1,140 @@
+package subscriber
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := gin.New()
+	r.Use(RequestLogger(logger))
+	r.GET("/api/feed/:feedid", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/feed/test-feed", nil)
+	r.ServeHTTP(w, req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to parse log entry: %v, log: %s", err, buf.String())
+	}
+	expected := map[string]any{
+		"level":  "WARN",
+		"method": "GET",
+		"path":   "/api/feed/test-feed",
+		"status": float64(http.StatusNotFound),
+		"feedId": "test-feed",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("log %s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["latency"]; !ok {
+		t.Error("latency is not logged")
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(exemptReadOnly bool) *gin.Engine {
+		r := gin.New()
+		g := r.Group("/api/feed")
+		g.Use(BearerAuth("secret", exemptReadOnly))
+		g.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+		g.PUT("/:feedid", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+
+	tests := []struct {
+		name           string
+		exemptReadOnly bool
+		method         string
+		path           string
+		authorization  string
+		wantStatus     int
+	}{
+		{name: "valid token", method: "PUT", path: "/api/feed/test", authorization: "Bearer secret", wantStatus: http.StatusOK},
+		{name: "missing token", method: "PUT", path: "/api/feed/test", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", method: "PUT", path: "/api/feed/test", authorization: "Bearer wrong", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", method: "PUT", path: "/api/feed/test", authorization: "Basic secret", wantStatus: http.StatusUnauthorized},
+		{name: "GET requires token by default", method: "GET", path: "/api/feed", wantStatus: http.StatusUnauthorized},
+		{name: "GET exempted", exemptReadOnly: true, method: "GET", path: "/api/feed", wantStatus: http.StatusOK},
+		{name: "PUT not exempted", exemptReadOnly: true, method: "PUT", path: "/api/feed/test", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			newRouter(tt.exemptReadOnly).ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(origins []string) *gin.Engine {
+		r := gin.New()
+		r.Use(CORS(origins))
+		r.GET("/api/feed", func(c *gin.Context) { c.Status(http.StatusOK) })
+		r.PUT("/api/feed/:feedid", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+
+	tests := []struct {
+		name        string
+		origins     []string
+		method      string
+		path        string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantAllowed string
+	}{
+		{name: "allowed origin", origins: []string{"https://example.com"}, method: "GET", path: "/api/feed", origin: "https://example.com", wantStatus: http.StatusOK, wantAllowed: "https://example.com"},
+		{name: "disallowed origin", origins: []string{"https://example.com"}, method: "GET", path: "/api/feed", origin: "https://other.example", wantStatus: http.StatusOK, wantAllowed: ""},
+		{name: "wildcard", origins: []string{"*"}, method: "GET", path: "/api/feed", origin: "https://other.example", wantStatus: http.StatusOK, wantAllowed: "*"},
+		{name: "no origin header", origins: []string{"*"}, method: "GET", path: "/api/feed", wantStatus: http.StatusOK, wantAllowed: ""},
+		{name: "preflight", origins: []string{"https://example.com"}, method: "OPTIONS", path: "/api/feed/test", origin: "https://example.com", preflight: true, wantStatus: http.StatusNoContent, wantAllowed: "https://example.com"},
+		{name: "preflight from disallowed origin", origins: []string{"https://example.com"}, method: "OPTIONS", path: "/api/feed/test", origin: "https://other.example", preflight: true, wantStatus: http.StatusNotFound, wantAllowed: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", "PUT")
+			}
+			newRouter(tt.origins).ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowed {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowed)
+			}
+		})
+	}
+}
@@ -1,12 +1,17 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +22,12 @@ import (
 	"go.uber.org/atomic"
 )
 
+const (
+	DefaultReadTimeout                = time.Minute
+	DefaultPingInterval               = 30 * time.Second
+	DefaultMaxConsecutiveDecodeErrors = 10
+)
+
 type ClientConfig struct {
 	Compress          bool
 	WebsocketURL      string
@@ -24,6 +35,23 @@ type ClientConfig struct {
 	WantedCollections []string
 	MaxSize           uint32
 	ExtraHeaders      map[string]string
+	// ReadTimeout is the read deadline extended on each pong from the server.
+	// Must be larger than PingInterval.
+	ReadTimeout time.Duration
+	// PingInterval is the cadence of pings sent to the server.
+	PingInterval time.Duration
+	// Decoder is a zstd decoder shared between clients. if nil, the client creates its own.
+	// use NewDecoder to create one with the jetstream dictionary.
+	Decoder *zstd.Decoder
+	// MaxConsecutiveDecodeErrors is the number of consecutive malformed messages skipped
+	// before the read loop gives up and returns an error.
+	MaxConsecutiveDecodeErrors int
+	// TLSConfig is the tls config for wss connections. if nil, the default config is used.
+	TLSConfig *tls.Config
+	// PinnedKeys are base64 SHA-256 hashes of public keys (see ParsePinnedKeys).
+	// if set, wss connections are accepted only if a certificate of the server has one of them,
+	// and ws connections are refused.
+	PinnedKeys []string
 }
 
 type Scheduler interface {
@@ -38,6 +66,8 @@ type Client struct {
 	config     *ClientConfig
 	logger     *slog.Logger
 	decoder    *zstd.Decoder
+	decodeBuf  []byte
+	pinnedKeys [][sha256.Size]byte
 	BytesRead  atomic.Int64
 	EventsRead atomic.Int64
 	shutdown   chan chan struct{}
@@ -45,11 +75,14 @@ type Client struct {
 
 func DefaultClientConfig() *ClientConfig {
 	return &ClientConfig{
-		Compress:          true,
-		WebsocketURL:      "ws://localhost:6008/subscribe",
-		WantedDids:        []string{},
-		WantedCollections: []string{},
-		MaxSize:           0,
+		Compress:                   true,
+		WebsocketURL:               "ws://localhost:6008/subscribe",
+		WantedDids:                 []string{},
+		WantedCollections:          []string{},
+		MaxSize:                    0,
+		ReadTimeout:                DefaultReadTimeout,
+		PingInterval:               DefaultPingInterval,
+		MaxConsecutiveDecodeErrors: DefaultMaxConsecutiveDecodeErrors,
 		ExtraHeaders: map[string]string{
 			"User-Agent": "yuge-jetstream-client/v0.0.1",
 		},
@@ -61,26 +94,96 @@ func NewClient(config *ClientConfig, logger *slog.Logger, scheduler Scheduler) (
 		config = DefaultClientConfig()
 	}
 
+	if config.ReadTimeout <= 0 {
+		config.ReadTimeout = DefaultReadTimeout
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = DefaultPingInterval
+	}
+	if config.MaxConsecutiveDecodeErrors <= 0 {
+		config.MaxConsecutiveDecodeErrors = DefaultMaxConsecutiveDecodeErrors
+	}
+	if config.ReadTimeout <= config.PingInterval {
+		return nil, fmt.Errorf("read timeout (%s) must be larger than ping interval (%s)", config.ReadTimeout, config.PingInterval)
+	}
+
+	pinnedKeys, err := ParsePinnedKeys(config.PinnedKeys)
+	if err != nil {
+		return nil, err
+	}
+
 	logger = logger.With("component", "jetstream-client")
 	c := Client{
-		config:    config,
-		shutdown:  make(chan chan struct{}),
-		logger:    logger,
-		Scheduler: scheduler,
+		pinnedKeys: pinnedKeys,
+		config:     config,
+		shutdown:   make(chan chan struct{}),
+		logger:     logger,
+		Scheduler:  scheduler,
 	}
 
 	if config.Compress {
 		c.config.ExtraHeaders["Socket-Encoding"] = "zstd"
-		dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(models.ZSTDDictionary))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+		if err := c.ensureDecoder(); err != nil {
+			return nil, err
 		}
-		c.decoder = dec
 	}
 
 	return &c, nil
 }
 
+// zstd frame magic number
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// NewDecoder creates a zstd decoder with the jetstream dictionary.
+// the decoder is safe for concurrent use and can be shared between clients via ClientConfig.Decoder.
+func NewDecoder() (*zstd.Decoder, error) {
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(models.ZSTDDictionary))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+	}
+	return dec, nil
+}
+
+func (c *Client) ensureDecoder() error {
+	if c.decoder != nil {
+		return nil
+	}
+	if c.config.Decoder != nil {
+		c.decoder = c.config.Decoder
+		return nil
+	}
+	dec, err := NewDecoder()
+	if err != nil {
+		return err
+	}
+	c.decoder = dec
+	return nil
+}
+
+// decompress decodes msg if it is a zstd frame.
+// a compressed frame can arrive even when compression is disabled (e.g. a proxy forcing compression),
+// so the frame is detected by its magic number and the decoder is created on demand.
+func (c *Client) decompress(msg []byte) ([]byte, error) {
+	if !c.config.Compress {
+		if !bytes.HasPrefix(msg, zstdMagic) {
+			return msg, nil
+		}
+		if c.decoder == nil {
+			c.logger.Error("received compressed frame while compression is disabled, decoding as zstd")
+			if err := c.ensureDecoder(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// 展開用バッファを使い回す。json.Unmarshalは入力をコピーするので、次のメッセージで上書きしても問題ない
+	m, err := c.decoder.DecodeAll(msg, c.decodeBuf[:0])
+	if err != nil {
+		return nil, err
+	}
+	c.decodeBuf = m
+	return m, nil
+}
+
 func (c *Client) SendPing() error {
 	if c.con == nil {
 		return nil
@@ -103,6 +206,12 @@ func (c *Client) SetWebsocketURL(rawURL string) error {
 	return nil
 }
 
+// SetWantedDids sets the DIDs to subscribe. an empty list subscribes to all DIDs.
+// the change takes effect on the next connection.
+func (c *Client) SetWantedDids(dids []string) {
+	c.config.WantedDids = slices.Clone(dids)
+}
+
 func (c *Client) WebsocketURL() string {
 	if c.config == nil {
 		return ""
@@ -110,6 +219,13 @@ func (c *Client) WebsocketURL() string {
 	return c.config.WebsocketURL
 }
 
+func (c *Client) PingInterval() time.Duration {
+	if c == nil || c.config == nil {
+		return DefaultPingInterval
+	}
+	return c.config.PingInterval
+}
+
 func (c *Client) Close() error {
 	if c.con == nil {
 		return nil
@@ -119,6 +235,21 @@ func (c *Client) Close() error {
 	return err
 }
 
+// DialError is returned by ConnectAndRead when the connection to the server could not be established.
+// errors returned after connecting, e.g. read or decode errors, are not DialError.
+type DialError struct {
+	URL string
+	Err error
+}
+
+func (e *DialError) Error() string {
+	return fmt.Sprintf("failed to connect to %s: %v", e.URL, e.Err)
+}
+
+func (e *DialError) Unwrap() error {
+	return e.Err
+}
+
 func (c *Client) ConnectAndRead(ctx context.Context, cursor int64) error {
 	defer func() {
 		if c.con != nil {
@@ -167,13 +298,33 @@ func (c *Client) ConnectAndRead(ctx context.Context, cursor int64) error {
 
 	u, err := url.Parse(fullURL)
 	if err != nil {
-		return fmt.Errorf("failed to parse connection url %q: %w", c.config.WebsocketURL, err)
+		return &DialError{URL: c.config.WebsocketURL, Err: fmt.Errorf("failed to parse connection url: %w", err)}
+	}
+
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = c.config.TLSConfig
+	if len(c.pinnedKeys) > 0 {
+		// ピン留めしている場合は平文の接続を許可しない
+		if u.Scheme != "wss" {
+			return &DialError{URL: c.config.WebsocketURL, Err: errors.New("certificate pinning requires a wss url")}
+		}
+		pinMismatches := clientPinMismatches.WithLabelValues(c.config.WebsocketURL)
+		dialer.TLSClientConfig = pinnedTLSConfig(c.config.TLSConfig, c.pinnedKeys, func() {
+			pinMismatches.Inc()
+			c.logger.Error("rejected jetstream server certificate not matching the pinned keys", "url", c.config.WebsocketURL)
+		})
 	}
 
 	c.logger.Info("connecting to websocket", "url", u.String(), "cursor", c.Cursor)
-	con, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	con, resp, err := dialer.DialContext(ctx, u.String(), header)
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return err
+		}
+		return &DialError{URL: c.config.WebsocketURL, Err: err}
+	}
+	if enc := resp.Header.Get("Socket-Encoding"); enc != "" && !c.config.Compress {
+		c.logger.Warn("server negotiated compression while compression is disabled", "encoding", enc)
 	}
 
 	//ホストjetstreamとのping&pong設定
@@ -188,7 +339,7 @@ func (c *Client) ConnectAndRead(ctx context.Context, cursor int64) error {
 	})
 
 	con.SetPongHandler(func(_ string) error {
-		if err := c.con.SetReadDeadline(time.Now().Add(time.Minute)); err != nil {
+		if err := c.con.SetReadDeadline(time.Now().Add(c.config.ReadTimeout)); err != nil {
 			return fmt.Errorf("failed to set read deadline: %s", err)
 		}
 		return nil
@@ -202,6 +353,14 @@ func (c *Client) ConnectAndRead(ctx context.Context, cursor int64) error {
 
 	c.con = con
 
+	// pongが読み取り期限内に届かない場合はReadMessageがタイムアウトし、接続を閉じる
+	if err := con.SetReadDeadline(time.Now().Add(c.config.ReadTimeout)); err != nil {
+		return fmt.Errorf("failed to set read deadline: %w", err)
+	}
+	pingCtx, stopPing := context.WithCancel(ctx)
+	defer stopPing()
+	go c.pingLoop(pingCtx, con)
+
 	if err := c.readLoop(ctx); err != nil {
 		return fmt.Errorf("read loop failed: %w", err)
 	}
@@ -209,11 +368,45 @@ func (c *Client) ConnectAndRead(ctx context.Context, cursor int64) error {
 	return nil
 }
 
+// pingLoop sends a ping to the server every PingInterval until ctx is done.
+func (c *Client) pingLoop(ctx context.Context, con *websocket.Conn) {
+	t := time.NewTicker(c.config.PingInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			c.logger.Debug("send ping to jetstream")
+			if err := con.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(time.Second*10)); err != nil {
+				c.logger.Warn("failed to ping", "error", err)
+			}
+		case <-ctx.Done():
+			c.logger.Debug("jetstream ping loop finished")
+			return
+		}
+	}
+}
+
 func (c *Client) readLoop(ctx context.Context) error {
 	c.logger.Info("starting websocket read loop")
 
 	bytesRead := clientBytesRead.WithLabelValues(c.config.WebsocketURL)
 	eventsRead := clientEventsRead.WithLabelValues(c.config.WebsocketURL)
+	eventsDropped := clientEventsDropped.WithLabelValues(c.config.WebsocketURL)
+	decodeErrors := clientDecodeErrors.WithLabelValues(c.config.WebsocketURL)
+	decompressErrors := clientDecompressErrors.WithLabelValues(c.config.WebsocketURL)
+
+	// 壊れたメッセージ1件で再接続しないよう、連続エラーが上限を超えるまではスキップする
+	consecutiveErrors := 0
+	skipOrFail := func(msg string, err error) error {
+		consecutiveErrors++
+		if consecutiveErrors >= c.config.MaxConsecutiveDecodeErrors {
+			c.logger.Error(msg, "error", err, "consecutiveErrors", consecutiveErrors)
+			return fmt.Errorf("%s: too many consecutive errors (%d): %w", msg, consecutiveErrors, err)
+		}
+		c.logger.Warn(msg+", skipping", "error", err, "consecutiveErrors", consecutiveErrors)
+		return nil
+	}
 
 	for {
 		select {
@@ -237,23 +430,28 @@ func (c *Client) readLoop(ctx context.Context) error {
 			c.EventsRead.Inc()
 
 			// Decompress the message if necessary
-			if c.decoder != nil && c.config.Compress {
-				m, err := c.decoder.DecodeAll(msg, nil)
-				if err != nil {
-					c.logger.Error("failed to decompress message", "error", err)
-					return fmt.Errorf("failed to decompress message: %w", err)
+			msg, err = c.decompress(msg)
+			if err != nil {
+				decompressErrors.Inc()
+				if err := skipOrFail("failed to decompress message", err); err != nil {
+					return err
 				}
-				msg = m
+				continue
 			}
 
 			// Unpack the message and pass it to the handler
 			var event models.Event
 			if err := json.Unmarshal(msg, &event); err != nil {
-				c.logger.Error("failed to unmarshal event", "error", err)
-				return fmt.Errorf("failed to unmarshal event: %w", err)
+				decodeErrors.Inc()
+				if err := skipOrFail("failed to unmarshal event", err); err != nil {
+					return err
+				}
+				continue
 			}
+			consecutiveErrors = 0
 
 			if err := c.Scheduler.AddWork(ctx, "jetstream_repo", &event); err != nil {
+				eventsDropped.Inc()
 				c.logger.Error("failed to add work to scheduler", "error", err)
 				return fmt.Errorf("failed to add work to scheduler: %w", err)
 			}
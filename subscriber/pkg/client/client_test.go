@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bluesky-social/jetstream/pkg/models"
+	"github.com/gorilla/websocket"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type recordScheduler struct {
+	mu     sync.Mutex
+	events []*models.Event
+}
+
+func (s *recordScheduler) AddWork(ctx context.Context, repo string, evt *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, evt)
+	return nil
+}
+
+func (s *recordScheduler) Shutdown() {}
+
+// newTestServer starts a websocket server that sends msgs and closes the connection
+func newTestServer(t *testing.T, msgs []string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(testServerHandler(msgs))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testServerHandler(msgs []string) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		con, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer con.Close()
+		for _, m := range msgs {
+			if err := con.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+				return
+			}
+		}
+		con.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	})
+}
+
+func compressEvent(t *testing.T, msg string) string {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderDict(models.ZSTDDictionary))
+	if err != nil {
+		t.Fatalf("failed to create zstd encoder: %v", err)
+	}
+	defer enc.Close()
+	return string(enc.EncodeAll([]byte(msg), nil))
+}
+
+func TestReadLoopDecodeErrors(t *testing.T) {
+	valid := `{"did":"did:plc:test","time_us":100,"kind":"commit"}`
+	tests := []struct {
+		name       string
+		compress   bool
+		msgs       []string
+		maxErrors  int
+		wantEvents int
+		wantErr    string
+		wantDecode float64
+		wantDecomp float64
+	}{
+		{
+			name:       "skip single malformed message",
+			msgs:       []string{valid, "{invalid", valid},
+			maxErrors:  3,
+			wantEvents: 2,
+			wantErr:    "failed to read message",
+			wantDecode: 1,
+		},
+		{
+			name:       "non consecutive errors are skipped",
+			msgs:       []string{"{invalid", "{invalid", valid, "{invalid", "{invalid", valid},
+			maxErrors:  3,
+			wantEvents: 2,
+			wantErr:    "failed to read message",
+			wantDecode: 4,
+		},
+		{
+			name:       "give up after consecutive errors",
+			msgs:       []string{valid, "{invalid", "{invalid", "{invalid", valid},
+			maxErrors:  3,
+			wantEvents: 1,
+			wantErr:    "too many consecutive errors",
+			wantDecode: 3,
+		},
+		{
+			name:       "compressed frame with compression disabled",
+			msgs:       []string{compressEvent(t, valid), valid},
+			maxErrors:  1,
+			wantEvents: 2,
+			wantErr:    "failed to read message",
+		},
+		{
+			name:       "decompress error",
+			compress:   true,
+			msgs:       []string{"not zstd", "not zstd"},
+			maxErrors:  2,
+			wantErr:    "failed to decompress message",
+			wantDecomp: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, tt.msgs)
+			cfg := DefaultClientConfig()
+			cfg.WebsocketURL = "ws" + strings.TrimPrefix(srv.URL, "http")
+			cfg.Compress = tt.compress
+			cfg.MaxConsecutiveDecodeErrors = tt.maxErrors
+			sched := &recordScheduler{}
+			c, err := NewClient(cfg, slog.Default(), sched)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			err = c.ConnectAndRead(context.Background(), 0)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ConnectAndRead() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(sched.events) != tt.wantEvents {
+				t.Errorf("expected %d events, got %d", tt.wantEvents, len(sched.events))
+			}
+			if got := testutil.ToFloat64(clientDecodeErrors.WithLabelValues(cfg.WebsocketURL)); got != tt.wantDecode {
+				t.Errorf("expected %v decode errors, got %v", tt.wantDecode, got)
+			}
+			if got := testutil.ToFloat64(clientDecompressErrors.WithLabelValues(cfg.WebsocketURL)); got != tt.wantDecomp {
+				t.Errorf("expected %v decompress errors, got %v", tt.wantDecomp, got)
+			}
+		})
+	}
+}
+
+func newDecompressClient(tb testing.TB, dec *zstd.Decoder) *Client {
+	tb.Helper()
+	cfg := DefaultClientConfig()
+	cfg.Decoder = dec
+	c, err := NewClient(cfg, slog.Default(), &recordScheduler{})
+	if err != nil {
+		tb.Fatalf("failed to create client: %v", err)
+	}
+	return c
+}
+
+func TestSharedDecoder(t *testing.T) {
+	dec, err := NewDecoder()
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+	defer dec.Close()
+	c1 := newDecompressClient(t, dec)
+	c2 := newDecompressClient(t, dec)
+	if c1.decoder != dec || c2.decoder != dec {
+		t.Fatal("expected clients to share the decoder")
+	}
+
+	valid := `{"did":"did:plc:test","time_us":100,"kind":"commit"}`
+	msg := []byte(compressEvent(t, valid))
+	for _, c := range []*Client{c1, c2} {
+		got, err := c.decompress(msg)
+		if err != nil {
+			t.Fatalf("failed to decompress: %v", err)
+		}
+		if string(got) != valid {
+			t.Errorf("decompress() = %s, want %s", got, valid)
+		}
+	}
+}
+
+func TestDecompressReusesBuffer(t *testing.T) {
+	c := newDecompressClient(t, nil)
+	msg := []byte(compressEvent(t, strings.Repeat(`{"did":"did:plc:test","time_us":100,"kind":"commit"}`, 10)))
+
+	fresh := testing.AllocsPerRun(100, func() {
+		if _, err := c.decoder.DecodeAll(msg, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	reused := testing.AllocsPerRun(100, func() {
+		if _, err := c.decompress(msg); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Logf("allocs per decode: fresh=%v reused=%v", fresh, reused)
+	if reused >= fresh {
+		t.Errorf("expected fewer allocations with reused buffer: fresh=%v reused=%v", fresh, reused)
+	}
+}
+
+func BenchmarkDecompress(b *testing.B) {
+	c := newDecompressClient(b, nil)
+	enc, _ := zstd.NewWriter(nil, zstd.WithEncoderDict(models.ZSTDDictionary))
+	msg := enc.EncodeAll([]byte(strings.Repeat(`{"did":"did:plc:test","time_us":100,"kind":"commit"}`, 10)), nil)
+	enc.Close()
+
+	b.Run("fresh buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			c.decoder.DecodeAll(msg, nil)
+		}
+	})
+	b.Run("reused buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			c.decompress(msg)
+		}
+	})
+}
@@ -1,16 +1,36 @@
 package client
 
 import (
+	"github.com/nus25/yuge/feed/metrics"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-var clientBytesRead = promauto.NewCounterVec(prometheus.CounterOpts{
+var clientBytesRead = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
 	Name: "jetstream_client_bytes_read",
 	Help: "The total number of bytes read from the server",
 }, []string{"client"})
 
-var clientEventsRead = promauto.NewCounterVec(prometheus.CounterOpts{
+var clientEventsRead = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
 	Name: "jetstream_client_events_read",
 	Help: "The total number of events read from the server",
 }, []string{"client"})
+
+var clientEventsDropped = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "jetstream_events_dropped_total",
+	Help: "The total number of events dropped because the scheduler rejected them",
+}, []string{"client"})
+
+var clientDecodeErrors = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "jetstream_decode_errors_total",
+	Help: "The total number of messages that failed to unmarshal",
+}, []string{"client"})
+
+var clientDecompressErrors = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "jetstream_decompress_errors_total",
+	Help: "The total number of messages that failed to decompress",
+}, []string{"client"})
+
+var clientPinMismatches = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+	Name: "jetstream_tls_pin_mismatches_total",
+	Help: "The total number of connections rejected because the server certificate did not match the pinned keys",
+}, []string{"client"})
@@ -1,31 +1,31 @@
 package schedulers
 
 import (
+	"github.com/nus25/yuge/feed/metrics"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-var WorkItemsAdded = promauto.NewCounterVec(prometheus.CounterOpts{
+var WorkItemsAdded = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
 	Name: "jetstream_scheduler_work_items_added_total",
 	Help: "Total number of work items added to the consumer pool",
 }, []string{"pool", "scheduler_type"})
 
-var WorkItemsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+var WorkItemsProcessed = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
 	Name: "jetstream_scheduler_work_items_processed_total",
 	Help: "Total number of work items processed by the consumer pool",
 }, []string{"pool", "scheduler_type"})
 
-var WorkItemsActive = promauto.NewCounterVec(prometheus.CounterOpts{
+var WorkItemsActive = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
 	Name: "jetstream_scheduler_work_items_active_total",
 	Help: "Total number of work items passed into a worker",
 }, []string{"pool", "scheduler_type"})
 
-var WorkersActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+var WorkersActive = metrics.Factory.NewGaugeVec(prometheus.GaugeOpts{
 	Name: "jetstream_scheduler_workers_active",
 	Help: "Number of workers currently active",
 }, []string{"pool", "scheduler_type"})
 
-var WorkItemsQueued = promauto.NewGaugeVec(prometheus.GaugeOpts{
+var WorkItemsQueued = metrics.Factory.NewGaugeVec(prometheus.GaugeOpts{
 	Name: "jetstream_scheduler_work_items_queued",
 	Help: "Number of work items currently queued in the scheduler",
 }, []string{"pool", "scheduler_type"})
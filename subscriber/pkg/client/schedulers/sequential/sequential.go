@@ -4,8 +4,8 @@ import (
 	"context"
 	"log/slog"
 
-	"github.com/bluesky-social/jetstream/pkg/client/schedulers"
 	"github.com/bluesky-social/jetstream/pkg/models"
+	"github.com/nus25/yuge/subscriber/pkg/client/schedulers"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -21,6 +21,7 @@ type Scheduler struct {
 	itemsProcessed prometheus.Counter
 	itemsActive    prometheus.Counter
 	workersActive  prometheus.Gauge
+	itemsQueued    prometheus.Gauge
 }
 
 func NewScheduler(ident string, logger *slog.Logger, handleEvent func(context.Context, *models.Event) error) *Scheduler {
@@ -35,6 +36,7 @@ func NewScheduler(ident string, logger *slog.Logger, handleEvent func(context.Co
 		itemsProcessed: schedulers.WorkItemsProcessed.WithLabelValues(ident, "sequential"),
 		itemsActive:    schedulers.WorkItemsActive.WithLabelValues(ident, "sequential"),
 		workersActive:  schedulers.WorkersActive.WithLabelValues(ident, "sequential"),
+		itemsQueued:    schedulers.WorkItemsQueued.WithLabelValues(ident, "sequential"),
 	}
 
 	p.workersActive.Set(1)
@@ -48,6 +50,8 @@ func (p *Scheduler) Shutdown() {
 
 func (s *Scheduler) AddWork(ctx context.Context, repo string, val *models.Event) error {
 	s.itemsAdded.Inc()
+	s.itemsQueued.Inc()
+	defer s.itemsQueued.Dec()
 	s.itemsActive.Inc()
 	err := s.handleEvent(ctx, val)
 	s.itemsProcessed.Inc()
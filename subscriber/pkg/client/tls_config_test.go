@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(testServerHandler([]string{`{"did":"did:plc:test","time_us":100,"kind":"commit"}`}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	writePEM := func(name string, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	caFile := writePEM("ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(srv.TLS.Certificates[0].PrivateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	keyFile := writePEM("key.pem", "PRIVATE KEY", keyDer)
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidFile, []byte("not a pem"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		caFile   string
+		certFile string
+		keyFile  string
+		wantNil  bool
+		wantErr  string
+	}{
+		{name: "no files", wantNil: true},
+		{name: "ca file", caFile: caFile},
+		{name: "client certificate", certFile: caFile, keyFile: keyFile},
+		{name: "missing ca file", caFile: filepath.Join(dir, "missing.pem"), wantErr: "failed to read ca file"},
+		{name: "invalid ca file", caFile: invalidFile, wantErr: "no certificate found"},
+		{name: "cert without key", certFile: caFile, wantErr: "must be specified together"},
+		{name: "invalid key", certFile: caFile, keyFile: invalidFile, wantErr: "failed to load client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewTLSConfig(tt.caFile, tt.certFile, tt.keyFile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (cfg == nil) != tt.wantNil {
+				t.Errorf("expected nil config %v, got %v", tt.wantNil, cfg)
+			}
+		})
+	}
+
+	// 自己署名証明書のサーバーにはCAファイルを指定した場合のみ接続できる
+	connect := func(caFile string) (int, error) {
+		tlsConfig, err := NewTLSConfig(caFile, "", "")
+		if err != nil {
+			t.Fatalf("failed to create tls config: %v", err)
+		}
+		cfg := DefaultClientConfig()
+		cfg.WebsocketURL = "wss" + strings.TrimPrefix(srv.URL, "https")
+		cfg.Compress = false
+		cfg.TLSConfig = tlsConfig
+		sched := &recordScheduler{}
+		c, err := NewClient(cfg, slog.Default(), sched)
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		err = c.ConnectAndRead(context.Background(), 0)
+		return len(sched.events), err
+	}
+	if events, err := connect(caFile); events != 1 {
+		t.Errorf("expected 1 event with the ca file, got %d: %v", events, err)
+	}
+	if events, err := connect(""); events != 0 || err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("expected certificate error without the ca file, got %d events: %v", events, err)
+	}
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParsePinnedKeys(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	tests := []struct {
+		name    string
+		pins    []string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty", pins: nil, want: 0},
+		{name: "bare base64", pins: []string{valid}, want: 1},
+		{name: "with prefix and spaces", pins: []string{" sha256/" + valid + " ", ""}, want: 1},
+		{name: "invalid base64", pins: []string{"sha256/!!!"}, wantErr: true},
+		{name: "wrong length", pins: []string{base64.StdEncoding.EncodeToString([]byte("short"))}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParsePinnedKeys(tt.pins)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePinnedKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(keys) != tt.want {
+				t.Errorf("expected %d keys, got %d", tt.want, len(keys))
+			}
+		})
+	}
+}
+
+func TestCertificatePinning(t *testing.T) {
+	valid := `{"did":"did:plc:test","time_us":100,"kind":"commit"}`
+	srv := httptest.NewTLSServer(testServerHandler([]string{valid}))
+	t.Cleanup(srv.Close)
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	serverPin := PublicKeyPin(srv.Certificate())
+	otherPin := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name         string
+		url          string
+		pins         []string
+		wantEvents   int
+		wantErr      string
+		wantMismatch float64
+	}{
+		{name: "matching pin", pins: []string{otherPin, serverPin}, wantEvents: 1, wantErr: "failed to read message"},
+		{name: "no pin", wantEvents: 1, wantErr: "failed to read message"},
+		{name: "pin mismatch", pins: []string{otherPin}, wantErr: ErrCertificatePinMismatch.Error(), wantMismatch: 1},
+		{name: "plain ws with pins", url: "ws://127.0.0.1:1/subscribe", pins: []string{serverPin}, wantErr: "requires a wss url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultClientConfig()
+			cfg.WebsocketURL = "wss" + strings.TrimPrefix(srv.URL, "https")
+			if tt.url != "" {
+				cfg.WebsocketURL = tt.url
+			}
+			cfg.Compress = false
+			cfg.TLSConfig = &tls.Config{RootCAs: pool}
+			cfg.PinnedKeys = tt.pins
+			sched := &recordScheduler{}
+			c, err := NewClient(cfg, slog.Default(), sched)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			before := testutil.ToFloat64(clientPinMismatches.WithLabelValues(cfg.WebsocketURL))
+
+			err = c.ConnectAndRead(context.Background(), 0)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ConnectAndRead() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantMismatch > 0 && !errors.Is(err, ErrCertificatePinMismatch) {
+				t.Errorf("expected ErrCertificatePinMismatch, got %v", err)
+			}
+			if len(sched.events) != tt.wantEvents {
+				t.Errorf("expected %d events, got %d", tt.wantEvents, len(sched.events))
+			}
+			if got := testutil.ToFloat64(clientPinMismatches.WithLabelValues(cfg.WebsocketURL)) - before; got != tt.wantMismatch {
+				t.Errorf("expected %v pin mismatches, got %v", tt.wantMismatch, got)
+			}
+		})
+	}
+
+	if _, err := NewClient(&ClientConfig{PinnedKeys: []string{"invalid"}, ExtraHeaders: map[string]string{}}, slog.Default(), &recordScheduler{}); err == nil {
+		t.Error("expected NewClient to reject an invalid pin")
+	}
+}
+
+// newTestCert creates a certificate signed by parent, or a self-signed one if parent is nil
+func newTestCert(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if !isCA {
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+func TestCertificatePinningVerifiedChainOnly(t *testing.T) {
+	ca, caKey := newTestCert(t, "test ca", true, nil, nil)
+	leaf, leafKey := newTestCert(t, "127.0.0.1", false, ca, caKey)
+	// サーバーが検証済みチェーンと無関係な証明書を追加で送る
+	unrelated, _ := newTestCert(t, "unrelated", true, nil, nil)
+
+	srv := httptest.NewUnstartedServer(testServerHandler([]string{`{"did":"did:plc:test","time_us":100,"kind":"commit"}`}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.Raw, unrelated.Raw},
+		PrivateKey:  leafKey,
+	}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	tests := []struct {
+		name       string
+		pin        string
+		wantEvents int
+		wantErr    string
+	}{
+		{name: "pin of the ca in the verified chain", pin: PublicKeyPin(ca), wantEvents: 1, wantErr: "failed to read message"},
+		{name: "pin of an unrelated certificate sent by the server", pin: PublicKeyPin(unrelated), wantErr: ErrCertificatePinMismatch.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultClientConfig()
+			cfg.WebsocketURL = "wss" + strings.TrimPrefix(srv.URL, "https")
+			cfg.Compress = false
+			cfg.TLSConfig = &tls.Config{RootCAs: pool}
+			cfg.PinnedKeys = []string{tt.pin}
+			sched := &recordScheduler{}
+			c, err := NewClient(cfg, slog.Default(), sched)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			err = c.ConnectAndRead(context.Background(), 0)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ConnectAndRead() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(sched.events) != tt.wantEvents {
+				t.Errorf("expected %d events, got %d", tt.wantEvents, len(sched.events))
+			}
+		})
+	}
+}
@@ -5,15 +5,17 @@ import (
 	"embed"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nus25/yuge/feed/metrics"
 	"github.com/nus25/yuge/feed/store/editor"
 	_ "github.com/nus25/yuge/subscriber/customfeedlogic" //for register custom logic block
 	jetstreamClient "github.com/nus25/yuge/subscriber/pkg/client"
@@ -40,34 +42,78 @@ func getLogLevel(level string) slog.Level {
 	}
 }
 
+func getLogHandler(format string, level slog.Level) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.NewTextHandler(os.Stdout, opts)
+	default:
+		return slog.NewJSONHandler(os.Stdout, opts)
+	}
+}
+
 func JetstreamSubscriber(cctx *cli.Context) error {
 	ctx := cctx.Context
 	//// Prepare
 	logLevel := getLogLevel(cctx.String("log-level"))
-	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	log := slog.New(getLogHandler(cctx.String("log-format"), logLevel))
 	slog.SetDefault(log)
 	logger := slog.Default()
-	log.Info("log level", "level", logLevel)
+	log.Info("log level", "level", logLevel, "format", cctx.String("log-format"))
 
 	gin.SetMode(gin.ReleaseMode)
 
-	u, err := url.Parse(cctx.String("jetstream-url"))
+	jetstreamURLs, err := ParseJetstreamURLs(cctx.String("jetstream-url"))
 	if err != nil {
 		return fmt.Errorf("failed to parse jetstream-url: %w", err)
 	}
+	pins := cctx.StringSlice("jetstream-tls-pins")
+	if len(pins) > 0 {
+		if _, err := jetstreamClient.ParsePinnedKeys(pins); err != nil {
+			return fmt.Errorf("failed to parse jetstream-tls-pins: %w", err)
+		}
+		for _, u := range jetstreamURLs {
+			if !strings.HasPrefix(u, "wss://") {
+				return fmt.Errorf("jetstream-tls-pins requires wss urls: %s", u)
+			}
+		}
+	}
+	tlsConfig, err := jetstreamClient.NewTLSConfig(cctx.String("jetstream-tls-ca-file"), cctx.String("jetstream-tls-cert-file"), cctx.String("jetstream-tls-key-file"))
+	if err != nil {
+		return fmt.Errorf("failed to create jetstream tls config: %w", err)
+	}
+	maxSize := cctx.Int("jetstream-max-size")
+	if maxSize < 0 || maxSize > math.MaxUint32 {
+		return fmt.Errorf("jetstream-max-size must be between 0 and %d: %d", uint32(math.MaxUint32), maxSize)
+	}
+
+	// ディレクトリの問題はフィードの読み込み時ではなく起動時にエラーにする
+	if p := cctx.String("config-directory-path"); p != "" {
+		if err := ValidateConfigDirectory(p); err != nil {
+			return err
+		}
+	}
+	created, err := PrepareDataDirectory(cctx.String("data-directory-path"))
+	if err != nil {
+		return err
+	}
+	if created {
+		logger.Info("created data directory", "data-directory-path", cctx.String("data-directory-path"))
+	}
 
 	//// setup store editor
 	var se editor.StoreEditor
+	// gyoka client options, also used for the mirrors of feeds
+	var opts []editor.ClientOptionFunc
+	if cctx.String("feed-editor-cf-id") != "" {
+		opts = append(opts, editor.WithCfToken(cctx.String("feed-editor-cf-id"), cctx.String("feed-editor-cf-secret")))
+	}
+	if cctx.String("gyoka-api-key") != "" {
+		opts = append(opts, editor.WithApiKey(cctx.String("gyoka-api-key")))
+	}
 	//Gyoka Editor
 	if cctx.String("feed-editor-endpoint") != "" {
 		logger.Info("feed editor config", "endpoint", cctx.String("feed-editor-endpoint"))
-		var opts []editor.ClientOptionFunc
-		if cctx.String("feed-editor-cf-id") != "" {
-			opts = append(opts, editor.WithCfToken(cctx.String("feed-editor-cf-id"), cctx.String("feed-editor-cf-secret")))
-		}
-		if cctx.String("gyoka-api-key") != "" {
-			opts = append(opts, editor.WithApiKey(cctx.String("gyoka-api-key")))
-		}
 		se, err = editor.NewGyokaEditor(cctx.String("feed-editor-endpoint"), logger, opts...)
 		if err != nil {
 			return fmt.Errorf("failed to create gyoka editor: %w", err)
@@ -75,11 +121,38 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 	} else {
 		logger.Info("feed editor endpoint is not set. run local mode.")
 	}
-	// if no feed editor endpoint, use file editor
+	// if no feed editor endpoint, use local store backend
 	if se == nil {
-		se, err = editor.NewFileEditor(cctx.String("data-directory-path"), logger)
+		switch backend := cctx.String("store-backend"); backend {
+		case "file":
+			se, err = editor.NewFileEditor(cctx.String("data-directory-path"), logger)
+			if err != nil {
+				return fmt.Errorf("failed to create file editor: %w", err)
+			}
+		case "sqlite":
+			se, err = editor.NewSQLiteEditor(filepath.Join(cctx.String("data-directory-path"), editor.SQLiteFileName), logger)
+			if err != nil {
+				return fmt.Errorf("failed to create sqlite editor: %w", err)
+			}
+		default:
+			return fmt.Errorf("unknown store-backend: %s", backend)
+		}
+	}
+
+	// publish feed mutations to the message bus in addition to the store editor
+	if u := cctx.String("stream-nats-url"); u != "" {
+		logger.Info("stream editor config", "nats-url", u, "subject", cctx.String("stream-subject"))
+		pub, err := editor.NewNATSPublisher(u, logger)
+		if err != nil {
+			return fmt.Errorf("failed to create nats publisher: %w", err)
+		}
+		stream, err := editor.NewStreamEditor(pub, cctx.String("stream-subject"), logger)
+		if err != nil {
+			return fmt.Errorf("failed to create stream editor: %w", err)
+		}
+		se, err = editor.NewCompositeEditor(se, []editor.StoreEditor{stream}, logger)
 		if err != nil {
-			return fmt.Errorf("failed to create file editor: %w", err)
+			return fmt.Errorf("failed to create composite editor: %w", err)
 		}
 	}
 
@@ -99,6 +172,18 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create feed service: %w", err)
 	}
+	fs.SetFeedTimeouts(cctx.Duration("feed-create-timeout"), cctx.Duration("feed-shutdown-timeout"))
+	fs.SetMirrorEditorOptions(opts...)
+	fs.SetRecentTestsSize(cctx.Int("feed-recent-tests"))
+	fs.SetBlockTestTimeout(cctx.Duration("feed-block-test-timeout"))
+	if ids := cctx.StringSlice("feeds"); len(ids) > 0 {
+		logger.Info("running only the specified feeds", "feeds", ids)
+		fs.SetFeedFilter(ids)
+	}
+	if cctx.Bool("disable-pds-config-cache") {
+		logger.Info("PDS config cache is disabled")
+		fs.DisablePDSConfigCache()
+	}
 	logger.Info("loading feeds")
 	if err := fs.LoadFeeds(context.Background()); err != nil {
 		logger.Error("failed to load some feed", "error", err)
@@ -107,12 +192,29 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 
 	// handler
 	h := NewHandler(logger, fs)
+	h.RemoveDeletedAccounts = cctx.Bool("remove-deleted-accounts")
+	if h.RemoveDeletedAccounts {
+		logger.Info("posts of deleted or taken down accounts will be removed")
+	}
+	h.IngestFilter = NewIngestFilter(cctx.StringSlice("ingest-langs"))
+	if h.IngestFilter != nil {
+		logger.Info("ingest filter enabled, posts without the langs are skipped", "langs", h.IngestFilter.Langs())
+	}
 
 	// setup jetstream client
 	config := jetstreamClient.DefaultClientConfig()
 	config.WantedCollections = []string{"app.bsky.feed.post"}
-	config.WebsocketURL = u.String()
+	config.WebsocketURL = jetstreamURLs[0]
 	config.Compress = cctx.Bool("jetstream-commpression")
+	config.ReadTimeout = cctx.Duration("jetstream-read-timeout")
+	config.PingInterval = cctx.Duration("jetstream-ping-interval")
+	config.MaxConsecutiveDecodeErrors = cctx.Int("jetstream-max-decode-errors")
+	config.MaxSize = uint32(maxSize)
+	config.TLSConfig = tlsConfig
+	config.PinnedKeys = pins
+	if len(pins) > 0 {
+		logger.Info("jetstream server certificate pinning enabled", "pins", len(pins))
+	}
 	// 受信を非同期にしてイベント受信の負荷を緩和する
 	sched := parallel.NewScheduler(1, "jetstream_client", logger, h.HandlePostEvent)
 	defer sched.Shutdown()
@@ -123,27 +225,57 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 	}
 	h.Jsc = jsc
 	cursor := cctx.Int64("override-cursor")
-	jetstreamController := NewRuntimeJetstreamController(log, h, u.String(), cursor)
+	cursorPath := filepath.Join(cctx.String("data-directory-path"), CursorFileName)
+	if cursor < 0 {
+		// override-cursor未指定の場合は前回終了時のカーソルから再開する
+		if c, err := readCursorFile(cursorPath); err == nil {
+			log.Info("resuming from persisted cursor", "cursor", c, "path", cursorPath)
+			cursor = c
+		} else if !os.IsNotExist(err) {
+			log.Warn("failed to read cursor file", "path", cursorPath, "error", err)
+		}
+	}
+	jetstreamController := NewRuntimeJetstreamController(log, h, jetstreamURLs[0], cursor)
+	jetstreamController.SetEndpoints(jetstreamURLs)
+	log.Info("jetstream endpoints", "urls", jetstreamURLs)
+	if cctx.Bool("jetstream-wanted-dids") {
+		// 全フィードが投稿者を限定している場合はそのDIDのみ購読する
+		wdu := NewWantedDidsUpdater(log, fs, jetstreamController)
+		wdu.Update()
+		fs.SetFeedsChangedHandler(wdu.Update)
+	}
 	if _, err := jetstreamController.Connect(JetstreamConnectRequest{Cursor: &cursor}); err != nil {
 		log.Error("failed to start jetstream controller", "error", err)
 		return err
 	}
 
+	// エラー状態のフィードを定期的に再読み込みする
+	retryCtx, cancelRetry := context.WithCancel(context.Background())
+	defer cancelRetry()
+	retryDone := make(chan struct{})
+	if interval := cctx.Duration("feed-retry-interval"); interval > 0 && fdp != nil {
+		retrier := NewFeedRetrier(log, fs, interval, cctx.Int("feed-retry-max-attempts"))
+		go func() {
+			defer close(retryDone)
+			retrier.Run(retryCtx)
+		}()
+	} else {
+		close(retryDone)
+	}
+
 	// Prometheusメトリクスエンドポイントの設定
 	metricsServer := &http.Server{
-		Addr:    cctx.String("metrics-listen-addr"),
-		Handler: promhttp.Handler(),
+		Addr: cctx.String("metrics-listen-addr"),
 	}
+	// yugeのメトリクスのみを専用のレジストリから公開する
+	metricsHandler := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{Registry: metrics.Registry})
+	metricsUpdater := newFeedMetricsUpdater(fs, cctx.Duration("metrics-cache-ttl"))
 	go func() {
 		mux := http.NewServeMux()
 		// フィードの投稿数をメトリクスエンドポイントへのアクセス時に収集
 		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-			for _, f := range fs.GetAllFeeds() {
-				if f.Status.LastStatus != FeedStatusError && f.Feed != nil {
-					updateMetrics(f.Feed)
-				}
-			}
-			promhttp.Handler().ServeHTTP(w, r)
+			metricsUpdater.Update()
+			metricsHandler.ServeHTTP(w, r)
 		})
 		metricsServer.Handler = mux
 		log.Info("starting metrics server", "addr", metricsServer.Addr)
@@ -156,11 +288,18 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 	apiServer := &http.Server{
 		Addr: cctx.String("api-listen-addr"),
 		Handler: func() http.Handler {
-			r := gin.Default()
+			r := gin.New()
+			r.Use(gin.Recovery(), RequestLogger(logger))
+			if origins := cctx.StringSlice("cors-origins"); len(origins) > 0 {
+				r.Use(CORS(origins))
+			}
+			if cctx.Bool("api-compression") {
+				r.Use(CompressionMiddleware())
+			}
 			feedAPI := NewFeedApiHandler(fs)
 			jetstreamAPI := NewJetstreamApiHandler(jetstreamController)
 			r.GET("", func(c *gin.Context) {
-				c.String(200, fmt.Sprintf("hello yuge feed subscriber\njetstream-url: %s", u.String()))
+				c.String(200, fmt.Sprintf("hello yuge feed subscriber\njetstream-url: %s", jetstreamController.Status().WebsocketURL))
 			})
 			r.GET("/api", func(c *gin.Context) {
 				content, _ := webContent.ReadFile("webcontent/index.html")
@@ -169,22 +308,51 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 			r.POST("/api/jetstream/connect", jetstreamAPI.Connect)
 			r.POST("/api/jetstream/disconnect", jetstreamAPI.Disconnect)
 			r.GET("/api/jetstream/status", jetstreamAPI.Status)
-			r.GET("/api/feed", feedAPI.ListFeed)
-			r.PUT("/api/feed/:feedid", feedAPI.RegisterFeed) // POSTからPUTに変更
-			r.Group("/api/feed/:feedid").Use(feedAPI.ValidateFeedId()).
+			// トークン指定時に保護するAPI
+			protectedRoutes := r.Group("/api")
+			if token := cctx.String("api-token"); token != "" {
+				protectedRoutes.Use(BearerAuth(token, cctx.Bool("api-token-exempt-read")))
+			}
+			feedRoutes := protectedRoutes.Group("/feed")
+			feedListAPI := NewFeedListApiHandler(fs)
+			feedListRoutes := protectedRoutes.Group("/feedlist")
+			feedListRoutes.GET("/versions", feedListAPI.ListVersions)
+			feedListRoutes.GET("/versions/:version", feedListAPI.GetVersion)
+			feedListRoutes.POST("/rollback/:version", feedListAPI.Rollback)
+			feedListRoutes.POST("/reload", feedListAPI.ReloadFeedList)
+			protectedRoutes.GET("/summary", feedAPI.Summary)
+			protectedRoutes.GET("/logicblocks/types", NewLogicBlockApiHandler(nil).ListLogicBlockTypes)
+			feedRoutes.GET("", feedAPI.ListFeed)
+			feedRoutes.PUT("/:feedid", feedAPI.RegisterFeed) // POSTからPUTに変更
+			feedRoutes.PATCH("/status", feedAPI.BulkUpdateFeedStatus)
+			feedRoutes.Group("/:feedid").Use(feedAPI.ValidateFeedId()).
 				GET("", feedAPI.GetFeedInfo).
 				DELETE("", feedAPI.UnregisterFeed).
 				GET("/status", feedAPI.GetFeedStatus).
 				PATCH("/status", feedAPI.UpdateFeedStatus).
+				PATCH("/ingest", feedAPI.UpdateIngest).
 				POST("/clear", feedAPI.ClearFeed).
 				POST("/reload", feedAPI.ReloadFeed).
+				POST("/reindex", feedAPI.ReindexFeed).
+				POST("/reevaluate", feedAPI.ReevaluateFeed).
+				GET("/validate", feedAPI.ValidateFeed).
+				GET("/store/stats", feedAPI.GetStoreStats).
+				GET("/recent", feedAPI.GetRecentTests).
+				GET("/matches", feedAPI.GetRecentMatches).
+				POST("/test", feedAPI.TestPost).
 				GET("/config", feedAPI.GetConfig).
+				POST("/config/diff", feedAPI.DiffConfig).
+				GET("/authors", feedAPI.GetAuthors).
+				GET("/count", feedAPI.GetPostCount).
 				GET("/post", feedAPI.GetAllPosts).
 				GET("/post/:did", feedAPI.GetPostsByDid).
 				GET("/post/:did/:rkey", feedAPI.GetPostByRkey).
 				POST("/post/:did/:rkey", feedAPI.AddPost).
 				DELETE("/post/:did", feedAPI.DeletePostByDid).
 				DELETE("/post/:did/:rkey", feedAPI.DeletePost).
+				GET("/logicblocks", feedAPI.ListLogicBlocks).
+				GET("/state", feedAPI.GetFeedState).
+				POST("/state", feedAPI.ImportFeedState).
 				POST("/logicblock/:logicblockname/:command", feedAPI.ProcessLogicBlockCommand)
 
 			return r
@@ -208,6 +376,9 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 	go func() {
 		l := log.With("source", "feed")
 		<-shutdownFeed
+		// 再試行中のリロードが終わるのを待ってからフィードを終了する
+		cancelRetry()
+		<-retryDone
 		l.Info("shutting down feed")
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -246,6 +417,14 @@ func JetstreamSubscriber(cctx *cli.Context) error {
 	case <-time.After(10 * time.Second):
 		log.Warn("shutdown timeout at jetstream client")
 	}
+	// 次回起動時に再開できるよう最終カーソルを保存する
+	if lastCursor := jetstreamController.Status().Cursor; lastCursor > 0 {
+		if err := writeCursorFile(cursorPath, lastCursor); err != nil {
+			log.Error("failed to persist cursor", "cursor", lastCursor, "path", cursorPath, "error", err)
+		} else {
+			log.Info("cursor persisted", "cursor", lastCursor, "path", cursorPath)
+		}
+	}
 	close(shutdownFeed)
 	select {
 	case <-feedShutdown:
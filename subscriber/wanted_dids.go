@@ -0,0 +1,70 @@
+package subscriber
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// jetstream accepts up to 10,000 wantedDids
+const maxWantedDids = 10000
+
+type wantedDidsSetter interface {
+	SetWantedDids(dids []string) error
+}
+
+// WantedDidsUpdater subscribes jetstream only to the authors of the feeds
+// when every active feed is scoped to a set of authors (e.g. userlist with allow: true).
+// otherwise the full stream is subscribed.
+//
+// tradeoff: this saves bandwidth for author allowlist feeds, but the client reconnects
+// each time the set of DIDs changes, and changes of a user list are applied only when
+// the feed is reloaded. delete events from other authors are not received either.
+type WantedDidsUpdater struct {
+	logger     *slog.Logger
+	fs         *FeedService
+	controller wantedDidsSetter
+
+	mu      sync.Mutex
+	current []string
+}
+
+func NewWantedDidsUpdater(logger *slog.Logger, fs *FeedService, controller wantedDidsSetter) *WantedDidsUpdater {
+	return &WantedDidsUpdater{
+		logger:     logger.With("source", "wanted-dids"),
+		fs:         fs,
+		controller: controller,
+	}
+}
+
+// Update recalculates the wanted DIDs and applies them if changed.
+func (u *WantedDidsUpdater) Update() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	dids, scoped := u.fs.WantedDids()
+	switch {
+	case !scoped:
+		dids = nil
+	case len(dids) == 0:
+		// wantedDidsが空だと全件購読になるので、対象がないことはここでは表現できない
+		u.logger.Warn("active feeds have no authors, subscribing to full stream")
+		dids = nil
+	case len(dids) > maxWantedDids:
+		u.logger.Warn("too many wanted dids, subscribing to full stream", "count", len(dids), "max", maxWantedDids)
+		dids = nil
+	}
+	if slices.Equal(dids, u.current) {
+		return
+	}
+	if err := u.controller.SetWantedDids(dids); err != nil {
+		u.logger.Error("failed to update wanted dids", "error", err)
+		return
+	}
+	u.current = dids
+	if dids == nil {
+		u.logger.Info("subscribing to full stream")
+	} else {
+		u.logger.Info("subscribing to feed authors only", "count", len(dids))
+	}
+}
@@ -0,0 +1,73 @@
+package subscriber
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/nus25/yuge/feed"
+)
+
+// scopedFeed overrides AuthorDids of feed.Feed for testing
+type scopedFeed struct {
+	feed.Feed
+	dids   []string
+	scoped bool
+}
+
+func (f *scopedFeed) AuthorDids() ([]string, bool) {
+	return f.dids, f.scoped
+}
+
+type recordingDidsSetter struct {
+	calls [][]string
+}
+
+func (r *recordingDidsSetter) SetWantedDids(dids []string) error {
+	r.calls = append(r.calls, dids)
+	return nil
+}
+
+func TestWantedDidsUpdater(t *testing.T) {
+	fs := &FeedService{feeds: make(map[string]FeedInfo), logger: slog.Default()}
+	setter := &recordingDidsSetter{}
+	u := NewWantedDidsUpdater(slog.Default(), fs, setter)
+	fs.SetFeedsChangedHandler(u.Update)
+
+	register := func(id string, f feed.Feed, status Status) {
+		fs.registerFeed(FeedDefinition{ID: id}, f, FeedStatus{FeedID: id, LastStatus: status})
+	}
+
+	// 対象フィードなし: 全件購読のまま
+	u.Update()
+	if len(setter.calls) != 0 {
+		t.Fatalf("expected no update, got %v", setter.calls)
+	}
+
+	register("a", &scopedFeed{dids: []string{"did:plc:b", "did:plc:a"}, scoped: true}, FeedStatusActive)
+	register("b", &scopedFeed{dids: []string{"did:plc:c", "did:plc:a"}, scoped: true}, FeedStatusActive)
+	want := []string{"did:plc:a", "did:plc:b", "did:plc:c"}
+	if got := setter.calls[len(setter.calls)-1]; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// 非アクティブなフィードは投稿者を限定していなくても影響しない
+	calls := len(setter.calls)
+	register("c", &scopedFeed{scoped: false}, FeedStatusInactive)
+	if len(setter.calls) != calls {
+		t.Errorf("expected no update for inactive feed, got %v", setter.calls[calls:])
+	}
+
+	// 投稿者を限定しないフィードが有効になると全件購読に戻る
+	if err := fs.UpdateStatus("c", FeedStatusActive); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if got := setter.calls[len(setter.calls)-1]; got != nil {
+		t.Errorf("expected full stream, got %v", got)
+	}
+
+	fs.unregisterFeed("c")
+	if got := setter.calls[len(setter.calls)-1]; !slices.Equal(got, want) {
+		t.Errorf("expected %v after unregister, got %v", want, got)
+	}
+}
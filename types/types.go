@@ -2,6 +2,7 @@ package types
 
 import (
 	"errors"
+	"time"
 
 	"github.com/bluesky-social/indigo/util"
 )
@@ -14,6 +15,25 @@ type Post struct {
 	Langs     []string `json:"langs,omitempty"`
 }
 
+// IndexedAtFormat is the canonical format of Post.IndexedAt.
+// it has a fixed width in UTC so that posts can be ordered by comparing the strings.
+const IndexedAtFormat = "2006-01-02T15:04:05.000000000Z"
+
+// FormatIndexedAt formats t in IndexedAtFormat
+func FormatIndexedAt(t time.Time) string {
+	return t.UTC().Format(IndexedAtFormat)
+}
+
+// NormalizeIndexedAt converts an RFC3339 timestamp to IndexedAtFormat.
+// s is returned unchanged if it cannot be parsed.
+func NormalizeIndexedAt(s string) string {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return FormatIndexedAt(t)
+}
+
 type FeedUri string
 
 func (f FeedUri) Validate() error {